@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/collection"
+	"github.com/tbourrel/apitty/internal/har"
+	"github.com/tbourrel/apitty/internal/history"
+	"github.com/tbourrel/apitty/internal/insomnia"
+	"github.com/tbourrel/apitty/internal/mock"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/openapi"
+	"github.com/tbourrel/apitty/internal/postman"
+	"github.com/tbourrel/apitty/internal/runner"
+)
+
+const importUsage = `Usage: apitty import <format> <file> [-o collection.json] [-env environment.json]...
+
+Formats:
+  openapi   OpenAPI 3 or Swagger 2 specification (YAML or JSON)
+  postman   Postman v2.1 collection, -env adds Postman environments
+  insomnia  Insomnia v4 export (JSON)
+  har       HAR 1.2 file, as a collection or with -history into the history
+`
+
+const testUsage = `Usage: apitty test <collection> [options]
+
+Sends the requests of a collection in order and checks their assertions.
+Values extracted by a request are available to the following ones.
+
+Options:
+  -env NAME        environment to use
+  -folder PATH     only run the requests of a folder, such as Orders/Admin
+  -var KEY=VALUE   override a variable (repeatable)
+  -data FILE       run once per row of a CSV file or JSON array of objects,
+                   with the columns bound as variables
+  -bail            stop at the first failed request
+  -delay DURATION  pause between requests, such as 500ms
+  -fresh           open a new connection for every request instead of
+                   reusing kept-alive ones
+  -reporter NAME   write a report: text, junit, tap or json
+  -o FILE          write the report to this file instead of stdout
+
+The exit status is 1 when a request fails.
+`
+
+const mockUsage = `Usage: apitty mock <collection> [options]
+
+Serves the saved examples of a collection until interrupted. Requests are
+routed by method and path template, then by the header and query
+conditions of the examples.
+
+Options:
+  -port N          port to listen on (default 8080, 0 picks a free one)
+  -host ADDR       address to listen on (default 127.0.0.1)
+  -env NAME        environment whose variables the examples see
+  -latency D       delay every response, such as 200ms
+  -jitter D        add a random delay up to D
+  -fail-rate R     answer this share of requests with a 500, such as 0.1
+  -drop-rate R     close the connection of this share of requests
+  -seed N          make the faults and the jitter repeatable
+`
+
+const exportUsage = `Usage: apitty export har [-o history.har]
+
+Writes the request history as a HAR 1.2 file.
+`
+
+// fileList collects the values of a repeatable flag
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// runImport converts a file into a collection, written to -o or stdout
+func runImport(args []string, stdout, stderr io.Writer) error {
+	if len(args) < 2 {
+		fmt.Fprint(stderr, importUsage)
+		return fmt.Errorf("missing format or file")
+	}
+	format, path := args[0], args[1]
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the collection to this file instead of stdout")
+	var envFiles fileList
+	fs.Var(&envFiles, "env", "Postman environment to add to the collection (repeatable)")
+	toHistory := fs.Bool("history", false, "add HAR entries to the history instead of writing a collection")
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+
+	if len(envFiles) > 0 && format != "postman" {
+		return fmt.Errorf("-env is only supported for postman imports")
+	}
+	if *toHistory && format != "har" {
+		return fmt.Errorf("-history is only supported for har imports")
+	}
+
+	var c *model.Collection
+	var warnings []string
+	var err error
+	switch format {
+	case "openapi", "swagger":
+		doc, err := openapi.Load(path)
+		if err != nil {
+			return err
+		}
+		c = doc.ToCollection()
+	case "postman":
+		c, warnings, err = postman.Load(path)
+		if err != nil {
+			return err
+		}
+		for _, envFile := range envFiles {
+			env, err := postman.LoadEnvironment(envFile)
+			if err != nil {
+				return fmt.Errorf("%s: %w", envFile, err)
+			}
+			c.Environments = append(c.Environments, env)
+		}
+	case "insomnia":
+		c, warnings, err = insomnia.Load(path)
+		if err != nil {
+			return err
+		}
+	case "har":
+		h, err := har.Load(path)
+		if err != nil {
+			return err
+		}
+		if *toHistory {
+			return importHistory(h, stderr)
+		}
+		c = h.ToCollection(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	default:
+		fmt.Fprint(stderr, importUsage)
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	fmt.Fprintf(stderr, "Imported %q: %d requests in %d folders", c.Name, len(c.Entries()), len(c.Folders))
+	if len(c.Environments) > 0 {
+		fmt.Fprintf(stderr, ", %d environments", len(c.Environments))
+	}
+	fmt.Fprintln(stderr)
+	if len(warnings) > 0 {
+		fmt.Fprintf(stderr, "%d items could not be converted:\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintf(stderr, "  - %s\n", w)
+		}
+	}
+	if *output != "" {
+		return collection.Save(c, *output)
+	}
+	data, err := collection.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(data)
+	return err
+}
+
+// importHistory appends the entries of a HAR file to the history
+func importHistory(h *har.HAR, stderr io.Writer) error {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	imported := h.History()
+	if err := history.Save(path, history.Add(entries, imported...)); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Added %d requests to the history (%s)\n", len(imported), path)
+	return nil
+}
+
+// runExport writes the history in another format, to -o or stdout
+func runExport(args []string, stdout, stderr io.Writer) error {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, exportUsage)
+		return fmt.Errorf("missing format")
+	}
+	if args[0] != "har" {
+		fmt.Fprint(stderr, exportUsage)
+		return fmt.Errorf("unknown format %q", args[0])
+	}
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the HAR file to this file instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	path, err := history.DefaultPath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	data, err := har.Marshal(har.FromHistory(entries))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Exported %d requests\n", len(entries))
+	if *output != "" {
+		return os.WriteFile(*output, data, 0o644)
+	}
+	_, err = stdout.Write(data)
+	return err
+}
+
+// runTest runs a collection and fails when one of its requests fails
+func runTest(args []string, stdout, stderr io.Writer) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprint(stderr, testUsage)
+		return fmt.Errorf("missing collection")
+	}
+	path := args[0]
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, testUsage) }
+	env := fs.String("env", "", "environment to use")
+	folder := fs.String("folder", "", "only run the requests of this folder")
+	var overrides fileList
+	fs.Var(&overrides, "var", "override a variable, as KEY=VALUE (repeatable)")
+	dataFile := fs.String("data", "", "CSV or JSON file to run the collection once per row")
+	bail := fs.Bool("bail", false, "stop at the first failed request")
+	delay := fs.Duration("delay", 0, "pause between requests")
+	fresh := fs.Bool("fresh", false, "open a new connection for every request")
+	reporter := fs.String("reporter", "", "report format: "+strings.Join(runner.Formats, ", "))
+	output := fs.String("o", "", "write the report to this file instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *output != "" && *reporter == "" {
+		return fmt.Errorf("-o needs a -reporter")
+	}
+	if *reporter != "" && !slices.Contains(runner.Formats, *reporter) {
+		return fmt.Errorf("unknown reporter %q, expected %s", *reporter, strings.Join(runner.Formats, ", "))
+	}
+
+	variables := make(map[string]string)
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid -var %q, expected KEY=VALUE", o)
+		}
+		variables[strings.TrimSpace(key)] = value
+	}
+
+	c, err := collection.Load(path)
+	if err != nil {
+		return err
+	}
+	var data *runner.Dataset
+	if *dataFile != "" {
+		if data, err = runner.LoadData(*dataFile); err != nil {
+			return err
+		}
+	}
+	iteration := -1
+	report, err := runner.Run(c, runner.Options{
+		Environment: *env,
+		Folder:      *folder,
+		Data:        data,
+		Variables:   variables,
+		Bail:        *bail,
+		Delay:       *delay,
+		Fresh:       *fresh,
+		Progress: func(res runner.Result) {
+			if data != nil && res.Iteration != iteration {
+				if iteration >= 0 {
+					fmt.Fprintln(stderr)
+				}
+				iteration = res.Iteration
+				fmt.Fprintln(stderr, runner.IterationLabel(data, iteration))
+			}
+			fmt.Fprintln(stderr, runner.Line(res))
+			for _, f := range res.Failures() {
+				fmt.Fprintln(stderr, "    "+f)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stderr)
+	if data != nil {
+		if err := runner.WriteSummaryTable(stderr, report); err != nil {
+			return err
+		}
+		fmt.Fprintln(stderr)
+	}
+	fmt.Fprintln(stderr, runner.Summary(report))
+
+	if *reporter != "" {
+		var buf bytes.Buffer
+		if err := runner.Write(&buf, report, *reporter); err != nil {
+			return err
+		}
+		if *output != "" {
+			err = os.WriteFile(*output, buf.Bytes(), 0o644)
+		} else {
+			_, err = stdout.Write(buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d requests failed", failed, len(report.Results))
+	}
+	return nil
+}
+
+// runMock serves the examples of a collection until ctx is done
+func runMock(ctx context.Context, args []string, stderr io.Writer) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprint(stderr, mockUsage)
+		return fmt.Errorf("missing collection")
+	}
+	path := args[0]
+
+	fs := flag.NewFlagSet("mock", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, mockUsage) }
+	port := fs.Int("port", 8080, "port to listen on")
+	host := fs.String("host", "127.0.0.1", "address to listen on")
+	env := fs.String("env", "", "environment to use")
+	latency := fs.Duration("latency", 0, "delay every response")
+	jitter := fs.Duration("jitter", 0, "add a random delay up to this duration")
+	failRate := fs.Float64("fail-rate", 0, "share of requests answered with a 500")
+	dropRate := fs.Float64("drop-rate", 0, "share of requests whose connection is closed")
+	seed := fs.Int64("seed", 0, "seed of the faults and the jitter")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	c, err := collection.Load(path)
+	if err != nil {
+		return err
+	}
+	// The handler logs from its own goroutines
+	var logMu sync.Mutex
+	server, err := mock.New(c, mock.Options{
+		Environment: *env,
+		Latency:     *latency,
+		Jitter:      *jitter,
+		FailRate:    *failRate,
+		DropRate:    *dropRate,
+		Seed:        *seed,
+		Log: func(h mock.Hit) {
+			logMu.Lock()
+			defer logMu.Unlock()
+			fmt.Fprintln(stderr, mockLine(h))
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(*host, strconv.Itoa(*port)))
+	if err != nil {
+		return err
+	}
+	logMu.Lock()
+	for _, r := range server.Routes() {
+		fmt.Fprintf(stderr, "  %-7s %-30s %s\n", r.Method, r.Template, r.Name)
+	}
+	fmt.Fprintf(stderr, "\nServing %d examples of %q on http://%s\n", len(server.Routes()), c.Name, listener.Addr())
+	logMu.Unlock()
+
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdown)
+	}()
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// mockLine describes a request the mock server answered
+func mockLine(h mock.Hit) string {
+	line := fmt.Sprintf("%s %s", h.Method, h.Path)
+	switch {
+	case h.Fault == "drop":
+		line += " → dropped the connection"
+	case h.Route == nil:
+		line += fmt.Sprintf(" → %d no matching example", h.Status)
+	case h.Fault != "":
+		line += fmt.Sprintf(" → %d injected fault", h.Status)
+	default:
+		line += fmt.Sprintf(" → %d %s", h.Status, h.Route.Name)
+	}
+	return line + fmt.Sprintf("  %s", h.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/collection"
+	"github.com/tbourrel/apitty/internal/har"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestRunImport_OpenAPI(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "petstore.json")
+	var stdout, stderr bytes.Buffer
+
+	err := runImport([]string{"openapi", "internal/openapi/testdata/petstore.yaml", "-o", out}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "4 requests") {
+		t.Errorf("expected summary, got %q", stderr.String())
+	}
+
+	c, err := collection.Load(out)
+	if err != nil {
+		t.Fatalf("failed to load generated collection: %v", err)
+	}
+	if c.Name != "Petstore" {
+		t.Errorf("expected collection Petstore, got %s", c.Name)
+	}
+}
+
+func TestRunImport_Stdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := runImport([]string{"openapi", "internal/openapi/testdata/petstore-swagger.json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"name": "Petstore v2"`) {
+		t.Errorf("expected collection JSON on stdout, got %s", stdout.String())
+	}
+}
+
+func TestRunImport_UnknownFormat(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := runImport([]string{"nope", "file"}, &stdout, &stderr); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestRunImport_PostmanWithEnvironment(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := runImport([]string{
+		"postman", "internal/postman/testdata/shop.postman_collection.json",
+		"-env", "internal/postman/testdata/staging.postman_environment.json",
+	}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "6 requests in 1 folders, 1 environments") {
+		t.Errorf("expected summary with environments, got %q", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "3 items could not be converted") ||
+		!strings.Contains(stderr.String(), "  - Upload: digest auth is not supported") {
+		t.Errorf("expected unconverted items to be listed, got %q", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"name": "Staging"`) {
+		t.Errorf("expected the environment in the collection, got %s", stdout.String())
+	}
+}
+
+func TestRunImport_EnvRequiresPostman(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := runImport([]string{"insomnia", "internal/insomnia/testdata/shop.insomnia.json", "-env", "env.json"}, &stdout, &stderr)
+	if err == nil {
+		t.Error("expected error when -env is used with another format")
+	}
+}
+
+func TestRunImport_HARCollection(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := runImport([]string{"har", "internal/har/testdata/session.har"}, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), `Imported "session": 2 requests in 2 folders`) {
+		t.Errorf("unexpected summary %q", stderr.String())
+	}
+}
+
+func TestRunImportExport_HARHistory(t *testing.T) {
+	t.Setenv("APITTY_HISTORY", filepath.Join(t.TempDir(), "history.json"))
+
+	var stdout, stderr bytes.Buffer
+	if err := runImport([]string{"har", "internal/har/testdata/session.har", "-history"}, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Added 2 requests to the history") {
+		t.Errorf("unexpected summary %q", stderr.String())
+	}
+
+	out := filepath.Join(t.TempDir(), "export.har")
+	stderr.Reset()
+	if err := runExport([]string{"har", "-o", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h, err := har.Load(out)
+	if err != nil {
+		t.Fatalf("failed to load exported HAR: %v", err)
+	}
+	if len(h.Log.Entries) != 2 || h.Log.Entries[0].Response.Content.Text != `{"ok":true}` {
+		t.Errorf("unexpected exported entries %+v", h.Log.Entries)
+	}
+	if h.Log.Entries[0].Timings.Wait != 140 {
+		t.Errorf("expected timings to be kept, got %+v", h.Log.Entries[0].Timings)
+	}
+}
+
+func TestRunImport_HistoryRequiresHAR(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := runImport([]string{"openapi", "internal/openapi/testdata/petstore.yaml", "-history"}, &stdout, &stderr)
+	if err == nil {
+		t.Error("expected error when -history is used with another format")
+	}
+}
+
+func TestRunTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "up"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "smoke.json")
+	c := &model.Collection{
+		Name:      "Smoke",
+		Variables: []model.Variable{{Key: "base", Value: "http://invalid.example"}},
+		Requests: []model.SavedRequest{
+			{Name: "Health", Request: model.Request{Method: "GET", URL: "{{base}}/health", Assertions: []model.Assertion{
+				{Kind: model.AssertJSONPath, Target: "$.status", Op: "==", Value: "up"},
+			}}},
+			{Name: "Version", Request: model.Request{Method: "GET", URL: "{{base}}/version", Assertions: []model.Assertion{
+				{Kind: model.AssertStatus, Op: "==", Value: "200"},
+			}}},
+		},
+	}
+	if err := collection.Save(c, path); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	report := filepath.Join(t.TempDir(), "report.xml")
+	err := runTest([]string{path, "-var", "base=" + server.URL, "-reporter", "junit", "-o", report}, &stdout, &stderr)
+	if err == nil || err.Error() != "1 of 2 requests failed" {
+		t.Fatalf("expected the version request to fail, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "✓ Health  200 OK") || !strings.Contains(stderr.String(), "    status == 200: got 404") {
+		t.Errorf("expected progress on stderr, got %q", stderr.String())
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<testsuite name="Smoke" tests="2" failures="1"`) {
+		t.Errorf("unexpected JUnit report:\n%s", data)
+	}
+
+	stdout.Reset()
+	_ = runTest([]string{path, "-var", "base=" + server.URL, "-reporter", "tap"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "ok 1 - Health\nnot ok 2 - Version\n") {
+		t.Errorf("expected a TAP report on stdout, got:\n%s", stdout.String())
+	}
+	// One iteration per row of the dataset
+	hosts := filepath.Join(t.TempDir(), "hosts.csv")
+	if err := os.WriteFile(hosts, []byte("base\n"+server.URL+"\nhttp://127.0.0.1:1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	err = runTest([]string{path, "-data", hosts}, &stdout, &stderr)
+	if err == nil || err.Error() != "3 of 4 requests failed" {
+		t.Errorf("expected the second host to fail, got %v", err)
+	}
+	for _, want := range []string{"Iteration 1/2 (base=http://127.0.0.1", "Iteration 2/2 (base=http://127.0.0.1:1)\n✗ Health  no response", "#  base", "2 iterations, 4 requests, 1 passed, 3 failed"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("expected %q on stderr, got:\n%s", want, stderr.String())
+		}
+	}
+
+	if err := runTest([]string{path, "-var", "base=" + server.URL, "-folder", "Nope"}, &stdout, &stderr); err == nil {
+		t.Error("expected an unknown folder to fail")
+	}
+	if err := runTest([]string{path, "-reporter", "xml"}, &stdout, &stderr); err == nil {
+		t.Error("expected an unknown reporter to fail")
+	}
+}
+
+func TestRunMock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.json")
+	c := &model.Collection{
+		Name:      "Shop",
+		Variables: []model.Variable{{Key: "base", Value: "https://api.example.com/v1"}},
+		Requests: []model.SavedRequest{{
+			Name:     "Get order",
+			Request:  model.Request{Method: "GET", URL: "{{base}}/orders/{{id}}"},
+			Examples: []model.Example{{Body: `{"id": {{path.id}}}`}},
+		}},
+	}
+	if err := collection.Save(c, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server logs from its own goroutines, read them through a pipe
+	reader, writer := io.Pipe()
+	lines := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runMock(ctx, []string{path, "-port", "0"}, writer) }()
+
+	var base string
+	for line := range lines {
+		if _, addr, ok := strings.Cut(line, " on "); ok {
+			base = addr
+			break
+		}
+	}
+	resp, err := http.Get(base + "/v1/orders/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"id": 7}` {
+		t.Errorf("unexpected body %s", body)
+	}
+	if line := <-lines; !strings.HasPrefix(line, "GET /v1/orders/7 → 200 Get order") {
+		t.Errorf("unexpected log %q", line)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected the server to stop cleanly, got %v", err)
+	}
+
+	var stderr bytes.Buffer
+	if err := runMock(context.Background(), nil, &stderr); err == nil || !strings.Contains(stderr.String(), "Usage: apitty mock") {
+		t.Errorf("expected the usage, got %v", err)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	if err := collection.Save(&model.Collection{Name: "Empty"}, empty); err != nil {
+		t.Fatal(err)
+	}
+	if err := runMock(context.Background(), []string{empty}, &stderr); err == nil || err.Error() != "the collection has no saved examples" {
+		t.Errorf("expected a collection without examples to be refused, got %v", err)
+	}
+}
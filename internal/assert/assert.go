@@ -0,0 +1,449 @@
+package assert
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// ops lists the operators, longer ones first so <= is not read as <
+var ops = []string{"==", "!=", "<=", ">=", "<", ">", "contains", "matches", "!exists", "exists", "type"}
+
+// unary operators take no value
+func unary(op string) bool {
+	return op == "exists" || op == "!exists"
+}
+
+// jsonTypes are the values accepted by the type operator
+var jsonTypes = []string{"string", "number", "boolean", "object", "array", "null"}
+
+// Parse reads an assertion written as "<kind> [target] <op> [value]":
+//
+//	status == 200
+//	status == 2xx
+//	header Content-Type contains json
+//	jsonpath $.items[?(@.id == 3)].name == "Widget"
+//	jsonpath $.id type number
+//	jsonpath $.error !exists
+//	time < 500ms
+//	size <= 10kb
+func Parse(line string) (model.Assertion, error) {
+	var a model.Assertion
+	line = strings.TrimSpace(line)
+	kind, rest, _ := strings.Cut(line, " ")
+	a.Kind = model.AssertionKind(strings.ToLower(kind))
+	rest = strings.TrimSpace(rest)
+
+	switch a.Kind {
+	case model.AssertStatus, model.AssertTime, model.AssertSize:
+	case model.AssertHeader, model.AssertJSONPath:
+		target, after, err := splitTarget(rest)
+		if err != nil {
+			return a, err
+		}
+		a.Target, rest = target, after
+	default:
+		return a, fmt.Errorf("unknown assertion %q, expected status, header, jsonpath, time or size", kind)
+	}
+
+	for _, op := range ops {
+		if rest == op || strings.HasPrefix(rest, op+" ") {
+			a.Op = op
+			a.Value = unquote(strings.TrimSpace(rest[len(op):]))
+			break
+		}
+	}
+	if a.Op == "" {
+		return a, fmt.Errorf("expected an operator (%s) in %q", strings.Join(ops, ", "), line)
+	}
+	if err := Validate(a); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// splitTarget reads the header name or JSONPath at the start of s. Spaces
+// inside brackets, as in filters, belong to the path.
+func splitTarget(s string) (target, rest string, err error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case ' ':
+			if depth == 0 {
+				return s[:i], strings.TrimSpace(s[i+1:]), nil
+			}
+		}
+	}
+	if s == "" {
+		return "", "", fmt.Errorf("expected a header name or JSONPath")
+	}
+	return s, "", nil
+}
+
+// unquote strips the double quotes around a JSON string value
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+// Validate checks that the operator and value suit the kind of assertion
+func Validate(a model.Assertion) error {
+	if unary(a.Op) != (a.Value == "") {
+		if unary(a.Op) {
+			return fmt.Errorf("%s takes no value", a.Op)
+		}
+		return fmt.Errorf("%s needs a value", a.Op)
+	}
+	switch a.Kind {
+	case model.AssertStatus:
+		if !isOneOf(a.Op, "==", "!=", "<", "<=", ">", ">=") {
+			return fmt.Errorf("status supports ==, !=, <, <=, > and >=")
+		}
+		if _, _, err := statusRange(a.Value); err != nil {
+			return err
+		}
+	case model.AssertTime:
+		if !isOneOf(a.Op, "<", "<=", ">", ">=") {
+			return fmt.Errorf("time supports <, <=, > and >=")
+		}
+		if _, err := duration(a.Value); err != nil {
+			return err
+		}
+	case model.AssertSize:
+		if !isOneOf(a.Op, "==", "!=", "<", "<=", ">", ">=") {
+			return fmt.Errorf("size supports ==, !=, <, <=, > and >=")
+		}
+		if _, err := size(a.Value); err != nil {
+			return err
+		}
+	case model.AssertHeader:
+		if a.Target == "" {
+			return fmt.Errorf("expected a header name")
+		}
+		if !isOneOf(a.Op, "==", "!=", "contains", "matches", "exists", "!exists") {
+			return fmt.Errorf("header supports ==, !=, contains, matches, exists and !exists")
+		}
+	case model.AssertJSONPath:
+		if _, err := jsonpath.Parse(a.Target); err != nil {
+			return err
+		}
+		if a.Op == "type" && !isOneOf(a.Value, jsonTypes...) {
+			return fmt.Errorf("unknown type %q, expected %s", a.Value, strings.Join(jsonTypes, ", "))
+		}
+	default:
+		return fmt.Errorf("unknown assertion %q", a.Kind)
+	}
+	if a.Op == "matches" {
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format writes an assertion the way Parse reads it
+func Format(a model.Assertion) string {
+	parts := []string{string(a.Kind)}
+	if a.Target != "" {
+		parts = append(parts, a.Target)
+	}
+	parts = append(parts, a.Op)
+	if a.Value != "" {
+		v := a.Value
+		if strings.TrimSpace(v) != v {
+			v = strconv.Quote(v)
+		}
+		parts = append(parts, v)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Evaluate checks every assertion against a response
+func Evaluate(assertions []model.Assertion, e model.HistoryEntry) []model.AssertionResult {
+	results := make([]model.AssertionResult, len(assertions))
+	var doc any
+	var docErr error
+	decoded := false
+	for i, a := range assertions {
+		r := &results[i]
+		r.Assertion = a
+		if e.Error != "" {
+			r.Message = "no response: " + e.Error
+			continue
+		}
+		if err := Validate(a); err != nil {
+			r.Message = err.Error()
+			continue
+		}
+
+		switch a.Kind {
+		case model.AssertStatus:
+			r.Actual = strconv.Itoa(e.Response.StatusCode)
+			lo, hi, _ := statusRange(a.Value)
+			code := e.Response.StatusCode
+			switch a.Op {
+			case "==":
+				r.Passed = code >= lo && code <= hi
+			case "!=":
+				r.Passed = code < lo || code > hi
+			default:
+				r.Passed = compareNumbers(float64(code), a.Op, float64(lo))
+			}
+
+		case model.AssertTime:
+			total := e.Timings.Total()
+			r.Actual = total.Round(time.Millisecond).String()
+			limit, _ := duration(a.Value)
+			r.Passed = compareNumbers(float64(total), a.Op, float64(limit))
+
+		case model.AssertSize:
+			// A streamed body keeps only its start, its size is the whole one
+			n := max(e.Response.Size, int64(len(e.Response.Body)))
+			r.Actual = fmt.Sprintf("%d bytes", n)
+			limit, _ := size(a.Value)
+			r.Passed = compareNumbers(float64(n), a.Op, float64(limit))
+
+		case model.AssertHeader:
+			var values []string
+			for _, h := range e.Response.Headers {
+				if strings.EqualFold(h.Key, a.Target) {
+					values = append(values, h.Value)
+				}
+			}
+			r.Actual = strings.Join(values, ", ")
+			switch a.Op {
+			case "exists":
+				r.Passed = len(values) > 0
+			case "!exists":
+				r.Passed = len(values) == 0
+			default:
+				if len(values) == 0 {
+					r.Message = "no " + a.Target + " header"
+					continue
+				}
+				r.Passed = compareStrings(r.Actual, a.Op, a.Value)
+			}
+
+		case model.AssertJSONPath:
+			if !decoded {
+				doc, docErr = jsonpath.Decode([]byte(e.Response.Body))
+				decoded = true
+			}
+			if docErr != nil {
+				r.Message = "the body is not JSON"
+				continue
+			}
+			values, _ := jsonpath.Query(doc, a.Target)
+			evaluateJSON(r, values)
+		}
+
+		if !r.Passed && r.Message == "" {
+			r.Message = "got " + r.Actual
+			if r.Actual == "" {
+				r.Message = "got nothing"
+			}
+		}
+	}
+	return results
+}
+
+// evaluateJSON checks the values matched by a JSONPath. The assertion
+// passes when one of them satisfies it.
+func evaluateJSON(r *model.AssertionResult, values []any) {
+	a := r.Assertion
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = jsonpath.Format(v)
+	}
+	r.Actual = strings.Join(formatted, ", ")
+
+	switch a.Op {
+	case "exists":
+		r.Passed = len(values) > 0
+		return
+	case "!exists":
+		r.Passed = len(values) == 0
+		return
+	}
+	if len(values) == 0 {
+		r.Message = "no match for " + a.Target
+		return
+	}
+
+	for i, v := range values {
+		switch a.Op {
+		case "type":
+			r.Passed = typeOf(v) == a.Value
+			if !r.Passed {
+				r.Actual = typeOf(v)
+			}
+		case "==", "!=":
+			equal := normalize(formatted[i]) == normalize(a.Value)
+			r.Passed = equal == (a.Op == "==")
+		case "<", "<=", ">", ">=":
+			x, errX := strconv.ParseFloat(formatted[i], 64)
+			y, errY := strconv.ParseFloat(a.Value, 64)
+			if errX == nil && errY == nil {
+				r.Passed = compareNumbers(x, a.Op, y)
+			} else {
+				r.Passed = compareStrings(formatted[i], a.Op, a.Value)
+			}
+		default:
+			r.Passed = compareStrings(formatted[i], a.Op, a.Value)
+		}
+		if r.Passed {
+			return
+		}
+	}
+}
+
+// normalize formats a JSON literal value the way jsonpath.Format does, so
+// 1.0 equals 1 and {"a": 1} equals {"a":1}. Other values are plain strings.
+func normalize(value string) string {
+	v, err := jsonpath.Decode([]byte(value))
+	if err != nil {
+		return value
+	}
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return jsonpath.Format(v)
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return "null"
+}
+
+func compareNumbers(x float64, op string, y float64) bool {
+	switch op {
+	case "==":
+		return x == y
+	case "!=":
+		return x != y
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case ">=":
+		return x >= y
+	}
+	return false
+}
+
+func compareStrings(actual, op, value string) bool {
+	switch op {
+	case "==":
+		return actual == value
+	case "!=":
+		return actual != value
+	case "contains":
+		return strings.Contains(actual, value)
+	case "matches":
+		re, err := regexp.Compile(value)
+		return err == nil && re.MatchString(actual)
+	case "<":
+		return actual < value
+	case "<=":
+		return actual <= value
+	case ">":
+		return actual > value
+	case ">=":
+		return actual >= value
+	}
+	return false
+}
+
+// statusRange reads a status code such as 201, or a class such as 2xx
+func statusRange(s string) (lo, hi int, err error) {
+	if len(s) == 3 && strings.EqualFold(s[1:], "xx") && s[0] >= '1' && s[0] <= '5' {
+		lo = int(s[0]-'0') * 100
+		return lo, lo + 99, nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("invalid status %q, expected a code such as 200 or a class such as 2xx", s)
+	}
+	return code, code, nil
+}
+
+// duration reads 500ms, 1.5s or a bare number of milliseconds
+func duration(s string) (time.Duration, error) {
+	if ms, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected milliseconds or a value such as 1.5s", s)
+	}
+	return d, nil
+}
+
+// size reads a byte count, with an optional kb or mb suffix
+func size(s string) (int64, error) {
+	lower := strings.ToLower(strings.TrimSpace(s))
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(lower, "kb"):
+		lower, unit = strings.TrimSuffix(lower, "kb"), 1<<10
+	case strings.HasSuffix(lower, "mb"):
+		lower, unit = strings.TrimSuffix(lower, "mb"), 1<<20
+	case strings.HasSuffix(lower, "b"):
+		lower = strings.TrimSuffix(lower, "b")
+	}
+	lower = strings.TrimSpace(lower)
+	n, err := strconv.ParseFloat(lower, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected bytes, or a value such as 10kb", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func isOneOf(s string, values ...string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed counts the results that did not pass
+func Failed(results []model.AssertionResult) int {
+	n := 0
+	for _, r := range results {
+		if !r.Passed {
+			n++
+		}
+	}
+	return n
+}
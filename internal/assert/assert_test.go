@@ -0,0 +1,157 @@
+package assert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want model.Assertion
+	}{
+		{"status == 200", model.Assertion{Kind: model.AssertStatus, Op: "==", Value: "200"}},
+		{"status == 2xx", model.Assertion{Kind: model.AssertStatus, Op: "==", Value: "2xx"}},
+		{"header Content-Type contains json", model.Assertion{Kind: model.AssertHeader, Target: "Content-Type", Op: "contains", Value: "json"}},
+		{"header X-Request-Id exists", model.Assertion{Kind: model.AssertHeader, Target: "X-Request-Id", Op: "exists"}},
+		{`jsonpath $.items[?(@.id == 3)].name == "Big Widget"`, model.Assertion{Kind: model.AssertJSONPath, Target: "$.items[?(@.id == 3)].name", Op: "==", Value: "Big Widget"}},
+		{"jsonpath $.id type number", model.Assertion{Kind: model.AssertJSONPath, Target: "$.id", Op: "type", Value: "number"}},
+		{"jsonpath $.error !exists", model.Assertion{Kind: model.AssertJSONPath, Target: "$.error", Op: "!exists"}},
+		{"time < 500ms", model.Assertion{Kind: model.AssertTime, Op: "<", Value: "500ms"}},
+		{"size <= 10kb", model.Assertion{Kind: model.AssertSize, Op: "<=", Value: "10kb"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.line)
+		if err != nil {
+			t.Errorf("%s: %v", tt.line, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.line, tt.want, got)
+		}
+		if again, err := Parse(Format(got)); err != nil || again != got {
+			t.Errorf("%s: Format gave %q, which parses to %+v, %v", tt.line, Format(got), again, err)
+		}
+	}
+
+	errors := map[string]string{
+		"body contains x":          "unknown assertion",
+		"status is 200":            "expected an operator",
+		"status == ok":             "invalid status",
+		"status contains 2":        "status supports",
+		"time < soon":              "invalid duration",
+		"size > lots":              "invalid size",
+		"header":                   "expected a header name",
+		"header Location exists 1": "takes no value",
+		"jsonpath $.id ==":         "needs a value",
+		"jsonpath $.id type date":  "unknown type",
+		"jsonpath $.a.. exists":    "invalid JSONPath",
+		"header Server matches (":  "missing closing )",
+	}
+	for line, want := range errors {
+		if _, err := Parse(line); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error %q, got %v", line, want, err)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	entry := model.HistoryEntry{
+		Response: model.Response{
+			StatusCode: 201,
+			Headers: []model.HeaderPair{
+				{Key: "Content-Type", Value: "application/json; charset=utf-8"},
+				{Key: "Location", Value: "/items/3"},
+			},
+			Body: `{"id": 3, "price": 1.50, "name": "Widget", "tags": ["a"], "owner": null, "items": [{"id": 1}, {"id": 3, "ok": true}]}`,
+		},
+		Timings: model.Timings{Wait: 120 * time.Millisecond, Receive: 30 * time.Millisecond},
+	}
+
+	pass := []string{
+		"status == 201",
+		"status == 2xx",
+		"status != 4xx",
+		"status < 300",
+		"header content-type contains json",
+		"header Location == /items/3",
+		"header Location matches ^/items/\\d+$",
+		"header X-Missing !exists",
+		"jsonpath $.id == 3",
+		"jsonpath $.price == 1.5",
+		"jsonpath $.name == Widget",
+		`jsonpath $.name == "Widget"`,
+		`jsonpath $.tags == ["a"]`,
+		"jsonpath $.id type number",
+		"jsonpath $.tags type array",
+		"jsonpath $.owner type null",
+		"jsonpath $.items[?(@.id == 3)].ok == true",
+		"jsonpath $.items[*].id == 3",
+		"jsonpath $.price < 2",
+		"jsonpath $.name matches ^Wid",
+		"jsonpath $.error !exists",
+		"time < 200ms",
+		"time >= 0.1s",
+		"size < 1kb",
+		"size > 10",
+	}
+	fail := map[string]string{
+		"status == 200":                        "got 201",
+		"header Server contains nginx":         "no Server header",
+		"jsonpath $.name == Gadget":            "got Widget",
+		"jsonpath $.id type string":            "got number",
+		"jsonpath $.missing == 1":              "no match for $.missing",
+		"jsonpath $.items[*].id == 2":          "got 1, 3",
+		"time < 100ms":                         "got 150ms",
+		"size > 1mb":                           "bytes",
+		"header Location !exists":              "got /items/3",
+		"jsonpath $.items[?(@.id > 5)] exists": "got nothing",
+	}
+
+	var assertions []model.Assertion
+	for _, line := range pass {
+		a, err := Parse(line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		assertions = append(assertions, a)
+	}
+	for i, r := range Evaluate(assertions, entry) {
+		if !r.Passed {
+			t.Errorf("%s: expected a pass, got %s", pass[i], r.Message)
+		}
+	}
+
+	for line, want := range fail {
+		a, err := Parse(line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		r := Evaluate([]model.Assertion{a}, entry)[0]
+		if r.Passed || !strings.Contains(r.Message, want) {
+			t.Errorf("%s: expected a failure with %q, got %+v", line, want, r)
+		}
+	}
+
+	// A streamed body keeps only its start, its size is the whole one
+	streamed := entry
+	streamed.Response.Size, streamed.Response.Truncated = 2<<20, true
+	if a, _ := Parse("size > 1mb"); !Evaluate([]model.Assertion{a}, streamed)[0].Passed {
+		t.Error("expected the size of a streamed body to be its whole size")
+	}
+
+	// Every assertion fails without a response
+	results := Evaluate(assertions[:2], model.HistoryEntry{Error: "connection refused"})
+	if Failed(results) != 2 || !strings.Contains(results[0].Message, "connection refused") {
+		t.Errorf("expected both assertions to fail, got %+v", results)
+	}
+
+	entry.Response.Body = "<html></html>"
+	r := Evaluate(assertions[8:9], entry)[0]
+	if r.Passed || r.Message != "the body is not JSON" {
+		t.Errorf("expected a JSON failure, got %+v", r)
+	}
+}
@@ -0,0 +1,469 @@
+// Package bench fires a request repeatedly, at a set concurrency or rate,
+// and measures its latency.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Config sets how many requests a benchmark sends and how fast
+type Config struct {
+	// Requests stops the benchmark after that many requests, when set
+	Requests int `json:"requests,omitempty"`
+	// Duration stops the benchmark after that time, when set
+	Duration time.Duration `json:"durationNs,omitempty"`
+	// Concurrency is the number of requests in flight, 1 when unset
+	Concurrency int `json:"concurrency"`
+	// RPS caps the rate of requests per second, unlimited when unset
+	RPS float64 `json:"rps,omitempty"`
+}
+
+// Validate checks that the benchmark stops at some point
+func (c Config) Validate() error {
+	if c.Requests < 0 || c.Duration < 0 || c.Concurrency < 0 || c.RPS < 0 {
+		return fmt.Errorf("values cannot be negative")
+	}
+	if c.Requests == 0 && c.Duration == 0 {
+		return fmt.Errorf("set a number of requests or a duration")
+	}
+	return nil
+}
+
+// Result is the outcome of one request
+type Result struct {
+	Status int
+	Bytes  int
+	// Reused is set when the request was sent on a kept-alive connection
+	Reused bool
+	Err    error
+}
+
+// SendFunc sends the benchmarked request once
+type SendFunc func() Result
+
+// Runner runs a benchmark and collects its samples. Snapshots can be taken
+// while it runs.
+type Runner struct {
+	cfg  Config
+	send SendFunc
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	started  time.Time
+	finished time.Time
+	done     bool
+	stats    stats
+}
+
+// stats accumulate the results as they are recorded, so a snapshot taken
+// while the benchmark runs does not go over every request
+type stats struct {
+	requests int
+	errors   int
+	reused   int
+	bytes    int64
+
+	statuses   map[int]int
+	errorKinds map[string]int
+	// recent holds when the requests of the last second ended, since the
+	// start
+	recent []time.Duration
+
+	// latencies of the successful requests are only sorted for the final
+	// report, buckets estimate their percentiles while the benchmark runs
+	latencies []time.Duration
+	sorted    bool
+	buckets   []int
+	total     time.Duration
+	min, max  time.Duration
+}
+
+// Latency buckets grow by 1% from 1µs, so percentiles estimated from them
+// are within 1% of the exact ones
+const (
+	bucketBase   = time.Microsecond
+	bucketGrowth = 1.01
+)
+
+// bucketIndex returns the latency bucket of l
+func bucketIndex(l time.Duration) int {
+	if l <= bucketBase {
+		return 0
+	}
+	return int(math.Log(float64(l)/float64(bucketBase))/math.Log(bucketGrowth)) + 1
+}
+
+// bucketUpper returns the largest latency of bucket i
+func bucketUpper(i int) time.Duration {
+	return time.Duration(float64(bucketBase) * math.Pow(bucketGrowth, float64(i)))
+}
+
+func (st *stats) add(end, latency time.Duration, res Result, kind string) {
+	st.requests++
+	st.bytes += int64(res.Bytes)
+	if res.Reused {
+		st.reused++
+	}
+	cut := 0
+	for cut < len(st.recent) && end-st.recent[cut] >= time.Second {
+		cut++
+	}
+	st.recent = append(st.recent[cut:], end)
+	if kind != "" {
+		st.errors++
+		st.errorKinds[kind]++
+		return
+	}
+
+	st.statuses[res.Status]++
+	if len(st.latencies) == 0 || latency < st.min {
+		st.min = latency
+	}
+	st.max = max(st.max, latency)
+	st.total += latency
+	st.latencies = append(st.latencies, latency)
+	st.sorted = false
+	i := bucketIndex(latency)
+	if i >= len(st.buckets) {
+		st.buckets = append(st.buckets, make([]int, i+1-len(st.buckets))...)
+	}
+	st.buckets[i]++
+}
+
+// estimate returns the p-th percentile of the latencies from their
+// buckets, by the nearest-rank method
+func (st *stats) estimate(p float64) time.Duration {
+	rank := max(int(math.Ceil(float64(len(st.latencies))*p/100)), 1)
+	seen := 0
+	for i, n := range st.buckets {
+		seen += n
+		if seen >= rank {
+			return min(max(bucketUpper(i), st.min), st.max)
+		}
+	}
+	return st.max
+}
+
+// histogram spreads the latency buckets over the bars of the histogram
+func (st *stats) histogram() []Bucket {
+	buckets, width := newHistogram(st.min, st.max, len(st.latencies))
+	if width <= 0 {
+		return buckets
+	}
+	for i, n := range st.buckets {
+		if n > 0 {
+			l := min(max(bucketUpper(i), st.min), st.max)
+			buckets[min(int((l-st.min)/width), len(buckets)-1)].Count += n
+		}
+	}
+	return buckets
+}
+
+// New prepares a benchmark of send
+func New(cfg Config, send SendFunc) *Runner {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Runner{cfg: cfg, send: send, cancel: func() {}, stats: stats{statuses: map[int]int{}, errorKinds: map[string]int{}}}
+}
+
+// Config returns the settings of the benchmark
+func (r *Runner) Config() Config {
+	return r.cfg
+}
+
+// Run sends the requests and returns once the benchmark is over, stopped or
+// ctx is done
+func (r *Runner) Run(ctx context.Context) {
+	if r.cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Duration)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r.mu.Lock()
+	r.cancel = cancel
+	r.started = time.Now()
+	r.mu.Unlock()
+
+	// Tokens pace the workers when a rate is set
+	var tokens <-chan time.Time
+	if r.cfg.RPS > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / r.cfg.RPS))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	var issued atomic.Int64
+	var wg sync.WaitGroup
+	for range r.cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if tokens != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-tokens:
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+				if r.cfg.Requests > 0 && issued.Add(1) > int64(r.cfg.Requests) {
+					return
+				}
+				start := time.Now()
+				res := r.send()
+				r.record(start, res)
+			}
+		}()
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	r.finished = time.Now()
+	r.done = true
+	r.mu.Unlock()
+}
+
+// Stop ends the benchmark, requests in flight complete
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.mu.Unlock()
+	cancel()
+}
+
+func (r *Runner) record(start time.Time, res Result) {
+	end := time.Now()
+	kind := ""
+	if res.Err != nil {
+		kind = Category(res.Err)
+	}
+	r.mu.Lock()
+	r.stats.add(end.Sub(r.started), end.Sub(start), res, kind)
+	r.mu.Unlock()
+}
+
+// Category groups errors into the kinds shown in the dashboard
+func Category(err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var urlErr *url.Error
+	msg := err.Error()
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case strings.Contains(msg, "connection refused"):
+		return "connection refused"
+	case strings.Contains(msg, "connection reset"), strings.Contains(msg, "broken pipe"), strings.Contains(msg, "EOF"):
+		return "connection reset"
+	case strings.Contains(msg, "tls:"), strings.Contains(msg, "x509:"), strings.Contains(msg, "certificate"):
+		return "tls"
+	case errors.As(err, &urlErr):
+		return "request"
+	}
+	return "other"
+}
+
+// Bucket is a bar of the latency histogram
+type Bucket struct {
+	// From and To bound the latencies of the bucket, To included
+	From  time.Duration `json:"fromNs"`
+	To    time.Duration `json:"toNs"`
+	Count int           `json:"count"`
+}
+
+// Summary holds the statistics of a benchmark so far
+type Summary struct {
+	Config   Config        `json:"config"`
+	Running  bool          `json:"-"`
+	Elapsed  time.Duration `json:"elapsedNs"`
+	Requests int           `json:"requests"`
+	Errors   int           `json:"errors"`
+	Bytes    int64         `json:"bytes"`
+	// Reused counts the requests sent on a kept-alive connection
+	Reused int `json:"reusedConnections"`
+	// RPS is the mean rate since the start, CurrentRPS the rate over the
+	// last second
+	RPS        float64        `json:"rps"`
+	CurrentRPS float64        `json:"-"`
+	Min        time.Duration  `json:"minNs"`
+	Mean       time.Duration  `json:"meanNs"`
+	Max        time.Duration  `json:"maxNs"`
+	P50        time.Duration  `json:"p50Ns"`
+	P90        time.Duration  `json:"p90Ns"`
+	P99        time.Duration  `json:"p99Ns"`
+	Histogram  []Bucket       `json:"histogram"`
+	Statuses   map[int]int    `json:"statuses"`
+	ErrorKinds map[string]int `json:"errorKinds,omitempty"`
+}
+
+// HistogramBuckets is the number of bars of the latency histogram
+const HistogramBuckets = 10
+
+// Snapshot computes the statistics of the requests completed so far.
+// Percentiles are estimated while the benchmark runs, and exact once it is
+// over.
+func (r *Runner) Snapshot() Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := &r.stats
+
+	s := Summary{Config: r.cfg, Running: !r.done, Statuses: map[int]int{}, ErrorKinds: map[string]int{}}
+	switch {
+	case r.started.IsZero():
+	case r.done:
+		s.Elapsed = r.finished.Sub(r.started)
+	default:
+		s.Elapsed = time.Since(r.started)
+	}
+	s.Requests, s.Errors, s.Reused, s.Bytes = st.requests, st.errors, st.reused, st.bytes
+	if s.Elapsed > 0 {
+		s.RPS = float64(s.Requests) / s.Elapsed.Seconds()
+	}
+	for status, n := range st.statuses {
+		s.Statuses[status] = n
+	}
+	for kind, n := range st.errorKinds {
+		s.ErrorKinds[kind] = n
+	}
+	for _, end := range st.recent {
+		if s.Elapsed-end < time.Second {
+			s.CurrentRPS++
+		}
+	}
+	if r.done {
+		s.CurrentRPS = 0
+	} else if s.Elapsed < time.Second {
+		s.CurrentRPS = s.RPS
+	}
+	if len(st.latencies) == 0 {
+		return s
+	}
+
+	s.Min, s.Max = st.min, st.max
+	s.Mean = st.total / time.Duration(len(st.latencies))
+	if !r.done {
+		s.P50, s.P90, s.P99 = st.estimate(50), st.estimate(90), st.estimate(99)
+		s.Histogram = st.histogram()
+		return s
+	}
+	if !st.sorted {
+		sort.Slice(st.latencies, func(i, j int) bool { return st.latencies[i] < st.latencies[j] })
+		st.sorted = true
+	}
+	s.P50 = Percentile(st.latencies, 50)
+	s.P90 = Percentile(st.latencies, 90)
+	s.P99 = Percentile(st.latencies, 99)
+	s.Histogram = histogram(st.latencies)
+	return s
+}
+
+// Percentile returns the p-th percentile of sorted latencies, by the
+// nearest-rank method
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(len(sorted))*p/100)) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// histogram spreads sorted latencies over equal-width buckets from the
+// fastest to the slowest
+func histogram(sorted []time.Duration) []Bucket {
+	lo := sorted[0]
+	buckets, width := newHistogram(lo, sorted[len(sorted)-1], len(sorted))
+	if width <= 0 {
+		return buckets
+	}
+	for _, l := range sorted {
+		i := min(int((l-lo)/width), len(buckets)-1)
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// newHistogram returns the empty equal-width bars from lo to hi and their
+// width, or a single bar holding all n latencies when they are all equal
+func newHistogram(lo, hi time.Duration, n int) ([]Bucket, time.Duration) {
+	width := (hi - lo) / HistogramBuckets
+	if width <= 0 {
+		return []Bucket{{From: lo, To: hi, Count: n}}, 0
+	}
+	buckets := make([]Bucket, HistogramBuckets)
+	for i := range buckets {
+		buckets[i].From = lo + time.Duration(i)*width
+		buckets[i].To = lo + time.Duration(i+1)*width
+	}
+	buckets[len(buckets)-1].To = hi
+	return buckets, width
+}
+
+// Marshal encodes a summary as indented JSON
+func Marshal(s Summary) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// TickMsg asks the dashboard to refresh while a benchmark runs
+type TickMsg struct{}
+
+// DoneMsg reports the end of a benchmark
+type DoneMsg struct {
+	Runner *Runner
+}
+
+// ExportedMsg reports the result of an export
+type ExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// RunCmd runs the benchmark in the background and reports when it is over
+func RunCmd(r *Runner) tea.Cmd {
+	return func() tea.Msg {
+		r.Run(context.Background())
+		return DoneMsg{Runner: r}
+	}
+}
+
+// TickCmd schedules the next refresh of the dashboard
+func TickCmd() tea.Cmd {
+	return tea.Tick(250*time.Millisecond, func(time.Time) tea.Msg {
+		return TickMsg{}
+	})
+}
+
+// ExportCmd writes a summary as JSON
+func ExportCmd(path string, s Summary) tea.Cmd {
+	return func() tea.Msg {
+		data, err := Marshal(s)
+		if err == nil {
+			err = os.WriteFile(path, data, 0o644)
+		}
+		return ExportedMsg{Path: path, Err: err}
+	}
+}
@@ -0,0 +1,144 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond, 0: time.Millisecond} {
+		if got := Percentile(sorted, p); got != want {
+			t.Errorf("p%v: expected %v, got %v", p, want, got)
+		}
+	}
+	if Percentile(sorted[:1], 99) != time.Millisecond || Percentile(nil, 50) != 0 {
+		t.Error("unexpected percentile of short lists")
+	}
+
+	buckets := histogram(sorted)
+	total := 0
+	for _, b := range buckets {
+		total += b.Count
+	}
+	if len(buckets) != HistogramBuckets || total != 100 || buckets[0].From != time.Millisecond || buckets[9].To != 100*time.Millisecond {
+		t.Errorf("unexpected histogram %+v", buckets)
+	}
+	if buckets := histogram(sorted[:1]); len(buckets) != 1 || buckets[0].Count != 1 {
+		t.Errorf("expected a single bucket, got %+v", buckets)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	st := stats{statuses: map[int]int{}, errorKinds: map[string]int{}}
+	var sorted []time.Duration
+	for i := 1; i <= 1000; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+		// Record out of order, as concurrent workers do
+		st.add(0, time.Duration((i*7919)%1000+1)*time.Millisecond, Result{Status: 200}, "")
+	}
+	for _, p := range []float64{50, 90, 99} {
+		exact, got := Percentile(sorted, p), st.estimate(p)
+		if got < exact || float64(got) > float64(exact)*bucketGrowth {
+			t.Errorf("p%v: expected about %v, got %v", p, exact, got)
+		}
+	}
+
+	total := 0
+	buckets := st.histogram()
+	for i, b := range buckets {
+		total += b.Count
+		if exact := histogram(sorted)[i].Count; b.Count < exact-10 || b.Count > exact+10 {
+			t.Errorf("bar %d: expected about %d latencies, got %d", i, exact, b.Count)
+		}
+	}
+	if len(buckets) != HistogramBuckets || total != 1000 || buckets[0].From != time.Millisecond || buckets[9].To != time.Second {
+		t.Errorf("unexpected histogram %+v", buckets)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int64
+	send := func() Result {
+		n := calls.Add(1)
+		time.Sleep(time.Millisecond)
+		switch {
+		case n%10 == 0:
+			return Result{Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+		case n%5 == 0:
+			return Result{Status: 503, Bytes: 2}
+		}
+		return Result{Status: 200, Bytes: 10, Reused: n > 4}
+	}
+
+	r := New(Config{Requests: 50, Concurrency: 4}, send)
+	r.Run(context.Background())
+	s := r.Snapshot()
+	if calls.Load() != 50 || s.Requests != 50 || s.Running {
+		t.Fatalf("expected 50 requests, sent %d, got %+v", calls.Load(), s)
+	}
+	if s.Errors != 5 || s.ErrorKinds["connection refused"] != 5 || s.Statuses[503] != 5 || s.Statuses[200] != 40 || s.Bytes != 410 || s.Reused != 36 {
+		t.Errorf("unexpected breakdown %+v", s)
+	}
+	if s.Min < time.Millisecond || s.P50 < s.Min || s.P99 < s.P90 || s.Max < s.P99 || s.RPS <= 0 {
+		t.Errorf("unexpected latencies %+v", s)
+	}
+	data, err := Marshal(s)
+	if err != nil || !strings.Contains(string(data), `"p99Ns"`) || !strings.Contains(string(data), `"503": 5`) {
+		t.Errorf("unexpected export %s, %v", data, err)
+	}
+
+	// A rate caps the requests, a duration ends the run
+	calls.Store(0)
+	r = New(Config{Duration: 300 * time.Millisecond, Concurrency: 8, RPS: 50}, send)
+	r.Run(context.Background())
+	if n := calls.Load(); n < 10 || n > 16 {
+		t.Errorf("expected about 15 requests at 50/s in 300ms, got %d", n)
+	}
+
+	// Stop ends an endless run
+	r = New(Config{Duration: time.Hour}, send)
+	done := make(chan struct{})
+	go func() {
+		r.Run(context.Background())
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if !r.Snapshot().Running {
+		t.Error("expected the benchmark to be running")
+	}
+	r.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Stop to end the benchmark")
+	}
+}
+
+func TestCategory(t *testing.T) {
+	tests := map[error]string{
+		context.DeadlineExceeded:                                        "timeout",
+		&net.DNSError{Err: "no such host", Name: "nope.invalid"}:        "dns",
+		errors.New("dial tcp 127.0.0.1:1: connect: connection refused"): "connection refused",
+		errors.New("read: connection reset by peer"):                    "connection reset",
+		errors.New("tls: failed to verify certificate"):                 "tls",
+		fmt.Errorf("boom"): "other",
+	}
+	for err, want := range tests {
+		if got := Category(err); got != want {
+			t.Errorf("%v: expected %q, got %q", err, want, got)
+		}
+	}
+	if err := (Config{}).Validate(); err == nil {
+		t.Error("expected a benchmark without an end to be rejected")
+	}
+}
@@ -0,0 +1,131 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// Generator renders a request as code for one language or tool
+type Generator interface {
+	// Name is the label shown in the target list
+	Name() string
+	// Extension is the file extension used when saving the code
+	Extension() string
+	// Generate returns the code sending r
+	Generate(r model.Request) string
+}
+
+var registry []Generator
+
+// Register adds a generator to the targets offered by Generators
+func Register(g Generator) {
+	registry = append(registry, g)
+}
+
+// Generators lists the registered generators in registration order
+func Generators() []Generator {
+	return registry
+}
+
+// Lookup finds a generator by name, ignoring case
+func Lookup(name string) (Generator, bool) {
+	for _, g := range registry {
+		if strings.EqualFold(g.Name(), name) {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	Register(goGenerator{})
+	Register(pythonGenerator{})
+	Register(fetchGenerator{})
+	Register(httpieGenerator{})
+	Register(powershellGenerator{})
+}
+
+// prepare applies the auth settings of r as headers or query parameters, drops
+// empty headers and drops the body of methods that do not send one, so that
+// generators only deal with a method, URL, headers and body
+func prepare(r model.Request) model.Request {
+	out := model.Request{Method: strings.ToUpper(r.Method), URL: r.URL}
+	if out.Method == "" {
+		out.Method = "GET"
+	}
+	for _, h := range r.Headers {
+		if h.Key != "" {
+			out.Headers = append(out.Headers, h)
+		}
+	}
+	if out.Method == "POST" || out.Method == "PUT" || out.Method == "PATCH" {
+		out.Body = r.Body
+	}
+
+	if r.Auth == nil {
+		return out
+	}
+	switch r.Auth.Type {
+	case model.AuthBasic:
+		creds := base64.StdEncoding.EncodeToString([]byte(r.Auth.Username + ":" + r.Auth.Password))
+		out.Headers = setHeader(out.Headers, "Authorization", "Basic "+creds)
+	case model.AuthBearer:
+		out.Headers = setHeader(out.Headers, "Authorization", "Bearer "+r.Auth.Token)
+	case model.AuthAPIKey:
+		if r.Auth.Key == "" {
+			break
+		}
+		if r.Auth.In == "query" {
+			if u, err := url.Parse(out.URL); err == nil {
+				q := u.Query()
+				q.Set(r.Auth.Key, r.Auth.Value)
+				u.RawQuery = q.Encode()
+				out.URL = u.String()
+			}
+		} else {
+			out.Headers = setHeader(out.Headers, r.Auth.Key, r.Auth.Value)
+		}
+	}
+	return out
+}
+
+// setHeader replaces a header, ignoring case, or appends it
+func setHeader(headers []model.HeaderPair, key, value string) []model.HeaderPair {
+	for i, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			headers[i].Value = value
+			return headers
+		}
+	}
+	return append(headers, model.HeaderPair{Key: key, Value: value})
+}
+
+// jsonString quotes s as a JSON string, which is also a valid Python and
+// JavaScript string literal
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@%+=,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// psQuote quotes s as a PowerShell verbatim string
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,148 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+var postRequest = model.Request{
+	Method: "POST",
+	URL:    "https://api.example.com/items?tag=new",
+	Headers: []model.HeaderPair{
+		{Key: "Content-Type", Value: "application/json"},
+		{Key: "", Value: "ignored"},
+	},
+	Body: "{\n  \"name\": \"it's\"\n}",
+	Auth: &model.Auth{Type: model.AuthBearer, Token: "secret"},
+}
+
+func TestRegistry(t *testing.T) {
+	var names []string
+	for _, g := range Generators() {
+		names = append(names, g.Name())
+	}
+	want := "Go net/http, Python requests, JavaScript fetch, HTTPie, PowerShell"
+	if strings.Join(names, ", ") != want {
+		t.Errorf("unexpected generators %v", names)
+	}
+	if g, ok := Lookup("httpie"); !ok || g.Extension() != "sh" {
+		t.Errorf("expected to find HTTPie ignoring case")
+	}
+	if _, ok := Lookup("cobol"); ok {
+		t.Error("expected unknown generator not to be found")
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	r := prepare(model.Request{
+		Method: "get",
+		URL:    "https://example.com/?a=1",
+		Body:   "dropped",
+		Auth:   &model.Auth{Type: model.AuthAPIKey, Key: "key", Value: "v", In: "query"},
+	})
+	if r.Method != "GET" || r.Body != "" || r.URL != "https://example.com/?a=1&key=v" {
+		t.Errorf("unexpected request %+v", r)
+	}
+
+	r = prepare(model.Request{Method: "GET", URL: "https://example.com", Auth: &model.Auth{Type: model.AuthBasic, Username: "u", Password: "p"}})
+	if len(r.Headers) != 1 || r.Headers[0].Value != "Basic dTpw" {
+		t.Errorf("expected basic auth header, got %+v", r.Headers)
+	}
+}
+
+func TestGoGenerator(t *testing.T) {
+	code := goGenerator{}.Generate(postRequest)
+	if _, err := parser.ParseFile(token.NewFileSet(), "main.go", code, 0); err != nil {
+		t.Fatalf("generated Go does not parse: %v\n%s", err, code)
+	}
+	for _, want := range []string{
+		"body := strings.NewReader(`{\n  \"name\": \"it's\"\n}`)",
+		`http.NewRequest("POST", "https://api.example.com/items?tag=new", body)`,
+		`req.Header.Set("Authorization", "Bearer secret")`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("expected %q in:\n%s", want, code)
+		}
+	}
+
+	code = goGenerator{}.Generate(model.Request{Method: "GET", URL: "https://example.com"})
+	if strings.Contains(code, `"strings"`) || !strings.Contains(code, `"https://example.com", nil)`) {
+		t.Errorf("expected a request without body:\n%s", code)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "main.go", code, 0); err != nil {
+		t.Fatalf("generated Go does not parse: %v", err)
+	}
+}
+
+func TestPythonGenerator(t *testing.T) {
+	code := pythonGenerator{}.Generate(postRequest)
+	want := `import requests
+
+url = "https://api.example.com/items?tag=new"
+headers = {
+    "Content-Type": "application/json",
+    "Authorization": "Bearer secret",
+}
+data = "{\n  \"name\": \"it's\"\n}"
+
+response = requests.request("POST", url, headers=headers, data=data)
+print(response.status_code)
+print(response.text)
+`
+	if code != want {
+		t.Errorf("unexpected code:\n%s", code)
+	}
+}
+
+func TestFetchGenerator(t *testing.T) {
+	code := fetchGenerator{}.Generate(model.Request{Method: "DELETE", URL: "https://example.com/items/1"})
+	want := `const response = await fetch("https://example.com/items/1", {
+  method: "DELETE",
+});
+
+console.log(response.status);
+console.log(await response.text());
+`
+	if code != want {
+		t.Errorf("unexpected code:\n%s", code)
+	}
+	if code := (fetchGenerator{}).Generate(postRequest); !strings.Contains(code, `body: "{\n  \"name\": \"it's\"\n}",`) {
+		t.Errorf("expected the body to be quoted:\n%s", code)
+	}
+}
+
+func TestHTTPieGenerator(t *testing.T) {
+	code := httpieGenerator{}.Generate(postRequest)
+	want := `http --raw '{
+  "name": "it'\''s"
+}' POST 'https://api.example.com/items?tag=new' \
+  Content-Type:application/json \
+  'Authorization:Bearer secret'
+`
+	if code != want {
+		t.Errorf("unexpected code:\n%s", code)
+	}
+}
+
+func TestPowerShellGenerator(t *testing.T) {
+	code := powershellGenerator{}.Generate(postRequest)
+	want := `$headers = @{
+    'Authorization' = 'Bearer secret'
+}
+$body = @'
+{
+  "name": "it's"
+}
+'@
+
+$response = Invoke-RestMethod -Uri 'https://api.example.com/items?tag=new' -Method Post -Headers $headers -ContentType 'application/json' -Body $body
+$response
+`
+	if code != want {
+		t.Errorf("unexpected code:\n%s", code)
+	}
+}
@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// goGenerator renders a Go program using net/http
+type goGenerator struct{}
+
+func (goGenerator) Name() string      { return "Go net/http" }
+func (goGenerator) Extension() string { return "go" }
+
+func (goGenerator) Generate(r model.Request) string {
+	r = prepare(r)
+	var b strings.Builder
+
+	b.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n")
+	if r.Body != "" {
+		b.WriteString("\t\"strings\"\n")
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+
+	bodyArg := "nil"
+	if r.Body != "" {
+		fmt.Fprintf(&b, "\tbody := strings.NewReader(%s)\n", goString(r.Body))
+		bodyArg = "body"
+	}
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n", strconv.Quote(r.Method), strconv.Quote(r.URL), bodyArg)
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	for _, h := range r.Headers {
+		fmt.Fprintf(&b, "\treq.Header.Set(%s, %s)\n", strconv.Quote(h.Key), strconv.Quote(h.Value))
+	}
+
+	b.WriteString(`
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(resp.Status)
+	fmt.Println(string(data))
+}
+`)
+	return b.String()
+}
+
+// goString quotes s as a raw string literal when it can, to keep JSON readable
+func goString(s string) string {
+	if !strings.Contains(s, "`") && !strings.Contains(s, "\r") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
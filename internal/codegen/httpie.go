@@ -0,0 +1,29 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// httpieGenerator renders an HTTPie command line
+type httpieGenerator struct{}
+
+func (httpieGenerator) Name() string      { return "HTTPie" }
+func (httpieGenerator) Extension() string { return "sh" }
+
+func (httpieGenerator) Generate(r model.Request) string {
+	r = prepare(r)
+
+	first := []string{"http"}
+	if r.Body != "" {
+		first = append(first, "--raw", shellQuote(r.Body))
+	}
+	first = append(first, r.Method, shellQuote(r.URL))
+
+	lines := []string{strings.Join(first, " ")}
+	for _, h := range r.Headers {
+		lines = append(lines, "  "+shellQuote(h.Key+":"+h.Value))
+	}
+	return strings.Join(lines, " \\\n") + "\n"
+}
@@ -0,0 +1,34 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// fetchGenerator renders JavaScript using the fetch API
+type fetchGenerator struct{}
+
+func (fetchGenerator) Name() string      { return "JavaScript fetch" }
+func (fetchGenerator) Extension() string { return "js" }
+
+func (fetchGenerator) Generate(r model.Request) string {
+	r = prepare(r)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", jsonString(r.URL))
+	fmt.Fprintf(&b, "  method: %s,\n", jsonString(r.Method))
+	if len(r.Headers) > 0 {
+		b.WriteString("  headers: {\n")
+		for _, h := range r.Headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonString(h.Key), jsonString(h.Value))
+		}
+		b.WriteString("  },\n")
+	}
+	if r.Body != "" {
+		fmt.Fprintf(&b, "  body: %s,\n", jsonString(r.Body))
+	}
+	b.WriteString("});\n\nconsole.log(response.status);\nconsole.log(await response.text());\n")
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// powershellGenerator renders a PowerShell Invoke-RestMethod call
+type powershellGenerator struct{}
+
+func (powershellGenerator) Name() string      { return "PowerShell" }
+func (powershellGenerator) Extension() string { return "ps1" }
+
+func (powershellGenerator) Generate(r model.Request) string {
+	r = prepare(r)
+	var b strings.Builder
+
+	// Invoke-RestMethod takes the content type as a parameter rather than a header
+	contentType := ""
+	var headers []model.HeaderPair
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			contentType = h.Value
+			continue
+		}
+		headers = append(headers, h)
+	}
+
+	method := strings.ToUpper(r.Method[:1]) + strings.ToLower(r.Method[1:])
+	args := []string{"-Uri " + psQuote(r.URL), "-Method " + method}
+	if len(headers) > 0 {
+		b.WriteString("$headers = @{\n")
+		for _, h := range headers {
+			fmt.Fprintf(&b, "    %s = %s\n", psQuote(h.Key), psQuote(h.Value))
+		}
+		b.WriteString("}\n")
+		args = append(args, "-Headers $headers")
+	}
+	if contentType != "" {
+		args = append(args, "-ContentType "+psQuote(contentType))
+	}
+	if r.Body != "" {
+		if strings.Contains(r.Body, "\n") && !strings.Contains(r.Body, "\n'@") {
+			fmt.Fprintf(&b, "$body = @'\n%s\n'@\n", r.Body)
+		} else {
+			fmt.Fprintf(&b, "$body = %s\n", psQuote(r.Body))
+		}
+		args = append(args, "-Body $body")
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "$response = Invoke-RestMethod %s\n$response\n", strings.Join(args, " "))
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// pythonGenerator renders a Python script using requests
+type pythonGenerator struct{}
+
+func (pythonGenerator) Name() string      { return "Python requests" }
+func (pythonGenerator) Extension() string { return "py" }
+
+func (pythonGenerator) Generate(r model.Request) string {
+	r = prepare(r)
+	var b strings.Builder
+
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", jsonString(r.URL))
+	args := []string{"url"}
+	if len(r.Headers) > 0 {
+		b.WriteString("headers = {\n")
+		for _, h := range r.Headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonString(h.Key), jsonString(h.Value))
+		}
+		b.WriteString("}\n")
+		args = append(args, "headers=headers")
+	}
+	if r.Body != "" {
+		fmt.Fprintf(&b, "data = %s\n", jsonString(r.Body))
+		args = append(args, "data=data")
+	}
+
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s, %s)\n", jsonString(r.Method), strings.Join(args, ", "))
+	b.WriteString("print(response.status_code)\nprint(response.text)\n")
+	return b.String()
+}
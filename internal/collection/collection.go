@@ -0,0 +1,40 @@
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// Load reads a collection from a JSON file
+func Load(path string) (*model.Collection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c model.Collection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s is not a valid collection: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes a collection to a JSON file
+func Save(c *model.Collection, path string) error {
+	data, err := Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Marshal encodes a collection as indented JSON
+func Marshal(c *model.Collection) ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
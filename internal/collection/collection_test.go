@@ -0,0 +1,57 @@
+package collection
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	c := &model.Collection{
+		Name:      "Pets",
+		Variables: []model.Variable{{Key: "baseUrl", Value: "https://pets.example.com"}},
+		Folders: []model.Folder{{
+			Name: "pets",
+			Requests: []model.SavedRequest{{
+				Name: "List pets",
+				Request: model.Request{
+					Method:  "GET",
+					URL:     "{{baseUrl}}/pets",
+					Headers: []model.HeaderPair{{Key: "Accept", Value: "application/json"}},
+					Auth:    &model.Auth{Type: model.AuthBearer, Token: "{{token}}"},
+				},
+			}},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "pets.json")
+	if err := Save(c, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := loaded.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	req := entries[0].Request
+	if req.Name != "List pets" || req.URL != "{{baseUrl}}/pets" || req.Auth.Token != "{{token}}" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if entries[0].Path[0] != "pets" {
+		t.Errorf("expected folder path [pets], got %v", entries[0].Path)
+	}
+	if loaded.VariableMap()["baseUrl"] != "https://pets.example.com" {
+		t.Errorf("unexpected variables: %v", loaded.Variables)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,156 @@
+// Package contract checks responses against the contract of their request:
+// a JSON Schema for the body, or the operation of an OpenAPI specification
+// for the status, content type, headers and body.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/openapi"
+	"github.com/tbourrel/apitty/internal/schema"
+)
+
+// Paths of the violations that are not about the body, which use JSONPath
+const (
+	PathContract = "contract"
+	PathStatus   = "status"
+	PathHeader   = "header "
+)
+
+// Check validates a response against a contract. Problems with the contract
+// itself, such as a missing file, are violations too so they are not missed.
+func Check(c model.Contract, e model.HistoryEntry) []schema.Violation {
+	if c.IsZero() {
+		return nil
+	}
+	if e.Error != "" {
+		return []schema.Violation{{Path: PathContract, Message: "no response: " + e.Error}}
+	}
+
+	var out []schema.Violation
+	if c.Schema != "" {
+		root, err := schema.Load(c.Schema)
+		if err != nil {
+			out = append(out, schema.Violation{Path: PathContract, Message: fmt.Sprintf("could not load the schema: %v", err)})
+		} else {
+			out = append(out, checkBody(schema.New(root), root, e)...)
+		}
+	}
+	if c.OpenAPI != "" {
+		out = append(out, checkOperation(c, e)...)
+	}
+	return out
+}
+
+func checkOperation(c model.Contract, e model.HistoryEntry) []schema.Violation {
+	fail := func(path, format string, args ...any) []schema.Violation {
+		return []schema.Violation{{Path: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	doc, err := openapi.Load(c.OpenAPI)
+	if err != nil {
+		return fail(PathContract, "could not load the specification: %v", err)
+	}
+	var op *openapi.Operation
+	if c.Operation != "" {
+		op, err = doc.FindOperation(c.Operation)
+	} else {
+		op, err = doc.MatchOperation(e.Request.Method, e.Request.URL)
+	}
+	if err != nil {
+		return fail(PathContract, "%v", err)
+	}
+
+	resp, ok := op.Response(e.Response.StatusCode)
+	if !ok {
+		return fail(PathStatus, "%d is not documented for %s %s, expected %s",
+			e.Response.StatusCode, op.Method, op.Path, strings.Join(op.Statuses(), ", "))
+	}
+
+	var out []schema.Violation
+	validator := schema.New(doc.Root())
+	for _, h := range resp.Headers {
+		// Content-Type is checked against the media types below
+		if strings.EqualFold(h.Name, "Content-Type") {
+			continue
+		}
+		value, found := header(e, h.Name)
+		switch {
+		case !found && h.Required:
+			out = append(out, fail(PathHeader+h.Name, "required header is missing")...)
+		case found && h.Schema != nil:
+			for _, v := range validator.Validate(h.Schema, headerValue(value, h.Schema)) {
+				out = append(out, schema.Violation{Path: PathHeader + h.Name, Message: v.Message})
+			}
+		}
+	}
+
+	if len(resp.Content) == 0 || e.Request.Method == "HEAD" {
+		return out
+	}
+	contentType, _ := header(e, "Content-Type")
+	if contentType == "" {
+		if e.Response.Body == "" && e.Response.Size == 0 {
+			return append(out, fail("$", "the body is empty, expected %s", strings.Join(resp.MediaTypes(), ", "))...)
+		}
+		return append(out, fail(PathHeader+"Content-Type", "missing, expected %s", strings.Join(resp.MediaTypes(), ", "))...)
+	}
+	mediaType, ok := resp.MediaType(contentType)
+	if !ok {
+		return append(out, fail(PathHeader+"Content-Type", "%s is not one of %s", contentType, strings.Join(resp.MediaTypes(), ", "))...)
+	}
+	body := resp.Content[mediaType]
+	if body == nil || !isJSON(contentType) {
+		// Only JSON bodies are validated against their schema
+		return out
+	}
+	return append(out, checkBody(validator, body, e)...)
+}
+
+// checkBody validates the JSON body of a response
+func checkBody(v *schema.Validator, s any, e model.HistoryEntry) []schema.Violation {
+	if e.Response.Truncated || int64(len(e.Response.Body)) < e.Response.Size {
+		return []schema.Violation{{Path: "$", Message: "only the start of the body was kept, it cannot be validated"}}
+	}
+	doc, err := jsonpath.Decode([]byte(e.Response.Body))
+	if err != nil {
+		return []schema.Violation{{Path: "$", Message: "the body is not JSON"}}
+	}
+	return v.Validate(s, doc)
+}
+
+// header returns the first value of a response header
+func header(e model.HistoryEntry, name string) (string, bool) {
+	for _, h := range e.Response.Headers {
+		if strings.EqualFold(h.Key, name) {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+// headerValue converts a header to the type of its schema, as headers are
+// text on the wire
+func headerValue(value string, s any) any {
+	m, _ := s.(map[string]any)
+	switch m["type"] {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
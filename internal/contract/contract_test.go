@@ -0,0 +1,95 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func response(status int, contentType, body string, headers ...model.HeaderPair) model.HistoryEntry {
+	if contentType != "" {
+		headers = append(headers, model.HeaderPair{Key: "Content-Type", Value: contentType})
+	}
+	return model.HistoryEntry{
+		Request: model.Request{Method: "GET", URL: "https://shop.example.com/api/orders/7"},
+		Response: model.Response{
+			StatusCode: status,
+			Headers:    headers,
+			Body:       body,
+			Size:       int64(len(body)),
+		},
+	}
+}
+
+func violations(c model.Contract, e model.HistoryEntry) string {
+	var lines []string
+	for _, v := range Check(c, e) {
+		lines = append(lines, v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCheckOpenAPI(t *testing.T) {
+	rate := model.HeaderPair{Key: "x-rate-limit", Value: "99"}
+	const order = `{"id": 7, "status": "open", "note": null, "lines": [{"sku": "A1", "quantity": 2}]}`
+
+	tests := []struct {
+		name     string
+		entry    model.HistoryEntry
+		expected string
+	}{
+		{"valid", response(200, "application/json; charset=utf-8", order, rate), ""},
+		{"undocumented status", response(500, "text/plain", "oops"), "status: 500 is not documented for GET /orders/{id}, expected 200, 4XX"},
+		{"missing header", response(200, "application/json", order), "header X-Rate-Limit: required header is missing"},
+		{"header type", response(200, "application/json", order, model.HeaderPair{Key: "X-Rate-Limit", Value: "-1"}), "header X-Rate-Limit: -1 is less than the minimum 0"},
+		{"content type", response(200, "text/html", "<p>", rate), "header Content-Type: text/html is not one of application/json"},
+		{"not JSON", response(200, "application/json", "<p>", rate), "$: the body is not JSON"},
+		{"body", response(200, "application/json", `{"id": "7", "status": "lost", "lines": [{"sku": "A1", "quantity": 0}]}`, rate),
+			"$.id: expected integer, got string\n$.lines[0].quantity: 0 is less than the minimum 1\n$.status: \"lost\" is not one of [\"open\",\"shipped\"]"},
+		{"range", response(404, "application/problem+json", `{"detail": "no order 7"}`), "$.title: required property is missing"},
+	}
+	for _, test := range tests {
+		c := model.Contract{OpenAPI: "testdata/orders.yaml"}
+		if got := violations(c, test.entry); got != test.expected {
+			t.Errorf("%s:\ngot      %q\nexpected %q", test.name, got, test.expected)
+		}
+		c.Operation = "getOrder"
+		if got := violations(c, test.entry); got != test.expected {
+			t.Errorf("%s by operationId:\ngot      %q\nexpected %q", test.name, got, test.expected)
+		}
+	}
+
+	e := response(200, "application/json", order, rate)
+	e.Request.URL = "https://shop.example.com/api/customers/7"
+	if got := violations(model.Contract{OpenAPI: "testdata/orders.yaml"}, e); !strings.HasPrefix(got, "contract: no operation") {
+		t.Errorf("expected no operation to match, got %q", got)
+	}
+	if got := violations(model.Contract{OpenAPI: "testdata/orders.yaml", Operation: "deleteOrder"}, e); got != "contract: no operation deleteOrder in the specification" {
+		t.Errorf("unexpected violations %q", got)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	c := model.Contract{Schema: "testdata/order.schema.json"}
+	if got := violations(c, response(200, "application/json", `{"id": 7}`)); got != "" {
+		t.Errorf("expected no violations, got %q", got)
+	}
+	if got := violations(c, response(200, "application/json", `{"id": 7.5}`)); got != "$.id: expected integer, got number" {
+		t.Errorf("unexpected violations %q", got)
+	}
+
+	preview := response(200, "application/json", `{"id": 7`)
+	preview.Response.Size = 4096
+	if got := violations(c, preview); got != "$: only the start of the body was kept, it cannot be validated" {
+		t.Errorf("unexpected violations %q", got)
+	}
+
+	missing := model.Contract{Schema: "testdata/missing.json"}
+	if got := violations(missing, response(200, "", "")); !strings.HasPrefix(got, "contract: could not load the schema") {
+		t.Errorf("unexpected violations %q", got)
+	}
+	if got := violations(model.Contract{}, response(200, "", "")); got != "" {
+		t.Errorf("expected an empty contract to check nothing, got %q", got)
+	}
+}
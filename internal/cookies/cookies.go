@@ -0,0 +1,397 @@
+package cookies
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Cookie is a cookie stored in a jar
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// Expires is zero for session cookies
+	Expires  time.Time `json:"expires,omitzero"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"httpOnly,omitempty"`
+	SameSite string    `json:"sameSite,omitempty"`
+	// HostOnly cookies are only sent to Domain, not to its subdomains
+	HostOnly bool `json:"hostOnly,omitempty"`
+}
+
+// Expired reports whether the cookie expired at now
+func (c Cookie) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// Flags describes the attributes of the cookie, such as "Secure, HttpOnly"
+func (c Cookie) Flags() string {
+	var flags []string
+	if c.Secure {
+		flags = append(flags, "Secure")
+	}
+	if c.HttpOnly {
+		flags = append(flags, "HttpOnly")
+	}
+	if c.SameSite != "" {
+		flags = append(flags, "SameSite="+c.SameSite)
+	}
+	if c.HostOnly {
+		flags = append(flags, "HostOnly")
+	}
+	return strings.Join(flags, ", ")
+}
+
+// sameKey reports whether a and b are the same cookie, which replace each other
+func sameKey(a, b Cookie) bool {
+	return a.Name == b.Name && strings.EqualFold(a.Domain, b.Domain) && a.Path == b.Path
+}
+
+// Jar is an http.CookieJar that can list and edit its cookies. It is safe
+// for concurrent use.
+type Jar struct {
+	mu      sync.Mutex
+	cookies []Cookie
+	now     func() time.Time
+}
+
+// NewJar returns a jar holding cookies
+func NewJar(cookies ...Cookie) *Jar {
+	j := &Jar{now: time.Now}
+	for _, c := range cookies {
+		j.Set(c)
+	}
+	return j
+}
+
+// SetCookies stores the cookies set by a response from u
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	for _, hc := range cookies {
+		c := Cookie{
+			Name:     hc.Name,
+			Value:    hc.Value,
+			Path:     hc.Path,
+			Secure:   hc.Secure,
+			HttpOnly: hc.HttpOnly,
+			SameSite: sameSite(hc.SameSite),
+		}
+		if c.Path == "" || c.Path[0] != '/' {
+			c.Path = defaultPath(u.Path)
+		}
+
+		domain := strings.TrimPrefix(strings.ToLower(hc.Domain), ".")
+		switch {
+		case domain == "" || domain == host:
+			c.Domain, c.HostOnly = host, domain == ""
+		case net.ParseIP(host) == nil && strings.HasSuffix(host, "."+domain) && !isPublicSuffix(domain):
+			c.Domain = domain
+		default:
+			// A host can't set cookies for another domain, nor for a public
+			// suffix such as co.uk that every site under it would receive
+			continue
+		}
+
+		switch {
+		case hc.MaxAge < 0:
+			c.Expires = now
+		case hc.MaxAge > 0:
+			c.Expires = now.Add(time.Duration(hc.MaxAge) * time.Second)
+		case !hc.Expires.IsZero():
+			c.Expires = hc.Expires
+		}
+		j.set(c)
+	}
+}
+
+// Cookies returns the cookies to send in a request to u
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.expire()
+
+	var matched []Cookie
+	for _, c := range j.cookies {
+		if c.Secure && u.Scheme != "https" {
+			continue
+		}
+		if !domainMatch(c, host) || !pathMatch(c.Path, path) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	// Longer paths first, as browsers do
+	sort.SliceStable(matched, func(a, b int) bool {
+		return len(matched[a].Path) > len(matched[b].Path)
+	})
+	out := make([]*http.Cookie, len(matched))
+	for i, c := range matched {
+		out[i] = &http.Cookie{Name: c.Name, Value: c.Value}
+	}
+	return out
+}
+
+// List returns the unexpired cookies sorted by domain, path and name
+func (j *Jar) List() []Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.expire()
+	out := append([]Cookie{}, j.cookies...)
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].Domain != out[b].Domain {
+			return out[a].Domain < out[b].Domain
+		}
+		if out[a].Path != out[b].Path {
+			return out[a].Path < out[b].Path
+		}
+		return out[a].Name < out[b].Name
+	})
+	return out
+}
+
+// Set adds c, replacing the cookie with the same name, domain and path
+func (j *Jar) Set(c Cookie) {
+	c.Domain = strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.set(c)
+}
+
+// Delete removes the cookie with the name, domain and path of c
+func (j *Jar) Delete(c Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range j.cookies {
+		if sameKey(j.cookies[i], c) {
+			j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			return
+		}
+	}
+}
+
+// Clear removes every cookie
+func (j *Jar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cookies = nil
+}
+
+// set replaces or appends c, dropping it when it already expired. The
+// caller holds the lock.
+func (j *Jar) set(c Cookie) {
+	expired := c.Expired(j.now())
+	for i := range j.cookies {
+		if sameKey(j.cookies[i], c) {
+			if expired {
+				j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			} else {
+				j.cookies[i] = c
+			}
+			return
+		}
+	}
+	if !expired {
+		j.cookies = append(j.cookies, c)
+	}
+}
+
+// expire drops the expired cookies. The caller holds the lock.
+func (j *Jar) expire() {
+	now := j.now()
+	kept := j.cookies[:0]
+	for _, c := range j.cookies {
+		if !c.Expired(now) {
+			kept = append(kept, c)
+		}
+	}
+	j.cookies = kept
+}
+
+// canonicalHost lowercases the host of a URL and strips its port
+func canonicalHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(strings.ToLower(host), "[]"), ".")
+	if host == "" {
+		return "", fmt.Errorf("no host")
+	}
+	return host, nil
+}
+
+// isPublicSuffix reports whether domain is a suffix under which anyone can
+// register names, such as com or github.io
+func isPublicSuffix(domain string) bool {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix == domain
+}
+
+// defaultPath is the directory of a request path, per RFC 6265 5.1.4
+func defaultPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+func domainMatch(c Cookie, host string) bool {
+	if c.HostOnly || host == c.Domain {
+		return host == c.Domain
+	}
+	return net.ParseIP(host) == nil && strings.HasSuffix(host, "."+c.Domain)
+}
+
+func pathMatch(cookiePath, path string) bool {
+	if path == cookiePath {
+		return true
+	}
+	return strings.HasPrefix(path, cookiePath) &&
+		(strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/')
+}
+
+func sameSite(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+// Store holds a jar per environment, keyed by environment name. The empty
+// name is used when no environment is selected.
+type Store struct {
+	mu   sync.Mutex
+	jars map[string]*Jar
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{jars: make(map[string]*Jar)}
+}
+
+// Jar returns the jar of an environment, creating it if needed
+func (s *Store) Jar(env string) *Jar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jars[env]
+	if !ok {
+		j = NewJar()
+		s.jars[env] = j
+	}
+	return j
+}
+
+// DefaultPath returns where cookies are saved: $APITTY_COOKIES, or
+// cookies.json in the apitty folder of the user config directory
+func DefaultPath() (string, error) {
+	if path := os.Getenv("APITTY_COOKIES"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "apitty", "cookies.json"), nil
+}
+
+// Load reads a store saved by Save. The second result is false when the
+// file does not exist, in which case the store is empty.
+func Load(path string) (*Store, bool, error) {
+	s := NewStore()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var envs map[string][]Cookie
+	if err := json.Unmarshal(data, &envs); err != nil {
+		return nil, false, fmt.Errorf("%s is not a valid cookie file: %w", path, err)
+	}
+	for env, cookies := range envs {
+		s.jars[env] = NewJar(cookies...)
+	}
+	return s, true, nil
+}
+
+// Save writes the unexpired cookies of every environment, creating the
+// directory if needed
+func Save(path string, s *Store) error {
+	envs := make(map[string][]Cookie)
+	s.mu.Lock()
+	for env, j := range s.jars {
+		if cookies := j.List(); len(cookies) > 0 {
+			envs[env] = cookies
+		}
+	}
+	s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(envs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// SavedMsg reports the result of writing or removing the cookie file
+type SavedMsg struct {
+	Err error
+}
+
+// SaveCmd writes the store in the background
+func SaveCmd(path string, s *Store) tea.Cmd {
+	return func() tea.Msg {
+		return SavedMsg{Err: Save(path, s)}
+	}
+}
+
+// RemoveCmd deletes the cookie file in the background, so cookies are no
+// longer kept across sessions
+func RemoveCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return SavedMsg{Err: err}
+	}
+}
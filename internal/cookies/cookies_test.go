@@ -0,0 +1,159 @@
+package cookies
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mustURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// names returns the name=value pairs of cookies
+func names(cookies []*http.Cookie) []string {
+	var out []string
+	for _, c := range cookies {
+		out = append(out, c.Name+"="+c.Value)
+	}
+	return out
+}
+
+func TestJarMatching(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	j := NewJar()
+	j.now = func() time.Time { return now }
+
+	j.SetCookies(mustURL(t, "https://api.example.com/auth/login"), []*http.Cookie{
+		{Name: "session", Value: "abc", HttpOnly: true},
+		{Name: "shared", Value: "1", Domain: ".example.com", Path: "/"},
+		{Name: "secure", Value: "s", Path: "/", Secure: true},
+		{Name: "deep", Value: "d", Path: "/auth/refresh"},
+		{Name: "other", Value: "x", Domain: "other.com"},
+		{Name: "tld", Value: "x", Domain: ".com"},
+	})
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		// session defaults to the /auth directory of the login path
+		{"https://api.example.com/auth/me", []string{"session=abc", "shared=1", "secure=s"}},
+		{"https://api.example.com/auth/refresh", []string{"deep=d", "session=abc", "shared=1", "secure=s"}},
+		{"http://api.example.com/authx", []string{"shared=1"}},
+		{"http://www.example.com/", []string{"shared=1"}},
+		{"http://sub.api.example.com/auth/", []string{"shared=1"}},
+		{"https://other.com/", nil},
+	}
+	for _, tt := range tests {
+		got := names(j.Cookies(mustURL(t, tt.url)))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.url, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %v, got %v", tt.url, tt.want, got)
+				break
+			}
+		}
+	}
+
+	list := j.List()
+	if len(list) != 4 || list[0].Domain != "api.example.com" || !list[0].HostOnly {
+		t.Fatalf("unexpected cookies %+v", list)
+	}
+	if list[len(list)-1].Domain != "example.com" || list[len(list)-1].HostOnly {
+		t.Errorf("expected the domain cookie last, got %+v", list[len(list)-1])
+	}
+
+	// Max-Age and Expires
+	j.SetCookies(mustURL(t, "https://api.example.com/"), []*http.Cookie{
+		{Name: "shared", Domain: "example.com", Path: "/", MaxAge: -1},
+		{Name: "short", Value: "1", Path: "/", MaxAge: 60},
+		{Name: "old", Value: "1", Path: "/", Expires: now.Add(-time.Hour)},
+	})
+	got := names(j.Cookies(mustURL(t, "https://api.example.com/")))
+	if len(got) != 2 || got[0] != "secure=s" || got[1] != "short=1" {
+		t.Fatalf("expected the deleted and past cookies to be gone, got %v", got)
+	}
+	now = now.Add(2 * time.Minute)
+	if got := names(j.Cookies(mustURL(t, "https://api.example.com/"))); len(got) != 1 {
+		t.Errorf("expected short to expire, got %v", got)
+	}
+
+	// A public suffix would share the cookie with every site under it
+	j.SetCookies(mustURL(t, "https://shop.example.co.uk/"), []*http.Cookie{
+		{Name: "suffix", Value: "x", Domain: "co.uk"},
+		{Name: "site", Value: "1", Domain: "example.co.uk"},
+	})
+	if got := names(j.Cookies(mustURL(t, "https://other.co.uk/"))); len(got) != 0 {
+		t.Errorf("expected no cookie for another site under co.uk, got %v", got)
+	}
+	if got := names(j.Cookies(mustURL(t, "https://www.example.co.uk/"))); len(got) != 1 || got[0] != "site=1" {
+		t.Errorf("expected the site cookie, got %v", got)
+	}
+}
+
+func TestJarEdit(t *testing.T) {
+	j := NewJar(Cookie{Name: "a", Value: "1", Domain: ".Example.com"})
+	if got := names(j.Cookies(mustURL(t, "http://www.example.com/x"))); len(got) != 1 || got[0] != "a=1" {
+		t.Fatalf("expected the added cookie to match subdomains, got %v", got)
+	}
+
+	j.Set(Cookie{Name: "a", Value: "2", Domain: "example.com", Path: "/"})
+	list := j.List()
+	if len(list) != 1 || list[0].Value != "2" {
+		t.Fatalf("expected the cookie to be replaced, got %+v", list)
+	}
+
+	j.Delete(list[0])
+	if len(j.List()) != 0 {
+		t.Errorf("expected the cookie to be deleted, got %+v", j.List())
+	}
+
+	j.Set(Cookie{Name: "b", Domain: "example.com", Secure: true, SameSite: "Lax", HostOnly: true})
+	if flags := j.List()[0].Flags(); flags != "Secure, SameSite=Lax, HostOnly" {
+		t.Errorf("unexpected flags %q", flags)
+	}
+	j.Clear()
+	if len(j.List()) != 0 {
+		t.Error("expected an empty jar")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "cookies.json")
+
+	s, saved, err := Load(path)
+	if err != nil || saved {
+		t.Fatalf("expected an empty store for a missing file, got %v, %v", saved, err)
+	}
+
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Jar("").Set(Cookie{Name: "session", Value: "abc", Domain: "example.com", HttpOnly: true})
+	s.Jar("staging").Set(Cookie{Name: "token", Value: "t", Domain: "staging.example.com", Expires: expires})
+	s.Jar("staging").Set(Cookie{Name: "gone", Value: "t", Domain: "staging.example.com", Expires: time.Now().Add(-time.Minute)})
+	s.Jar("empty")
+	if err := Save(path, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, saved, err := Load(path)
+	if err != nil || !saved {
+		t.Fatalf("unexpected result %v, %v", saved, err)
+	}
+	if got := loaded.Jar("").List(); len(got) != 1 || got[0].Value != "abc" || !got[0].HttpOnly || got[0].Path != "/" {
+		t.Errorf("unexpected default jar %+v", got)
+	}
+	if got := loaded.Jar("staging").List(); len(got) != 1 || !got[0].Expires.Equal(expires) {
+		t.Errorf("unexpected staging jar %+v", got)
+	}
+}
@@ -0,0 +1,133 @@
+package extract
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// Result is the value a rule extracted, or why it could not
+type Result struct {
+	Rule  model.ExtractRule
+	Value string
+	Err   error
+}
+
+// Validate checks that a rule is complete and its expression compiles
+func Validate(rule model.ExtractRule) error {
+	if strings.TrimSpace(rule.Variable) == "" {
+		return fmt.Errorf("the rule needs a variable name")
+	}
+	if strings.TrimSpace(rule.Expression) == "" {
+		return fmt.Errorf("the rule needs an expression")
+	}
+	switch rule.Source {
+	case model.ExtractJSON:
+		_, err := jsonpath.Parse(rule.Expression)
+		return err
+	case model.ExtractRegex:
+		_, err := regexp.Compile(rule.Expression)
+		return err
+	case model.ExtractHeader, model.ExtractCookie:
+		return nil
+	}
+	return fmt.Errorf("unknown source %q", rule.Source)
+}
+
+// Apply runs every rule against a response to a request sent to
+// requestURL. Cookies are read from the Set-Cookie headers of the response,
+// then from jar, when set, as redirects may hide the response that set them.
+func Apply(rules []model.ExtractRule, requestURL string, resp model.Response, jar http.CookieJar) []Result {
+	results := make([]Result, len(rules))
+	var doc any
+	var docErr error
+	decoded := false
+	for i, rule := range rules {
+		results[i].Rule = rule
+		if err := Validate(rule); err != nil {
+			results[i].Err = err
+			continue
+		}
+		switch rule.Source {
+		case model.ExtractJSON:
+			if !decoded {
+				doc, docErr = jsonpath.Decode([]byte(resp.Body))
+				decoded = true
+			}
+			if docErr != nil {
+				results[i].Err = fmt.Errorf("the body is not JSON: %w", docErr)
+				continue
+			}
+			results[i].Value, results[i].Err = fromJSON(doc, rule.Expression)
+		case model.ExtractHeader:
+			results[i].Value, results[i].Err = fromHeader(resp.Headers, rule.Expression)
+		case model.ExtractRegex:
+			results[i].Value, results[i].Err = fromRegex(resp.Body, rule.Expression)
+		case model.ExtractCookie:
+			results[i].Value, results[i].Err = fromCookie(resp.Headers, rule.Expression, requestURL, jar)
+		}
+	}
+	return results
+}
+
+func fromJSON(doc any, expr string) (string, error) {
+	values, err := jsonpath.Query(doc, expr)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("no match for %s", expr)
+	}
+	return jsonpath.Format(values[0]), nil
+}
+
+func fromHeader(headers []model.HeaderPair, name string) (string, error) {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, strings.TrimSpace(name)) {
+			return h.Value, nil
+		}
+	}
+	return "", fmt.Errorf("no %s header in the response", name)
+}
+
+func fromRegex(body, expr string) (string, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", err
+	}
+	match := re.FindStringSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("no match for /%s/", expr)
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+func fromCookie(headers []model.HeaderPair, name, requestURL string, jar http.CookieJar) (string, error) {
+	name = strings.TrimSpace(name)
+	for _, h := range headers {
+		if !strings.EqualFold(h.Key, "Set-Cookie") {
+			continue
+		}
+		if c, err := http.ParseSetCookie(h.Value); err == nil && c.Name == name {
+			return c.Value, nil
+		}
+	}
+	if jar != nil {
+		if u, err := url.Parse(requestURL); err == nil {
+			for _, c := range jar.Cookies(u) {
+				if c.Name == name {
+					return c.Value, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no %s cookie set", name)
+}
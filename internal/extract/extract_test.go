@@ -0,0 +1,63 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/cookies"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestApply(t *testing.T) {
+	resp := model.Response{
+		StatusCode: 200,
+		Headers: []model.HeaderPair{
+			{Key: "Content-Type", Value: "application/json"},
+			{Key: "Location", Value: "/users/42"},
+			{Key: "Set-Cookie", Value: "theme=dark; Path=/"},
+			{Key: "Set-Cookie", Value: "session=abc123; Path=/; HttpOnly"},
+		},
+		Body: `{"access_token": "eyJ.token", "expires_in": 3600, "user": {"id": 42, "roles": ["admin"]}}`,
+	}
+	jar := cookies.NewJar(cookies.Cookie{Name: "csrf", Value: "xyz", Domain: "api.example.com"})
+
+	rules := []model.ExtractRule{
+		{Variable: "token", Source: model.ExtractJSON, Expression: "$.access_token"},
+		{Variable: "ttl", Source: model.ExtractJSON, Expression: "expires_in"},
+		{Variable: "roles", Source: model.ExtractJSON, Expression: "$.user.roles"},
+		{Variable: "location", Source: model.ExtractHeader, Expression: "location"},
+		{Variable: "id", Source: model.ExtractRegex, Expression: `"id":\s*(\d+)`},
+		{Variable: "whole", Source: model.ExtractRegex, Expression: `eyJ\.\w+`},
+		{Variable: "session", Source: model.ExtractCookie, Expression: "session"},
+		{Variable: "csrf", Source: model.ExtractCookie, Expression: "csrf"},
+		{Variable: "missing", Source: model.ExtractJSON, Expression: "$.refresh_token"},
+		{Variable: "nocookie", Source: model.ExtractCookie, Expression: "other"},
+		{Variable: "", Source: model.ExtractHeader, Expression: "Location"},
+		{Variable: "bad", Source: model.ExtractRegex, Expression: "("},
+	}
+	want := []string{"eyJ.token", "3600", `["admin"]`, "/users/42", "42", "eyJ.token", "abc123", "xyz"}
+	wantErr := []string{"no match for $.refresh_token", "no other cookie set", "needs a variable name", "missing closing )"}
+
+	results := Apply(rules, "https://api.example.com/login", resp, jar)
+	for i, r := range results {
+		if i < len(want) {
+			if r.Err != nil || r.Value != want[i] {
+				t.Errorf("%s: expected %q, got %q, %v", r.Rule.Variable, want[i], r.Value, r.Err)
+			}
+			continue
+		}
+		if r.Err == nil || !strings.Contains(r.Err.Error(), wantErr[i-len(want)]) {
+			t.Errorf("%s: expected error %q, got %v", r.Rule.Variable, wantErr[i-len(want)], r.Err)
+		}
+	}
+
+	// JSON rules fail on other bodies, the others still apply
+	resp.Body = "<html>token=abc</html>"
+	results = Apply([]model.ExtractRule{rules[0], {Variable: "t", Source: model.ExtractRegex, Expression: "token=(\\w+)"}}, "", resp, nil)
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "not JSON") {
+		t.Errorf("expected a JSON error, got %v", results[0].Err)
+	}
+	if results[1].Value != "abc" {
+		t.Errorf("expected the regex to match, got %q, %v", results[1].Value, results[1].Err)
+	}
+}
@@ -0,0 +1,120 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCellWidth cuts the cells of wide columns, so that a long value does not
+// push the other columns out of view
+const maxCellWidth = 40
+
+// csvFormatter aligns comma or tab separated values in a table
+type csvFormatter struct {
+	comma rune
+}
+
+func (f csvFormatter) Name() string {
+	if f.comma == '\t' {
+		return "TSV"
+	}
+	return "CSV"
+}
+
+func (f csvFormatter) Match(mediaType string) bool {
+	if f.comma == '\t' {
+		return mediaType == "text/tab-separated-values"
+	}
+	return mediaType == "text/csv" || mediaType == "application/csv"
+}
+
+// Sniff leaves plain text alone, since most of it parses as one column
+func (csvFormatter) Sniff([]byte) bool { return false }
+
+func (f csvFormatter) Format(data []byte) (string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = f.comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		return "", errors.New("no records")
+	}
+
+	var widths []int
+	for _, record := range records {
+		for i, cell := range record {
+			cell = cutCell(cell)
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var b strings.Builder
+	for n, record := range records {
+		var cells []string
+		for i, width := range widths {
+			cell := ""
+			if i < len(record) {
+				cell = cutCell(record[i])
+			}
+			cells = append(cells, cell+strings.Repeat(" ", width-utf8.RuneCountInString(cell)))
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, " │ "), " ") + "\n")
+		if n == 0 {
+			var rules []string
+			for _, width := range widths {
+				rules = append(rules, strings.Repeat("─", width))
+			}
+			b.WriteString(strings.Join(rules, "─┼─") + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// cutCell puts a cell on one line, no wider than maxCellWidth
+func cutCell(cell string) string {
+	cell = strings.Join(strings.Fields(cell), " ")
+	if utf8.RuneCountInString(cell) > maxCellWidth {
+		cell = string([]rune(cell)[:maxCellWidth-1]) + "…"
+	}
+	return cell
+}
+
+// Highlight dims the column separators and the rule under the header
+func (csvFormatter) Highlight(line string) string {
+	if strings.HasPrefix(line, "─") {
+		return mutedStyle.Render(line)
+	}
+	cells := strings.Split(line, " │ ")
+	for i, cell := range cells {
+		if isNumber(strings.TrimSpace(cell)) {
+			cells[i] = numberStyle.Render(cell)
+		}
+	}
+	return strings.Join(cells, mutedStyle.Render(" │ "))
+}
+
+// isNumber tells whether a cell holds a decimal number
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	digits := 0
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && i > 0:
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
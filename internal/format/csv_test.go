@@ -0,0 +1,31 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVFormatter(t *testing.T) {
+	body := "id,name,note\n1,Alice,\"multi\nline\"\n2,Bob\n3,Carol," + strings.Repeat("x", 50) + "\n"
+	expected := `id │ name  │ note
+───┼───────┼─────────────────────────────────────────
+1  │ Alice │ multi line
+2  │ Bob   │
+3  │ Carol │ ` + strings.Repeat("x", 39) + "…"
+
+	out, err := csvFormatter{comma: ','}.Format([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expected {
+		t.Errorf("unexpected table:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	out, err = csvFormatter{comma: '\t'}.Format([]byte("a\tb\n10\t20"))
+	if err != nil || out != "a  │ b\n───┼───\n10 │ 20" {
+		t.Errorf("unexpected TSV table %q, %v", out, err)
+	}
+
+	highlighted(t, csvFormatter{}, "1  │ Alice │ multi line")
+	highlighted(t, csvFormatter{}, "───┼───────┼───")
+}
@@ -0,0 +1,104 @@
+// Package format pretty-prints response bodies. Formatters are registered
+// for families of content types, and are also tried by sniffing the body
+// when its Content-Type is missing, generic or wrong.
+package format
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Formatter pretty-prints the bodies of one family of content types
+type Formatter interface {
+	// Name is the label shown next to the response tab
+	Name() string
+	// Match tells whether the formatter handles a media type, given without
+	// parameters
+	Match(mediaType string) bool
+	// Sniff tells whether a body of an unknown type looks like the format
+	Sniff(data []byte) bool
+	// Format returns the body pretty-printed without colors, or an error
+	// when it is not in the format
+	Format(data []byte) (string, error)
+	// Highlight colors a line of the formatted body
+	Highlight(line string) string
+}
+
+var registry []Formatter
+
+// Register adds a formatter, tried after the ones registered before it
+func Register(f Formatter) {
+	registry = append(registry, f)
+}
+
+// Formatters lists the registered formatters in registration order
+func Formatters() []Formatter {
+	return registry
+}
+
+// Lookup finds a formatter by name, ignoring case
+func Lookup(name string) (Formatter, bool) {
+	for _, f := range registry {
+		if strings.EqualFold(f.Name(), name) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	Register(problemFormatter{})
+	Register(ndjsonFormatter{})
+	Register(jsonFormatter{})
+	Register(htmlFormatter{})
+	Register(xmlFormatter{})
+	Register(yamlFormatter{})
+	Register(csvFormatter{comma: ','})
+	Register(csvFormatter{comma: '\t'})
+}
+
+// MediaType returns the lowercased media type of a Content-Type header,
+// without its parameters
+func MediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return t
+}
+
+// Format pretty-prints a body with the formatter of its media type, or with
+// the first formatter whose sniffing recognizes it. It returns the body
+// unchanged, and a nil formatter, when none applies.
+func Format(mediaType string, data []byte) (string, Formatter) {
+	var tried Formatter
+	for _, f := range registry {
+		if f.Match(mediaType) {
+			if out, err := f.Format(data); err == nil {
+				return out, f
+			}
+			tried = f
+			break
+		}
+	}
+	for _, f := range registry {
+		if f != tried && f.Sniff(data) {
+			if out, err := f.Format(data); err == nil {
+				return out, f
+			}
+		}
+	}
+	return string(data), nil
+}
+
+// Colors shared by the highlighters, matching the JSON highlighting
+var (
+	keyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	stringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+	numberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+	literalStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6B6B"))
+	bracketStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00D9FF"))
+	mutedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+)
@@ -0,0 +1,100 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestRegistry(t *testing.T) {
+	var names []string
+	for _, f := range Formatters() {
+		names = append(names, f.Name())
+	}
+	want := "Problem, NDJSON, JSON, HTML, XML, YAML, CSV, TSV"
+	if strings.Join(names, ", ") != want {
+		t.Errorf("unexpected formatters %v", names)
+	}
+	if f, ok := Lookup("yaml"); !ok || f.Name() != "YAML" {
+		t.Error("expected to find YAML ignoring case")
+	}
+	if _, ok := Lookup("cobol"); ok {
+		t.Error("expected unknown formatter not to be found")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		body      string
+		formatter string
+	}{
+		{"JSON by type", "application/json", `{"a": 1}`, "JSON"},
+		{"JSON suffix", "application/vnd.api+json", `{"a": 1}`, "JSON"},
+		{"Problem", "application/problem+json", `{"title": "Oops"}`, "Problem"},
+		{"SOAP", "application/soap+xml", `<a><b>1</b></a>`, "XML"},
+		{"HTML", "text/html", `<p>hi`, "HTML"},
+		{"XHTML", "application/xhtml+xml", `<html><body/></html>`, "HTML"},
+		{"YAML", "application/yaml", "a: 1", "YAML"},
+		{"CSV", "text/csv", "a,b\n1,2", "CSV"},
+		{"TSV", "text/tab-separated-values", "a\tb\n1\t2", "TSV"},
+		{"NDJSON", "application/x-ndjson", "{\"a\": 1}\n", "NDJSON"},
+		{"Sniffed JSON", "text/plain", `[1, 2]`, "JSON"},
+		{"Sniffed JSON Lines", "", "{\"a\": 1}\n{\"a\": 2}", "NDJSON"},
+		{"Sniffed XML", "application/octet-stream", `<?xml version="1.0"?><a/>`, "XML"},
+		{"Sniffed HTML", "", "<!DOCTYPE html><title>x</title>", "HTML"},
+		{"Sniffed YAML", "", "---\na: 1", "YAML"},
+		{"Wrong type sniffed", "application/json", "<html><body>Bad gateway</body></html>", "HTML"},
+		{"Plain text", "text/plain", "hello: world", ""},
+		{"Invalid JSON", "application/json", `{"a": `, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, f := Format(tt.mediaType, []byte(tt.body))
+			name := ""
+			if f != nil {
+				name = f.Name()
+			}
+			if name != tt.formatter {
+				t.Fatalf("expected the %q formatter, got %q", tt.formatter, name)
+			}
+			if f == nil && out != tt.body {
+				t.Errorf("expected the body unchanged, got %q", out)
+			}
+		})
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	if mediaType := MediaType("Application/JSON; charset=utf-8"); mediaType != "application/json" {
+		t.Errorf("expected the parameters to be dropped, got %q", mediaType)
+	}
+	if mediaType := MediaType("text/csv;;"); mediaType != "text/csv;;" {
+		t.Errorf("expected an invalid type to be kept, got %q", mediaType)
+	}
+}
+
+var ansiCodes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// highlighted checks that a highlighter colors a line without changing its
+// text
+func highlighted(t *testing.T, f Formatter, line string) string {
+	t.Helper()
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(profile)
+
+	out := f.Highlight(line)
+	if !strings.Contains(out, "\x1b[") {
+		t.Errorf("expected %q to be colored", line)
+	}
+	if plain := ansiCodes.ReplaceAllString(out, ""); plain != line {
+		t.Errorf("expected the highlighted text to be %q, got %q", line, plain)
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlFormatter indents HTML as a browser parses it, with the elements HTML
+// leaves open closed
+type htmlFormatter struct{}
+
+func (htmlFormatter) Name() string { return "HTML" }
+
+func (htmlFormatter) Match(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func (htmlFormatter) Sniff(data []byte) bool {
+	return sniffHTML(bytes.TrimSpace(data))
+}
+
+func (htmlFormatter) Format(data []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeHTML(&b, doc, 0)
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (htmlFormatter) Highlight(line string) string {
+	return highlightMarkup(line)
+}
+
+// sniffHTML tells whether a trimmed body starts like an HTML document
+func sniffHTML(trim []byte) bool {
+	start := strings.ToLower(string(trim[:min(len(trim), 14)]))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+// voidElements have no content and no closing tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawElements hold text that is not HTML, kept unescaped
+var rawElements = map[string]bool{"script": true, "style": true}
+
+// writeHTML writes a node and its children, one per line indented by depth.
+// Elements holding only a short text stay on one line.
+func writeHTML(b *strings.Builder, n *html.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n.Type {
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeHTML(b, c, depth)
+		}
+	case html.DoctypeNode:
+		b.WriteString(indent + "<!DOCTYPE " + n.Data + ">\n")
+	case html.CommentNode:
+		b.WriteString(indent + "<!--" + n.Data + "-->\n")
+	case html.TextNode:
+		raw := n.Parent != nil && rawElements[n.Parent.Data]
+		for _, line := range strings.Split(n.Data, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if !raw {
+				line = html.EscapeString(strings.Join(strings.Fields(line), " "))
+			}
+			b.WriteString(indent + line + "\n")
+		}
+	case html.ElementNode:
+		var tag strings.Builder
+		tag.WriteString("<" + n.Data)
+		for _, a := range n.Attr {
+			name := a.Key
+			if a.Namespace != "" {
+				name = a.Namespace + ":" + a.Key
+			}
+			tag.WriteString(" " + name + `="` + html.EscapeString(a.Val) + `"`)
+		}
+		tag.WriteString(">")
+		end := "</" + n.Data + ">"
+
+		switch {
+		case voidElements[n.Data]:
+			b.WriteString(indent + tag.String() + "\n")
+		case n.FirstChild == nil:
+			b.WriteString(indent + tag.String() + end + "\n")
+		case n.FirstChild == n.LastChild && n.FirstChild.Type == html.TextNode &&
+			!strings.Contains(strings.TrimSpace(n.FirstChild.Data), "\n"):
+			text := strings.TrimSpace(n.FirstChild.Data)
+			if !rawElements[n.Data] {
+				text = html.EscapeString(strings.Join(strings.Fields(text), " "))
+			}
+			b.WriteString(indent + tag.String() + text + end + "\n")
+		default:
+			b.WriteString(indent + tag.String() + "\n")
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				writeHTML(b, c, depth+1)
+			}
+			b.WriteString(indent + end + "\n")
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package format
+
+import "testing"
+
+func TestHTMLFormatter(t *testing.T) {
+	body := "<!DOCTYPE html>\n<html><head><title>Hi</title><meta charset=utf-8></head><body><p>One<p>Two &amp;  <b>three</b><br>" +
+		"<ul><li>a<li>b</ul><script>if (a < b && c) {}</script><!-- c --></body></html>"
+	expected := `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Hi</title>
+    <meta charset="utf-8">
+  </head>
+  <body>
+    <p>One</p>
+    <p>
+      Two &amp;
+      <b>three</b>
+      <br>
+    </p>
+    <ul>
+      <li>a</li>
+      <li>b</li>
+    </ul>
+    <script>if (a < b && c) {}</script>
+    <!-- c -->
+  </body>
+</html>`
+
+	out, err := htmlFormatter{}.Format([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expected {
+		t.Errorf("unexpected HTML:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	for _, line := range []string{`    <meta charset="utf-8">`, `<p>One</p>`, `<!DOCTYPE html>`} {
+		highlighted(t, htmlFormatter{}, line)
+	}
+}
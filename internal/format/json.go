@@ -0,0 +1,38 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	apijson "github.com/tbourrel/apitty/internal/json"
+)
+
+// jsonFormatter indents JSON objects and arrays
+type jsonFormatter struct{}
+
+func (jsonFormatter) Name() string { return "JSON" }
+
+func (jsonFormatter) Match(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json")
+}
+
+func (jsonFormatter) Sniff(data []byte) bool {
+	trim := bytes.TrimSpace(data)
+	return len(trim) > 0 && (trim[0] == '{' || trim[0] == '[')
+}
+
+func (jsonFormatter) Format(data []byte) (string, error) {
+	out, ok := apijson.PrettyJSON(data)
+	if !ok {
+		return "", errNotJSON
+	}
+	return out, nil
+}
+
+// Highlight colors a line of indented JSON, which holds whole tokens
+func (jsonFormatter) Highlight(line string) string {
+	return apijson.ColorizeJSON(line)
+}
+
+var errNotJSON = errors.New("not a JSON object or array")
@@ -0,0 +1,83 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apijson "github.com/tbourrel/apitty/internal/json"
+)
+
+// ndjsonFormatter indents each record of newline delimited JSON, also known
+// as JSON Lines, under a record number
+type ndjsonFormatter struct{}
+
+func (ndjsonFormatter) Name() string { return "NDJSON" }
+
+func (ndjsonFormatter) Match(mediaType string) bool {
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl",
+		"application/x-jsonlines", "application/jsonlines":
+		return true
+	}
+	return false
+}
+
+// Sniff recognizes two or more lines that each hold a JSON value
+func (ndjsonFormatter) Sniff(data []byte) bool {
+	records := 0
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' && line[0] != '[' || !json.Valid(line) {
+			return false
+		}
+		records++
+	}
+	return records > 1
+}
+
+func (ndjsonFormatter) Format(data []byte) (string, error) {
+	var b strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	records := 0
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return "", fmt.Errorf("line %d is not JSON", n)
+		}
+		if records > 0 {
+			b.WriteString("\n")
+		}
+		records++
+		record, ok := apijson.PrettyJSON(line)
+		if !ok {
+			// Scalars are valid records, shown as they are
+			record = string(line)
+		}
+		fmt.Fprintf(&b, "# %d\n%s\n", records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if records == 0 {
+		return "", errNotJSON
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// Highlight dims the record numbers and colors the records as JSON
+func (ndjsonFormatter) Highlight(line string) string {
+	if strings.HasPrefix(line, "# ") {
+		return mutedStyle.Render(line)
+	}
+	return apijson.ColorizeJSON(line)
+}
@@ -0,0 +1,24 @@
+package format
+
+import "testing"
+
+func TestNDJSONFormatter(t *testing.T) {
+	out, err := ndjsonFormatter{}.Format([]byte("{\"a\":1}\n\n[1,2]\n3\n"))
+	expected := "# 1\n{\n  \"a\": 1\n}\n\n# 2\n[\n  1,\n  2\n]\n\n# 3\n3"
+	if err != nil || out != expected {
+		t.Errorf("unexpected records:\n%s\nexpected:\n%s\n%v", out, expected, err)
+	}
+	if _, err := (ndjsonFormatter{}).Format([]byte("{\"a\":1}\n{\"a\":")); err == nil || err.Error() != "line 2 is not JSON" {
+		t.Errorf("expected the invalid line to be reported, got %v", err)
+	}
+
+	if !(ndjsonFormatter{}).Sniff([]byte("{\"a\":1}\n{\"a\":2}\n")) {
+		t.Error("expected two JSON lines to be sniffed")
+	}
+	if (ndjsonFormatter{}).Sniff([]byte("{\n  \"a\": 1\n}")) {
+		t.Error("expected an indented document not to be sniffed")
+	}
+
+	highlighted(t, ndjsonFormatter{}, "# 2")
+	highlighted(t, ndjsonFormatter{}, `  "a": 1`)
+}
@@ -0,0 +1,74 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apijson "github.com/tbourrel/apitty/internal/json"
+)
+
+// problemFormatter shows an RFC 9457 problem details document as a summary
+// of its standard members, above the whole document
+type problemFormatter struct{}
+
+func (problemFormatter) Name() string { return "Problem" }
+
+func (problemFormatter) Match(mediaType string) bool {
+	return mediaType == "application/problem+json"
+}
+
+// Sniff leaves problems without their media type to the JSON formatter
+func (problemFormatter) Sniff([]byte) bool { return false }
+
+func (problemFormatter) Format(data []byte) (string, error) {
+	var problem struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance"`
+	}
+	if err := json.Unmarshal(data, &problem); err != nil {
+		return "", fmt.Errorf("invalid problem details: %w", err)
+	}
+	document, ok := apijson.PrettyJSON(data)
+	if !ok {
+		return "", errNotJSON
+	}
+
+	var b strings.Builder
+	title := problem.Title
+	if title == "" {
+		title = "Untitled problem"
+	}
+	b.WriteString("Problem: " + title)
+	if problem.Status != 0 {
+		fmt.Fprintf(&b, " (%d)", problem.Status)
+	}
+	b.WriteString("\n")
+	for _, member := range []struct{ label, value string }{
+		{"Detail", problem.Detail},
+		{"Type", problem.Type},
+		{"Instance", problem.Instance},
+	} {
+		if member.value != "" {
+			fmt.Fprintf(&b, "%-9s %s\n", member.label+":", member.value)
+		}
+	}
+	b.WriteString("\n" + document)
+	return b.String(), nil
+}
+
+// Highlight colors the summary, then the document as JSON
+func (problemFormatter) Highlight(line string) string {
+	for _, label := range []string{"Problem:", "Detail:", "Type:", "Instance:"} {
+		if rest, ok := strings.CutPrefix(line, label); ok {
+			if label == "Problem:" {
+				return literalStyle.Bold(true).Render(line)
+			}
+			return keyStyle.Render(label) + rest
+		}
+	}
+	return apijson.ColorizeJSON(line)
+}
@@ -0,0 +1,36 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProblemFormatter(t *testing.T) {
+	body := `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.",` +
+		`"status":403,"detail":"Your balance is 30.","instance":"/account/12345/msgs/abc","balance":30}`
+	out, err := problemFormatter{}.Format([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := `Problem: You do not have enough credit. (403)
+Detail:   Your balance is 30.
+Type:     https://example.com/probs/out-of-credit
+Instance: /account/12345/msgs/abc
+
+{`
+	if !strings.HasPrefix(out, summary) || !strings.Contains(out, `"balance": 30`) {
+		t.Errorf("unexpected problem:\n%s", out)
+	}
+
+	out, err = problemFormatter{}.Format([]byte(`{"status": 500}`))
+	if err != nil || !strings.HasPrefix(out, "Problem: Untitled problem (500)\n\n{") {
+		t.Errorf("unexpected problem without members %q, %v", out, err)
+	}
+	if _, err := (problemFormatter{}).Format([]byte(`[]`)); err == nil {
+		t.Error("expected a problem that is not an object to be refused")
+	}
+
+	highlighted(t, problemFormatter{}, "Problem: You do not have enough credit. (403)")
+	highlighted(t, problemFormatter{}, "Detail:   Your balance is 30.")
+	highlighted(t, problemFormatter{}, `  "status": 403,`)
+}
@@ -0,0 +1,202 @@
+package format
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlFormatter indents XML documents, SOAP envelopes included, keeping
+// their namespace prefixes
+type xmlFormatter struct{}
+
+func (xmlFormatter) Name() string { return "XML" }
+
+func (xmlFormatter) Match(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "/xml") || strings.HasSuffix(mediaType, "+xml")
+}
+
+func (xmlFormatter) Sniff(data []byte) bool {
+	trim := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trim, []byte("<?xml")) || bytes.HasPrefix(trim, []byte("<")) && !sniffHTML(trim)
+}
+
+func (xmlFormatter) Format(data []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	return indentMarkup(d.RawToken)
+}
+
+func (xmlFormatter) Highlight(line string) string {
+	return highlightMarkup(line)
+}
+
+// indentMarkup prints the tokens of an XML document one per line, indented
+// by depth. Elements holding only text stay on one line, and names keep the
+// namespace prefix RawToken reads.
+func indentMarkup(next func() (xml.Token, error)) (string, error) {
+	var tokens []xml.Token
+	for {
+		t, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if text, ok := t.(xml.CharData); ok && len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		tokens = append(tokens, xml.CopyToken(t))
+	}
+
+	name := func(n xml.Name) string {
+		if n.Space != "" {
+			return n.Space + ":" + n.Local
+		}
+		return n.Local
+	}
+	start := func(e xml.StartElement, end string) string {
+		var b strings.Builder
+		b.WriteString("<" + name(e.Name))
+		for _, a := range e.Attr {
+			b.WriteString(" " + name(a.Name) + `="`)
+			_ = xml.EscapeText(&b, []byte(a.Value))
+			b.WriteString(`"`)
+		}
+		b.WriteString(end)
+		return b.String()
+	}
+
+	var b strings.Builder
+	var open []string
+	elements := 0
+	line := func(s string) {
+		b.WriteString(strings.Repeat("  ", len(open)) + s + "\n")
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch t := tokens[i].(type) {
+		case xml.StartElement:
+			elements++
+			if i+1 < len(tokens) {
+				if _, ok := tokens[i+1].(xml.EndElement); ok {
+					line(start(t, "/>"))
+					i++
+					continue
+				}
+			}
+			if i+2 < len(tokens) {
+				text, isText := tokens[i+1].(xml.CharData)
+				_, isEnd := tokens[i+2].(xml.EndElement)
+				if isText && isEnd && !bytes.Contains(bytes.TrimSpace(text), []byte("\n")) {
+					var escaped bytes.Buffer
+					_ = xml.EscapeText(&escaped, bytes.TrimSpace(text))
+					line(start(t, ">") + escaped.String() + "</" + name(t.Name) + ">")
+					i += 2
+					continue
+				}
+			}
+			line(start(t, ">"))
+			open = append(open, name(t.Name))
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != name(t.Name) {
+				return "", fmt.Errorf("unexpected closing tag </%s>", name(t.Name))
+			}
+			open = open[:len(open)-1]
+			line("</" + name(t.Name) + ">")
+		case xml.CharData:
+			for _, text := range strings.Split(strings.TrimSpace(string(t)), "\n") {
+				if text = strings.TrimSpace(text); text != "" {
+					var escaped bytes.Buffer
+					_ = xml.EscapeText(&escaped, []byte(text))
+					line(escaped.String())
+				}
+			}
+		case xml.Comment:
+			line("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			line("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Directive:
+			line("<!" + string(t) + ">")
+		}
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("unclosed tag <%s>", open[len(open)-1])
+	}
+	if elements == 0 {
+		return "", errors.New("no root element")
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// highlightMarkup colors the tags, attributes and comments of a line of
+// indented XML or HTML
+func highlightMarkup(line string) string {
+	var b strings.Builder
+	for len(line) > 0 {
+		lt := strings.IndexByte(line, '<')
+		if lt < 0 {
+			b.WriteString(line)
+			break
+		}
+		b.WriteString(line[:lt])
+		line = line[lt:]
+		if strings.HasPrefix(line, "<!--") {
+			end := strings.Index(line, "-->")
+			if end < 0 {
+				end = len(line)
+			} else {
+				end += len("-->")
+			}
+			b.WriteString(mutedStyle.Render(line[:end]))
+			line = line[end:]
+			continue
+		}
+		gt := strings.IndexByte(line, '>')
+		if gt < 0 {
+			gt = len(line) - 1
+		}
+		b.WriteString(highlightTag(line[:gt+1]))
+		line = line[gt+1:]
+	}
+	return b.String()
+}
+
+// highlightTag colors a tag: its name, then its attributes and their values
+func highlightTag(tag string) string {
+	nameStart := min(len(tag), 1)
+	if len(tag) > 1 && strings.IndexByte("/?!", tag[1]) >= 0 {
+		nameStart++
+	}
+	nameEnd := len(tag)
+	if end := strings.IndexAny(tag[nameStart:], " \t/?>"); end >= 0 {
+		nameEnd = nameStart + end
+	}
+	var b strings.Builder
+	b.WriteString(keyStyle.Render(tag[:nameEnd]))
+	rest := tag[nameEnd:]
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == '"' || rest[0] == '\'':
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			b.WriteString(stringStyle.Render(rest[:end+2]))
+			rest = rest[end+2:]
+		case rest[0] == '>' || rest[0] == '/' || rest[0] == '?':
+			b.WriteString(keyStyle.Render(rest))
+			rest = ""
+		default:
+			end := strings.IndexAny(rest, `"'>/?`)
+			if end < 0 {
+				end = len(rest)
+			}
+			b.WriteString(numberStyle.Render(rest[:end]))
+			rest = rest[end:]
+		}
+	}
+	return b.String()
+}
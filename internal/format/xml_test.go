@@ -0,0 +1,37 @@
+package format
+
+import "testing"
+
+func TestXMLFormatter(t *testing.T) {
+	body := `<?xml version="1.0"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>` +
+		`<m:Price xmlns:m="https://example.com/prices"><m:Item a="1">Apples &amp; pears</m:Item><m:Empty/></m:Price>` +
+		`<!-- note --></soap:Body></soap:Envelope>`
+	expected := `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <m:Price xmlns:m="https://example.com/prices">
+      <m:Item a="1">Apples &amp; pears</m:Item>
+      <m:Empty/>
+    </m:Price>
+    <!-- note -->
+  </soap:Body>
+</soap:Envelope>`
+
+	out, err := xmlFormatter{}.Format([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expected {
+		t.Errorf("unexpected XML:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	for _, invalid := range []string{"<a><b></a>", "<a>", "", "not xml"} {
+		if _, err := (xmlFormatter{}).Format([]byte(invalid)); err == nil {
+			t.Errorf("expected %q to be refused", invalid)
+		}
+	}
+
+	for _, line := range []string{`      <m:Item a="1">Apples &amp; pears</m:Item>`, `<?xml version="1.0"?>`, `  <!-- note -->`, `</soap:Body>`} {
+		highlighted(t, xmlFormatter{}, line)
+	}
+}
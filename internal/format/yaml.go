@@ -0,0 +1,132 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFormatter re-indents YAML documents, keeping their comments
+type yamlFormatter struct{}
+
+func (yamlFormatter) Name() string { return "YAML" }
+
+func (yamlFormatter) Match(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "yaml") || strings.HasSuffix(mediaType, "yml")
+}
+
+// Sniff only recognizes documents that start with a marker, since most
+// plain text is also valid YAML
+func (yamlFormatter) Sniff(data []byte) bool {
+	trim := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trim, []byte("---\n")) || bytes.HasPrefix(trim, []byte("%YAML"))
+}
+
+func (yamlFormatter) Format(data []byte) (string, error) {
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	e := yaml.NewEncoder(&out)
+	e.SetIndent(2)
+	documents := 0
+	for {
+		var doc yaml.Node
+		err := d.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := e.Encode(&doc); err != nil {
+			return "", err
+		}
+		documents++
+	}
+	if err := e.Close(); err != nil {
+		return "", err
+	}
+	if documents == 0 {
+		return "", errors.New("empty document")
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// Highlight colors the keys, scalars and comments of a line of YAML
+func (yamlFormatter) Highlight(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	var b strings.Builder
+	b.WriteString(line[:indent])
+	rest := line[indent:]
+
+	if strings.HasPrefix(rest, "#") {
+		return line[:indent] + mutedStyle.Render(rest)
+	}
+	if rest == "---" || rest == "..." {
+		return line[:indent] + bracketStyle.Render(rest)
+	}
+	for rest == "-" || strings.HasPrefix(rest, "- ") {
+		b.WriteString(bracketStyle.Render("-"))
+		if rest == "-" {
+			return b.String()
+		}
+		b.WriteString(" ")
+		rest = rest[2:]
+	}
+	if key, value, ok := cutKey(rest); ok {
+		b.WriteString(keyStyle.Render(key) + ":")
+		if value == "" {
+			return b.String()
+		}
+		b.WriteString(" ")
+		rest = value
+	}
+	b.WriteString(highlightScalar(rest))
+	return b.String()
+}
+
+// cutKey splits a mapping entry into its key and value
+func cutKey(s string) (key, value string, ok bool) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 || !strings.HasPrefix(s[end+2:], ":") {
+			return "", "", false
+		}
+		return s[:end+2], strings.TrimPrefix(s[end+3:], " "), true
+	}
+	if i := strings.Index(s, ": "); i > 0 {
+		return s[:i], s[i+2:], true
+	}
+	if strings.HasSuffix(s, ":") {
+		return s[:len(s)-1], "", true
+	}
+	return "", "", false
+}
+
+// highlightScalar colors a value by its type, and its trailing comment
+func highlightScalar(s string) string {
+	comment := ""
+	if i := strings.Index(s, " #"); i >= 0 && !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, "'") {
+		s, comment = s[:i], mutedStyle.Render(s[i:])
+	}
+	var node yaml.Node
+	switch {
+	case s == "":
+	case s == "|" || s == ">" || s == "|-" || s == ">-" || s == "{}" || s == "[]":
+		s = bracketStyle.Render(s)
+	case yaml.Unmarshal([]byte(s), &node) != nil || len(node.Content) == 0:
+		s = stringStyle.Render(s)
+	default:
+		switch node.Content[0].Tag {
+		case "!!int", "!!float":
+			s = numberStyle.Render(s)
+		case "!!bool", "!!null":
+			s = literalStyle.Render(s)
+		default:
+			s = stringStyle.Render(s)
+		}
+	}
+	return s + comment
+}
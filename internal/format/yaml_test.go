@@ -0,0 +1,31 @@
+package format
+
+import "testing"
+
+func TestYAMLFormatter(t *testing.T) {
+	body := "# top\nname:   apitty\nlist: [1, 2]\nnested:\n    - a: 1\n      b: true\n    - null\n---\nsecond: 2.5 # trailing\n"
+	expected := `# top
+name: apitty
+list: [1, 2]
+nested:
+  - a: 1
+    b: true
+  - null
+---
+second: 2.5 # trailing`
+
+	out, err := yamlFormatter{}.Format([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expected {
+		t.Errorf("unexpected YAML:\n%s\nexpected:\n%s", out, expected)
+	}
+	if _, err := (yamlFormatter{}).Format([]byte("a: [1, 2")); err == nil {
+		t.Error("expected invalid YAML to be refused")
+	}
+
+	for _, line := range []string{"# top", "name: apitty", "  - a: 1", "    b: true", "  - null", "second: 2.5 # trailing", `"quoted key": x`, "---"} {
+		highlighted(t, yamlFormatter{}, line)
+	}
+}
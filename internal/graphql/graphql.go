@@ -0,0 +1,432 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	apijson "github.com/tbourrel/apitty/internal/json"
+)
+
+// IntrospectionQuery is the query sent to an endpoint to retrieve its schema
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      description
+      fields(includeDeprecated: true) {
+        name
+        description
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: true) { name description }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType { kind name }
+      }
+    }
+  }
+}`
+
+// BuildEnvelope wraps a query and its JSON variables in the standard GraphQL request body
+func BuildEnvelope(query, variables string) ([]byte, error) {
+	envelope := struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables,omitempty"`
+	}{Query: query}
+
+	variables = strings.TrimSpace(variables)
+	if variables != "" {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(variables), &obj); err != nil {
+			return nil, fmt.Errorf("variables must be a JSON object: %w", err)
+		}
+		envelope.Variables = json.RawMessage(variables)
+	}
+
+	return json.Marshal(envelope)
+}
+
+// TypeRef is a reference to a type, possibly wrapped in NON_NULL or LIST
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// String renders the reference in SDL notation, e.g. [User!]!
+func (t *TypeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// Named returns the name of the innermost named type
+func (t *TypeRef) Named() string {
+	for t != nil {
+		if t.Name != "" {
+			return t.Name
+		}
+		t = t.OfType
+	}
+	return ""
+}
+
+// InputValue is an argument or an input object field
+type InputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         TypeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+// Field is a field of an object or interface type
+type Field struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Args        []InputValue `json:"args"`
+	Type        TypeRef      `json:"type"`
+}
+
+// EnumValue is a single value of an enum type
+type EnumValue struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Type is a named type of the schema
+type Type struct {
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Fields      []Field      `json:"fields"`
+	InputFields []InputValue `json:"inputFields"`
+	EnumValues  []EnumValue  `json:"enumValues"`
+}
+
+// Schema is the result of an introspection query
+type Schema struct {
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+	Types            []Type
+}
+
+// ParseIntrospection parses the response to IntrospectionQuery
+func ParseIntrospection(data []byte) (*Schema, error) {
+	var resp struct {
+		Data struct {
+			Schema *struct {
+				QueryType        *struct{ Name string } `json:"queryType"`
+				MutationType     *struct{ Name string } `json:"mutationType"`
+				SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+				Types            []Type                 `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", resp.Errors[0].Message)
+	}
+	if resp.Data.Schema == nil {
+		return nil, fmt.Errorf("introspection response has no __schema")
+	}
+
+	s := resp.Data.Schema
+	schema := &Schema{Types: s.Types}
+	if s.QueryType != nil {
+		schema.QueryType = s.QueryType.Name
+	}
+	if s.MutationType != nil {
+		schema.MutationType = s.MutationType.Name
+	}
+	if s.SubscriptionType != nil {
+		schema.SubscriptionType = s.SubscriptionType.Name
+	}
+	return schema, nil
+}
+
+// Type returns the named type, or nil if the schema doesn't define it
+func (s *Schema) Type(name string) *Type {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// UserTypes returns the schema types, excluding the introspection ones
+func (s *Schema) UserTypes() []Type {
+	var types []Type
+	for _, t := range s.Types {
+		if !strings.HasPrefix(t.Name, "__") {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// Complete returns the fields that can be typed at the given cursor offset
+// along with the partial identifier they complete
+func Complete(schema *Schema, query string, cursor int) (prefix string, candidates []string) {
+	if schema == nil {
+		return "", nil
+	}
+	if cursor > len(query) {
+		cursor = len(query)
+	}
+	before := query[:cursor]
+
+	// Extract the identifier being typed
+	start := len(before)
+	for start > 0 && isNameChar(before[start-1]) {
+		start--
+	}
+	prefix = before[start:]
+
+	stack := []string{}
+	operation := schema.QueryType
+	lastName := ""
+	pendingType := ""
+	for _, tok := range tokenize(before[:start]) {
+		switch tok {
+		case "{":
+			var next string
+			switch {
+			case pendingType != "":
+				next = pendingType
+			case len(stack) == 0:
+				next = operation
+			default:
+				if f := findField(schema.Type(stack[len(stack)-1]), lastName); f != nil {
+					next = f.Type.Named()
+				}
+			}
+			stack = append(stack, next)
+			pendingType = ""
+			lastName = ""
+		case "}":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case "query":
+			if len(stack) == 0 {
+				operation = schema.QueryType
+				continue
+			}
+			lastName = tok
+		case "mutation":
+			if len(stack) == 0 {
+				operation = schema.MutationType
+				continue
+			}
+			lastName = tok
+		case "subscription":
+			if len(stack) == 0 {
+				operation = schema.SubscriptionType
+				continue
+			}
+			lastName = tok
+		default:
+			if lastName == "on" {
+				pendingType = tok
+			}
+			lastName = tok
+		}
+	}
+
+	if len(stack) == 0 {
+		return prefix, nil
+	}
+	t := schema.Type(stack[len(stack)-1])
+	if t == nil {
+		return prefix, nil
+	}
+	for _, f := range t.Fields {
+		if strings.HasPrefix(f.Name, prefix) && f.Name != prefix {
+			candidates = append(candidates, f.Name)
+		}
+	}
+	return prefix, candidates
+}
+
+// tokenize splits a query into names and braces, skipping arguments,
+// strings and comments
+func tokenize(s string) []string {
+	var tokens []string
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case ch == '"':
+			i++
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+		case ch == '(':
+			depth++
+		case ch == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+			// Inside arguments
+		case ch == '{' || ch == '}':
+			tokens = append(tokens, string(ch))
+		case isNameChar(ch):
+			j := i
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j - 1
+		}
+	}
+	return tokens
+}
+
+func isNameChar(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+func findField(t *Type, name string) *Field {
+	if t == nil {
+		return nil
+	}
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// DescribeType renders a type definition for the schema explorer
+func DescribeType(t *Type) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n", strings.ToLower(t.Kind), t.Name))
+	if t.Description != "" {
+		b.WriteString(t.Description + "\n")
+	}
+	b.WriteString("\n")
+	for _, member := range Members(t) {
+		b.WriteString("  " + member + "\n")
+	}
+	return b.String()
+}
+
+// Members returns the signatures of a type's fields, input fields and enum values
+func Members(t *Type) []string {
+	var members []string
+	for _, f := range t.Fields {
+		signature := f.Name
+		if len(f.Args) > 0 {
+			args := make([]string, 0, len(f.Args))
+			for _, a := range f.Args {
+				args = append(args, a.Name+": "+a.Type.String())
+			}
+			signature += "(" + strings.Join(args, ", ") + ")"
+		}
+		members = append(members, signature+": "+f.Type.String())
+	}
+	for _, f := range t.InputFields {
+		members = append(members, f.Name+": "+f.Type.String())
+	}
+	for _, v := range t.EnumValues {
+		members = append(members, v.Name)
+	}
+	return members
+}
+
+// FormatResponse renders a GraphQL response, showing errors apart from data
+func FormatResponse(data []byte) string {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message   string        `json:"message"`
+			Path      []interface{} `json:"path"`
+			Locations []struct {
+				Line   int `json:"line"`
+				Column int `json:"column"`
+			} `json:"locations"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &resp); err != nil || len(resp.Errors) == 0 {
+		return apijson.TryPrettyJSON(data)
+	}
+
+	errorTitle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF6B6B"))
+	errorText := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6B6B"))
+	sectionTitle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+
+	var b strings.Builder
+	b.WriteString(errorTitle.Render(fmt.Sprintf("Errors (%d)", len(resp.Errors))))
+	b.WriteString("\n")
+	for _, e := range resp.Errors {
+		b.WriteString(errorText.Render("✗ " + e.Message))
+		b.WriteString("\n")
+		if len(e.Path) > 0 {
+			parts := make([]string, 0, len(e.Path))
+			for _, p := range e.Path {
+				parts = append(parts, fmt.Sprint(p))
+			}
+			b.WriteString("    path: " + strings.Join(parts, ".") + "\n")
+		}
+		for _, l := range e.Locations {
+			b.WriteString(fmt.Sprintf("    at line %d, column %d\n", l.Line, l.Column))
+		}
+	}
+
+	if len(resp.Data) > 0 && string(resp.Data) != "null" {
+		b.WriteString("\n")
+		b.WriteString(sectionTitle.Render("Data"))
+		b.WriteString("\n")
+		b.WriteString(apijson.TryPrettyJSON(resp.Data))
+	}
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testIntrospection = `{"data":{"__schema":{
+  "queryType":{"name":"Query"},
+  "mutationType":{"name":"Mutation"},
+  "subscriptionType":null,
+  "types":[
+    {"kind":"OBJECT","name":"Query","fields":[
+      {"name":"user","args":[{"name":"id","type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID"}}}],
+       "type":{"kind":"OBJECT","name":"User"}},
+      {"name":"users","args":[],"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"LIST","name":null,"ofType":{"kind":"OBJECT","name":"User"}}}}
+    ]},
+    {"kind":"OBJECT","name":"Mutation","fields":[
+      {"name":"createUser","args":[],"type":{"kind":"OBJECT","name":"User"}}
+    ]},
+    {"kind":"OBJECT","name":"User","fields":[
+      {"name":"id","args":[],"type":{"kind":"SCALAR","name":"ID"}},
+      {"name":"name","args":[],"type":{"kind":"SCALAR","name":"String"}},
+      {"name":"nickname","args":[],"type":{"kind":"SCALAR","name":"String"}},
+      {"name":"friends","args":[],"type":{"kind":"LIST","name":null,"ofType":{"kind":"OBJECT","name":"User"}}}
+    ]},
+    {"kind":"OBJECT","name":"__Schema","fields":[]}
+  ]}}}`
+
+func TestBuildEnvelope(t *testing.T) {
+	body, err := BuildEnvelope("{ users { id } }", `{"limit": 10}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("envelope is not valid JSON: %v", err)
+	}
+	if envelope["query"] != "{ users { id } }" {
+		t.Errorf("unexpected query: %v", envelope["query"])
+	}
+	vars, ok := envelope["variables"].(map[string]interface{})
+	if !ok || vars["limit"] != float64(10) {
+		t.Errorf("unexpected variables: %v", envelope["variables"])
+	}
+}
+
+func TestBuildEnvelope_NoVariables(t *testing.T) {
+	body, err := BuildEnvelope("{ users { id } }", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(body), "variables") {
+		t.Errorf("expected variables to be omitted, got %s", body)
+	}
+}
+
+func TestBuildEnvelope_InvalidVariables(t *testing.T) {
+	if _, err := BuildEnvelope("{ users { id } }", `[1, 2]`); err == nil {
+		t.Error("expected error for non-object variables")
+	}
+}
+
+func TestParseIntrospection(t *testing.T) {
+	schema, err := ParseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.QueryType != "Query" || schema.MutationType != "Mutation" {
+		t.Errorf("unexpected root types: %q %q", schema.QueryType, schema.MutationType)
+	}
+	if len(schema.UserTypes()) != 3 {
+		t.Errorf("expected 3 user types, got %d", len(schema.UserTypes()))
+	}
+
+	users := schema.Type("Query").Fields[1]
+	if users.Type.String() != "[User]!" {
+		t.Errorf("expected [User]!, got %s", users.Type.String())
+	}
+	if users.Type.Named() != "User" {
+		t.Errorf("expected User, got %s", users.Type.Named())
+	}
+}
+
+func TestParseIntrospection_Errors(t *testing.T) {
+	_, err := ParseIntrospection([]byte(`{"errors":[{"message":"introspection disabled"}]}`))
+	if err == nil || !strings.Contains(err.Error(), "introspection disabled") {
+		t.Errorf("expected introspection error, got %v", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	schema, err := ParseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		prefix     string
+		candidates []string
+	}{
+		{"root fields", "{ u", "u", []string{"user", "users"}},
+		{"nested fields", `query { user(id: "1") { n`, "n", []string{"name", "nickname"}},
+		{"list fields", "{ users { friends { i", "i", []string{"id"}},
+		{"after closed selection", "{ user { id } users { f", "f", []string{"friends"}},
+		{"mutation root", "mutation { c", "c", []string{"createUser"}},
+		{"fragment", "fragment F on User { fr", "fr", []string{"friends"}},
+		{"outside selection", "que", "que", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, candidates := Complete(schema, tt.query, len(tt.query))
+			if prefix != tt.prefix {
+				t.Errorf("expected prefix %q, got %q", tt.prefix, prefix)
+			}
+			if strings.Join(candidates, ",") != strings.Join(tt.candidates, ",") {
+				t.Errorf("expected candidates %v, got %v", tt.candidates, candidates)
+			}
+		})
+	}
+}
+
+func TestDescribeType(t *testing.T) {
+	schema, _ := ParseIntrospection([]byte(testIntrospection))
+	desc := DescribeType(schema.Type("Query"))
+
+	if !strings.Contains(desc, "user(id: ID!): User") {
+		t.Errorf("expected field signature in description, got:\n%s", desc)
+	}
+}
+
+func TestFormatResponse_Errors(t *testing.T) {
+	out := FormatResponse([]byte(`{"data":{"user":null},"errors":[{"message":"not found","path":["user"],"locations":[{"line":1,"column":3}]}]}`))
+
+	if !strings.Contains(out, "Errors (1)") || !strings.Contains(out, "not found") {
+		t.Errorf("expected errors section, got:\n%s", out)
+	}
+	if !strings.Contains(out, "path: user") {
+		t.Errorf("expected error path, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Data") {
+		t.Errorf("expected data section, got:\n%s", out)
+	}
+}
+
+func TestFormatResponse_DataOnly(t *testing.T) {
+	out := FormatResponse([]byte(`{"data":{"user":{"id":"1"}}}`))
+	if strings.Contains(out, "Errors") {
+		t.Errorf("expected no errors section, got:\n%s", out)
+	}
+	if !strings.Contains(out, "user") {
+		t.Errorf("expected data in output, got:\n%s", out)
+	}
+}
@@ -0,0 +1,222 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/json"
+	"github.com/tbourrel/apitty/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const callTimeout = 15 * time.Second
+
+// Result holds the outcome of a gRPC call
+type Result struct {
+	Responses []string
+	Status    *status.Status
+	Header    metadata.MD
+	Trailer   metadata.MD
+}
+
+// LoadDescriptorsCmd resolves descriptors from local files when paths are
+// given, or through server reflection otherwise
+func LoadDescriptorsCmd(target string, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+		defer cancel()
+
+		var files *protoregistry.Files
+		var err error
+		if len(paths) > 0 {
+			files, err = LoadFiles(ctx, paths)
+		} else {
+			files, err = LoadReflection(ctx, target)
+		}
+		return model.GRPCDescriptorsMsg{Files: files, Err: err}
+	}
+}
+
+// InvokeCmd calls a method with a JSON request and returns a model.ResponseMsg.
+// Cancelling ctx aborts the call, which then reports context.Canceled.
+func InvokeCmd(ctx context.Context, target string, files *protoregistry.Files, method, request string, headers []model.HeaderPair) tea.Cmd {
+	return func() tea.Msg {
+		md, err := FindMethod(files, method)
+		if err != nil {
+			return model.ResponseMsg{Err: err}
+		}
+		callCtx, cancel := context.WithTimeout(ctx, callTimeout)
+		defer cancel()
+
+		result, err := Invoke(callCtx, target, md, request, headers)
+		if ctx.Err() != nil {
+			return model.ResponseMsg{Err: ctx.Err()}
+		}
+		if err != nil {
+			return model.ResponseMsg{Err: err}
+		}
+		return model.ResponseMsg{
+			Resp:    FormatResponses(result),
+			Headers: FormatMetadata(result),
+			Status:  FormatStatus(result),
+		}
+	}
+}
+
+// Invoke performs a unary or server-streaming call. Errors returned by the
+// server are reported in Result.Status rather than as an error.
+func Invoke(ctx context.Context, target string, md protoreflect.MethodDescriptor, request string, headers []model.HeaderPair) (*Result, error) {
+	if md.IsStreamingClient() {
+		return nil, fmt.Errorf("%s is a client-streaming method, only unary and server-streaming calls are supported", md.FullName())
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if strings.TrimSpace(request) != "" {
+		if err := protojson.Unmarshal([]byte(request), req); err != nil {
+			return nil, fmt.Errorf("invalid request for %s: %w", md.Input().FullName(), err)
+		}
+	}
+
+	conn, err := dial(target)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	for _, h := range headers {
+		if h.Key != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(h.Key), h.Value)
+		}
+	}
+
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	result := &Result{}
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: md.IsStreamingServer()}, fullMethod)
+	if err != nil {
+		result.Status = status.Convert(err)
+		return result, nil
+	}
+	if err := stream.SendMsg(req); err != nil && !errors.Is(err, io.EOF) {
+		result.Status = status.Convert(err)
+		return result, nil
+	}
+	if err := stream.CloseSend(); err != nil {
+		result.Status = status.Convert(err)
+		return result, nil
+	}
+
+	for {
+		resp := dynamicpb.NewMessage(md.Output())
+		err := stream.RecvMsg(resp)
+		if errors.Is(err, io.EOF) {
+			result.Status = status.New(0, "")
+			break
+		}
+		if err != nil {
+			result.Status = status.Convert(err)
+			break
+		}
+		out, err := protojson.MarshalOptions{Multiline: true, Indent: "  ", EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		result.Responses = append(result.Responses, string(out))
+		if !md.IsStreamingServer() {
+			result.Status = status.New(0, "")
+			break
+		}
+	}
+
+	result.Header, _ = stream.Header()
+	result.Trailer = stream.Trailer()
+	return result, nil
+}
+
+// FormatResponses renders the response messages, numbering streamed ones
+func FormatResponses(r *Result) string {
+	var b strings.Builder
+	for i, resp := range r.Responses {
+		if len(r.Responses) > 1 {
+			b.WriteString(fmt.Sprintf("── message %d ──\n", i+1))
+		}
+		b.WriteString(json.ColorizeJSON(resp))
+		b.WriteString("\n")
+	}
+	if r.Status.Code() != 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("Error: %s\n", r.Status.Message()))
+	}
+	return b.String()
+}
+
+// FormatStatus renders the status code followed by the trailers
+func FormatStatus(r *Result) string {
+	line := fmt.Sprintf("gRPC %d %s", r.Status.Code(), r.Status.Code())
+	if len(r.Trailer) > 0 {
+		var pairs []string
+		for _, k := range sortedKeys(r.Trailer) {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", k, strings.Join(r.Trailer[k], ",")))
+		}
+		line += " · " + strings.Join(pairs, " ")
+	}
+	return line
+}
+
+// FormatMetadata renders the header and trailer metadata for the headers view
+func FormatMetadata(r *Result) string {
+	var b strings.Builder
+	for _, k := range sortedKeys(r.Header) {
+		b.WriteString(fmt.Sprintf("%s: %s\n", k, strings.Join(r.Header[k], ", ")))
+	}
+	if len(r.Trailer) > 0 {
+		b.WriteString("\nTrailers:\n")
+		for _, k := range sortedKeys(r.Trailer) {
+			b.WriteString(fmt.Sprintf("%s: %s\n", k, strings.Join(r.Trailer[k], ", ")))
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys(md metadata.MD) []string {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseTarget strips the scheme from a target and picks the transport credentials
+func parseTarget(target string) (string, credentials.TransportCredentials) {
+	target = strings.TrimSpace(target)
+	for _, scheme := range []string{"grpcs://", "https://"} {
+		if strings.HasPrefix(target, scheme) {
+			return strings.TrimSuffix(strings.TrimPrefix(target, scheme), "/"), credentials.NewTLS(&tls.Config{})
+		}
+	}
+	for _, scheme := range []string{"grpc://", "http://"} {
+		if strings.HasPrefix(target, scheme) {
+			return strings.TrimSuffix(strings.TrimPrefix(target, scheme), "/"), insecure.NewCredentials()
+		}
+	}
+	return target, insecure.NewCredentials()
+}
@@ -0,0 +1,249 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// LoadReflection lists the services of a server through server reflection
+// and resolves the descriptors of their methods
+func LoadReflection(ctx context.Context, target string) (*protoregistry.Files, error) {
+	conn, err := dial(target)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection unavailable: %w", err)
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+
+	resp, err := reflectionCall(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fds := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(svc.GetName(), "grpc.reflection.") {
+			continue
+		}
+		resp, err := reflectionCall(stream, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: svc.GetName()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := addFileDescriptors(fds, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch any dependency the server didn't send along
+	for {
+		missing := missingDependencies(fds)
+		if len(missing) == 0 {
+			break
+		}
+		for _, name := range missing {
+			if fd, err := protoregistry.GlobalFiles.FindFileByPath(name); err == nil {
+				fds[name] = protodesc.ToFileDescriptorProto(fd)
+				continue
+			}
+			resp, err := reflectionCall(stream, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := addFileDescriptors(fds, resp); err != nil {
+				return nil, err
+			}
+			if _, ok := fds[name]; !ok {
+				return nil, fmt.Errorf("server did not return %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fds {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+func reflectionCall(stream rpb.ServerReflection_ServerReflectionInfoClient, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, fmt.Errorf("server reflection failed: %w", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("server reflection failed: %w", err)
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("server reflection failed: %s", e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+func addFileDescriptors(fds map[string]*descriptorpb.FileDescriptorProto, resp *rpb.ServerReflectionResponse) error {
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return fmt.Errorf("invalid file descriptor: %w", err)
+		}
+		fds[fd.GetName()] = fd
+	}
+	return nil
+}
+
+func missingDependencies(fds map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, fd := range fds {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := fds[dep]; !ok {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	return missing
+}
+
+// LoadFiles resolves descriptors from .proto sources or from binary
+// descriptor sets (as produced by protoc --descriptor_set_out)
+func LoadFiles(ctx context.Context, paths []string) (*protoregistry.Files, error) {
+	files := &protoregistry.Files{}
+	var sources, importPaths []string
+
+	for _, path := range paths {
+		if filepath.Ext(path) != ".proto" {
+			if err := loadDescriptorSet(files, path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		importPaths = append(importPaths, filepath.Dir(path))
+		sources = append(sources, filepath.Base(path))
+	}
+
+	if len(sources) > 0 {
+		compiler := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+		}
+		compiled, err := compiler.Compile(ctx, sources...)
+		if err != nil {
+			return nil, err
+		}
+		for _, fd := range compiled {
+			if err := registerWithDependencies(files, fd); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+func loadDescriptorSet(files *protoregistry.Files, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return fmt.Errorf("%s is not a descriptor set: %w", path, err)
+	}
+	parsed, err := protodesc.NewFiles(set)
+	if err != nil {
+		return err
+	}
+	var regErr error
+	parsed.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		regErr = registerWithDependencies(files, fd)
+		return regErr == nil
+	})
+	return regErr
+}
+
+func registerWithDependencies(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerWithDependencies(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(fd)
+}
+
+// Methods lists the methods of all services as "package.Service/Method"
+func Methods(files *protoregistry.Files) []string {
+	var methods []string
+	if files == nil {
+		return methods
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			svc := services.Get(i)
+			if strings.HasPrefix(string(svc.FullName()), "grpc.reflection.") {
+				continue
+			}
+			for j := 0; j < svc.Methods().Len(); j++ {
+				methods = append(methods, fmt.Sprintf("%s/%s", svc.FullName(), svc.Methods().Get(j).Name()))
+			}
+		}
+		return true
+	})
+	sort.Strings(methods)
+	return methods
+}
+
+// FindMethod looks up a method by its "package.Service/Method" name
+func FindMethod(files *protoregistry.Files, name string) (protoreflect.MethodDescriptor, error) {
+	svcName, methodName, ok := strings.Cut(name, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid method name %q, expected package.Service/Method", name)
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(svcName))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %s", svcName)
+	}
+	svc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", svcName)
+	}
+	method := svc.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("unknown method %s", name)
+	}
+	return method, nil
+}
+
+// dial creates a client connection; grpcs:// and https:// targets use TLS
+func dial(target string) (*grpc.ClientConn, error) {
+	address, creds := parseTarget(target)
+	return grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+}
@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func loadEchoFiles(t *testing.T) *protoregistry.Files {
+	t.Helper()
+	files, err := LoadFiles(context.Background(), []string{"testdata/echo.proto"})
+	if err != nil {
+		t.Fatalf("failed to load echo.proto: %v", err)
+	}
+	return files
+}
+
+// startEchoServer serves the echo service through an unknown-service
+// handler driven by the test descriptors
+func startEchoServer(t *testing.T, files *protoregistry.Files) string {
+	t.Helper()
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		md, err := FindMethod(files, strings.TrimPrefix(fullMethod, "/"))
+		if err != nil {
+			return status.Error(codes.Unimplemented, err.Error())
+		}
+		req := dynamicpb.NewMessage(md.Input())
+		if err := stream.RecvMsg(req); err != nil {
+			return err
+		}
+		message := req.Get(md.Input().Fields().ByName("message")).String()
+		if message == "fail" {
+			return status.Error(codes.InvalidArgument, "refusing to echo")
+		}
+
+		times := 1
+		if f := md.Input().Fields().ByName("times"); f != nil {
+			times = int(req.Get(f).Int())
+		}
+		stream.SetTrailer(metadata.Pairs("x-echo-count", "done"))
+		for i := 0; i < times; i++ {
+			resp := dynamicpb.NewMessage(md.Output())
+			resp.Set(md.Output().Fields().ByName("message"), protoreflect.ValueOfString(message))
+			resp.Set(md.Output().Fields().ByName("index"), protoreflect.ValueOfInt32(int32(i)))
+			if err := stream.SendMsg(resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer(grpc.UnknownServiceHandler(handler))
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestLoadFiles_Proto(t *testing.T) {
+	files := loadEchoFiles(t)
+	methods := Methods(files)
+
+	expected := []string{"echo.v1.EchoService/Echo", "echo.v1.EchoService/Repeat"}
+	if strings.Join(methods, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected methods %v, got %v", expected, methods)
+	}
+}
+
+func TestLoadFiles_DescriptorSet(t *testing.T) {
+	files := loadEchoFiles(t)
+	set := &descriptorpb.FileDescriptorSet{}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+		return true
+	})
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatalf("failed to marshal descriptor set: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "echo.pb")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write descriptor set: %v", err)
+	}
+
+	loaded, err := LoadFiles(context.Background(), []string{path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(Methods(loaded)) != 2 {
+		t.Errorf("expected 2 methods, got %v", Methods(loaded))
+	}
+}
+
+func TestFindMethod_Unknown(t *testing.T) {
+	files := loadEchoFiles(t)
+	if _, err := FindMethod(files, "echo.v1.EchoService/Nope"); err == nil {
+		t.Error("expected error for unknown method")
+	}
+	if _, err := FindMethod(files, "missing-slash"); err == nil {
+		t.Error("expected error for malformed method name")
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	files := loadEchoFiles(t)
+	md, err := FindMethod(files, "echo.v1.EchoService/Echo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tmpl := Template(md.Input())
+	for _, want := range []string{`"message": ""`, `"count": "0"`, `"tags": [""]`, `"key": 0`, `"mood": "MOOD_UNSPECIFIED"`, `"sentAt": "1970-01-01T00:00:00Z"`, `"flag": false`} {
+		if !strings.Contains(tmpl, want) {
+			t.Errorf("expected template to contain %s, got:\n%s", want, tmpl)
+		}
+	}
+
+	// The template must be a valid request for the method
+	if err := protojson.Unmarshal([]byte(tmpl), dynamicpb.NewMessage(md.Input())); err != nil {
+		t.Errorf("template is not a valid request: %v", err)
+	}
+}
+
+func TestInvoke_Unary(t *testing.T) {
+	files := loadEchoFiles(t)
+	target := startEchoServer(t, files)
+	md, _ := FindMethod(files, "echo.v1.EchoService/Echo")
+
+	result, err := Invoke(context.Background(), target, md, `{"message": "hello"}`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status.Code() != codes.OK {
+		t.Fatalf("expected OK, got %v", result.Status)
+	}
+	if len(result.Responses) != 1 || !strings.Contains(result.Responses[0], `"hello"`) {
+		t.Errorf("unexpected responses: %v", result.Responses)
+	}
+	if !strings.Contains(FormatStatus(result), "x-echo-count=done") {
+		t.Errorf("expected trailers in status line, got %s", FormatStatus(result))
+	}
+}
+
+func TestInvoke_ServerStreaming(t *testing.T) {
+	files := loadEchoFiles(t)
+	target := startEchoServer(t, files)
+	md, _ := FindMethod(files, "echo.v1.EchoService/Repeat")
+
+	result, err := Invoke(context.Background(), "grpc://"+target, md, `{"message": "hi", "times": 3}`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(result.Responses))
+	}
+	if !strings.Contains(FormatResponses(result), "message 3") {
+		t.Errorf("expected numbered messages, got:\n%s", FormatResponses(result))
+	}
+}
+
+func TestInvoke_ErrorStatus(t *testing.T) {
+	files := loadEchoFiles(t)
+	target := startEchoServer(t, files)
+	md, _ := FindMethod(files, "echo.v1.EchoService/Echo")
+
+	result, err := Invoke(context.Background(), target, md, `{"message": "fail"}`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status.Code() != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", result.Status.Code())
+	}
+	if !strings.Contains(FormatStatus(result), "InvalidArgument") {
+		t.Errorf("expected status code in status line, got %s", FormatStatus(result))
+	}
+}
+
+func TestInvoke_InvalidRequest(t *testing.T) {
+	files := loadEchoFiles(t)
+	md, _ := FindMethod(files, "echo.v1.EchoService/Echo")
+
+	if _, err := Invoke(context.Background(), "127.0.0.1:1", md, `{"unknown": 1}`, nil); err == nil {
+		t.Error("expected error for request with unknown field")
+	}
+}
+
+func TestLoadReflection(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	files, err := LoadReflection(context.Background(), lis.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	methods := Methods(files)
+	if strings.Join(methods, ",") != "grpc.health.v1.Health/Check,grpc.health.v1.Health/List,grpc.health.v1.Health/Watch" {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+
+	msg := InvokeCmd(context.Background(), lis.Addr().String(), files, "grpc.health.v1.Health/Check", "{}", []model.HeaderPair{{Key: "X-Test", Value: "1"}})()
+	resp, ok := msg.(model.ResponseMsg)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", msg)
+	}
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err)
+	}
+	if !strings.Contains(resp.Resp, "SERVING") || !strings.HasPrefix(resp.Status, "gRPC 0 OK") {
+		t.Errorf("unexpected response %q with status %q", resp.Resp, resp.Status)
+	}
+
+	// Watch streams until the call is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	msg = InvokeCmd(ctx, lis.Addr().String(), files, "grpc.health.v1.Health/Watch", "{}", nil)()
+	if resp := msg.(model.ResponseMsg); !errors.Is(resp.Err, context.Canceled) {
+		t.Errorf("expected the call to be cancelled, got %v", resp.Err)
+	}
+}
@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxTemplateDepth stops recursive message types from expanding forever
+const maxTemplateDepth = 5
+
+// Template builds a JSON request skeleton for a message type, with every
+// field set to its zero value in declaration order
+func Template(md protoreflect.MessageDescriptor) string {
+	var b strings.Builder
+	writeMessage(&b, md, "", 0)
+	return b.String()
+}
+
+func writeMessage(b *strings.Builder, md protoreflect.MessageDescriptor, indent string, depth int) {
+	if wkt, ok := wellKnownTemplate(md); ok {
+		b.WriteString(wkt)
+		return
+	}
+	fields := md.Fields()
+	if fields.Len() == 0 || depth >= maxTemplateDepth {
+		b.WriteString("{}")
+		return
+	}
+
+	b.WriteString("{\n")
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		b.WriteString(indent + "  " + strconv.Quote(fd.JSONName()) + ": ")
+		switch {
+		case fd.IsMap():
+			b.WriteString("{\n" + indent + "    " + strconv.Quote(mapKeyTemplate(fd.MapKey())) + ": ")
+			writeValue(b, fd.MapValue(), indent+"    ", depth+1)
+			b.WriteString("\n" + indent + "  }")
+		case fd.IsList():
+			b.WriteString("[")
+			writeValue(b, fd, indent+"  ", depth+1)
+			b.WriteString("]")
+		default:
+			writeValue(b, fd, indent+"  ", depth+1)
+		}
+		if i < fields.Len()-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + "}")
+}
+
+func writeValue(b *strings.Builder, fd protoreflect.FieldDescriptor, indent string, depth int) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		writeMessage(b, fd.Message(), indent, depth)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		if values.Len() > 0 {
+			b.WriteString(strconv.Quote(string(values.Get(0).Name())))
+		} else {
+			b.WriteString("0")
+		}
+	case protoreflect.BoolKind:
+		b.WriteString("false")
+	case protoreflect.StringKind:
+		b.WriteString(`""`)
+	case protoreflect.BytesKind:
+		b.WriteString(`""`)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64-bit integers are strings in the JSON mapping
+		b.WriteString(`"0"`)
+	default:
+		b.WriteString("0")
+	}
+}
+
+func mapKeyTemplate(fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return "key"
+	case protoreflect.BoolKind:
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+// wellKnownTemplate returns the JSON form of well-known types that don't
+// map to plain objects
+func wellKnownTemplate(md protoreflect.MessageDescriptor) (string, bool) {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return `"1970-01-01T00:00:00Z"`, true
+	case "google.protobuf.Duration":
+		return `"0s"`, true
+	case "google.protobuf.FieldMask":
+		return `""`, true
+	case "google.protobuf.Struct":
+		return "{}", true
+	case "google.protobuf.Value":
+		return "null", true
+	case "google.protobuf.ListValue":
+		return "[]", true
+	case "google.protobuf.Empty", "google.protobuf.Any":
+		return "{}", true
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return `""`, true
+	case "google.protobuf.BoolValue":
+		return "false", true
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return `"0"`, true
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return "0", true
+	}
+	return "", false
+}
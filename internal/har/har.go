@@ -0,0 +1,371 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// HAR is the top-level object of a HAR file
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the entries of a HAR file
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that wrote the file
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one request and its response
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the total time of the request in milliseconds
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	Cache    struct{} `json:"cache"`
+	Timings  Timings  `json:"timings"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+// Request is a HAR request
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is a HAR response
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header or a query parameter
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Cookie is a request or response cookie
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the body of a request
+type PostData struct {
+	MimeType string      `json:"mimeType"`
+	Text     string      `json:"text"`
+	Params   []NameValue `json:"params,omitempty"`
+}
+
+// Content is the body of a response
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings are the phases of a request in milliseconds, -1 when they do not apply
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Load reads a HAR file
+func Load(path string) (*HAR, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a HAR document
+func Parse(data []byte) (*HAR, error) {
+	var h HAR
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid HAR file: %w", err)
+	}
+	if h.Log.Version == "" && h.Log.Entries == nil {
+		return nil, fmt.Errorf("not a HAR file: missing log")
+	}
+	return &h, nil
+}
+
+// Marshal encodes a HAR document as indented JSON
+func Marshal(h *HAR) ([]byte, error) {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// History converts the entries into history entries, oldest first as in the file
+func (h *HAR) History() []model.HistoryEntry {
+	entries := make([]model.HistoryEntry, 0, len(h.Log.Entries))
+	for _, e := range h.Log.Entries {
+		entry := model.HistoryEntry{
+			Time:    e.StartedDateTime,
+			Request: request(e.Request),
+			Response: model.Response{
+				Status:     strings.TrimSpace(fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText)),
+				StatusCode: e.Response.Status,
+				Proto:      e.Response.HTTPVersion,
+				Headers:    headers(e.Response.Headers),
+				Body:       content(e.Response.Content),
+			},
+			Timings: model.Timings{
+				Blocked: duration(e.Timings.Blocked),
+				DNS:     duration(e.Timings.DNS),
+				Connect: duration(e.Timings.Connect),
+				SSL:     duration(e.Timings.SSL),
+				Send:    duration(e.Timings.Send),
+				Wait:    duration(e.Timings.Wait),
+				Receive: duration(e.Timings.Receive),
+			},
+		}
+		// Browsers record failed requests with a zero status
+		if e.Response.Status == 0 {
+			entry.Error = "no response"
+			if e.Response.StatusText != "" {
+				entry.Error = e.Response.StatusText
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ToCollection converts the requests into a collection with one folder per host
+func (h *HAR) ToCollection(name string) *model.Collection {
+	c := &model.Collection{Name: name}
+	folders := make(map[string]int)
+	for _, e := range h.Log.Entries {
+		r := request(e.Request)
+		host, saved := "", model.SavedRequest{Name: r.Method + " " + r.URL, Request: r}
+		if u, err := url.Parse(r.URL); err == nil {
+			host = u.Host
+			saved.Name = r.Method + " " + u.RequestURI()
+		}
+		idx, ok := folders[host]
+		if !ok {
+			idx = len(c.Folders)
+			folders[host] = idx
+			c.Folders = append(c.Folders, model.Folder{Name: host})
+		}
+		c.Folders[idx].Requests = append(c.Folders[idx].Requests, saved)
+	}
+	return c
+}
+
+// FromHistory converts history entries into a HAR document
+func FromHistory(entries []model.HistoryEntry) *HAR {
+	h := &HAR{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "apitty", Version: buildVersion()},
+		Entries: make([]Entry, 0, len(entries)),
+	}}
+	for _, e := range entries {
+		entry := Entry{
+			StartedDateTime: e.Time,
+			Time:            millis(e.Timings.Total()),
+			Request:         harRequest(e.Request),
+			Response: Response{
+				Status:      e.Response.StatusCode,
+				StatusText:  statusText(e.Response),
+				HTTPVersion: httpVersion(e.Response.Proto),
+				Cookies:     []Cookie{},
+				Headers:     nameValues(e.Response.Headers),
+				Content:     harContent(e.Response),
+				HeadersSize: -1,
+				BodySize:    len(e.Response.Body),
+			},
+			Timings: Timings{
+				Blocked: optionalMillis(e.Timings.Blocked),
+				DNS:     optionalMillis(e.Timings.DNS),
+				Connect: optionalMillis(e.Timings.Connect),
+				SSL:     optionalMillis(e.Timings.SSL),
+				Send:    millis(e.Timings.Send),
+				Wait:    millis(e.Timings.Wait),
+				Receive: millis(e.Timings.Receive),
+			},
+			Comment: e.Error,
+		}
+		for _, hdr := range e.Response.Headers {
+			if strings.EqualFold(hdr.Key, "Location") {
+				entry.Response.RedirectURL = hdr.Value
+			}
+		}
+		h.Log.Entries = append(h.Log.Entries, entry)
+	}
+	return h
+}
+
+// request converts a HAR request, skipping HTTP/2 pseudo-headers
+func request(r Request) model.Request {
+	out := model.Request{Method: strings.ToUpper(r.Method), URL: r.URL, Headers: headers(r.Headers)}
+	if r.PostData != nil {
+		out.Body = r.PostData.Text
+		if out.Body == "" && len(r.PostData.Params) > 0 {
+			params := make([]string, 0, len(r.PostData.Params))
+			for _, p := range r.PostData.Params {
+				params = append(params, url.QueryEscape(p.Name)+"="+url.QueryEscape(p.Value))
+			}
+			out.Body = strings.Join(params, "&")
+		}
+	}
+	return out
+}
+
+func headers(nvs []NameValue) []model.HeaderPair {
+	var out []model.HeaderPair
+	for _, nv := range nvs {
+		if strings.HasPrefix(nv.Name, ":") {
+			continue
+		}
+		out = append(out, model.HeaderPair{Key: nv.Name, Value: nv.Value})
+	}
+	return out
+}
+
+// content returns the text of a response, decoding base64 bodies
+func content(c Content) string {
+	if c.Encoding == "base64" {
+		if data, err := base64.StdEncoding.DecodeString(c.Text); err == nil {
+			return string(data)
+		}
+	}
+	return c.Text
+}
+
+func harRequest(r model.Request) Request {
+	out := Request{
+		Method:      r.Method,
+		URL:         r.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []Cookie{},
+		Headers:     nameValues(r.Headers),
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    len(r.Body),
+	}
+	if u, err := url.Parse(r.URL); err == nil && u.RawQuery != "" {
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			key, value, _ := strings.Cut(param, "=")
+			key, _ = url.QueryUnescape(key)
+			value, _ = url.QueryUnescape(value)
+			out.QueryString = append(out.QueryString, NameValue{Name: key, Value: value})
+		}
+	}
+	if r.Body != "" {
+		mimeType := ""
+		for _, h := range r.Headers {
+			if strings.EqualFold(h.Key, "Content-Type") {
+				mimeType = h.Value
+			}
+		}
+		out.PostData = &PostData{MimeType: mimeType, Text: r.Body}
+	}
+	return out
+}
+
+// harContent encodes a response body, as base64 when it is not valid UTF-8
+func harContent(r model.Response) Content {
+	c := Content{Size: len(r.Body), Text: r.Body}
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			c.MimeType = h.Value
+		}
+	}
+	if !utf8.ValidString(r.Body) {
+		c.Text = base64.StdEncoding.EncodeToString([]byte(r.Body))
+		c.Encoding = "base64"
+	}
+	return c
+}
+
+func nameValues(pairs []model.HeaderPair) []NameValue {
+	out := make([]NameValue, 0, len(pairs))
+	for _, p := range pairs {
+		out = append(out, NameValue{Name: p.Key, Value: p.Value})
+	}
+	return out
+}
+
+// statusText returns the reason phrase of a "200 OK" status line
+func statusText(r model.Response) string {
+	_, text, _ := strings.Cut(r.Status, " ")
+	return text
+}
+
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
+
+// buildVersion returns the module version apitty was built from
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// optionalMillis reports phases that did not happen as -1
+func optionalMillis(d time.Duration) float64 {
+	if d == 0 {
+		return -1
+	}
+	return millis(d)
+}
+
+func duration(ms float64) time.Duration {
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
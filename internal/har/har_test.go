@@ -0,0 +1,113 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestHistory(t *testing.T) {
+	h, err := Load("testdata/session.har")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries := h.History()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	get := entries[0]
+	if get.Request.Method != "GET" || get.Request.URL != "https://shop.example.com/api/orders?limit=10&sort=desc" {
+		t.Errorf("unexpected request %+v", get.Request)
+	}
+	if len(get.Request.Headers) != 1 || get.Request.Headers[0].Key != "accept" {
+		t.Errorf("expected pseudo-headers to be skipped, got %v", get.Request.Headers)
+	}
+	if get.Response.Status != "200 OK" || get.Response.Body != `{"ok":true}` {
+		t.Errorf("expected decoded base64 body, got %q %q", get.Response.Status, get.Response.Body)
+	}
+	if get.Timings.Wait != 140*time.Millisecond || get.Timings.DNS != 0 || get.Timings.Total() != 152500*time.Microsecond {
+		t.Errorf("unexpected timings %+v", get.Timings)
+	}
+	if !get.Time.Equal(time.Date(2026, 9, 1, 10, 0, 0, 123e6, time.UTC)) {
+		t.Errorf("unexpected start time %v", get.Time)
+	}
+
+	login := entries[1]
+	if login.Request.Body != "user=ada&scope=read+write" {
+		t.Errorf("expected params to become a form body, got %q", login.Request.Body)
+	}
+	if login.Error != "no response" {
+		t.Errorf("expected a failed request, got %q", login.Error)
+	}
+}
+
+func TestToCollection(t *testing.T) {
+	h, err := Load("testdata/session.har")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := h.ToCollection("session")
+	if len(c.Folders) != 2 || c.Folders[0].Name != "shop.example.com" || c.Folders[1].Name != "auth.example.com" {
+		t.Fatalf("expected one folder per host, got %+v", c.Folders)
+	}
+	if name := c.Folders[0].Requests[0].Name; name != "GET /api/orders?limit=10&sort=desc" {
+		t.Errorf("unexpected request name %q", name)
+	}
+}
+
+func TestFromHistory(t *testing.T) {
+	entries := []model.HistoryEntry{{
+		Time: time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC),
+		Request: model.Request{
+			Method:  "POST",
+			URL:     "https://example.com/items?tag=a%20b",
+			Headers: []model.HeaderPair{{Key: "Content-Type", Value: "application/json"}},
+			Body:    `{"name":"x"}`,
+		},
+		Response: model.Response{
+			Status:     "201 Created",
+			StatusCode: 201,
+			Proto:      "HTTP/1.1",
+			Headers:    []model.HeaderPair{{Key: "Content-Type", Value: "image/png"}, {Key: "Location", Value: "/items/1"}},
+			Body:       "\x89PNG",
+		},
+		Timings: model.Timings{Send: time.Millisecond, Wait: 20 * time.Millisecond},
+	}}
+
+	h := FromHistory(entries)
+	data, err := Marshal(h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("unexpected error parsing exported HAR: %v", err)
+	}
+
+	e := parsed.Log.Entries[0]
+	if parsed.Log.Version != "1.2" || parsed.Log.Creator.Name != "apitty" {
+		t.Errorf("unexpected log header %+v", parsed.Log)
+	}
+	if e.Time != 21 || e.Timings.DNS != -1 || e.Timings.Wait != 20 {
+		t.Errorf("unexpected timings %v %+v", e.Time, e.Timings)
+	}
+	if len(e.Request.QueryString) != 1 || e.Request.QueryString[0].Value != "a b" {
+		t.Errorf("unexpected query string %+v", e.Request.QueryString)
+	}
+	if e.Request.PostData == nil || e.Request.PostData.MimeType != "application/json" {
+		t.Errorf("unexpected post data %+v", e.Request.PostData)
+	}
+	if e.Response.StatusText != "Created" || e.Response.RedirectURL != "/items/1" {
+		t.Errorf("unexpected response %+v", e.Response)
+	}
+	if e.Response.Content.Encoding != "base64" {
+		t.Errorf("expected binary content to be base64 encoded, got %+v", e.Response.Content)
+	}
+
+	roundTrip := parsed.History()
+	if roundTrip[0].Response.Body != "\x89PNG" || roundTrip[0].Request.Body != `{"name":"x"}` {
+		t.Errorf("expected bodies to survive a round trip, got %+v", roundTrip[0])
+	}
+}
@@ -0,0 +1,153 @@
+// Package hexdump detects binary bodies and renders them as a hex and ASCII
+// dump, one line of Width bytes at a time.
+package hexdump
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Width is the number of bytes on a line of the dump
+const Width = 16
+
+// sniffLen is the number of bytes looked at to tell text from binary
+const sniffLen = 512
+
+// textual tells whether a media type describes text
+func textual(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "/json"), strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "/xml"), strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "yaml"),
+		strings.HasSuffix(mediaType, "javascript"), strings.HasSuffix(mediaType, "ecmascript"),
+		mediaType == "application/x-www-form-urlencoded", mediaType == "application/graphql",
+		mediaType == "application/x-ndjson":
+		return true
+	}
+	return false
+}
+
+// IsBinary tells whether a body of a media type would corrupt the terminal
+// if printed. Bodies declared as text are text, images, audio, video and
+// fonts are binary, and other bodies are sniffed.
+func IsBinary(mediaType string, data []byte) bool {
+	if len(data) == 0 || textual(mediaType) {
+		return false
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mediaType, prefix) && mediaType != "image/svg+xml" {
+			return true
+		}
+	}
+	return !looksLikeText(data)
+}
+
+// looksLikeText tells whether the start of data is UTF-8 text without
+// control characters, other than whitespace and color codes
+func looksLikeText(data []byte) bool {
+	sample := data[:min(len(data), sniffLen)]
+	// Ignore a character cut at the end of the sample
+	for i := 0; i < utf8.UTFMax && len(sample) < len(data) && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	if !utf8.Valid(sample) {
+		return false
+	}
+	for _, b := range sample {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != 0x1b || b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// Detect returns the media type of a body: the declared one, or the one
+// sniffed from its content when none or a generic one was declared
+func Detect(mediaType string, data []byte) string {
+	if mediaType != "" && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return sniffed
+}
+
+// Line renders the line of the dump starting at offset: the offset, the
+// bytes in hex, and their printable ASCII characters
+func Line(data []byte, offset int) string {
+	row := data[offset:min(len(data), offset+Width)]
+	var b strings.Builder
+	fmt.Fprintf(&b, "%08x  ", offset)
+	for i := 0; i < Width; i++ {
+		if i == Width/2 {
+			b.WriteByte(' ')
+		}
+		if i < len(row) {
+			b.WriteString(hex.EncodeToString(row[i : i+1]))
+			b.WriteByte(' ')
+		} else {
+			b.WriteString("   ")
+		}
+	}
+	b.WriteString(" |")
+	for _, c := range row {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('|')
+	return b.String()
+}
+
+// Dump renders the whole dump of data
+func Dump(data []byte) string {
+	var b strings.Builder
+	for offset := 0; offset < len(data); offset += Width {
+		if offset > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(Line(data, offset))
+	}
+	return b.String()
+}
+
+// ParseOffset parses an offset in decimal, or in hex with a 0x prefix
+func ParseOffset(s string) (int, error) {
+	digits, base := strings.TrimSpace(s), 10
+	if rest, ok := strings.CutPrefix(strings.ToLower(digits), "0x"); ok {
+		digits, base = rest, 16
+	}
+	n, err := strconv.ParseInt(digits, base, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid offset %q, expected a number like 4096 or 0x1000", s)
+	}
+	return int(n), nil
+}
+
+// ParsePattern parses the bytes to search for: hex bytes like "89 50 4e 47",
+// or text between double quotes
+func ParsePattern(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if text, err := strconv.Unquote(s); err == nil && strings.HasPrefix(s, `"`) {
+		if text == "" {
+			return nil, errors.New("empty search")
+		}
+		return []byte(text), nil
+	}
+	digits := strings.NewReplacer(" ", "", ":", "", "0x", "").Replace(strings.ToLower(s))
+	pattern, err := hex.DecodeString(digits)
+	if err != nil || len(pattern) == 0 {
+		return nil, fmt.Errorf(`invalid search %q, expected hex bytes like 89 50 4e 47 or "text"`, s)
+	}
+	return pattern, nil
+}
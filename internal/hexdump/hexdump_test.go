@@ -0,0 +1,102 @@
+package hexdump
+
+import (
+	"bytes"
+	"testing"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01")
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		data      []byte
+		expected  bool
+	}{
+		{"JSON", "application/json", []byte(`{"a": 1}`), false},
+		{"Declared text with control bytes", "text/plain", []byte("a\x00b"), false},
+		{"Image", "image/png", png, true},
+		{"SVG image", "image/svg+xml", []byte("<svg/>"), false},
+		{"Sniffed binary", "application/octet-stream", png, true},
+		{"Sniffed text", "", []byte("hello\tworld\n"), false},
+		{"Sniffed protobuf", "application/x-protobuf", []byte{0x08, 0x96, 0x01, 0x12, 0x03, 'f', 'o', 'o'}, true},
+		{"Colored text", "", []byte("\x1b[31mred\x1b[0m"), false},
+		{"Text cut in a character", "", append(bytes.Repeat([]byte("a"), sniffLen-1), "éé"...), false},
+		{"Empty", "application/octet-stream", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsBinary(tt.mediaType, tt.data); result != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	if mediaType := Detect("", png); mediaType != "image/png" {
+		t.Errorf("expected a sniffed PNG, got %q", mediaType)
+	}
+	if mediaType := Detect("application/x-protobuf", png); mediaType != "application/x-protobuf" {
+		t.Errorf("expected the declared type, got %q", mediaType)
+	}
+}
+
+func TestDump(t *testing.T) {
+	expected := "00000000  89 50 4e 47 0d 0a 1a 0a  00 00 00 0d 49 48 44 52  |.PNG........IHDR|\n" +
+		"00000010  00 00 00 01                                       |....|"
+	if dump := Dump(png); dump != expected {
+		t.Errorf("unexpected dump:\n%s\nexpected:\n%s", dump, expected)
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		wantErr  bool
+	}{
+		{"4096", 4096, false},
+		{"0x1000", 4096, false},
+		{" 0X10 ", 16, false},
+		{"-1", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			n, err := ParseOffset(tt.input)
+			if (err != nil) != tt.wantErr || n != tt.expected {
+				t.Errorf("expected %d (error %t), got %d, %v", tt.expected, tt.wantErr, n, err)
+			}
+		})
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []byte
+		wantErr  bool
+	}{
+		{"89 50 4e 47", []byte("\x89PNG"), false},
+		{"0x49 0x48", []byte("IH"), false},
+		{"de:ad:BE:EF", []byte{0xde, 0xad, 0xbe, 0xef}, false},
+		{`"IHDR"`, []byte("IHDR"), false},
+		{`"\r\n"`, []byte("\r\n"), false},
+		{"abc", nil, true},
+		{`""`, nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			pattern, err := ParsePattern(tt.input)
+			if (err != nil) != tt.wantErr || !bytes.Equal(pattern, tt.expected) {
+				t.Errorf("expected %q (error %t), got %q, %v", tt.expected, tt.wantErr, pattern, err)
+			}
+		})
+	}
+}
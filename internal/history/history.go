@@ -0,0 +1,92 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+const (
+	// MaxEntries is the number of entries kept, older ones are dropped
+	MaxEntries = 200
+	// MaxBodySize is the number of response body bytes kept per entry
+	MaxBodySize = 1 << 20
+)
+
+// DefaultPath returns where the history is stored: $APITTY_HISTORY, or
+// history.json in the apitty folder of the user config directory
+func DefaultPath() (string, error) {
+	if path := os.Getenv("APITTY_HISTORY"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "apitty", "history.json"), nil
+}
+
+// Load reads the history. A missing file is an empty history.
+func Load(path string) ([]model.HistoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []model.HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s is not a valid history file: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Save writes the history, creating its directory if needed
+func Save(path string, entries []model.HistoryEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Add appends entries to the history, truncating large bodies and dropping
+// the oldest entries beyond MaxEntries
+func Add(history []model.HistoryEntry, entries ...model.HistoryEntry) []model.HistoryEntry {
+	for _, e := range entries {
+		if len(e.Response.Body) > MaxBodySize {
+			// Cut on a rune boundary so the body stays valid UTF-8
+			n := MaxBodySize
+			for n > 0 && !utf8.RuneStart(e.Response.Body[n]) {
+				n--
+			}
+			e.Response.Body = e.Response.Body[:n]
+			e.Response.Truncated = true
+		}
+		history = append(history, e)
+	}
+	if len(history) > MaxEntries {
+		history = append([]model.HistoryEntry{}, history[len(history)-MaxEntries:]...)
+	}
+	return history
+}
+
+// SaveCmd writes the history in the background. Nothing is written when
+// path is empty.
+func SaveCmd(path string, entries []model.HistoryEntry) tea.Cmd {
+	if path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		return model.HistorySavedMsg{Err: Save(path, entries)}
+	}
+}
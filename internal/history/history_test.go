@@ -0,0 +1,52 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.json")
+
+	entries, err := Load(path)
+	if err != nil || entries != nil {
+		t.Fatalf("expected an empty history for a missing file, got %v, %v", entries, err)
+	}
+
+	entries = Add(entries, model.HistoryEntry{
+		Time:     time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC),
+		Request:  model.Request{Method: "GET", URL: "https://example.com"},
+		Response: model.Response{Status: "200 OK", StatusCode: 200, Body: "ok"},
+		Timings:  model.Timings{Wait: 20 * time.Millisecond},
+	})
+	if err := Save(path, entries); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Request.URL != "https://example.com" || loaded[0].Timings.Wait != 20*time.Millisecond {
+		t.Errorf("unexpected history %+v", loaded)
+	}
+}
+
+func TestAdd_Limits(t *testing.T) {
+	var entries []model.HistoryEntry
+	for i := 0; i < MaxEntries+5; i++ {
+		entries = Add(entries, model.HistoryEntry{Request: model.Request{URL: strings.Repeat("x", i)}})
+	}
+	if len(entries) != MaxEntries || len(entries[0].Request.URL) != 5 {
+		t.Errorf("expected the oldest entries to be dropped, got %d entries starting at %d", len(entries), len(entries[0].Request.URL))
+	}
+
+	entries = Add(nil, model.HistoryEntry{Response: model.Response{Body: strings.Repeat("a", MaxBodySize+1)}})
+	if len(entries[0].Response.Body) != MaxBodySize || !entries[0].Response.Truncated {
+		t.Errorf("expected the body to be truncated")
+	}
+}
@@ -4,51 +4,321 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/tbourrel/apitty/internal/json"
+	"github.com/tbourrel/apitty/internal/format"
+	"github.com/tbourrel/apitty/internal/graphql"
 	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/transfer"
 )
 
 // SendRequestCmd performs the HTTP request in a goroutine and returns a tea.Cmd
 func SendRequestCmd(method, url string, headers []model.HeaderPair, body string) tea.Cmd {
+	return SendCmd(model.Request{Method: method, URL: url, Headers: headers, Body: body}, nil, nil)
+}
+
+// SendCmd performs a request, including its auth settings, and returns a
+// tea.Cmd. Cookies are sent from and stored into jar, unless it is nil. With
+// a transfer, the body is streamed to a temporary file and only its start
+// is kept in the entry.
+func SendCmd(r model.Request, jar http.CookieJar, t *transfer.Transfer) tea.Cmd {
 	return func() tea.Msg {
-		client := &http.Client{Timeout: 15 * time.Second}
-		var reqBody io.Reader
-		if method == "POST" || method == "PUT" || method == "PATCH" {
-			reqBody = strings.NewReader(body)
+		entry, path, err := do(defaultPool, r, jar, t)
+		if err != nil {
+			return model.ResponseMsg{Resp: "", Headers: "", Status: "", Err: err, Entry: entry}
 		}
-		req, err := http.NewRequest(method, url, reqBody)
+		pretty, formatter := formatStreamed(entry.Response, path, t)
+		return model.ResponseMsg{Resp: pretty, Headers: FormatHeaders(entry.Response.Headers), Status: entry.Response.Status, Err: nil, Entry: entry, BodyFile: path, Format: formatter}
+	}
+}
+
+// formatLimit is the largest streamed body read back whole to be
+// pretty-printed, since its start alone rarely parses
+const formatLimit = 32 << 20
+
+// streamedBody reads back the whole body of a response cut to its preview
+// from the file at path, when it is small enough to be formatted
+func streamedBody(r model.Response, path string) (string, bool) {
+	if !r.Truncated || path == "" || r.Size > formatLimit {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// cutPreview cuts a formatted body back to about the size of the preview,
+// on a line boundary, so only the start is rendered
+func cutPreview(pretty string, preview int64) string {
+	limit := int(preview)
+	if len(pretty) <= limit {
+		return pretty
+	}
+	cut := strings.LastIndexByte(pretty[:limit], '\n')
+	if cut <= 0 {
+		cut = limit
+	}
+	return pretty[:cut]
+}
+
+// formatStreamed pretty-prints a response whose body may have been streamed
+// to the file at path. A body cut to its preview is formatted whole from the
+// file, since its start alone rarely parses.
+func formatStreamed(r model.Response, path string, t *transfer.Transfer) (string, string) {
+	body, ok := streamedBody(r, path)
+	if !ok {
+		return FormatBody(r)
+	}
+	whole := r
+	whole.Body = body
+	pretty, formatter := FormatBody(whole)
+	if formatter == "" {
+		return FormatBody(r)
+	}
+	return cutPreview(pretty, t.Preview), formatter
+}
+
+// Send performs a request and waits for its response, for callers outside
+// of the TUI. Cookies are sent from and stored into jar, unless it is nil.
+func Send(r model.Request, jar http.CookieJar) (*model.HistoryEntry, error) {
+	entry, _, err := do(defaultPool, r, jar, nil)
+	return entry, err
+}
+
+// SendGraphQLCmd posts a GraphQL query and its variables as a JSON envelope.
+// The method and body of r are replaced by the envelope.
+func SendGraphQLCmd(r model.Request, query, variables string, jar http.CookieJar, t *transfer.Transfer) tea.Cmd {
+	return func() tea.Msg {
+		r, err := GraphQLRequest(r, query, variables)
 		if err != nil {
 			return model.ResponseMsg{Resp: "", Headers: "", Status: "", Err: err}
 		}
-		// Apply request headers
-		for _, h := range headers {
-			if h.Key != "" {
-				req.Header.Set(h.Key, h.Value)
-			}
+		entry, path, err := do(defaultPool, r, jar, t)
+		if err != nil {
+			return model.ResponseMsg{Resp: "", Headers: "", Status: "", Err: err, Entry: entry}
+		}
+		resp := graphql.FormatResponse([]byte(entry.Response.Body))
+		if body, ok := streamedBody(entry.Response, path); ok {
+			// The errors and data are only split from the whole body
+			resp = cutPreview(graphql.FormatResponse([]byte(body)), t.Preview)
+		}
+		return model.ResponseMsg{Resp: resp, Headers: FormatHeaders(entry.Response.Headers), Status: entry.Response.Status, Err: nil, Entry: entry, BodyFile: path}
+	}
+}
+
+// GraphQLRequest returns r as sent by SendGraphQLCmd: a POST of the query
+// and its variables as a JSON envelope
+func GraphQLRequest(r model.Request, query, variables string) (model.Request, error) {
+	envelope, err := graphql.BuildEnvelope(query, variables)
+	if err != nil {
+		return r, err
+	}
+	r.Method = "POST"
+	r.Headers = withJSONContentType(r.Headers)
+	r.Body = string(envelope)
+	return r, nil
+}
+
+// IntrospectCmd runs the introspection query against a GraphQL endpoint
+func IntrospectCmd(r model.Request, jar http.CookieJar) tea.Cmd {
+	return func() tea.Msg {
+		r, err := GraphQLRequest(r, graphql.IntrospectionQuery, "")
+		if err != nil {
+			return model.SchemaMsg{Err: err}
+		}
+		entry, _, err := do(defaultPool, r, jar, nil)
+		if err != nil {
+			return model.SchemaMsg{Err: err}
 		}
-		resp, err := client.Do(req)
+		schema, err := graphql.ParseIntrospection([]byte(entry.Response.Body))
 		if err != nil {
-			return model.ResponseMsg{Resp: "", Headers: "", Status: "", Err: err}
+			return model.SchemaMsg{Err: fmt.Errorf("%s: %w", entry.Response.Status, err)}
+		}
+		return model.SchemaMsg{Schema: schema}
+	}
+}
+
+// do sends a request on the connections of p and records it, with its
+// response and timings, as a history entry. The entry is also returned,
+// with its Error set, when the request fails after being built. With a
+// transfer, the path of the file holding the whole body is returned too.
+func do(p *Pool, r model.Request, jar http.CookieJar, t *transfer.Transfer) (*model.HistoryEntry, string, error) {
+	client, err := newClient(p, r.Settings)
+	if err != nil {
+		return nil, "", err
+	}
+	client.Jar = jar
+	req, err := BuildRequest(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if t != nil {
+		req = req.WithContext(t.Context())
+		// Release the context once the body is read
+		defer t.Cancel()
+	}
+
+	// Record the request as sent, with auth applied and variables expanded
+	entry := &model.HistoryEntry{
+		Time:    time.Now(),
+		Request: model.Request{Method: req.Method, URL: req.URL.String(), Headers: headerPairs(req.Header), Body: r.Body},
+	}
+	if req.Body == nil {
+		entry.Request.Body = ""
+	}
+	if jar != nil {
+		// The client adds the jar cookies itself, record them as it sends them
+		for _, c := range jar.Cookies(req.URL) {
+			entry.Request.Headers = append(entry.Request.Headers, model.HeaderPair{Key: "Cookie", Value: c.String()})
 		}
-		defer func() {
-			_ = resp.Body.Close()
-		}()
+	}
+	tm := &timer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tm.trace()))
+	tm.start = entry.Time
 
-		// Build headers string
-		var headersBuilder strings.Builder
-		for k, v := range resp.Header {
-			headersBuilder.WriteString(fmt.Sprintf("%s: %s\n", k, strings.Join(v, ", ")))
+	resp, err := client.Do(req)
+	entry.ReusedConnection = tm.connectionReused()
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Timings = tm.timings(time.Now())
+		return entry, "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	respBody, size, path, err := readBody(resp, r.Settings != nil && r.Settings.Compressed, t)
+	entry.Timings = tm.timings(time.Now())
+	if err != nil {
+		entry.Error = err.Error()
+		return entry, "", err
+	}
+
+	entry.Response = model.Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		Headers:    headerPairs(resp.Header),
+		Body:       string(respBody),
+		Size:       size,
+		Truncated:  size > int64(len(respBody)),
+	}
+	return entry, path, nil
+}
+
+// headerPairs lists headers sorted by name
+func headerPairs(h http.Header) []model.HeaderPair {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []model.HeaderPair
+	for _, k := range keys {
+		for _, v := range h[k] {
+			pairs = append(pairs, model.HeaderPair{Key: k, Value: v})
 		}
+	}
+	return pairs
+}
 
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return model.ResponseMsg{Resp: "", Headers: "", Status: "", Err: err}
+// FormatHeaders renders response headers one per line, joining repeated
+// headers with commas
+func FormatHeaders(headers []model.HeaderPair) string {
+	var keys []string
+	values := make(map[string][]string)
+	for _, h := range headers {
+		if _, ok := values[h.Key]; !ok {
+			keys = append(keys, h.Key)
+		}
+		values[h.Key] = append(values[h.Key], h.Value)
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("%s: %s\n", k, strings.Join(values[k], ", ")))
+	}
+	return b.String()
+}
+
+// FormatBody pretty-prints a response body for its Content-Type, and names
+// the formatter used, or returns it unchanged with an empty name
+func FormatBody(r model.Response) (string, string) {
+	contentType := ""
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			contentType = h.Value
+			break
+		}
+	}
+	out, f := format.Format(format.MediaType(contentType), []byte(r.Body))
+	if f == nil {
+		return out, ""
+	}
+	return out, f.Name()
+}
+
+// BuildRequest turns a request description into an *http.Request
+func BuildRequest(r model.Request) (*http.Request, error) {
+	var reqBody io.Reader
+	if r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH" {
+		reqBody = strings.NewReader(r.Body)
+	}
+	req, err := http.NewRequest(r.Method, r.URL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	// Apply request headers
+	for _, h := range r.Headers {
+		if h.Key != "" {
+			req.Header.Set(h.Key, h.Value)
+		}
+	}
+	if r.Settings != nil && r.Settings.Compressed && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	applyAuth(req, r.Auth)
+	return req, nil
+}
+
+// applyAuth adds the credentials of auth to the request
+func applyAuth(req *http.Request, auth *model.Auth) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case model.AuthBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case model.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case model.AuthAPIKey:
+		if auth.Key == "" {
+			return
+		}
+		if auth.In == "query" {
+			q := req.URL.Query()
+			q.Set(auth.Key, auth.Value)
+			req.URL.RawQuery = q.Encode()
+		} else {
+			req.Header.Set(auth.Key, auth.Value)
+		}
+	}
+}
+
+// withJSONContentType adds a JSON Content-Type unless one is already set
+func withJSONContentType(headers []model.HeaderPair) []model.HeaderPair {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			return headers
 		}
-		pretty := json.TryPrettyJSON(respBody)
-		return model.ResponseMsg{Resp: pretty, Headers: headersBuilder.String(), Status: resp.Status, Err: nil}
 	}
+	out := make([]model.HeaderPair, 0, len(headers)+1)
+	out = append(out, headers...)
+	return append(out, model.HeaderPair{Key: "Content-Type", Value: "application/json"})
 }
@@ -1,11 +1,27 @@
 package http
 
 import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/tbourrel/apitty/internal/cookies"
 	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/transfer"
 )
 
 func TestSendRequestCmd_HeadersAreSent(t *testing.T) {
@@ -184,3 +200,438 @@ func TestSendRequestCmd_AuthorizationWith401Response(t *testing.T) {
 		t.Error("Authorization header was NOT sent to the server!")
 	}
 }
+
+func TestSendGraphQLCmd_SendsEnvelope(t *testing.T) {
+	var receivedBody map[string]interface{}
+	var receivedContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedContentType = r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&receivedBody)
+		w.Write([]byte(`{"data": null, "errors": [{"message": "boom"}]}`))
+	}))
+	defer server.Close()
+
+	cmd := SendGraphQLCmd(model.Request{URL: server.URL}, "{ users { id } }", `{"limit": 5}`, nil, nil)
+	result := cmd()
+
+	responseMsg, ok := result.(model.ResponseMsg)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", result)
+	}
+	if responseMsg.Err != nil {
+		t.Fatalf("unexpected error: %v", responseMsg.Err)
+	}
+	if receivedContentType != "application/json" {
+		t.Errorf("expected JSON content type, got '%s'", receivedContentType)
+	}
+	if receivedBody["query"] != "{ users { id } }" {
+		t.Errorf("unexpected query: %v", receivedBody["query"])
+	}
+	if !strings.Contains(responseMsg.Resp, "Errors (1)") {
+		t.Errorf("expected errors to be rendered, got %s", responseMsg.Resp)
+	}
+}
+
+func TestIntrospectCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"__schema":{"queryType":{"name":"Query"},"types":[{"kind":"OBJECT","name":"Query","fields":[]}]}}}`))
+	}))
+	defer server.Close()
+
+	result := IntrospectCmd(model.Request{URL: server.URL}, nil)()
+
+	schemaMsg, ok := result.(model.SchemaMsg)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", result)
+	}
+	if schemaMsg.Err != nil {
+		t.Fatalf("unexpected error: %v", schemaMsg.Err)
+	}
+	if schemaMsg.Schema.QueryType != "Query" {
+		t.Errorf("expected query type 'Query', got '%s'", schemaMsg.Schema.QueryType)
+	}
+}
+
+func TestSendCmd_Auth(t *testing.T) {
+	var receivedAuth, receivedKey, receivedQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuth = r.Header.Get("Authorization")
+		receivedKey = r.Header.Get("X-API-Key")
+		receivedQuery = r.URL.Query().Get("api_key")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		auth          *model.Auth
+		expectedAuth  string
+		expectedKey   string
+		expectedQuery string
+	}{
+		{
+			name:         "Basic",
+			auth:         &model.Auth{Type: model.AuthBasic, Username: "user", Password: "pass"},
+			expectedAuth: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:         "Bearer",
+			auth:         &model.Auth{Type: model.AuthBearer, Token: "abc"},
+			expectedAuth: "Bearer abc",
+		},
+		{
+			name:        "API key in header",
+			auth:        &model.Auth{Type: model.AuthAPIKey, Key: "X-API-Key", Value: "k1", In: "header"},
+			expectedKey: "k1",
+		},
+		{
+			name:          "API key in query",
+			auth:          &model.Auth{Type: model.AuthAPIKey, Key: "api_key", Value: "k2", In: "query"},
+			expectedQuery: "k2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receivedAuth, receivedKey, receivedQuery = "", "", ""
+			result := SendCmd(model.Request{Method: "GET", URL: server.URL + "?x=1", Auth: tt.auth}, nil, nil)()
+			if responseMsg, ok := result.(model.ResponseMsg); !ok || responseMsg.Err != nil {
+				t.Fatalf("unexpected result: %+v", result)
+			}
+			if receivedAuth != tt.expectedAuth {
+				t.Errorf("expected Authorization '%s', got '%s'", tt.expectedAuth, receivedAuth)
+			}
+			if receivedKey != tt.expectedKey {
+				t.Errorf("expected X-API-Key '%s', got '%s'", tt.expectedKey, receivedKey)
+			}
+			if receivedQuery != tt.expectedQuery {
+				t.Errorf("expected api_key '%s', got '%s'", tt.expectedQuery, receivedQuery)
+			}
+		})
+	}
+}
+
+func TestSendCmd_Settings(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer tlsServer.Close()
+
+	t.Run("Insecure", func(t *testing.T) {
+		result := SendCmd(model.Request{Method: "GET", URL: tlsServer.URL}, nil, nil)()
+		if msg := result.(model.ResponseMsg); msg.Err == nil {
+			t.Error("expected the self-signed certificate to be rejected")
+		}
+		result = SendCmd(model.Request{Method: "GET", URL: tlsServer.URL, Settings: &model.Settings{Insecure: true}}, nil, nil)()
+		if msg := result.(model.ResponseMsg); msg.Err != nil {
+			t.Errorf("expected insecure to skip verification, got %v", msg.Err)
+		}
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/gzip":
+			if r.Header.Get("Accept-Encoding") != "gzip, deflate" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte("compressed"))
+			gz.Close()
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.Write([]byte("target"))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("NoRedirects", func(t *testing.T) {
+		msg := SendCmd(model.Request{Method: "GET", URL: server.URL + "/redirect"}, nil, nil)().(model.ResponseMsg)
+		if msg.Entry == nil || msg.Entry.Response.StatusCode != http.StatusOK {
+			t.Errorf("expected the redirect to be followed by default, got %q", msg.Status)
+		}
+		msg = SendCmd(model.Request{Method: "GET", URL: server.URL + "/redirect", Settings: &model.Settings{NoRedirects: true}}, nil, nil)().(model.ResponseMsg)
+		if msg.Entry == nil || msg.Entry.Response.StatusCode != http.StatusFound {
+			t.Errorf("expected the redirect response, got %q", msg.Status)
+		}
+	})
+
+	t.Run("Compressed", func(t *testing.T) {
+		msg := SendCmd(model.Request{Method: "GET", URL: server.URL + "/gzip", Settings: &model.Settings{Compressed: true}}, nil, nil)().(model.ResponseMsg)
+		if msg.Err != nil || msg.Resp != "compressed" {
+			t.Errorf("expected the body to be decoded, got %q, %v", msg.Resp, msg.Err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		msg := SendCmd(model.Request{Method: "GET", URL: server.URL + "/slow", Settings: &model.Settings{TimeoutMs: 50}}, nil, nil)().(model.ResponseMsg)
+		if msg.Err == nil {
+			t.Error("expected the request to time out")
+		}
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+		settings := &model.Settings{Resolve: []string{"api.test:" + port + ":127.0.0.1"}}
+		msg := SendCmd(model.Request{Method: "GET", URL: "http://api.test:" + port + "/", Settings: settings}, nil, nil)().(model.ResponseMsg)
+		if msg.Err != nil || msg.Resp != "target" {
+			t.Errorf("expected the pinned address to be used, got %q, %v", msg.Resp, msg.Err)
+		}
+
+		msg = SendCmd(model.Request{Method: "GET", URL: server.URL, Settings: &model.Settings{Resolve: []string{"api.test"}}}, nil, nil)().(model.ResponseMsg)
+		if msg.Err == nil || !strings.Contains(msg.Err.Error(), "invalid resolve entry") {
+			t.Errorf("expected an invalid entry error, got %v", msg.Err)
+		}
+	})
+
+	t.Run("Client certificate", func(t *testing.T) {
+		msg := SendCmd(model.Request{Method: "GET", URL: server.URL, Settings: &model.Settings{CertFile: filepath.Join(t.TempDir(), "missing.pem")}}, nil, nil)().(model.ResponseMsg)
+		if msg.Err == nil || !strings.Contains(msg.Err.Error(), "loading client certificate") {
+			t.Errorf("expected a certificate error, got %v", msg.Err)
+		}
+	})
+}
+
+func TestSend_ConnectionReuse(t *testing.T) {
+	var mu sync.Mutex
+	conns := make(map[string]bool)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Requests with the same TLS settings share their connections
+	insecure := &model.Settings{Insecure: true, TimeoutMs: 1000}
+	for i := range 3 {
+		entry, err := Send(model.Request{Method: "GET", URL: server.URL, Settings: insecure}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.ReusedConnection != (i > 0) {
+			t.Errorf("request %d: expected reused=%t", i, i > 0)
+		}
+		if i == 0 && entry.Timings.SSL == 0 {
+			t.Error("expected the first request to time the TLS handshake")
+		}
+	}
+	if len(conns) != 1 {
+		t.Errorf("expected a single connection, got %d", len(conns))
+	}
+
+	// A fresh connection is opened, and closed, every time
+	fresh := &model.Settings{Insecure: true, FreshConnection: true}
+	for range 2 {
+		entry, err := Send(model.Request{Method: "GET", URL: server.URL, Settings: fresh}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.ReusedConnection || entry.Timings.SSL == 0 {
+			t.Errorf("expected a new connection with a TLS handshake, got %+v", entry.Timings)
+		}
+	}
+	if len(conns) != 3 {
+		t.Errorf("expected 3 connections, got %d", len(conns))
+	}
+
+	// Closing idle connections makes the next request open one
+	CloseIdleConnections()
+	entry, err := Send(model.Request{Method: "GET", URL: server.URL, Settings: insecure}, nil)
+	if err != nil || entry.ReusedConnection {
+		t.Errorf("expected a new connection after closing the idle ones, got %v", err)
+	}
+
+	// A pool of its own keeps its connections apart from the shared ones
+	pool := NewPool(PoolOptions{MaxIdleConnsPerHost: 4})
+	for i := range 2 {
+		entry, err := pool.Send(model.Request{Method: "GET", URL: server.URL, Settings: insecure}, nil)
+		if err != nil || entry.ReusedConnection != (i > 0) {
+			t.Errorf("pool request %d: expected reused=%t, got %v", i, i > 0, err)
+		}
+	}
+	pool.CloseIdleConnections()
+	if entry, err := Send(model.Request{Method: "GET", URL: server.URL, Settings: insecure}, nil); err != nil || !entry.ReusedConnection {
+		t.Errorf("expected closing the pool to leave the shared connections, got %v", err)
+	}
+}
+
+func TestSendCmd_CookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			// Sessions are often set on a redirect to the app
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		default:
+			c, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(c.Value))
+		}
+	}))
+	defer server.Close()
+
+	jar := cookies.NewJar()
+	msg := SendCmd(model.Request{Method: "POST", URL: server.URL + "/login"}, jar, nil)().(model.ResponseMsg)
+	if msg.Err != nil || msg.Resp != "abc" {
+		t.Fatalf("expected the cookie to follow the redirect, got %q, %v", msg.Resp, msg.Err)
+	}
+	if list := jar.List(); len(list) != 1 || list[0].Name != "session" || !list[0].HttpOnly {
+		t.Fatalf("unexpected jar %+v", list)
+	}
+
+	msg = SendCmd(model.Request{Method: "GET", URL: server.URL + "/me"}, jar, nil)().(model.ResponseMsg)
+	if msg.Status != "200 OK" {
+		t.Errorf("expected the session to be sent, got %s", msg.Status)
+	}
+	if h := msg.Entry.Request.Headers; len(h) != 1 || h[0].Key != "Cookie" || h[0].Value != "session=abc" {
+		t.Errorf("expected the cookie in the history entry, got %v", h)
+	}
+
+	msg = SendCmd(model.Request{Method: "GET", URL: server.URL + "/me"}, nil, nil)().(model.ResponseMsg)
+	if msg.Status != "401 Unauthorized" {
+		t.Errorf("expected no cookies without a jar, got %s", msg.Status)
+	}
+}
+
+func TestSendCmd_Transfer(t *testing.T) {
+	body := strings.Repeat("0123456789", 300_000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(body))
+			gz.Close()
+		case "/zlib", "/deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+			var fw io.WriteCloser
+			if r.URL.Path == "/zlib" {
+				fw = zlib.NewWriter(w)
+			} else {
+				fw, _ = flate.NewWriter(w, flate.DefaultCompression)
+			}
+			fw.Write([]byte("deflated"))
+			fw.Close()
+		default:
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write([]byte(body))
+		}
+	}))
+	defer server.Close()
+
+	tr := transfer.New(1024)
+	msg := SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, tr)().(model.ResponseMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	defer os.Remove(msg.BodyFile)
+	resp := msg.Entry.Response
+	if len(resp.Body) != 1024 || resp.Size != int64(len(body)) || !resp.Truncated {
+		t.Errorf("expected a 1024 bytes preview of %d bytes, got %d of %d", len(body), len(resp.Body), resp.Size)
+	}
+	if received, total, _ := tr.Progress(); received != int64(len(body)) || total != int64(len(body)) {
+		t.Errorf("expected the progress to reach %d bytes, got %d of %d", len(body), received, total)
+	}
+	if tr.Context().Err() == nil {
+		t.Error("expected the context to be released once the body is received")
+	}
+	data, err := os.ReadFile(msg.BodyFile)
+	if err != nil || string(data) != body {
+		t.Fatalf("expected the whole body in %s, got %d bytes, %v", msg.BodyFile, len(data), err)
+	}
+
+	// Saving copies the whole body
+	dest := filepath.Join(t.TempDir(), "body.txt")
+	saved := SaveBodyCmd(msg.BodyFile, resp.Body, dest)().(BodySavedMsg)
+	if data, _ := os.ReadFile(dest); saved.Err != nil || saved.Size != int64(len(body)) || len(data) != len(body) {
+		t.Errorf("expected %d bytes saved, got %d, %v", len(body), saved.Size, saved.Err)
+	}
+
+	// Compressed bodies are decoded while streamed
+	compressed := &model.Settings{Compressed: true}
+	msg = SendCmd(model.Request{Method: "GET", URL: server.URL + "/gzip", Settings: compressed}, nil, transfer.New(10))().(model.ResponseMsg)
+	if msg.Err != nil || msg.Entry.Response.Body != "0123456789" || msg.Entry.Response.Size != int64(len(body)) {
+		t.Errorf("expected the decoded body, got %q, %v", msg.Entry.Response.Body, msg.Err)
+	}
+	os.Remove(msg.BodyFile)
+	for _, path := range []string{"/zlib", "/deflate"} {
+		msg = SendCmd(model.Request{Method: "GET", URL: server.URL + path, Settings: compressed}, nil, transfer.New(0))().(model.ResponseMsg)
+		if msg.Err != nil || msg.Resp != "deflated" || msg.Entry.Response.Truncated {
+			t.Errorf("%s: expected the decoded body, got %q, %v", path, msg.Resp, msg.Err)
+		}
+		os.Remove(msg.BodyFile)
+	}
+
+	// A cancelled transfer reports the cancellation
+	tr = transfer.New(0)
+	tr.Cancel()
+	msg = SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, tr)().(model.ResponseMsg)
+	if !errors.Is(msg.Err, context.Canceled) || msg.BodyFile != "" {
+		t.Errorf("expected a cancelled request, got %v", msg.Err)
+	}
+}
+
+func TestSendCmd_FormatsStreamedBody(t *testing.T) {
+	var items []string
+	for i := 0; i < 40_000; i++ {
+		items = append(items, fmt.Sprintf(`{"id":%d,"name":"item %d"}`, i, i))
+	}
+	body := "[" + strings.Join(items, ",") + "]"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	tr := transfer.New(0)
+	msg := SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, tr)().(model.ResponseMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	defer os.Remove(msg.BodyFile)
+	if !msg.Entry.Response.Truncated || len(body) <= transfer.DefaultPreview {
+		t.Fatalf("expected a body larger than the preview, got %d bytes", len(body))
+	}
+	if msg.Format != "JSON" || !strings.HasPrefix(msg.Resp, "[\n  {\n    \"id\": 0,") {
+		t.Fatalf("expected the body to be pretty-printed, got %s %q", msg.Format, msg.Resp[:40])
+	}
+	// Only the start is kept, cut after a whole line
+	last := msg.Resp[strings.LastIndexByte(msg.Resp, '\n')+1:]
+	if len(msg.Resp) > transfer.DefaultPreview || !strings.ContainsAny(last[len(last)-1:], ",{}\"") {
+		t.Errorf("expected the output to be cut on a line boundary within the preview, got %d bytes ending %q", len(msg.Resp), last)
+	}
+}
+
+func TestSendGraphQLCmd_FormatsStreamedBody(t *testing.T) {
+	var items []string
+	for i := 0; i < 40_000; i++ {
+		items = append(items, fmt.Sprintf(`{"id":%d,"name":"item %d"}`, i, i))
+	}
+	body := `{"data":{"items":[` + strings.Join(items, ",") + `]},"errors":[{"message":"partial result"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	msg := SendGraphQLCmd(model.Request{URL: server.URL}, "{ items { id name } }", "", nil, transfer.New(0))().(model.ResponseMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	defer os.Remove(msg.BodyFile)
+	if !msg.Entry.Response.Truncated {
+		t.Fatalf("expected a body larger than the preview, got %d bytes", len(body))
+	}
+	if !strings.Contains(msg.Resp, "Errors (1)") || !strings.Contains(msg.Resp, "partial result") || len(msg.Resp) > 2*transfer.DefaultPreview {
+		t.Errorf("expected the errors to be split from the data, got %q", msg.Resp[:min(len(msg.Resp), 200)])
+	}
+}
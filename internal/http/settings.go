@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// defaultTimeout bounds requests without a timeout setting
+const defaultTimeout = 15 * time.Second
+
+// newClient returns a client applying the settings of a request, on the
+// transport of the pool for its TLS and resolve settings
+func newClient(p *Pool, s *model.Settings) (*http.Client, error) {
+	t, err := p.transport(s)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: defaultTimeout, Transport: t}
+	if s == nil {
+		return client, nil
+	}
+
+	if s.TimeoutMs > 0 {
+		client.Timeout = time.Duration(s.TimeoutMs) * time.Millisecond
+	}
+	if s.NoRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client, nil
+}
+
+// parseResolve maps host:port to address:port for curl style
+// host:port:address entries
+func parseResolve(entries []string) (map[string]string, error) {
+	pins := make(map[string]string)
+	for _, e := range entries {
+		host, rest, _ := strings.Cut(e, ":")
+		port, addr, _ := strings.Cut(rest, ":")
+		// Only the first of several addresses is used
+		addr, _, _ = strings.Cut(addr, ",")
+		addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		if _, err := strconv.Atoi(port); host == "" || addr == "" || err != nil {
+			return nil, fmt.Errorf("invalid resolve entry %q, expected host:port:address", e)
+		}
+		pins[net.JoinHostPort(host, port)] = net.JoinHostPort(addr, port)
+	}
+	return pins, nil
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/transfer"
+)
+
+// prefixWriter keeps the first limit bytes written to it
+type prefixWriter struct {
+	buf   []byte
+	limit int64
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.limit - int64(len(w.buf)); room > 0 {
+		w.buf = append(w.buf, p[:min(int64(len(p)), room)]...)
+	}
+	return len(p), nil
+}
+
+// streamBody copies a body to a temporary file and returns its start, its
+// size and the path of the file
+func streamBody(body io.Reader, t *transfer.Transfer) (preview []byte, size int64, path string, err error) {
+	f, err := os.CreateTemp("", "apitty-body-*")
+	if err != nil {
+		return nil, 0, "", err
+	}
+	prefix := &prefixWriter{limit: t.Preview}
+	size, err = io.Copy(io.MultiWriter(f, prefix), body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return nil, 0, "", err
+	}
+	return prefix.buf, size, f.Name(), nil
+}
+
+// readBody reads a response body, decoded when compressed is set. With a
+// transfer, the body is streamed to a temporary file and only its start is
+// returned.
+func readBody(resp *http.Response, compressed bool, t *transfer.Transfer) (body []byte, size int64, path string, err error) {
+	var r io.Reader = resp.Body
+	if t != nil {
+		t.SetTotal(resp.ContentLength)
+		r = t.Reader(r)
+	}
+	encoding := resp.Header.Get("Content-Encoding")
+	if compressed {
+		decoder, err := decodeReader(encoding, r)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		defer func() {
+			_ = decoder.Close()
+		}()
+		r = decoder
+	}
+	if t != nil {
+		body, size, path, err = streamBody(r, t)
+	} else {
+		body, err = io.ReadAll(r)
+		size = int64(len(body))
+	}
+	if err != nil && compressed && encoding != "" {
+		err = fmt.Errorf("decoding %s response: %w", encoding, err)
+	}
+	return body, size, path, err
+}
+
+// decodeReader decodes a gzip or deflate encoded response body. Closing
+// the decoder leaves the body open.
+func decodeReader(encoding string, body io.Reader) (io.ReadCloser, error) {
+	var r io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(body)
+	case "deflate":
+		// deflate is meant to be zlib wrapped, but raw streams are common
+		br := bufio.NewReader(body)
+		if header, _ := br.Peek(2); len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			r, err = zlib.NewReader(br)
+		} else {
+			r = flate.NewReader(br)
+		}
+	default:
+		return io.NopCloser(body), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s response: %w", encoding, err)
+	}
+	return r, nil
+}
+
+// SaveBodyCmd writes a response body to path: the file a streamed body was
+// received into when src is set, body otherwise
+func SaveBodyCmd(src, body, path string) tea.Cmd {
+	return func() tea.Msg {
+		size, err := saveBody(src, body, path)
+		return BodySavedMsg{Path: path, Size: size, Err: err}
+	}
+}
+
+func saveBody(src, body, path string) (int64, error) {
+	if src == "" {
+		return int64(len(body)), os.WriteFile(path, []byte(body), 0o644)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return size, err
+}
+
+// BodySavedMsg reports the result of SaveBodyCmd
+type BodySavedMsg struct {
+	Path string
+	Size int64
+	Err  error
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// timer records the phases of a request through an httptrace.ClientTrace
+type timer struct {
+	mu                sync.Mutex
+	start             time.Time
+	dnsStart, dnsDone time.Time
+	connStart         time.Time
+	connDone          time.Time
+	tlsStart, tlsDone time.Time
+	gotConn, wrote    time.Time
+	firstByte         time.Time
+	// reused is set when the request got a kept-alive connection
+	reused bool
+}
+
+func (t *timer) set(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+}
+
+func (t *timer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.set(&t.connStart) },
+		ConnectDone:          func(string, string, error) { t.set(&t.connDone) },
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotConn:              t.gotConnection,
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.set(&t.wrote) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+// timings computes the HAR phases of a request that ended at end. Phases
+// that did not happen, such as DNS on a reused connection, are zero.
+func (t *timer) timings(end time.Time) model.Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+
+	var tm model.Timings
+	tm.DNS = between(t.dnsStart, t.dnsDone)
+	tm.SSL = between(t.tlsStart, t.tlsDone)
+	connEnd := t.connDone
+	if t.tlsDone.After(connEnd) {
+		connEnd = t.tlsDone
+	}
+	tm.Connect = between(t.connStart, connEnd)
+	tm.Blocked = max(between(t.start, t.gotConn)-tm.DNS-tm.Connect, 0)
+	tm.Send = between(t.gotConn, t.wrote)
+	tm.Wait = between(t.wrote, t.firstByte)
+	tm.Receive = between(t.firstByte, end)
+	if t.firstByte.IsZero() {
+		// The request failed before any response, count the rest as waiting
+		last := t.wrote
+		if last.IsZero() {
+			last = t.gotConn
+		}
+		tm.Wait = between(last, end)
+	}
+	return tm
+}
+
+func (t *timer) gotConnection(info httptrace.GotConnInfo) {
+	t.set(&t.gotConn)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reused = info.Reused
+}
+
+// connectionReused reports whether the request was sent on a kept-alive
+// connection
+func (t *timer) connectionReused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reused
+}
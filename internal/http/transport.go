@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// PoolOptions configure the connections kept alive between requests
+type PoolOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept per host
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer
+	IdleConnTimeout time.Duration
+}
+
+// DefaultPoolOptions keep enough connections for a benchmark at a moderate
+// concurrency
+var DefaultPoolOptions = PoolOptions{MaxIdleConnsPerHost: 32, IdleConnTimeout: 90 * time.Second}
+
+// Pool shares one transport per combination of transport settings, so that
+// requests to the same host reuse their connections
+type Pool struct {
+	mu         sync.Mutex
+	options    PoolOptions
+	transports map[string]*http.Transport
+}
+
+// NewPool returns an empty pool keeping connections alive with o
+func NewPool(o PoolOptions) *Pool {
+	return &Pool{options: o, transports: make(map[string]*http.Transport)}
+}
+
+// defaultPool holds the connections of the requests sent by Send and
+// SendCmd
+var defaultPool = NewPool(DefaultPoolOptions)
+
+// Send performs a request on the connections of the pool, as Send does on
+// the shared ones
+func (p *Pool) Send(r model.Request, jar http.CookieJar) (*model.HistoryEntry, error) {
+	entry, _, err := do(p, r, jar, nil)
+	return entry, err
+}
+
+// CloseIdleConnections closes the connections the pool keeps alive
+func (p *Pool) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+// CloseIdleConnections closes the shared connections kept alive, so that
+// the next requests open new ones
+func CloseIdleConnections() {
+	defaultPool.CloseIdleConnections()
+}
+
+// transportKey identifies the transport settings of a request
+func transportKey(s *model.Settings) string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%t|%s|%s|%s", s.Insecure, s.CertFile, s.KeyFile, strings.Join(s.Resolve, ","))
+}
+
+// transport returns the transport of the pool for the settings of a
+// request, or a transport of its own that closes its connection for a
+// fresh connection
+func (p *Pool) transport(s *model.Settings) (*http.Transport, error) {
+	if s != nil && s.FreshConnection {
+		t, err := newTransport(s, p.options)
+		if err != nil {
+			return nil, err
+		}
+		t.DisableKeepAlives = true
+		return t, nil
+	}
+
+	key := transportKey(s)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.transports[key]; ok {
+		return t, nil
+	}
+	t, err := newTransport(s, p.options)
+	if err != nil {
+		return nil, err
+	}
+	p.transports[key] = t
+	return t, nil
+}
+
+// newTransport returns a transport applying the TLS and resolve settings
+func newTransport(s *model.Settings, o PoolOptions) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		t.MaxIdleConns = max(t.MaxIdleConns, o.MaxIdleConnsPerHost)
+	}
+	if o.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+	if s == nil {
+		return t, nil
+	}
+
+	if s.Insecure || s.CertFile != "" {
+		tlsConfig := &tls.Config{InsecureSkipVerify: s.Insecure}
+		if s.CertFile != "" {
+			keyFile := s.KeyFile
+			if keyFile == "" {
+				// The key may be bundled with the certificate
+				keyFile = s.CertFile
+			}
+			cert, err := tls.LoadX509KeyPair(s.CertFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("loading client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	if len(s.Resolve) > 0 {
+		pins, err := parseResolve(s.Resolve)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if pinned, ok := pins[addr]; ok {
+				addr = pinned
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,350 @@
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// methods lists the HTTP methods recognised at the start of a request line
+var methods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+	"HEAD": true, "OPTIONS": true, "TRACE": true, "CONNECT": true,
+}
+
+var (
+	variablePattern = regexp.MustCompile(`^@([A-Za-z0-9_.\-]+)\s*=\s*(.*)$`)
+	namePattern     = regexp.MustCompile(`^(?:#|//)\s*@name\s+(.+)$`)
+	versionPattern  = regexp.MustCompile(`\s+(HTTP/[0-9.]+)$`)
+)
+
+// Header is a request header
+type Header struct {
+	Key   string
+	Value string
+}
+
+// Variable is a file variable defined as @name = value
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// Environment is a named set of variables from http-client.env.json
+type Environment struct {
+	Name      string
+	Variables []Variable
+}
+
+// Request is a request of a .http file along with where it sits in the file
+type Request struct {
+	Name    string
+	Method  string
+	URL     string
+	Headers []Header
+	Body    string
+	// Line is the 1-based line of the request line
+	Line int
+
+	version      string
+	implicitGet  bool
+	start, end   int // request line and its query continuation lines
+	headersEnd   int // end of the header lines, which start at end
+	bodyStart    int // first body line, or -1 without a body
+	bodyEnd      int
+	separatorEnd int // end of the block, where a body would be added
+}
+
+// File is a parsed .http or .rest file. It keeps the original lines so that
+// edits only rewrite the parts of a request that changed.
+type File struct {
+	Variables []Variable
+	Requests  []Request
+
+	lines []string
+	crlf  bool
+}
+
+// Load reads a .http or .rest file
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Parse splits a .http file into variables and requests. Blocks that hold no
+// request line, such as a leading block of variables, yield no request.
+func Parse(data []byte) *File {
+	text := string(data)
+	f := &File{crlf: strings.Contains(text, "\r\n")}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text != "" {
+		f.lines = strings.Split(text, "\n")
+	}
+	f.parse()
+	return f
+}
+
+func (f *File) parse() {
+	f.Variables = nil
+	f.Requests = nil
+	start := 0
+	name := ""
+	for i := 0; i <= len(f.lines); i++ {
+		if i < len(f.lines) && !isSeparator(f.lines[i]) {
+			continue
+		}
+		f.parseBlock(start, i, name)
+		if i < len(f.lines) {
+			name = strings.TrimSpace(strings.TrimLeft(f.lines[i], "#"))
+		}
+		start = i + 1
+	}
+}
+
+// parseBlock parses the lines [start, end) of a block
+func (f *File) parseBlock(start, end int, name string) {
+	i := start
+	for ; i < end; i++ {
+		line := strings.TrimSpace(f.lines[i])
+		switch {
+		case line == "":
+			continue
+		case variablePattern.MatchString(line):
+			m := variablePattern.FindStringSubmatch(line)
+			f.Variables = append(f.Variables, Variable{Name: m[1], Value: strings.TrimSpace(m[2])})
+			continue
+		case namePattern.MatchString(line):
+			name = strings.TrimSpace(namePattern.FindStringSubmatch(line)[1])
+			continue
+		case isComment(line):
+			continue
+		}
+		break
+	}
+	if i >= end {
+		return
+	}
+
+	r := Request{Name: name, Line: i + 1, start: i, bodyStart: -1}
+	r.Method, r.URL, r.version, r.implicitGet = parseRequestLine(strings.TrimSpace(f.lines[i]))
+
+	// Query parameters may continue on the following lines
+	i++
+	for ; i < end; i++ {
+		line := strings.TrimSpace(f.lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		r.URL += line
+	}
+	r.end = i
+
+	for ; i < end; i++ {
+		line := strings.TrimSpace(f.lines[i])
+		if line == "" {
+			break
+		}
+		if isComment(line) {
+			continue
+		}
+		key, value, _ := strings.Cut(line, ":")
+		r.Headers = append(r.Headers, Header{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	r.headersEnd = i
+
+	// The body runs until a response handler or the end of the block, minus
+	// trailing blank lines
+	bodyEnd := end
+	for j := i; j < end; j++ {
+		if isResponseHandler(f.lines[j]) {
+			bodyEnd = j
+			break
+		}
+	}
+	for bodyEnd > i && strings.TrimSpace(f.lines[bodyEnd-1]) == "" {
+		bodyEnd--
+	}
+	r.separatorEnd = bodyEnd
+	if i < bodyEnd {
+		r.bodyStart, r.bodyEnd = i+1, bodyEnd
+		r.Body = strings.Join(f.lines[r.bodyStart:r.bodyEnd], "\n")
+	}
+	if r.Name == "" {
+		r.Name = r.Method + " " + r.URL
+	}
+	f.Requests = append(f.Requests, r)
+}
+
+// parseRequestLine splits "METHOD URL HTTP/1.1". A line without a method is a GET.
+func parseRequestLine(line string) (method, url, version string, implicitGet bool) {
+	if m := versionPattern.FindStringSubmatch(line); m != nil {
+		version = m[1]
+		line = strings.TrimSpace(strings.TrimSuffix(line, m[0]))
+	}
+	first, rest, found := strings.Cut(line, " ")
+	if found && methods[strings.ToUpper(first)] {
+		return strings.ToUpper(first), strings.TrimSpace(rest), version, false
+	}
+	return "GET", line, version, true
+}
+
+func isSeparator(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "###")
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+// isResponseHandler matches "> {% ... %}" handlers and ">> file" redirections
+func isResponseHandler(line string) bool {
+	return strings.HasPrefix(line, "> ") || strings.HasPrefix(line, ">>")
+}
+
+// Update replaces the request at idx. Only the request line, headers and body
+// that differ from the file are rewritten; comments, variables and blank
+// lines around them are kept.
+func (f *File) Update(idx int, method, url string, headers []Header, body string) error {
+	if idx < 0 || idx >= len(f.Requests) {
+		return fmt.Errorf("request %d does not exist", idx)
+	}
+	r := f.Requests[idx]
+	body = strings.TrimRight(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	// Rewrite from the bottom up so the earlier line indexes stay valid
+	if body != r.Body {
+		var bodyLines []string
+		if body != "" {
+			bodyLines = strings.Split(body, "\n")
+		}
+		switch {
+		case r.bodyStart >= 0 && body == "":
+			// Drop the blank separator line along with the body
+			f.splice(r.headersEnd, r.bodyEnd, nil)
+		case r.bodyStart >= 0:
+			f.splice(r.bodyStart, r.bodyEnd, bodyLines)
+		default:
+			f.splice(r.headersEnd, r.separatorEnd, append([]string{""}, bodyLines...))
+		}
+	}
+
+	if !sameHeaders(headers, r.Headers) {
+		var lines []string
+		for _, line := range f.lines[r.end:r.headersEnd] {
+			if isComment(strings.TrimSpace(line)) {
+				lines = append(lines, line)
+			}
+		}
+		for _, h := range headers {
+			if h.Key != "" {
+				lines = append(lines, h.Key+": "+h.Value)
+			}
+		}
+		f.splice(r.end, r.headersEnd, lines)
+	}
+
+	method = strings.ToUpper(method)
+	if method != r.Method || url != r.URL {
+		line := method + " " + url
+		if r.implicitGet && method == "GET" {
+			line = url
+		}
+		if r.version != "" {
+			line += " " + r.version
+		}
+		f.splice(r.start, r.end, []string{line})
+	}
+
+	f.parse()
+	return nil
+}
+
+// splice replaces the lines [start, end) with repl
+func (f *File) splice(start, end int, repl []string) {
+	lines := make([]string, 0, len(f.lines)-(end-start)+len(repl))
+	lines = append(lines, f.lines[:start]...)
+	lines = append(lines, repl...)
+	lines = append(lines, f.lines[end:]...)
+	f.lines = lines
+}
+
+func sameHeaders(a, b []Header) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Bytes returns the file contents, with the original line endings
+func (f *File) Bytes() []byte {
+	newline := "\n"
+	if f.crlf {
+		newline = "\r\n"
+	}
+	if len(f.lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(f.lines, newline) + newline)
+}
+
+// Save writes the file to path
+func (f *File) Save(path string) error {
+	return os.WriteFile(path, f.Bytes(), 0o644)
+}
+
+// LoadEnvironments reads http-client.env.json from dir, with the values of
+// http-client.private.env.json taking precedence. Missing files are not an error.
+func LoadEnvironments(dir string) ([]Environment, error) {
+	merged := make(map[string]map[string]interface{})
+	for _, name := range []string{"http-client.env.json", "http-client.private.env.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var envs map[string]map[string]interface{}
+		if err := json.Unmarshal(data, &envs); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for env, values := range envs {
+			if merged[env] == nil {
+				merged[env] = make(map[string]interface{})
+			}
+			for k, v := range values {
+				merged[env][k] = v
+			}
+		}
+	}
+
+	var envs []Environment
+	for name, values := range merged {
+		env := Environment{Name: name}
+		for k, v := range values {
+			value, ok := v.(string)
+			if !ok {
+				data, _ := json.Marshal(v)
+				value = string(data)
+			}
+			env.Variables = append(env.Variables, Variable{Name: k, Value: value})
+		}
+		sort.Slice(env.Variables, func(i, j int) bool { return env.Variables[i].Name < env.Variables[j].Name })
+		envs = append(envs, env)
+	}
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+	return envs, nil
+}
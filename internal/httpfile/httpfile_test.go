@@ -0,0 +1,130 @@
+package httpfile
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func load(t *testing.T) (*File, string) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/api.http")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return Parse(data), string(data)
+}
+
+func TestParse(t *testing.T) {
+	f, _ := load(t)
+
+	if len(f.Variables) != 2 || f.Variables[1].Name != "baseUrl" || f.Variables[1].Value != "https://{{host}}/v1" {
+		t.Errorf("unexpected variables %+v", f.Variables)
+	}
+	if len(f.Requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(f.Requests))
+	}
+
+	list := f.Requests[0]
+	if list.Name != "List orders" || list.Method != "GET" || list.URL != "{{baseUrl}}/orders?limit=10&sort=desc" {
+		t.Errorf("unexpected first request %+v", list)
+	}
+	if len(list.Headers) != 1 || list.Headers[0] != (Header{Key: "Accept", Value: "application/json"}) {
+		t.Errorf("unexpected headers %+v", list.Headers)
+	}
+	if list.Body != "" || list.Line != 6 {
+		t.Errorf("expected no body on line 6, got %q on line %d", list.Body, list.Line)
+	}
+
+	create := f.Requests[1]
+	if create.Name != "createOrder" || create.Method != "POST" || create.URL != "{{baseUrl}}/orders" {
+		t.Errorf("unexpected second request %+v", create)
+	}
+	if len(create.Headers) != 2 || create.Headers[1].Key != "Authorization" {
+		t.Errorf("expected comments to be skipped in headers, got %+v", create.Headers)
+	}
+	if create.Body != "{\n  \"product\": \"p-1\"\n}" {
+		t.Errorf("expected the response handler to be left out of the body, got %q", create.Body)
+	}
+
+	health := f.Requests[2]
+	if health.Method != "GET" || health.URL != "https://shop.example.com/health" {
+		t.Errorf("expected a GET without explicit method, got %+v", health)
+	}
+}
+
+func TestUpdate_Unchanged(t *testing.T) {
+	f, original := load(t)
+	for i, r := range f.Requests {
+		if err := f.Update(i, r.Method, r.URL, r.Headers, r.Body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if string(f.Bytes()) != original {
+		t.Errorf("expected an unchanged file, got:\n%s", f.Bytes())
+	}
+}
+
+func TestUpdate_RewritesOnlyChangedParts(t *testing.T) {
+	f, original := load(t)
+
+	create := f.Requests[1]
+	headers := append([]Header{}, create.Headers...)
+	headers[0].Value = "application/json; charset=utf-8"
+	if err := f.Update(1, "PUT", create.URL, headers, `{"product": "p-2"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Update(2, "GET", "https://shop.example.com/ready", nil, "ping"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := strings.NewReplacer(
+		"POST {{baseUrl}}/orders HTTP/1.1", "PUT {{baseUrl}}/orders HTTP/1.1",
+		"Content-Type: application/json\n# Token comes from the environment\n",
+		"# Token comes from the environment\nContent-Type: application/json; charset=utf-8\n",
+		"{\n  \"product\": \"p-1\"\n}", `{"product": "p-2"}`,
+		"https://shop.example.com/health\n", "https://shop.example.com/ready\n\nping\n",
+	).Replace(original)
+	if string(f.Bytes()) != expected {
+		t.Errorf("unexpected file:\n%s\nexpected:\n%s", f.Bytes(), expected)
+	}
+	if len(f.Requests) != 3 || f.Requests[2].Body != "ping" {
+		t.Errorf("expected the file to be parsed again after the update, got %+v", f.Requests)
+	}
+}
+
+func TestUpdate_RemovesBody(t *testing.T) {
+	f := Parse([]byte("POST https://example.com\r\nContent-Type: text/plain\r\n\r\nhello\r\n"))
+	if err := f.Update(0, "POST", "https://example.com", f.Requests[0].Headers, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(f.Bytes()) != "POST https://example.com\r\nContent-Type: text/plain\r\n" {
+		t.Errorf("expected the body and CRLF line endings to be preserved, got %q", f.Bytes())
+	}
+	if err := f.Update(3, "GET", "", nil, ""); err == nil {
+		t.Error("expected error for an unknown request")
+	}
+}
+
+func TestLoadEnvironments(t *testing.T) {
+	envs, err := LoadEnvironments("testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(envs) != 2 || envs[0].Name != "dev" || envs[1].Name != "prod" {
+		t.Fatalf("expected dev and prod environments, got %+v", envs)
+	}
+	want := []Variable{{"host", "localhost:8080"}, {"port", "8080"}, {"token", "dev-token"}}
+	if len(envs[0].Variables) != len(want) {
+		t.Fatalf("unexpected dev variables %+v", envs[0].Variables)
+	}
+	for i, v := range want {
+		if envs[0].Variables[i] != v {
+			t.Errorf("expected %+v, got %+v", v, envs[0].Variables[i])
+		}
+	}
+
+	if envs, err := LoadEnvironments(t.TempDir()); err != nil || len(envs) != 0 {
+		t.Errorf("expected no environments without files, got %+v, %v", envs, err)
+	}
+}
@@ -0,0 +1,41 @@
+package infer
+
+import "strings"
+
+// Generator renders an inferred type for one language or schema format
+type Generator interface {
+	// Name is the label shown in the target list
+	Name() string
+	// Extension is the file extension used when saving the output
+	Extension() string
+	// Generate returns the declarations of t, the root one named name
+	Generate(t *Type, name string) string
+}
+
+var registry []Generator
+
+// Register adds a generator to the targets offered by Generators
+func Register(g Generator) {
+	registry = append(registry, g)
+}
+
+// Generators lists the registered generators in registration order
+func Generators() []Generator {
+	return registry
+}
+
+// Lookup finds a generator by name, ignoring case
+func Lookup(name string) (Generator, bool) {
+	for _, g := range registry {
+		if strings.EqualFold(g.Name(), name) {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	Register(schemaGenerator{})
+	Register(goGenerator{})
+	Register(typescriptGenerator{})
+}
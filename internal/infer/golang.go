@@ -0,0 +1,102 @@
+package infer
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goGenerator renders Go types with json tags
+type goGenerator struct{}
+
+func (goGenerator) Name() string      { return "Go" }
+func (goGenerator) Extension() string { return "go" }
+
+func (goGenerator) Generate(t *Type, name string) string {
+	types, names := nameTypes(t, name)
+	var b strings.Builder
+	for i, n := range types {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if n.Type.Kinds&Object == 0 {
+			fmt.Fprintf(&b, "type %s %s\n", n.Name, goType(n.Type, names, false))
+			continue
+		}
+		fmt.Fprintf(&b, "type %s struct {\n", n.Name)
+		used := map[string]bool{}
+		for _, k := range n.Type.FieldNames() {
+			if k == "" {
+				// A json tag cannot name an empty key
+				continue
+			}
+			field := goFieldName(k)
+			for i := 2; used[field]; i++ {
+				field = goFieldName(k) + strconv.Itoa(i)
+			}
+			used[field] = true
+			tag := k
+			optional := n.Type.Optional(k)
+			if optional {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&b, "\t%s %s `json:%s`\n", field, goType(n.Type.Fields[k], names, optional), strconv.Quote(tag))
+		}
+		b.WriteString("}\n")
+	}
+
+	src := "package models\n\n"
+	if strings.Contains(b.String(), "time.Time") {
+		src += "import \"time\"\n\n"
+	}
+	src += b.String()
+	if out, err := format.Source([]byte(src)); err == nil {
+		return string(out)
+	}
+	return src
+}
+
+// goFieldName returns an exported identifier for a key
+func goFieldName(key string) string {
+	name := TypeName(key)
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Field" + name
+	}
+	return name
+}
+
+// goType spells a type in Go. Nullable values are pointers, and so are
+// optional structs, which omitempty would not leave out otherwise.
+func goType(t *Type, names map[*Type]string, optional bool) string {
+	var base string
+	pointer := t.Nullable()
+	switch t.NonNull() {
+	case String:
+		base = "string"
+		if t.DateTime() {
+			base, pointer = "time.Time", pointer || optional
+		}
+	case Integer:
+		base = "int64"
+	case Number:
+		base = "float64"
+	case Boolean:
+		base = "bool"
+	case Object:
+		base, pointer = names[t], pointer || optional
+	case Array:
+		if t.Items == nil || t.Items.Kinds == 0 {
+			return "[]any"
+		}
+		return "[]" + goType(t.Items, names, false)
+	default:
+		// Never seen, only null, or of several kinds
+		return "any"
+	}
+	if pointer {
+		return "*" + base
+	}
+	return base
+}
@@ -0,0 +1,260 @@
+// Package infer guesses the shape of JSON documents from samples, and renders
+// it as a JSON Schema or as typed models to paste in a client.
+package infer
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Kind is a set of JSON types seen for a value
+type Kind uint8
+
+// The JSON types, Integer standing for numbers without a fraction
+const (
+	Null Kind = 1 << iota
+	Boolean
+	Integer
+	Number
+	String
+	Array
+	Object
+)
+
+// Type is the shape of the values seen at one place of the samples
+type Type struct {
+	// Kinds are the JSON types the values had
+	Kinds Kind
+	// Fields are the members seen in objects, and Present the number of
+	// objects holding each
+	Fields  map[string]*Type
+	Present map[string]int
+	// Objects is the number of objects seen
+	Objects int
+	// Items merges the elements of every array seen
+	Items *Type
+	// Strings counts the strings seen, and DateTimes those in RFC 3339
+	Strings   int
+	DateTimes int
+}
+
+// Infer merges the samples into one type. Samples are values decoded with
+// encoding/json, numbers as float64 or json.Number.
+func Infer(samples ...any) *Type {
+	t := &Type{}
+	for _, s := range samples {
+		t.add(s)
+	}
+	return t
+}
+
+func (t *Type) add(v any) {
+	switch v := v.(type) {
+	case nil:
+		t.Kinds |= Null
+	case bool:
+		t.Kinds |= Boolean
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			t.Kinds |= Number
+		} else {
+			t.Kinds |= Integer
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			t.Kinds |= Integer
+		} else {
+			t.Kinds |= Number
+		}
+	case string:
+		t.Kinds |= String
+		t.Strings++
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			t.DateTimes++
+		}
+	case []any:
+		t.Kinds |= Array
+		if t.Items == nil {
+			t.Items = &Type{}
+		}
+		for _, item := range v {
+			t.Items.add(item)
+		}
+	case map[string]any:
+		t.Kinds |= Object
+		t.Objects++
+		if t.Fields == nil {
+			t.Fields, t.Present = map[string]*Type{}, map[string]int{}
+		}
+		for k, item := range v {
+			if t.Fields[k] == nil {
+				t.Fields[k] = &Type{}
+			}
+			t.Fields[k].add(item)
+			t.Present[k]++
+		}
+	}
+}
+
+// Nullable tells whether the values were null as well as something else
+func (t *Type) Nullable() bool {
+	return t.Kinds&Null != 0 && t.Kinds != Null
+}
+
+// Optional tells whether some objects lacked a field
+func (t *Type) Optional(field string) bool {
+	return t.Present[field] < t.Objects
+}
+
+// DateTime tells whether every string was a date and time
+func (t *Type) DateTime() bool {
+	return t.Strings > 0 && t.DateTimes == t.Strings
+}
+
+// NonNull returns the kinds seen besides null
+func (t *Type) NonNull() Kind {
+	kinds := t.Kinds &^ Null
+	// An integer is a number that happened to be whole
+	if kinds&Number != 0 {
+		kinds &^= Integer
+	}
+	return kinds
+}
+
+// FieldNames lists the fields of objects, sorted
+func (t *Type) FieldNames() []string {
+	names := make([]string, 0, len(t.Fields))
+	for k := range t.Fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// named is an object type given a name to be declared under
+type named struct {
+	Name string
+	Type *Type
+}
+
+// nameTypes names the object types reachable from t, t itself included,
+// in the order they are declared. Object types take the name of their field,
+// made singular for the elements of an array, and the name of their parent
+// first when it is taken.
+func nameTypes(t *Type, root string) ([]named, map[*Type]string) {
+	names := map[*Type]string{}
+	taken := map[string]bool{}
+	var out []named
+
+	claim := func(t *Type, name, parent string) {
+		if name == "" {
+			name = "Field"
+		}
+		if !unicode.IsLetter([]rune(name)[0]) {
+			name = parent + name
+		}
+		if taken[name] {
+			name = parent + name
+		}
+		for n := 2; taken[name]; n++ {
+			name = strings.TrimRight(name, "0123456789") + strconv.Itoa(n)
+		}
+		taken[name] = true
+		names[t] = name
+		out = append(out, named{name, t})
+	}
+
+	// The root is declared even when it is not an object, as an alias
+	claim(t, root, "")
+	for i := 0; i < len(out); i++ {
+		parent := out[i]
+		var walk func(t *Type, name string)
+		walk = func(t *Type, name string) {
+			if t.Kinds&Object != 0 && names[t] == "" {
+				claim(t, name, parent.Name)
+			}
+			if t.Kinds&Array != 0 && t.Items != nil {
+				walk(t.Items, singular(name))
+			}
+		}
+		if parent.Type.Kinds&Object != 0 {
+			for _, k := range parent.Type.FieldNames() {
+				walk(parent.Type.Fields[k], TypeName(k))
+			}
+		}
+		if parent.Type.Kinds&Array != 0 && parent.Type.Items != nil {
+			walk(parent.Type.Items, singular(parent.Name))
+		}
+	}
+	return out, names
+}
+
+// singular names an element of a list, Users giving User
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+// initialisms are kept in capitals in names, as Go spells them
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true,
+	"SSH": true, "TLS": true, "TTL": true, "UI": true, "UID": true, "URI": true, "URL": true,
+	"UTF8": true, "UUID": true, "XML": true,
+}
+
+// TypeName turns a JSON key into an exported name: user_id gives UserID
+func TypeName(key string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		// Split camelCase words, so that userId gives UserID
+		start := 0
+		runes := []rune(word)
+		for i := 1; i <= len(runes); i++ {
+			if i < len(runes) && !(unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1])) {
+				continue
+			}
+			part := string(runes[start:i])
+			if upper := strings.ToUpper(part); initialisms[upper] {
+				b.WriteString(upper)
+			} else {
+				r := []rune(part)
+				b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+			}
+			start = i
+		}
+	}
+	return b.String()
+}
+
+// RootName names the root type after the last segment of a URL path without
+// digits, which skips identifiers and versions: /v1/users/42/orders gives
+// Orders for a list, Order for an object, and Response when none is left.
+func RootName(path string, t *Type) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		name := TypeName(segments[i])
+		if name == "" || !unicode.IsLetter([]rune(name)[0]) || strings.ContainsAny(segments[i], "0123456789") {
+			continue
+		}
+		if t.Kinds&Array == 0 {
+			if s := singular(name); !strings.HasSuffix(s, "Item") {
+				return s
+			}
+		}
+		return name
+	}
+	return "Response"
+}
@@ -0,0 +1,242 @@
+package infer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func samples(t *testing.T, bodies ...string) *Type {
+	t.Helper()
+	var docs []any
+	for _, body := range bodies {
+		d := json.NewDecoder(strings.NewReader(body))
+		d.UseNumber()
+		var doc any
+		if err := d.Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, doc)
+	}
+	return Infer(docs...)
+}
+
+func TestRegistry(t *testing.T) {
+	var names []string
+	for _, g := range Generators() {
+		names = append(names, g.Name())
+	}
+	if strings.Join(names, ",") != "JSON Schema,Go,TypeScript" {
+		t.Errorf("unexpected generators %v", names)
+	}
+	if g, ok := Lookup("typescript"); !ok || g.Extension() != "ts" {
+		t.Error("expected to find TypeScript ignoring case")
+	}
+}
+
+func TestInfer(t *testing.T) {
+	typ := samples(t,
+		`{"id": 1, "score": 2, "name": "Ann", "deleted_at": null, "tags": []}`,
+		`{"id": 2, "score": 2.5, "deleted_at": "2024-05-01T10:00:00Z", "tags": ["a"]}`,
+	)
+	if typ.NonNull() != Object || typ.Objects != 2 {
+		t.Fatalf("expected two objects, got %+v", typ)
+	}
+	if typ.Optional("id") || !typ.Optional("name") {
+		t.Error("expected name to be optional and id required")
+	}
+	if k := typ.Fields["score"].NonNull(); k != Number {
+		t.Errorf("expected integers and decimals to merge as numbers, got %v", k)
+	}
+	deleted := typ.Fields["deleted_at"]
+	if !deleted.Nullable() || deleted.NonNull() != String || !deleted.DateTime() {
+		t.Errorf("expected a nullable date-time, got %+v", deleted)
+	}
+	if items := typ.Fields["tags"].Items; items == nil || items.NonNull() != String {
+		t.Errorf("expected an array of strings, got %+v", items)
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	tests := map[string]string{
+		"id":         "ID",
+		"user_id":    "UserID",
+		"userId":     "UserID",
+		"avatar-url": "AvatarURL",
+		"firstName":  "FirstName",
+		"2fa":        "2fa",
+		"$ref":       "Ref",
+	}
+	for key, expected := range tests {
+		if name := TypeName(key); name != expected {
+			t.Errorf("TypeName(%q) = %q, expected %q", key, name, expected)
+		}
+	}
+}
+
+func TestRootName(t *testing.T) {
+	list, object := samples(t, `[]`), samples(t, `{}`)
+	tests := []struct {
+		path     string
+		typ      *Type
+		expected string
+	}{
+		{"/v1/users/42/orders", list, "Orders"},
+		{"/v1/users/42/orders", object, "Order"},
+		{"/users/42", object, "User"},
+		{"/categories", list, "Categories"},
+		{"/categories/7", object, "Category"},
+		{"/v2", object, "Response"},
+		{"", list, "Response"},
+	}
+	for _, test := range tests {
+		if name := RootName(test.path, test.typ); name != test.expected {
+			t.Errorf("RootName(%q) = %q, expected %q", test.path, name, test.expected)
+		}
+	}
+}
+
+const usersBody = `[
+	{"id": 1, "email": "a@example.com", "address": {"city": "Paris"}, "roles": [{"name": "admin"}], "created_at": "2024-01-01T00:00:00Z"},
+	{"id": 2, "email": null, "address": {"city": "Lyon", "zip": "69001"}, "roles": [], "nickname": "bo"}
+]`
+
+func TestJSONSchema(t *testing.T) {
+	g, _ := Lookup("JSON Schema")
+	out := g.Generate(samples(t, usersBody), "Users")
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Users",
+  "type": "array",
+  "items": {
+    "type": "object",
+    "properties": {
+      "address": {
+        "type": "object",
+        "properties": {
+          "city": {
+            "type": "string"
+          },
+          "zip": {
+            "type": "string"
+          }
+        },
+        "required": [
+          "city"
+        ]
+      },
+      "created_at": {
+        "type": "string",
+        "format": "date-time"
+      },
+      "email": {
+        "type": [
+          "string",
+          "null"
+        ]
+      },
+      "id": {
+        "type": "integer"
+      },
+      "nickname": {
+        "type": "string"
+      },
+      "roles": {
+        "type": "array",
+        "items": {
+          "type": "object",
+          "properties": {
+            "name": {
+              "type": "string"
+            }
+          },
+          "required": [
+            "name"
+          ]
+        }
+      }
+    },
+    "required": [
+      "address",
+      "email",
+      "id",
+      "roles"
+    ]
+  }
+}
+`
+	if out != expected {
+		t.Errorf("unexpected schema:\n%s", out)
+	}
+}
+
+func TestGo(t *testing.T) {
+	g, _ := Lookup("Go")
+	out := g.Generate(samples(t, usersBody), "Users")
+	expected := "package models\n\nimport \"time\"\n\n" + `type Users []User
+
+type User struct {
+	Address   Address    ` + "`json:\"address\"`" + `
+	CreatedAt *time.Time ` + "`json:\"created_at,omitempty\"`" + `
+	Email     *string    ` + "`json:\"email\"`" + `
+	ID        int64      ` + "`json:\"id\"`" + `
+	Nickname  string     ` + "`json:\"nickname,omitempty\"`" + `
+	Roles     []Role     ` + "`json:\"roles\"`" + `
+}
+
+type Address struct {
+	City string ` + "`json:\"city\"`" + `
+	Zip  string ` + "`json:\"zip,omitempty\"`" + `
+}
+
+type Role struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`
+	if out != expected {
+		t.Errorf("unexpected Go:\n%s", out)
+	}
+
+	// Keys that spell the same name are told apart
+	out = g.Generate(samples(t, `{"user_id": 1, "userId": 2, "2fa": true}`), "Response")
+	for _, field := range []string{"Field2fa bool", "UserID   int64", "UserID2  int64"} {
+		if !strings.Contains(out, field) {
+			t.Errorf("expected %q in:\n%s", field, out)
+		}
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	g, _ := Lookup("TypeScript")
+	out := g.Generate(samples(t, usersBody), "Users")
+	expected := `export type Users = User[];
+
+export interface User {
+  address: Address;
+  created_at?: string;
+  email: string | null;
+  id: number;
+  nickname?: string;
+  roles: Role[];
+}
+
+export interface Address {
+  city: string;
+  zip?: string;
+}
+
+export interface Role {
+  name: string;
+}
+`
+	if out != expected {
+		t.Errorf("unexpected TypeScript:\n%s", out)
+	}
+
+	out = g.Generate(samples(t, `{"content-type": "a", "values": [1, "b", null], "empty": []}`), "Response")
+	for _, field := range []string{`"content-type": string;`, "values: (string | number | null)[];", "empty: unknown[];"} {
+		if !strings.Contains(out, field) {
+			t.Errorf("expected %q in:\n%s", field, out)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package infer
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// SchemaDialect is the JSON Schema draft the schemas are written in
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaGenerator renders a JSON Schema draft 2020-12
+type schemaGenerator struct{}
+
+func (schemaGenerator) Name() string      { return "JSON Schema" }
+func (schemaGenerator) Extension() string { return "schema.json" }
+
+// schema holds the keywords written, in the order they read best
+type schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       any                `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *schema            `json:"items,omitempty"`
+}
+
+func (schemaGenerator) Generate(t *Type, name string) string {
+	s := toSchema(t)
+	s.Schema, s.Title = SchemaDialect, name
+
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(s); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// toSchema describes a type. Values never seen, such as the elements of
+// arrays that were always empty, accept anything.
+func toSchema(t *Type) *schema {
+	s := &schema{}
+	var types []string
+	for _, k := range []struct {
+		kind Kind
+		name string
+	}{{Object, "object"}, {Array, "array"}, {String, "string"}, {Integer, "integer"}, {Number, "number"}, {Boolean, "boolean"}, {Null, "null"}} {
+		if (t.NonNull()|t.Kinds&Null)&k.kind != 0 {
+			types = append(types, k.name)
+		}
+	}
+	switch len(types) {
+	case 0:
+	case 1:
+		s.Type = types[0]
+	default:
+		s.Type = types
+	}
+
+	if t.Kinds&String != 0 && t.DateTime() {
+		s.Format = "date-time"
+	}
+	if t.Kinds&Object != 0 {
+		s.Properties = map[string]*schema{}
+		for _, k := range t.FieldNames() {
+			s.Properties[k] = toSchema(t.Fields[k])
+			if !t.Optional(k) {
+				s.Required = append(s.Required, k)
+			}
+		}
+	}
+	if t.Kinds&Array != 0 && t.Items != nil && t.Items.Kinds != 0 {
+		s.Items = toSchema(t.Items)
+	}
+	return s
+}
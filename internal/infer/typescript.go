@@ -0,0 +1,79 @@
+package infer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// typescriptGenerator renders TypeScript interfaces
+type typescriptGenerator struct{}
+
+func (typescriptGenerator) Name() string      { return "TypeScript" }
+func (typescriptGenerator) Extension() string { return "ts" }
+
+// tsIdentifier matches the keys that need no quotes
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func (typescriptGenerator) Generate(t *Type, name string) string {
+	types, names := nameTypes(t, name)
+	var b strings.Builder
+	for i, n := range types {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if n.Type.Kinds&Object == 0 {
+			fmt.Fprintf(&b, "export type %s = %s;\n", n.Name, tsType(n.Type, names))
+			continue
+		}
+		fmt.Fprintf(&b, "export interface %s {\n", n.Name)
+		for _, k := range n.Type.FieldNames() {
+			key := k
+			if !tsIdentifier.MatchString(k) {
+				key = strconv.Quote(k)
+			}
+			if n.Type.Optional(k) {
+				key += "?"
+			}
+			fmt.Fprintf(&b, "  %s: %s;\n", key, tsType(n.Type.Fields[k], names))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// tsType spells a type in TypeScript, several kinds as a union
+func tsType(t *Type, names map[*Type]string) string {
+	var union []string
+	kinds := t.NonNull()
+	if kinds&Object != 0 {
+		union = append(union, names[t])
+	}
+	if kinds&Array != 0 {
+		item := "unknown"
+		if t.Items != nil && t.Items.Kinds != 0 {
+			item = tsType(t.Items, names)
+		}
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		union = append(union, item+"[]")
+	}
+	if kinds&String != 0 {
+		union = append(union, "string")
+	}
+	if kinds&(Integer|Number) != 0 {
+		union = append(union, "number")
+	}
+	if kinds&Boolean != 0 {
+		union = append(union, "boolean")
+	}
+	if t.Kinds&Null != 0 {
+		union = append(union, "null")
+	}
+	if len(union) == 0 {
+		return "unknown"
+	}
+	return strings.Join(union, " | ")
+}
@@ -0,0 +1,331 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/vars"
+)
+
+// exportFile is an Insomnia v4 export
+type exportFile struct {
+	Type      string     `json:"_type"`
+	Format    int        `json:"__export_format"`
+	Resources []resource `json:"resources"`
+}
+
+// resource is any exported object; which fields are set depends on Type
+type resource struct {
+	ID          string                 `json:"_id"`
+	Type        string                 `json:"_type"`
+	ParentID    string                 `json:"parentId"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	SortKey     float64                `json:"metaSortKey"`
+	Method      string                 `json:"method"`
+	URL         string                 `json:"url"`
+	Headers     []pair                 `json:"headers"`
+	Parameters  []pair                 `json:"parameters"`
+	Body        body                   `json:"body"`
+	Auth        map[string]interface{} `json:"authentication"`
+	Data        map[string]interface{} `json:"data"`
+	Environment map[string]interface{} `json:"environment"`
+	PreRequest  string                 `json:"preRequestScript"`
+	AfterResp   string                 `json:"afterResponseScript"`
+}
+
+type pair struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Type     string `json:"type"`
+}
+
+type body struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Params   []pair `json:"params"`
+	FileName string `json:"fileName"`
+}
+
+var (
+	// variablePattern matches {{ _.name }} and {{ name }} references
+	variablePattern = regexp.MustCompile(`\{\{\s*(?:_\.)?([A-Za-z0-9_.\-\[\]']+)\s*\}\}`)
+	// tagPattern matches template tags such as {% response ... %}
+	tagPattern = regexp.MustCompile(`\{%.*?%\}`)
+)
+
+// Load reads an Insomnia v4 export. The returned warnings describe what
+// could not be converted.
+func Load(path string) (*model.Collection, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Parse(data)
+}
+
+// Parse converts an Insomnia v4 export
+func Parse(data []byte) (*model.Collection, []string, error) {
+	var f exportFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("invalid Insomnia export: %w", err)
+	}
+	if f.Type != "export" || f.Format == 0 {
+		return nil, nil, fmt.Errorf("not an Insomnia export: missing _type or __export_format")
+	}
+	if f.Format != 4 {
+		return nil, nil, fmt.Errorf("unsupported Insomnia export format %d", f.Format)
+	}
+
+	cv := &converter{children: make(map[string][]resource)}
+	var workspaces []resource
+	for _, r := range f.Resources {
+		if r.Type == "workspace" {
+			workspaces = append(workspaces, r)
+		}
+		cv.children[r.ParentID] = append(cv.children[r.ParentID], r)
+	}
+	for id := range cv.children {
+		sort.SliceStable(cv.children[id], func(i, j int) bool {
+			return cv.children[id][i].SortKey < cv.children[id][j].SortKey
+		})
+	}
+	if len(workspaces) == 0 {
+		return nil, nil, fmt.Errorf("Insomnia export has no workspace")
+	}
+	for _, w := range workspaces[1:] {
+		cv.warn("workspace %q was skipped, only the first workspace is imported", w.Name)
+	}
+
+	ws := workspaces[0]
+	c := &model.Collection{Name: ws.Name, Description: ws.Description}
+	cv.environments(c, ws.ID)
+	c.Folders, c.Requests = cv.items(nil, ws.ID, nil)
+	return c, cv.warnings, nil
+}
+
+// converter walks the resource tree and collects warnings
+type converter struct {
+	children map[string][]resource
+	warnings []string
+}
+
+func (cv *converter) warn(format string, args ...interface{}) {
+	cv.warnings = append(cv.warnings, fmt.Sprintf(format, args...))
+}
+
+// environments converts the base environment of a workspace into collection
+// variables and its sub-environments into environments
+func (cv *converter) environments(c *model.Collection, workspaceID string) {
+	for _, base := range cv.children[workspaceID] {
+		if base.Type != "environment" {
+			continue
+		}
+		c.Variables = append(c.Variables, flatten("", base.Data)...)
+		for _, sub := range cv.children[base.ID] {
+			if sub.Type == "environment" {
+				c.Environments = append(c.Environments, model.Environment{Name: sub.Name, Variables: flatten("", sub.Data)})
+			}
+		}
+	}
+}
+
+// items converts the request groups and requests under parentID. Requests
+// without their own auth inherit the auth of their closest folder.
+func (cv *converter) items(path []string, parentID string, inherited *model.Auth) ([]model.Folder, []model.SavedRequest) {
+	var folders []model.Folder
+	var requests []model.SavedRequest
+	for _, r := range cv.children[parentID] {
+		itemPath := append(append([]string{}, path...), r.Name)
+		name := strings.Join(itemPath, " › ")
+
+		switch r.Type {
+		case "request_group":
+			if len(r.Environment) > 0 {
+				cv.warn("%s: folder environment was not converted", name)
+			}
+			folderAuth := cv.auth(name, r.Auth, inherited)
+			sub, reqs := cv.items(itemPath, r.ID, folderAuth)
+			folders = append(folders, model.Folder{Name: r.Name, Folders: sub, Requests: reqs})
+		case "request":
+			requests = append(requests, cv.request(name, r, inherited))
+		case "grpc_request":
+			cv.warn("%s: gRPC request is not supported", name)
+		case "websocket_request":
+			cv.warn("%s: WebSocket request is not supported", name)
+		}
+	}
+	return folders, requests
+}
+
+func (cv *converter) request(path string, r resource, inherited *model.Auth) model.SavedRequest {
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = "GET"
+	}
+	saved := model.SavedRequest{Name: r.Name, Request: model.Request{Method: method, URL: cv.text(path, r.URL)}}
+
+	var query []string
+	for _, p := range r.Parameters {
+		if !p.Disabled {
+			query = append(query, vars.QueryEscape(cv.text(path, p.Name))+"="+vars.QueryEscape(cv.text(path, p.Value)))
+		}
+	}
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(saved.URL, "?") {
+			sep = "&"
+		}
+		saved.URL += sep + strings.Join(query, "&")
+	}
+
+	for _, h := range r.Headers {
+		if !h.Disabled {
+			saved.Headers = append(saved.Headers, model.HeaderPair{Key: cv.text(path, h.Name), Value: cv.text(path, h.Value)})
+		}
+	}
+	cv.body(path, &saved.Request, r.Body)
+	saved.Auth = cv.auth(path, r.Auth, inherited)
+
+	if strings.TrimSpace(r.PreRequest) != "" {
+		cv.warn("%s: pre-request script was not converted", path)
+	}
+	if strings.TrimSpace(r.AfterResp) != "" {
+		cv.warn("%s: after-response script was not converted", path)
+	}
+	return saved
+}
+
+func (cv *converter) body(path string, r *model.Request, b body) {
+	switch {
+	case b.FileName != "":
+		cv.warn("%s: file body is not supported", path)
+	case b.MimeType == "application/x-www-form-urlencoded":
+		var pairs []string
+		for _, p := range b.Params {
+			if !p.Disabled {
+				pairs = append(pairs, vars.QueryEscape(cv.text(path, p.Name))+"="+vars.QueryEscape(cv.text(path, p.Value)))
+			}
+		}
+		r.Body = strings.Join(pairs, "&")
+		r.Headers = withContentType(r.Headers, b.MimeType)
+	case b.MimeType == "multipart/form-data":
+		cv.warn("%s: multipart/form-data body is not supported", path)
+	case b.MimeType == "application/graphql":
+		// The text already holds the {"query", "variables"} envelope
+		r.Body = cv.text(path, b.Text)
+		r.Headers = withContentType(r.Headers, "application/json")
+	default:
+		r.Body = cv.text(path, b.Text)
+		if b.MimeType != "" && r.Body != "" {
+			r.Headers = withContentType(r.Headers, b.MimeType)
+		}
+	}
+}
+
+// auth converts an authentication block. An empty block or the "inherit"
+// type keeps the inherited auth, "none" removes it.
+func (cv *converter) auth(path string, a map[string]interface{}, inherited *model.Auth) *model.Auth {
+	typ := str(a["type"])
+	if typ == "" || typ == "inherit" {
+		return inherited
+	}
+	if disabled, _ := a["disabled"].(bool); disabled || typ == "none" {
+		return nil
+	}
+	field := func(key string) string {
+		return cv.text(path, str(a[key]))
+	}
+	switch typ {
+	case "basic":
+		return &model.Auth{Type: model.AuthBasic, Username: field("username"), Password: field("password")}
+	case "bearer":
+		if prefix := str(a["prefix"]); prefix != "" && !strings.EqualFold(prefix, "Bearer") {
+			cv.warn("%s: bearer prefix %q was replaced by Bearer", path, prefix)
+		}
+		return &model.Auth{Type: model.AuthBearer, Token: field("token")}
+	case "apikey":
+		switch str(a["addTo"]) {
+		case "queryParams":
+			return &model.Auth{Type: model.AuthAPIKey, Key: field("key"), Value: field("value"), In: "query"}
+		case "cookie":
+			cv.warn("%s: API key sent as a cookie is not supported", path)
+			return nil
+		}
+		return &model.Auth{Type: model.AuthAPIKey, Key: field("key"), Value: field("value"), In: "header"}
+	case "oauth2":
+		if token := field("accessToken"); token != "" {
+			cv.warn("%s: OAuth 2.0 converted to a bearer token, the token will not be refreshed", path)
+			return &model.Auth{Type: model.AuthBearer, Token: token}
+		}
+	}
+	cv.warn("%s: %s auth is not supported", path, typ)
+	return nil
+}
+
+// text rewrites Insomnia variable references to {{name}} and reports
+// template tags, which are kept as is
+func (cv *converter) text(path, s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	if tag := tagPattern.FindString(s); tag != "" {
+		cv.warn("%s: template tag %s was not converted", path, tag)
+	}
+	return variablePattern.ReplaceAllString(s, "{{$1}}")
+}
+
+// flatten turns nested environment data into variables named with dotted
+// paths, matching how {{ _.a.b }} references them
+func flatten(prefix string, data map[string]interface{}) []model.Variable {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []model.Variable
+	for _, k := range keys {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		if nested, ok := data[k].(map[string]interface{}); ok {
+			out = append(out, flatten(name, nested)...)
+			continue
+		}
+		value := variablePattern.ReplaceAllString(str(data[k]), "{{$1}}")
+		out = append(out, model.Variable{Key: name, Value: value})
+	}
+	return out
+}
+
+// str returns a JSON value as text
+func str(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64, bool:
+		return fmt.Sprint(t)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// withContentType adds a Content-Type header unless one is already set
+func withContentType(headers []model.HeaderPair, contentType string) []model.HeaderPair {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			return headers
+		}
+	}
+	return append(headers, model.HeaderPair{Key: "Content-Type", Value: contentType})
+}
@@ -0,0 +1,96 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func findRequest(t *testing.T, c *model.Collection, name string) *model.SavedRequest {
+	t.Helper()
+	for _, e := range c.Entries() {
+		if e.Request.Name == name {
+			return e.Request
+		}
+	}
+	t.Fatalf("request %q not found", name)
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	c, warnings, err := Load("testdata/shop.insomnia.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.Name != "Shop API" || c.Description != "Orders and products" {
+		t.Errorf("unexpected name/description %q/%q", c.Name, c.Description)
+	}
+	vars := c.VariableMap()
+	if vars["baseUrl"] != "https://shop.example.com" || vars["product.id"] != "p-1" || vars["port"] != "8080" {
+		t.Errorf("unexpected base environment variables %v", vars)
+	}
+	if len(c.Environments) != 1 || c.Environments[0].Name != "Staging" {
+		t.Fatalf("expected a Staging environment, got %+v", c.Environments)
+	}
+	if staging := c.EnvironmentVariableMap(0); staging["baseUrl"] != "https://{{host}}" {
+		t.Errorf("expected staging baseUrl to reference host, got %q", staging["baseUrl"])
+	}
+
+	if len(c.Folders) != 1 || c.Folders[0].Name != "Orders" || len(c.Folders[0].Requests) != 2 {
+		t.Fatalf("expected an Orders folder with 2 requests, got %+v", c.Folders)
+	}
+	if c.Folders[0].Requests[0].Name != "List orders" {
+		t.Errorf("expected requests sorted by metaSortKey, got %s first", c.Folders[0].Requests[0].Name)
+	}
+	if len(c.Requests) != 2 || c.Requests[0].Name != "Login" {
+		t.Errorf("expected Login and Upload at the top level, got %+v", c.Requests)
+	}
+
+	list := findRequest(t, c, "List orders")
+	if list.URL != "{{baseUrl}}/orders?limit=10" {
+		t.Errorf("unexpected URL %q", list.URL)
+	}
+	if list.Auth == nil || list.Auth.Type != model.AuthAPIKey || list.Auth.Key != "X-API-Key" || list.Auth.Value != "{{apiKey}}" {
+		t.Errorf("expected API key auth inherited from the folder, got %+v", list.Auth)
+	}
+
+	create := findRequest(t, c, "Create order")
+	if create.Body != `{"product": "{{product.id}}"}` {
+		t.Errorf("unexpected body %q", create.Body)
+	}
+	if len(create.Headers) != 1 || create.Headers[0].Value != "application/json" {
+		t.Errorf("expected content type from the body mime type, got %v", create.Headers)
+	}
+
+	login := findRequest(t, c, "Login")
+	if login.Method != "POST" || login.Body != "user={{user}}&scope=read+write" {
+		t.Errorf("unexpected login request %+v", login.Request)
+	}
+	if login.Auth == nil || login.Auth.Type != model.AuthBasic || login.Auth.Username != "{{user}}" {
+		t.Errorf("unexpected login auth %+v", login.Auth)
+	}
+
+	want := []string{
+		"Orders › Create order: template tag {% response 'body', 'req_login', 'b64::JC50b2tlbg==::46b', 'never', 60 %} was not converted",
+		"Upload: multipart/form-data body is not supported",
+		"Upload: ntlm auth is not supported",
+		"Stock stream: gRPC request is not supported",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, _, err := Parse([]byte(`{"info": {}}`)); err == nil {
+		t.Error("expected error for a document that is not an export")
+	}
+	if _, _, err := Parse([]byte(`{"_type": "export", "__export_format": 3, "resources": []}`)); err == nil {
+		t.Error("expected error for an older export format")
+	}
+	if _, _, err := Parse([]byte(`{"_type": "export", "__export_format": 4, "resources": []}`)); err == nil {
+		t.Error("expected error for an export without workspace")
+	}
+}
@@ -3,6 +3,7 @@ package json
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -10,18 +11,28 @@ import (
 
 // TryPrettyJSON tries to pretty-print JSON, falls back to string if not JSON
 func TryPrettyJSON(data []byte) string {
+	pretty, ok := PrettyJSON(data)
+	if ok {
+		return ColorizeJSON(pretty)
+	}
+	return pretty
+}
+
+// PrettyJSON indents JSON without colors, for callers that colorize it line
+// by line. It tells whether data was JSON, and returns it unchanged if not.
+func PrettyJSON(data []byte) (string, bool) {
 	trim := bytes.TrimSpace(data)
 	if len(trim) == 0 {
-		return ""
+		return "", false
 	}
 	if trim[0] == '{' || trim[0] == '[' {
 		var out bytes.Buffer
 		err := indent(&out, trim)
 		if err == nil {
-			return ColorizeJSON(out.String())
+			return out.String(), true
 		}
 	}
-	return string(data)
+	return string(data), false
 }
 
 // indent indents JSON for pretty printing
@@ -42,9 +53,12 @@ func indent(out *bytes.Buffer, data []byte) error {
 			return marshalIndent(arr, out)
 		}
 	}
-	return nil
+	return errNotJSON
 }
 
+// errNotJSON reports data that is not a JSON object or array
+var errNotJSON = errors.New("not a JSON object or array")
+
 func marshalIndent(v interface{}, out *bytes.Buffer) error {
 	b, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
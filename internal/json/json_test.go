@@ -66,6 +66,11 @@ func TestTryPrettyJSON(t *testing.T) {
 			input:    []byte("Not JSON"),
 			wantJSON: false,
 		},
+		{
+			name:     "Truncated JSON object",
+			input:    []byte(`{"items": [{"id": 1}, {"i`),
+			wantJSON: false,
+		},
 		{
 			name:     "Empty input",
 			input:    []byte(""),
@@ -80,11 +85,13 @@ func TestTryPrettyJSON(t *testing.T) {
 				if result == string(tt.input) && len(result) > 0 {
 					t.Error("expected JSON to be pretty-printed")
 				}
-			} else {
-				if result == "" && len(tt.input) > 0 {
-					// Empty result for empty input is ok
-					return
-				}
+			} else if result != string(tt.input) {
+				t.Errorf("expected the input unchanged, got %q", result)
+			}
+
+			pretty, ok := PrettyJSON(tt.input)
+			if ok != tt.wantJSON || strings.Contains(pretty, "\x1b") {
+				t.Errorf("expected PrettyJSON to report %t without colors, got %t and %q", tt.wantJSON, ok, pretty)
 			}
 		})
 	}
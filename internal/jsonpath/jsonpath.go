@@ -0,0 +1,607 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression. It supports the usual subset:
+// $, .name, ['name'], [0], [-1], [1:3], [*], .*, ..name, unions such as
+// [0,2] and filters comparing a member to a literal, e.g.
+// [?(@.price < 10)] or [?(@.tags)].
+type Path struct {
+	expr  string
+	steps []step
+}
+
+type stepKind int
+
+const (
+	stepChild stepKind = iota
+	stepWildcard
+	stepIndex
+	stepSlice
+	stepFilter
+)
+
+type step struct {
+	kind stepKind
+	// descend applies the step to the node and all its descendants (..)
+	descend bool
+	names   []string
+	indexes []int
+	// start and end bound slices, nil when omitted
+	start, end *int
+	filter     *filter
+}
+
+// filter keeps the elements whose member at path compares to value with op.
+// An empty op only checks that the member exists.
+type filter struct {
+	path  Path
+	op    string
+	value any
+}
+
+// Parse compiles a JSONPath expression. The leading $ may be omitted, so
+// "data.token" is read as "$.data.token".
+func Parse(expr string) (Path, error) {
+	p := &parser{src: strings.TrimSpace(expr)}
+	steps, err := p.parse()
+	if err != nil {
+		return Path{}, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+	}
+	return Path{expr: expr, steps: steps}, nil
+}
+
+// String returns the expression the path was compiled from
+func (p Path) String() string {
+	return p.expr
+}
+
+// Query returns the values matched by the path in doc, a value decoded
+// with encoding/json
+func (p Path) Query(doc any) []any {
+	nodes := []any{doc}
+	for _, s := range p.steps {
+		var next []any
+		for _, n := range nodes {
+			if s.descend {
+				for _, d := range descendants(n) {
+					next = append(next, s.apply(d)...)
+				}
+			} else {
+				next = append(next, s.apply(n)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// Query compiles expr and runs it against doc
+func Query(doc any, expr string) ([]any, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(doc), nil
+}
+
+// Decode reads a JSON document, keeping numbers as json.Number so large
+// integers are not rounded
+func Decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Format renders a matched value: strings as is, anything else as JSON
+func Format(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Child appends a member to a path, quoting names that are not identifiers
+func Child(path, key string) string {
+	identifier := key != ""
+	for i, r := range key {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			identifier = false
+		}
+	}
+	if identifier {
+		return path + "." + key
+	}
+	return path + "['" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(key) + "']"
+}
+
+// descendants returns n followed by every value nested in it, depth first
+func descendants(n any) []any {
+	out := []any{n}
+	switch v := n.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(v) {
+			out = append(out, descendants(v[k])...)
+		}
+	case []any:
+		for _, e := range v {
+			out = append(out, descendants(e)...)
+		}
+	}
+	return out
+}
+
+// sortedKeys keeps wildcard results stable, as Go maps have no order
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s step) apply(n any) []any {
+	switch s.kind {
+	case stepChild:
+		obj, ok := n.(map[string]any)
+		if !ok {
+			return nil
+		}
+		var out []any
+		for _, name := range s.names {
+			if v, ok := obj[name]; ok {
+				out = append(out, v)
+			}
+		}
+		return out
+
+	case stepWildcard:
+		switch v := n.(type) {
+		case map[string]any:
+			out := make([]any, 0, len(v))
+			for _, k := range sortedKeys(v) {
+				out = append(out, v[k])
+			}
+			return out
+		case []any:
+			return v
+		}
+		return nil
+
+	case stepIndex:
+		arr, ok := n.([]any)
+		if !ok {
+			return nil
+		}
+		var out []any
+		for _, i := range s.indexes {
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				out = append(out, arr[i])
+			}
+		}
+		return out
+
+	case stepSlice:
+		arr, ok := n.([]any)
+		if !ok {
+			return nil
+		}
+		bound := func(b *int, def int) int {
+			if b == nil {
+				return def
+			}
+			i := *b
+			if i < 0 {
+				i += len(arr)
+			}
+			return min(max(i, 0), len(arr))
+		}
+		start, end := bound(s.start, 0), bound(s.end, len(arr))
+		if start >= end {
+			return nil
+		}
+		return arr[start:end]
+
+	case stepFilter:
+		var candidates []any
+		switch v := n.(type) {
+		case []any:
+			candidates = v
+		case map[string]any:
+			for _, k := range sortedKeys(v) {
+				candidates = append(candidates, v[k])
+			}
+		}
+		var out []any
+		for _, c := range candidates {
+			if s.filter.match(c) {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (f *filter) match(n any) bool {
+	values := f.path.Query(n)
+	if f.op == "" {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		if compare(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies op to a and b. Numbers compare numerically, strings
+// lexically, other values only for equality.
+func compare(a any, op string, b any) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch op {
+			case "==":
+				return x == y
+			case "!=":
+				return x != y
+			case "<":
+				return x < y
+			case "<=":
+				return x <= y
+			case ">":
+				return x > y
+			case ">=":
+				return x >= y
+			}
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			switch op {
+			case "==":
+				return x == y
+			case "!=":
+				return x != y
+			case "<":
+				return x < y
+			case "<=":
+				return x <= y
+			case ">":
+				return x > y
+			case ">=":
+				return x >= y
+			}
+		}
+	}
+	equal := Format(a) == Format(b)
+	switch op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	}
+	return false
+}
+
+// number converts JSON numbers to float64
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// parser reads a JSONPath expression
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// parse reads the steps of a path starting with $ or @, or of a bare
+// member path
+func (p *parser) parse() ([]step, error) {
+	if c := p.peek(); c == '$' || c == '@' {
+		p.pos++
+	} else if c != '.' && c != '[' && c != 0 {
+		// A bare path such as data.token
+		p.src = p.src[:p.pos] + "." + p.src[p.pos:]
+	}
+	return p.steps(func() bool { return p.pos >= len(p.src) })
+}
+
+// steps reads steps until done reports true
+func (p *parser) steps(done func() bool) ([]step, error) {
+	var steps []step
+	for !done() {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			descend := false
+			if p.peek() == '.' {
+				p.pos++
+				descend = true
+			}
+			if p.peek() == '[' {
+				if !descend {
+					return nil, p.errorf("unexpected [ after .")
+				}
+				s, err := p.bracket()
+				if err != nil {
+					return nil, err
+				}
+				s.descend = true
+				steps = append(steps, s)
+				continue
+			}
+			if p.peek() == '*' {
+				p.pos++
+				steps = append(steps, step{kind: stepWildcard, descend: descend})
+				continue
+			}
+			name := p.name()
+			if name == "" {
+				return nil, p.errorf("expected a member name")
+			}
+			steps = append(steps, step{kind: stepChild, descend: descend, names: []string{name}})
+		case '[':
+			s, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		default:
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+	return steps, nil
+}
+
+// name reads a dotted member name
+func (p *parser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '.' || c == '[' || c == ']' || c == ' ' || c == ')' || c == '=' || c == '!' || c == '<' || c == '>' {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// bracket reads a [...] selector
+func (p *parser) bracket() (step, error) {
+	p.pos++ // [
+	p.skipSpaces()
+	var s step
+
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		s = step{kind: stepWildcard}
+	case c == '?':
+		f, err := p.filter()
+		if err != nil {
+			return s, err
+		}
+		s = step{kind: stepFilter, filter: f}
+	case c == '\'' || c == '"':
+		s.kind = stepChild
+		for {
+			name, err := p.quoted()
+			if err != nil {
+				return s, err
+			}
+			s.names = append(s.names, name)
+			p.skipSpaces()
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+			p.skipSpaces()
+		}
+	default:
+		var err error
+		if s, err = p.indexes(); err != nil {
+			return s, err
+		}
+	}
+
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return s, p.errorf("expected ]")
+	}
+	p.pos++
+	return s, nil
+}
+
+// indexes reads [0], [0,2], [-1] and [1:3]
+func (p *parser) indexes() (step, error) {
+	var first *int
+	if p.peek() != ':' {
+		i, err := p.integer()
+		if err != nil {
+			return step{}, err
+		}
+		first = &i
+	}
+	p.skipSpaces()
+	if p.peek() == ':' {
+		p.pos++
+		s := step{kind: stepSlice, start: first}
+		p.skipSpaces()
+		if c := p.peek(); c != ']' && c != ':' {
+			end, err := p.integer()
+			if err != nil {
+				return s, err
+			}
+			s.end = &end
+		}
+		return s, nil
+	}
+	s := step{kind: stepIndex, indexes: []int{*first}}
+	for p.peek() == ',' {
+		p.pos++
+		p.skipSpaces()
+		i, err := p.integer()
+		if err != nil {
+			return s, err
+		}
+		s.indexes = append(s.indexes, i)
+		p.skipSpaces()
+	}
+	return s, nil
+}
+
+func (p *parser) integer() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	i, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("expected an index, a quoted name, * or a filter")
+	}
+	return i, nil
+}
+
+// quoted reads a single or double quoted string
+func (p *parser) quoted() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && p.pos < len(p.src):
+			b.WriteByte(p.src[p.pos])
+			p.pos++
+		case c == quote:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// filter reads ?(@.member op literal), with or without parentheses
+func (p *parser) filter() (*filter, error) {
+	p.pos++ // ?
+	p.skipSpaces()
+	paren := p.peek() == '('
+	if paren {
+		p.pos++
+		p.skipSpaces()
+	}
+	if p.peek() != '@' {
+		return nil, p.errorf("filters start with @")
+	}
+	p.pos++
+	steps, err := p.steps(func() bool {
+		c := p.peek()
+		return c == 0 || c == ' ' || c == ')' || c == ']' || strings.IndexByte("=!<>", c) >= 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	f := &filter{path: Path{steps: steps}}
+
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			f.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if f.op != "" {
+		p.skipSpaces()
+		if f.value, err = p.literal(); err != nil {
+			return nil, err
+		}
+	}
+
+	p.skipSpaces()
+	if paren {
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+	}
+	return f, nil
+}
+
+// literal reads a string, number, true, false or null
+func (p *parser) literal() (any, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		return p.quoted()
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		if _, err := strconv.ParseFloat(p.src[start:p.pos], 64); err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return json.Number(p.src[start:p.pos]), nil
+	}
+	for word, v := range map[string]any{"true": true, "false": false, "null": nil} {
+		if strings.HasPrefix(p.src[p.pos:], word) {
+			p.pos += len(word)
+			return v, nil
+		}
+	}
+	return nil, p.errorf("expected a literal")
+}
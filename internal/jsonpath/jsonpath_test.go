@@ -0,0 +1,92 @@
+package jsonpath
+
+import (
+	"strings"
+	"testing"
+)
+
+const store = `{
+  "store": {
+    "book": [
+      {"title": "Sayings", "author": "Rees", "price": 8.95, "tags": ["quotes"]},
+      {"title": "Sword", "author": "Waugh", "price": 12.99},
+      {"title": "Moby Dick", "author": "Melville", "price": 8.99, "isbn": "0-553-21311-3"},
+      {"title": "Rings", "author": "Tolkien", "price": 22.99, "isbn": "0-395-19395-8"}
+    ],
+    "bicycle": {"color": "red", "price": 19.95}
+  },
+  "id": 12345678901234567890,
+  "user.name": "ada"
+}`
+
+func TestQuery(t *testing.T) {
+	doc, err := Decode([]byte(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$.store.bicycle.color", "red"},
+		{"store.bicycle.color", "red"},
+		{"$['store']['bicycle']['price']", "19.95"},
+		{`$["user.name"]`, "ada"},
+		{"$.id", "12345678901234567890"},
+		{"$.store.book[0].title", "Sayings"},
+		{"$.store.book[-1].title", "Rings"},
+		{"$.store.book[0,2].author", "Rees|Melville"},
+		{"$.store.book[1:3].author", "Waugh|Melville"},
+		{"$.store.book[:1].author", "Rees"},
+		{"$.store.book[-2:].author", "Melville|Tolkien"},
+		{"$.store.book[*].price", "8.95|12.99|8.99|22.99"},
+		{"$.store.bicycle.*", "red|19.95"},
+		{"$..author", "Rees|Waugh|Melville|Tolkien"},
+		{"$..book[1].title", "Sword"},
+		{"$.store.book[?(@.price < 10)].title", "Sayings|Moby Dick"},
+		{"$.store.book[?(@.author == 'Tolkien')].price", "22.99"},
+		{"$.store.book[?@.isbn].title", "Moby Dick|Rings"},
+		{"$.store.book[?(@.tags[0] == \"quotes\")].title", "Sayings"},
+		{"$.store.book[0]", `{"author":"Rees","price":8.95,"tags":["quotes"],"title":"Sayings"}`},
+		{"$.store.missing", ""},
+		{"$.store.book[10]", ""},
+		{"$", ""},
+	}
+	for _, tt := range tests {
+		values, err := Query(doc, tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, v := range values {
+			got = append(got, Format(v))
+		}
+		if tt.expr == "$" {
+			if len(values) != 1 {
+				t.Errorf("$: expected the document, got %v", got)
+			}
+			continue
+		}
+		if strings.Join(got, "|") != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.expr, tt.want, strings.Join(got, "|"))
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"$.store[",
+		"$.store[abc]",
+		"$.store['book",
+		"$.store[?(@.price <)]",
+		"$.store[?(price < 3)]",
+		"$.",
+		"$.store.[0]",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
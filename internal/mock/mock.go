@@ -0,0 +1,339 @@
+// Package mock serves the saved examples of a collection over HTTP, so a
+// client can be built before the API it calls exists.
+package mock
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/vars"
+)
+
+// Options configure the latency and the faults of the server
+type Options struct {
+	// Environment is the name of the environment whose variables the
+	// templates see, none when empty
+	Environment string
+	// Latency delays every response, plus a random share of Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+	// FailRate is the share of requests answered with a 500, from 0 to 1
+	FailRate float64
+	// DropRate is the share of requests whose connection is closed without
+	// a response, from 0 to 1
+	DropRate float64
+	// Seed makes the faults and the jitter repeatable, the clock seeds them
+	// when 0
+	Seed int64
+	// Log, when set, is called after each request
+	Log func(Hit)
+}
+
+// Route serves the example of a saved request to the requests matching its
+// method, path template and conditions
+type Route struct {
+	Method string
+	// Template is the path of the saved request, with {param} segments
+	Template string
+	// Name is the folder path and name of the request and its example
+	Name    string
+	Example model.Example
+
+	segments []string
+}
+
+// Hit describes a request the server answered
+type Hit struct {
+	Method string
+	Path   string
+	// Route is nil when no example matched
+	Route  *Route
+	Status int
+	// Fault is "500" or "drop" when a fault was injected
+	Fault    string
+	Duration time.Duration
+}
+
+// Server is an http.Handler serving the examples of a collection
+type Server struct {
+	routes []*Route
+	vars   map[string]string
+	opts   Options
+	// sleep waits before a response, replaced in tests
+	sleep func(time.Duration)
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New builds a server for the examples of a collection
+func New(c *model.Collection, opts Options) (*Server, error) {
+	envIdx := -1
+	if opts.Environment != "" {
+		for i, env := range c.Environments {
+			if strings.EqualFold(env.Name, opts.Environment) {
+				envIdx = i
+			}
+		}
+		if envIdx < 0 {
+			return nil, fmt.Errorf("unknown environment %q", opts.Environment)
+		}
+	}
+	if opts.FailRate < 0 || opts.FailRate > 1 || opts.DropRate < 0 || opts.DropRate > 1 {
+		return nil, fmt.Errorf("fault rates must be between 0 and 1")
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &Server{
+		vars:  c.EnvironmentVariableMap(envIdx),
+		opts:  opts,
+		sleep: time.Sleep,
+		rnd:   rand.New(rand.NewSource(seed)),
+	}
+
+	for _, e := range c.Entries() {
+		template := Template(e.Request.URL, s.vars)
+		name := strings.Join(append(append([]string{}, e.Path...), e.Request.Name), " / ")
+		for i, ex := range e.Request.Examples {
+			r := &Route{
+				Method:   strings.ToUpper(e.Request.Method),
+				Template: template,
+				Name:     name,
+				Example:  ex,
+				segments: split(template),
+			}
+			if ex.Name != "" {
+				r.Name += " (" + ex.Name + ")"
+			} else if len(e.Request.Examples) > 1 {
+				r.Name += fmt.Sprintf(" (example %d)", i+1)
+			}
+			s.routes = append(s.routes, r)
+		}
+	}
+	if len(s.routes) == 0 {
+		return nil, fmt.Errorf("the collection has no saved examples")
+	}
+	return s, nil
+}
+
+// Routes lists the routes in collection order
+func (s *Server) Routes() []*Route {
+	return s.routes
+}
+
+// Template returns the path template of a saved request URL. The base URL
+// variable it starts with is expanded, and segments that are a single
+// {{variable}}, {param} or :param become parameters.
+func Template(rawURL string, variables map[string]string) string {
+	u := rawURL
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	if strings.HasPrefix(u, "{{") {
+		if end := strings.Index(u, "}}"); end >= 0 {
+			base := vars.Expand(u[:end+2], variables)
+			if strings.HasPrefix(base, "{{") {
+				// An undefined base URL adds nothing to the path
+				base = ""
+			}
+			u = base + u[end+2:]
+		}
+	}
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	}
+	if !strings.HasPrefix(u, "/") {
+		// Drop the host, which URLs without a scheme start with too
+		if slash := strings.Index(u, "/"); slash >= 0 {
+			u = u[slash:]
+		} else {
+			u = "/"
+		}
+	}
+
+	segments := split(u)
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, "{{") && strings.HasSuffix(seg, "}}") && strings.Count(seg, "{{") == 1:
+			segments[i] = "{" + strings.TrimSpace(seg[2:len(seg)-2]) + "}"
+		case strings.HasPrefix(seg, ":") && len(seg) > 1:
+			segments[i] = "{" + seg[1:] + "}"
+		default:
+			segments[i] = vars.Expand(seg, variables)
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func split(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func param(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(segment) > 2 {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// match tells whether a request path fits the template, and returns its
+// parameters and how many literal segments matched
+func (r *Route) match(path string) (map[string]string, int, bool) {
+	got := split(path)
+	if len(got) != len(r.segments) {
+		return nil, 0, false
+	}
+	params := map[string]string{}
+	literals := 0
+	for i, seg := range r.segments {
+		if name, ok := param(seg); ok {
+			params[name] = got[i]
+			continue
+		}
+		if seg != got[i] {
+			return nil, 0, false
+		}
+		literals++
+	}
+	return params, literals, true
+}
+
+// conditionsMet checks the header and query conditions of the example
+func (r *Route) conditionsMet(req *http.Request, variables map[string]string) bool {
+	for _, c := range r.Example.When {
+		var values []string
+		switch strings.ToLower(c.In) {
+		case "header":
+			values = req.Header.Values(c.Name)
+		case "query":
+			values = req.URL.Query()[c.Name]
+		default:
+			return false
+		}
+		if len(values) == 0 {
+			return false
+		}
+		if c.Value == "" {
+			continue
+		}
+		want := vars.Expand(c.Value, variables)
+		found := false
+		for _, v := range values {
+			if v == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the route for a request: the one with the most literal
+// segments, then the most conditions, then the first in the collection
+func (s *Server) Find(req *http.Request) (*Route, map[string]string) {
+	var best *Route
+	var bestParams map[string]string
+	bestLiterals, bestConditions := -1, -1
+	for _, r := range s.routes {
+		if r.Method != req.Method {
+			continue
+		}
+		params, literals, ok := r.match(req.URL.Path)
+		if !ok || !r.conditionsMet(req, s.vars) {
+			continue
+		}
+		if literals > bestLiterals || (literals == bestLiterals && len(r.Example.When) > bestConditions) {
+			best, bestParams = r, params
+			bestLiterals, bestConditions = literals, len(r.Example.When)
+		}
+	}
+	return best, bestParams
+}
+
+// chance draws a random number below 1, safely across requests
+func (s *Server) chance() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Float64()
+}
+
+// ServeHTTP answers with the example of the matching route
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	hit := Hit{Method: req.Method, Path: req.URL.RequestURI()}
+	defer func() {
+		if s.opts.Log != nil {
+			hit.Duration = time.Since(start)
+			s.opts.Log(hit)
+		}
+	}()
+
+	route, params := s.Find(req)
+	hit.Route = route
+	if route == nil {
+		hit.Status = http.StatusNotFound
+		http.Error(w, fmt.Sprintf("apitty mock: no example for %s %s", req.Method, req.URL.Path), hit.Status)
+		return
+	}
+
+	delay := s.opts.Latency + time.Duration(route.Example.LatencyMs)*time.Millisecond
+	if s.opts.Jitter > 0 {
+		delay += time.Duration(s.chance() * float64(s.opts.Jitter))
+	}
+	if delay > 0 {
+		s.sleep(delay)
+	}
+
+	if s.opts.DropRate > 0 && s.chance() < s.opts.DropRate {
+		hit.Fault = "drop"
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+		// The connection cannot be taken over, abort the response instead
+		panic(http.ErrAbortHandler)
+	}
+	if s.opts.FailRate > 0 && s.chance() < s.opts.FailRate {
+		hit.Fault, hit.Status = "500", http.StatusInternalServerError
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(hit.Status)
+		_, _ = io.WriteString(w, `{"error": "fault injected by apitty mock"}`+"\n")
+		return
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(req.Body, 10<<20))
+	t := &templateData{req: req, params: params, body: body, vars: s.vars}
+	for _, h := range route.Example.Headers {
+		switch strings.ToLower(h.Key) {
+		case "content-length", "transfer-encoding", "connection":
+			// Set by the server for the rendered body
+			continue
+		}
+		w.Header().Add(h.Key, t.render(h.Value))
+	}
+	hit.Status = route.Example.Status
+	if hit.Status == 0 {
+		hit.Status = http.StatusOK
+	}
+	w.WriteHeader(hit.Status)
+	if req.Method != http.MethodHead {
+		_, _ = io.WriteString(w, t.render(route.Example.Body))
+	}
+}
@@ -0,0 +1,184 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func shop() *model.Collection {
+	return &model.Collection{
+		Name:         "Shop",
+		Variables:    []model.Variable{{Key: "baseUrl", Value: "https://api.example.com/v1"}, {Key: "currency", Value: "EUR"}},
+		Environments: []model.Environment{{Name: "Local", Variables: []model.Variable{{Key: "currency", Value: "USD"}}}},
+		Folders: []model.Folder{{Name: "Users", Requests: []model.SavedRequest{
+			{
+				Name:    "Get user",
+				Request: model.Request{Method: "GET", URL: "{{baseUrl}}/users/{{userId}}?expand=orders"},
+				Examples: []model.Example{
+					{
+						Name:    "Found",
+						Headers: []model.HeaderPair{{Key: "Content-Type", Value: "application/json"}, {Key: "Content-Length", Value: "3"}},
+						Body:    `{"id": {{path.userId}}, "expand": "{{query.expand}}", "currency": "{{currency}}", "unknown": "{{nope}}"}`,
+					},
+					{
+						Name:   "Unauthorized",
+						Status: 401,
+						Body:   `{"error": "no token"}`,
+						When:   []model.Condition{{In: "header", Name: "X-Scenario", Value: "unauthorized"}},
+					},
+				},
+			},
+			{
+				Name:     "Current user",
+				Request:  model.Request{Method: "GET", URL: "{{baseUrl}}/users/me"},
+				Examples: []model.Example{{Body: `{"id": 1}`}},
+			},
+			{
+				Name:    "Create user",
+				Request: model.Request{Method: "POST", URL: "{{baseUrl}}/users"},
+				Examples: []model.Example{{
+					Status:    201,
+					Headers:   []model.HeaderPair{{Key: "Location", Value: "/v1/users/{{$uuid}}"}},
+					Body:      `{"name": "{{body.$.name}}", "method": "{{request.method}}"}`,
+					LatencyMs: 50,
+				}},
+			},
+			{
+				Name:    "Search",
+				Request: model.Request{Method: "GET", URL: "{{baseUrl}}/users"},
+				Examples: []model.Example{
+					{Body: `[]`, When: []model.Condition{{In: "query", Name: "q"}}},
+					{Body: `[{"id": 1}]`},
+				},
+			},
+			{Name: "No example", Request: model.Request{Method: "DELETE", URL: "{{baseUrl}}/users/{{userId}}"}},
+		}}},
+	}
+}
+
+func call(t *testing.T, url, method, body string, headers ...string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestTemplate(t *testing.T) {
+	variables := map[string]string{"baseUrl": "https://api.example.com/v1", "host": "localhost:8080", "version": "2"}
+	tests := map[string]string{
+		"{{baseUrl}}/users/{{userId}}?page=1":       "/v1/users/{userId}",
+		"{{undefined}}/users/:id/orders":            "/users/{id}/orders",
+		"https://api.example.com/pets/{petId}#frag": "/pets/{petId}",
+		"{{host}}/v{{version}}/health":              "/v2/health",
+		"http://localhost:8080":                     "/",
+	}
+	for url, expected := range tests {
+		if got := Template(url, variables); got != expected {
+			t.Errorf("Template(%q) = %q, expected %q", url, got, expected)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	var hits []Hit
+	s, err := New(shop(), Options{Environment: "local", Log: func(h Hit) { hits = append(hits, h) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Routes()) != 6 || s.Routes()[0].Name != "Users / Get user (Found)" || s.Routes()[0].Template != "/v1/users/{userId}" {
+		t.Fatalf("unexpected routes %+v", s.Routes()[0])
+	}
+	var delays []time.Duration
+	s.sleep = func(d time.Duration) { delays = append(delays, d) }
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, body := call(t, server.URL+"/v1/users/42?expand=orders", "GET", "")
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response %d %v", resp.StatusCode, resp.Header)
+	}
+	if expected := `{"id": 42, "expand": "orders", "currency": "USD", "unknown": "{{nope}}"}`; body != expected {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	// Conditions pick an example, and literal segments win over parameters
+	if resp, _ := call(t, server.URL+"/v1/users/42", "GET", "", "X-Scenario", "unauthorized"); resp.StatusCode != 401 {
+		t.Errorf("expected the unauthorized example, got %d", resp.StatusCode)
+	}
+	if _, body := call(t, server.URL+"/v1/users/me", "GET", ""); body != `{"id": 1}` {
+		t.Errorf("expected the current user, got %s", body)
+	}
+	if _, body := call(t, server.URL+"/v1/users?q=bob", "GET", ""); body != `[]` {
+		t.Errorf("expected the search example, got %s", body)
+	}
+	if _, body := call(t, server.URL+"/v1/users", "GET", ""); body != `[{"id": 1}]` {
+		t.Errorf("expected the list example, got %s", body)
+	}
+
+	resp, body = call(t, server.URL+"/v1/users", "POST", `{"name": "Ann"}`)
+	if resp.StatusCode != 201 || body != `{"name": "Ann", "method": "POST"}` || len(resp.Header.Get("Location")) != len("/v1/users/")+36 {
+		t.Errorf("unexpected response %d %v %s", resp.StatusCode, resp.Header, body)
+	}
+	if len(delays) != 1 || delays[0] != 50*time.Millisecond {
+		t.Errorf("expected the latency of the example, got %v", delays)
+	}
+
+	resp, body = call(t, server.URL+"/v1/users/42", "DELETE", "")
+	if resp.StatusCode != 404 || !strings.Contains(body, "no example for DELETE /v1/users/42") {
+		t.Errorf("expected no example for DELETE, got %d %s", resp.StatusCode, body)
+	}
+	if len(hits) != 7 || hits[0].Route == nil || hits[0].Path != "/v1/users/42?expand=orders" || hits[6].Route != nil || hits[6].Status != 404 {
+		t.Errorf("unexpected hits %+v", hits)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s, err := New(shop(), Options{FailRate: 1, Latency: time.Second, Jitter: time.Second, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var delays []time.Duration
+	s.sleep = func(d time.Duration) { delays = append(delays, d) }
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, body := call(t, server.URL+"/v1/users/me", "GET", "")
+	if resp.StatusCode != 500 || !strings.Contains(body, "fault injected") {
+		t.Errorf("expected an injected 500, got %d %s", resp.StatusCode, body)
+	}
+	if len(delays) != 1 || delays[0] < time.Second || delays[0] >= 2*time.Second {
+		t.Errorf("expected a latency between 1s and 2s, got %v", delays)
+	}
+
+	s.opts.DropRate = 1
+	if _, err := http.Get(server.URL + "/v1/users/me"); err == nil {
+		t.Error("expected the connection to be dropped")
+	}
+
+	if _, err := New(shop(), Options{DropRate: 2}); err == nil {
+		t.Error("expected a rate above 1 to be refused")
+	}
+	if _, err := New(shop(), Options{Environment: "Prod"}); err == nil {
+		t.Error("expected an unknown environment to be refused")
+	}
+	if _, err := New(&model.Collection{Name: "Empty"}, Options{}); err == nil || err.Error() != "the collection has no saved examples" {
+		t.Errorf("expected a collection without examples to be refused, got %v", err)
+	}
+}
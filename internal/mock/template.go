@@ -0,0 +1,107 @@
+package mock
+
+import (
+	"crypto/rand"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"github.com/tbourrel/apitty/internal/vars"
+)
+
+// referencePattern matches {{name}} references, as in requests
+var referencePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// templateData is what the body and headers of an example may reference
+type templateData struct {
+	req    *http.Request
+	params map[string]string
+	body   []byte
+	vars   map[string]string
+
+	doc     any
+	decoded bool
+}
+
+// render replaces the references of an example in a single pass, so values
+// taken from the request are never expanded themselves. Unknown references
+// are left untouched.
+//
+//	{{path.id}}            parameter of the path template
+//	{{query.page}}         query parameter
+//	{{header.X-Tenant}}    request header
+//	{{body.$.user.name}}   JSONPath of a JSON request body
+//	{{request.method}}     method, also request.path and request.body
+//	{{$uuid}}              random UUID, also $timestamp, $isoTimestamp
+//	                       and $randomInt
+//	{{name}}               variable of the collection or environment
+func (t *templateData) render(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return referencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := t.value(referencePattern.FindStringSubmatch(ref)[1]); ok {
+			return v
+		}
+		return ref
+	})
+}
+
+func (t *templateData) value(name string) (string, bool) {
+	source, key, _ := strings.Cut(name, ".")
+	switch {
+	case source == "path" && key != "":
+		v, ok := t.params[key]
+		return v, ok
+	case source == "query" && key != "":
+		values, ok := t.req.URL.Query()[key]
+		if !ok {
+			return "", true
+		}
+		return values[0], true
+	case source == "header" && key != "":
+		return t.req.Header.Get(key), true
+	case source == "body" && strings.HasPrefix(key, "$"):
+		if !t.decoded {
+			t.doc, _ = jsonpath.Decode(t.body)
+			t.decoded = true
+		}
+		values, err := jsonpath.Query(t.doc, key)
+		if err != nil || len(values) == 0 {
+			return "", true
+		}
+		return jsonpath.Format(values[0]), true
+	case name == "request.method":
+		return t.req.Method, true
+	case name == "request.path":
+		return t.req.URL.Path, true
+	case name == "request.body":
+		return string(t.body), true
+	case name == "$uuid":
+		return uuid(), true
+	case name == "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), true
+	case name == "$isoTimestamp":
+		return time.Now().UTC().Format(time.RFC3339), true
+	case name == "$randomInt":
+		return strconv.Itoa(mathrand.Intn(1000)), true
+	}
+	if v, ok := t.vars[name]; ok {
+		return vars.Expand(v, t.vars), true
+	}
+	return "", false
+}
+
+// uuid returns a random version 4 UUID
+func uuid() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,279 @@
+package model
+
+// AuthType identifies how a request authenticates
+type AuthType string
+
+const (
+	// AuthNone sends no credentials
+	AuthNone AuthType = ""
+	// AuthBasic sends a username and password as Basic auth
+	AuthBasic AuthType = "basic"
+	// AuthBearer sends a token in the Authorization header
+	AuthBearer AuthType = "bearer"
+	// AuthAPIKey sends a key in a header or a query parameter
+	AuthAPIKey AuthType = "apikey"
+)
+
+// Auth holds the credentials of a request
+type Auth struct {
+	Type     AuthType `json:"type"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Token    string   `json:"token,omitempty"`
+	Key      string   `json:"key,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	// In is "header" or "query" for API keys
+	In string `json:"in,omitempty"`
+}
+
+// Settings holds the transport options of a request. The zero value keeps
+// the defaults: verify certificates, follow redirects and time out after 15s.
+type Settings struct {
+	// Insecure skips TLS certificate verification
+	Insecure bool `json:"insecure,omitempty"`
+	// NoRedirects returns redirect responses instead of following them
+	NoRedirects bool `json:"noRedirects,omitempty"`
+	// Compressed asks for a gzip or deflate encoded response and decodes it
+	Compressed bool `json:"compressed,omitempty"`
+	// TimeoutMs overrides the default timeout
+	TimeoutMs int `json:"timeoutMs,omitempty"`
+	// CertFile and KeyFile are a PEM client certificate and its private key
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// Resolve pins hosts to addresses, as host:port:address entries
+	Resolve []string `json:"resolve,omitempty"`
+	// FreshConnection opens a new connection instead of reusing a kept-alive
+	// one, to measure DNS, connect and TLS setup
+	FreshConnection bool `json:"freshConnection,omitempty"`
+	// PreviewKB is the start of the body shown in the TUI, the rest is kept
+	// in a file. 1024 when unset.
+	PreviewKB int `json:"previewKb,omitempty"`
+}
+
+// IsZero reports whether s only holds defaults
+func (s Settings) IsZero() bool {
+	return !s.Insecure && !s.NoRedirects && !s.Compressed && s.TimeoutMs == 0 &&
+		s.CertFile == "" && s.KeyFile == "" && len(s.Resolve) == 0 && !s.FreshConnection &&
+		s.PreviewKB == 0
+}
+
+// ExtractSource identifies where an extraction rule reads its value
+type ExtractSource string
+
+const (
+	// ExtractJSON reads a JSONPath of the response body
+	ExtractJSON ExtractSource = "json"
+	// ExtractHeader reads a response header
+	ExtractHeader ExtractSource = "header"
+	// ExtractRegex reads the first group, or the whole match, of a regular
+	// expression over the response body
+	ExtractRegex ExtractSource = "regex"
+	// ExtractCookie reads a cookie set by the response
+	ExtractCookie ExtractSource = "cookie"
+)
+
+// ExtractRule stores a value of the response into a variable
+type ExtractRule struct {
+	Variable string        `json:"variable"`
+	Source   ExtractSource `json:"source"`
+	// Expression is the JSONPath, header name, regular expression or cookie name
+	Expression string `json:"expression"`
+}
+
+// AssertionKind identifies what an assertion checks
+type AssertionKind string
+
+const (
+	// AssertStatus checks the status code
+	AssertStatus AssertionKind = "status"
+	// AssertHeader checks a response header, named by Target
+	AssertHeader AssertionKind = "header"
+	// AssertJSONPath checks the values at the JSONPath in Target
+	AssertJSONPath AssertionKind = "jsonpath"
+	// AssertTime checks the total response time
+	AssertTime AssertionKind = "time"
+	// AssertSize checks the size of the response body
+	AssertSize AssertionKind = "size"
+)
+
+// Assertion is a check run against the response of a request, such as
+// status == 200 or jsonpath $.id type number
+type Assertion struct {
+	Kind AssertionKind `json:"kind"`
+	// Target is the header name or the JSONPath
+	Target string `json:"target,omitempty"`
+	// Op is one of ==, !=, <, <=, >, >=, contains, matches, exists,
+	// !exists and type
+	Op    string `json:"op"`
+	Value string `json:"value,omitempty"`
+}
+
+// AssertionResult is the outcome of an assertion
+type AssertionResult struct {
+	Assertion Assertion
+	Passed    bool
+	// Actual is the value the assertion found
+	Actual string
+	// Message explains a failure
+	Message string
+}
+
+// Contract links a request to the schema its responses must follow
+type Contract struct {
+	// Schema is a JSON Schema file the response body must match
+	Schema string `json:"schema,omitempty"`
+	// OpenAPI is a specification file the status, headers and body must
+	// match
+	OpenAPI string `json:"openapi,omitempty"`
+	// Operation is the operationId, or "METHOD /path/{template}", of the
+	// request in OpenAPI. It is found from the URL when empty.
+	Operation string `json:"operation,omitempty"`
+}
+
+// IsZero reports whether the contract checks nothing
+func (c Contract) IsZero() bool {
+	return c.Schema == "" && c.OpenAPI == ""
+}
+
+// Request describes an HTTP request that can be sent or saved
+type Request struct {
+	Method   string       `json:"method"`
+	URL      string       `json:"url"`
+	Headers  []HeaderPair `json:"headers,omitempty"`
+	Body     string       `json:"body,omitempty"`
+	Auth     *Auth        `json:"auth,omitempty"`
+	Settings *Settings    `json:"settings,omitempty"`
+	// Extract runs after a response arrives, to chain requests
+	Extract []ExtractRule `json:"extract,omitempty"`
+	// Assertions are checked against every response
+	Assertions []Assertion `json:"assertions,omitempty"`
+	// Contract validates every response against a schema
+	Contract *Contract `json:"contract,omitempty"`
+}
+
+// Variable is a named value referenced as {{name}} in requests
+type Variable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Condition restricts an example to the requests carrying a header or a
+// query parameter
+type Condition struct {
+	// In is "header" or "query"
+	In   string `json:"in"`
+	Name string `json:"name"`
+	// Value is the expected value, any value matches when empty
+	Value string `json:"value,omitempty"`
+}
+
+// Example is a saved response of a request, served by apitty mock
+type Example struct {
+	Name string `json:"name,omitempty"`
+	// Status is 200 when unset
+	Status  int          `json:"status,omitempty"`
+	Headers []HeaderPair `json:"headers,omitempty"`
+	// Body may reference the request, such as {{path.id}}, {{query.page}},
+	// {{header.X-Tenant}} or {{body.$.name}}, and variables
+	Body string `json:"body,omitempty"`
+	// When lists the conditions a request must meet to get this example
+	When []Condition `json:"when,omitempty"`
+	// LatencyMs delays this example on top of the latency of the server
+	LatencyMs int `json:"latencyMs,omitempty"`
+}
+
+// SavedRequest is a named request stored in a collection
+type SavedRequest struct {
+	Name string `json:"name"`
+	Request
+	// Examples are the responses apitty mock serves for the request
+	Examples []Example `json:"examples,omitempty"`
+}
+
+// Folder groups requests and nested folders
+type Folder struct {
+	Name     string         `json:"name"`
+	Folders  []Folder       `json:"folders,omitempty"`
+	Requests []SavedRequest `json:"requests,omitempty"`
+}
+
+// Environment is a named set of variables overriding the collection ones
+type Environment struct {
+	Name      string     `json:"name"`
+	Variables []Variable `json:"variables,omitempty"`
+}
+
+// Collection is a named set of requests and the variables they use
+type Collection struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description,omitempty"`
+	Variables    []Variable     `json:"variables,omitempty"`
+	Environments []Environment  `json:"environments,omitempty"`
+	Folders      []Folder       `json:"folders,omitempty"`
+	Requests     []SavedRequest `json:"requests,omitempty"`
+}
+
+// CollectionEntry is a request of a collection along with its folder path
+type CollectionEntry struct {
+	Path    []string
+	Request *SavedRequest
+}
+
+// Entries lists every request of the collection, folders first, depth first
+func (c *Collection) Entries() []CollectionEntry {
+	if c == nil {
+		return nil
+	}
+	var entries []CollectionEntry
+	var walk func(path []string, folders []Folder, requests []SavedRequest)
+	walk = func(path []string, folders []Folder, requests []SavedRequest) {
+		for i := range folders {
+			folderPath := append(append([]string{}, path...), folders[i].Name)
+			walk(folderPath, folders[i].Folders, folders[i].Requests)
+		}
+		for i := range requests {
+			entries = append(entries, CollectionEntry{Path: path, Request: &requests[i]})
+		}
+	}
+	walk(nil, c.Folders, c.Requests)
+	return entries
+}
+
+// VariableMap returns the collection variables keyed by name
+func (c *Collection) VariableMap() map[string]string {
+	return c.EnvironmentVariableMap(-1)
+}
+
+// SetVariable sets a variable of the environment at envIdx, or of the
+// collection itself when envIdx is negative, adding it if needed
+func (c *Collection) SetVariable(envIdx int, key, value string) {
+	vars := &c.Variables
+	if envIdx >= 0 && envIdx < len(c.Environments) {
+		vars = &c.Environments[envIdx].Variables
+	}
+	for i := range *vars {
+		if (*vars)[i].Key == key {
+			(*vars)[i].Value = value
+			return
+		}
+	}
+	*vars = append(*vars, Variable{Key: key, Value: value})
+}
+
+// EnvironmentVariableMap returns the collection variables overridden by
+// those of the environment at envIdx. A negative index selects no environment.
+func (c *Collection) EnvironmentVariableMap(envIdx int) map[string]string {
+	vars := make(map[string]string)
+	if c == nil {
+		return vars
+	}
+	for _, v := range c.Variables {
+		vars[v.Key] = v.Value
+	}
+	if envIdx >= 0 && envIdx < len(c.Environments) {
+		for _, v := range c.Environments[envIdx].Variables {
+			vars[v.Key] = v.Value
+		}
+	}
+	return vars
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Timings breaks down the time spent on a request, following the HAR phases
+type Timings struct {
+	Blocked time.Duration `json:"blocked"`
+	DNS     time.Duration `json:"dns"`
+	Connect time.Duration `json:"connect"`
+	// SSL is part of Connect
+	SSL     time.Duration `json:"ssl"`
+	Send    time.Duration `json:"send"`
+	Wait    time.Duration `json:"wait"`
+	Receive time.Duration `json:"receive"`
+}
+
+// Total returns the time from the start of the request to the end of the response
+func (t Timings) Total() time.Duration {
+	return t.Blocked + t.DNS + t.Connect + t.Send + t.Wait + t.Receive
+}
+
+// Response is a received HTTP response
+type Response struct {
+	Status     string       `json:"status"`
+	StatusCode int          `json:"statusCode"`
+	Proto      string       `json:"proto,omitempty"`
+	Headers    []HeaderPair `json:"headers,omitempty"`
+	Body       string       `json:"body,omitempty"`
+	// Truncated is set when only the start of the body was kept
+	Truncated bool `json:"truncated,omitempty"`
+	// Size is the size of the whole body
+	Size int64 `json:"size,omitempty"`
+}
+
+// HistoryEntry is a sent request along with its response and timings
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Request  Request   `json:"request"`
+	Response Response  `json:"response"`
+	Timings  Timings   `json:"timings"`
+	// ReusedConnection is set when the request was sent on a kept-alive
+	// connection
+	ReusedConnection bool   `json:"reusedConnection,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
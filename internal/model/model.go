@@ -1,9 +1,20 @@
 package model
 
 import (
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/bench"
+	"github.com/tbourrel/apitty/internal/cookies"
+	"github.com/tbourrel/apitty/internal/graphql"
+	"github.com/tbourrel/apitty/internal/httpfile"
+	"github.com/tbourrel/apitty/internal/infer"
+	"github.com/tbourrel/apitty/internal/pager"
+	"github.com/tbourrel/apitty/internal/schema"
+	"github.com/tbourrel/apitty/internal/table"
+	"github.com/tbourrel/apitty/internal/transfer"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 // Methods contains all supported HTTP methods
@@ -17,6 +28,12 @@ const (
 	ViewBody ResponseView = iota
 	// ViewHeaders shows the response headers
 	ViewHeaders
+	// ViewCookies shows the cookie jar of the active environment
+	ViewCookies
+	// ViewTests shows the results of the assertions
+	ViewTests
+	// ViewContract shows where the response breaks its contract
+	ViewContract
 )
 
 // FocusArea represents which UI element is currently focused
@@ -45,10 +62,20 @@ const (
 	HeaderModeEdit
 )
 
+// BodyMode represents how the request body is composed
+type BodyMode int
+
+const (
+	// BodyRaw sends Body as-is
+	BodyRaw BodyMode = iota
+	// BodyGraphQL sends the GraphQL query and variables as a JSON envelope
+	BodyGraphQL
+)
+
 // HeaderPair represents a single HTTP header key-value pair
 type HeaderPair struct {
-	Key   string
-	Value string
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // Model represents the application state
@@ -64,7 +91,7 @@ type Model struct {
 	MethodOpen        bool
 	Width             int
 	Height            int
-	Viewport          viewport.Model
+	Viewport          pager.Model
 	ViewportReady     bool
 	Fullscreen        bool
 	CurrentView       ResponseView
@@ -77,9 +104,190 @@ type Model struct {
 	HeaderFormMode    HeaderFormMode
 	HeaderFocusField  int
 	HeaderIsEditing   bool
-	ShowCurlImport    bool
-	CurlInput         textinput.Model
-	HelpViewport      viewport.Model
+	ShowImport        bool
+	ImportInput       textarea.Model
+	ImportError       string
+	// ImportPreview is the parsed request awaiting confirmation
+	ImportPreview *Request
+	ImportFormat  string
+	HelpViewport  viewport.Model
+
+	BodyMode             BodyMode
+	ShowGraphQLForm      bool
+	GraphQLQuery         textarea.Model
+	GraphQLVariables     textarea.Model
+	GraphQLFocusField    int
+	GraphQLSchema        *graphql.Schema
+	GraphQLStatus        string
+	GraphQLSuggestions   []string
+	GraphQLSuggestionIdx int
+	ShowSchemaExplorer   bool
+	SchemaExplorerPath   []string
+	SchemaExplorerIdx    int
+
+	ShowGRPCForm   bool
+	GRPCFiles      *protoregistry.Files
+	GRPCMethods    []string
+	GRPCMethodIdx  int
+	GRPCMethod     string
+	GRPCProtoInput textinput.Model
+	GRPCRequest    textarea.Model
+	GRPCFocusField int
+	GRPCStatus     string
+
+	Auth           Auth
+	ShowAuthForm   bool
+	AuthFocusField int
+	AuthInputs     []textinput.Model
+
+	Settings           Settings
+	ShowSettingsForm   bool
+	SettingsFocusField int
+	SettingsInputs     []textinput.Model
+
+	Collection     *Collection
+	CollectionPath string
+	ShowCollection bool
+	CollectionIdx  int
+	EnvironmentIdx int
+	StatusMessage  string
+
+	// HTTPFile is set when the collection was opened from a .http file
+	HTTPFile *httpfile.File
+	// LoadedRequestIdx is the collection entry loaded in the editor, or -1
+	LoadedRequestIdx int
+
+	History     []HistoryEntry
+	HistoryPath string
+	ShowHistory bool
+	HistoryIdx  int
+
+	ShowCodegen   bool
+	CodegenIdx    int
+	CodegenOffset int
+
+	// Extract lists the rules storing response values into variables
+	Extract         []ExtractRule
+	ShowExtractForm bool
+	ExtractIdx      int
+	// ExtractEditIdx is the rule being edited, len(Extract) for a new one,
+	// or -1 while the list is shown
+	ExtractEditIdx    int
+	ExtractFocusField int
+	ExtractSource     ExtractSource
+	ExtractInputs     []textinput.Model
+
+	// Assertions are checked against every response
+	Assertions     []Assertion
+	ShowAssertForm bool
+	AssertIdx      int
+	// AssertEditIdx is the assertion being edited, len(Assertions) for a
+	// new one, or -1 while the list is shown
+	AssertEditIdx int
+	AssertInput   textinput.Model
+	// SentAssertions are the assertions of the request in flight, as it was
+	// sent, so editing the request meanwhile does not change the check
+	SentAssertions []Assertion
+	// TestResults are the outcome of the assertions on the last response
+	TestResults []AssertionResult
+	// Contract validates every response against a schema file or an
+	// OpenAPI operation
+	Contract           Contract
+	ShowContractForm   bool
+	ContractFocusField int
+	ContractInputs     []textinput.Model
+	// Violations are where the last response breaks the contract, and
+	// ContractChecked tells whether it was checked at all
+	Violations      []schema.Violation
+	ContractChecked bool
+	// Connection tells whether the last response reused a kept-alive
+	// connection
+	Connection string
+	// ResponseBody is the raw body of the last response, only its start when
+	// it was streamed to BodyFile
+	ResponseBody string
+	// ResponseSize is the size of the whole body
+	ResponseSize int64
+	// ResponseType is the media type of the last response, without parameters
+	ResponseType string
+	// ResponseRequest is the request the last response answered
+	ResponseRequest Request
+	// ResponseStatusCode and ResponseHeaderList are the status and headers
+	// of the last response, kept to save it as an example
+	ResponseStatusCode int
+	ResponseHeaderList []HeaderPair
+	// ResponseFormat names the formatter of Response, which colors its
+	// lines as they are shown
+	ResponseFormat string
+	// ResponseBinary tells that Response is a hex dump of ResponseBody
+	ResponseBinary bool
+	// BodyFile is the temporary file holding the last streamed body
+	BodyFile string
+	// Transfer tracks the body being received while Loading
+	Transfer      *transfer.Transfer
+	ShowSaveBody  bool
+	SaveBodyInput textinput.Model
+	// ShowHexPrompt asks for an offset to jump to in the hex view, or for
+	// bytes to search when HexSearch is set
+	ShowHexPrompt bool
+	HexSearch     bool
+	HexInput      textinput.Model
+	// HexPattern is the last search, and HexMatch the offset of its last
+	// match, or -1
+	HexPattern []byte
+	HexMatch   int
+
+	// ShowTable lays out the array of objects at TablePath in the body
+	ShowTable bool
+	Table     *table.Table
+	TablePath string
+	// TableRow and TableCol are the selected cell, TableTop and TableLeft
+	// the first row and column in view
+	TableRow  int
+	TableCol  int
+	TableTop  int
+	TableLeft int
+	// ShowTableCell shows the selected cell in full
+	ShowTableCell bool
+	// TableEditPath edits TablePath in TablePathInput
+	TableEditPath  bool
+	TablePathInput textinput.Model
+
+	// ShowInfer shows the types inferred from the body, in the target at
+	// InferIdx
+	ShowInfer   bool
+	InferIdx    int
+	InferOffset int
+	// InferHistory merges the responses to the same request in the history
+	// into InferType, InferSamples counting them
+	InferHistory bool
+	InferType    *infer.Type
+	InferName    string
+	InferSamples int
+
+	// ShowBench shows the benchmark form, or the dashboard once Bench is set
+	ShowBench       bool
+	BenchFocusField int
+	// BenchInputs are the requests, duration, concurrency and rate
+	BenchInputs  []textinput.Model
+	Bench        *bench.Runner
+	BenchSummary bench.Summary
+	// BenchTarget describes the benchmarked request
+	BenchTarget string
+
+	// Cookies holds a cookie jar per environment
+	Cookies *cookies.Store
+	// CookiesPath is where cookies are saved when PersistCookies is set
+	CookiesPath      string
+	PersistCookies   bool
+	CookieIdx        int
+	ShowCookieForm   bool
+	CookieFocusField int
+	CookieInputs     []textinput.Model
+	// CookieDraft holds the flags of the cookie being edited
+	CookieDraft cookies.Cookie
+	// CookieEditing is the cookie the form replaces, nil when adding one
+	CookieEditing *cookies.Cookie
 }
 
 // ResponseMsg represents the message returned from an HTTP request
@@ -88,6 +296,31 @@ type ResponseMsg struct {
 	Headers string
 	Status  string
 	Err     error
+	// Entry records the exchange for the history, when it reached the network
+	Entry *HistoryEntry
+	// BodyFile is the temporary file holding the whole body, when it was
+	// streamed
+	BodyFile string
+	// Format names the formatter that pretty-printed Resp, left to color
+	// its lines as they are shown
+	Format string
+}
+
+// HistorySavedMsg reports the result of writing the history to disk
+type HistorySavedMsg struct {
+	Err error
+}
+
+// SchemaMsg represents the result of a GraphQL introspection query
+type SchemaMsg struct {
+	Schema *graphql.Schema
+	Err    error
+}
+
+// GRPCDescriptorsMsg represents the descriptors resolved for a gRPC server
+type GRPCDescriptorsMsg struct {
+	Files *protoregistry.Files
+	Err   error
 }
 
 // InitialModel creates and returns a new model with default values
@@ -107,14 +340,120 @@ func InitialModel() Model {
 	headerVal.CharLimit = 200
 	headerVal.Width = 50
 
-	curlInput := textinput.New()
-	curlInput.Placeholder = "Paste curl command here..."
-	curlInput.CharLimit = 2000
-	curlInput.Width = 80
+	importInput := textarea.New()
+	importInput.Placeholder = "Paste a curl, fetch, HTTPie or PowerShell command here..."
+	importInput.CharLimit = 0
+	importInput.MaxHeight = 0
+	importInput.ShowLineNumbers = false
+	importInput.SetWidth(80)
+	importInput.SetHeight(10)
+
+	gqlQuery := textarea.New()
+	gqlQuery.Placeholder = "query { ... }"
+	gqlQuery.CharLimit = 0
+	gqlQuery.SetWidth(80)
+	gqlQuery.SetHeight(12)
+
+	gqlVariables := textarea.New()
+	gqlVariables.Placeholder = `{"id": 1}`
+	gqlVariables.CharLimit = 0
+	gqlVariables.SetWidth(80)
+	gqlVariables.SetHeight(5)
+
+	grpcProto := textinput.New()
+	grpcProto.Placeholder = "empty for server reflection, or path/to/service.proto, descriptors.pb"
+	grpcProto.CharLimit = 500
+	grpcProto.Width = 80
+
+	grpcRequest := textarea.New()
+	grpcRequest.Placeholder = "{}"
+	grpcRequest.CharLimit = 0
+	grpcRequest.SetWidth(80)
+	grpcRequest.SetHeight(8)
+
+	authInputs := make([]textinput.Model, 3)
+	for i := range authInputs {
+		authInputs[i] = textinput.New()
+		authInputs[i].CharLimit = 2000
+		authInputs[i].Width = 50
+	}
+
+	// Timeout, client certificate, key, resolve entries and preview size
+	settingsInputs := make([]textinput.Model, 5)
+	for i := range settingsInputs {
+		settingsInputs[i] = textinput.New()
+		settingsInputs[i].CharLimit = 2000
+		settingsInputs[i].Width = 50
+	}
+	settingsInputs[0].Placeholder = "15000"
+	settingsInputs[3].Placeholder = "host:port:address, ..."
+	settingsInputs[4].Placeholder = "1024"
+
+	// Variable name and expression of an extraction rule
+	extractInputs := make([]textinput.Model, 2)
+	for i := range extractInputs {
+		extractInputs[i] = textinput.New()
+		extractInputs[i].CharLimit = 2000
+		extractInputs[i].Width = 50
+	}
+	extractInputs[0].Placeholder = "auth_token"
+
+	assertInput := textinput.New()
+	assertInput.Placeholder = "status == 200"
+	assertInput.CharLimit = 2000
+	assertInput.Width = 60
+
+	// Schema file, OpenAPI specification and operation of a contract
+	contractInputs := make([]textinput.Model, 3)
+	for i := range contractInputs {
+		contractInputs[i] = textinput.New()
+		contractInputs[i].CharLimit = 2000
+		contractInputs[i].Width = 50
+	}
+	contractInputs[0].Placeholder = "order.schema.json"
+	contractInputs[1].Placeholder = "openapi.yaml"
+	contractInputs[2].Placeholder = "found from the URL, or getOrder, GET /orders/{id}"
+
+	saveBodyInput := textinput.New()
+	saveBodyInput.Placeholder = "response.json"
+	saveBodyInput.CharLimit = 4000
+	saveBodyInput.Width = 60
+
+	hexInput := textinput.New()
+	hexInput.CharLimit = 200
+	hexInput.Width = 60
+
+	tablePathInput := textinput.New()
+	tablePathInput.Placeholder = "$.data.items"
+	tablePathInput.CharLimit = 200
+	tablePathInput.Width = 60
+
+	// Requests, duration, concurrency and rate of a benchmark
+	benchInputs := make([]textinput.Model, 4)
+	for i := range benchInputs {
+		benchInputs[i] = textinput.New()
+		benchInputs[i].CharLimit = 20
+		benchInputs[i].Width = 20
+	}
+	benchInputs[0].SetValue("100")
+	benchInputs[0].Placeholder = "unlimited"
+	benchInputs[1].Placeholder = "30s, or unlimited"
+	benchInputs[2].SetValue("10")
+	benchInputs[3].Placeholder = "unlimited"
+
+	// Name, value, domain, path, expiry and SameSite of a cookie
+	cookieInputs := make([]textinput.Model, 6)
+	for i := range cookieInputs {
+		cookieInputs[i] = textinput.New()
+		cookieInputs[i].CharLimit = 4000
+		cookieInputs[i].Width = 50
+	}
+	cookieInputs[3].Placeholder = "/"
+	cookieInputs[4].Placeholder = "session, or 2006-01-02 15:04"
+	cookieInputs[5].Placeholder = "Lax, Strict or None"
 
-	vp := viewport.New(0, 0)
+	vp := pager.New(0, 0)
 	helpVp := viewport.New(0, 0)
-	vp.KeyMap = viewport.KeyMap{} // Disable default keybindings
 
 	return Model{
 		Focus:             FocusMethod,
@@ -131,8 +470,29 @@ func InitialModel() Model {
 		HeaderSelectedIdx: 0,
 		HeaderFormMode:    HeaderModeList,
 		HeaderFocusField:  0,
-		CurlInput:         curlInput,
+		ImportInput:       importInput,
 		HelpViewport:      helpVp,
+		BodyMode:          BodyRaw,
+		GraphQLQuery:      gqlQuery,
+		GraphQLVariables:  gqlVariables,
+		GRPCProtoInput:    grpcProto,
+		GRPCRequest:       grpcRequest,
+		AuthInputs:        authInputs,
+		SettingsInputs:    settingsInputs,
+		Cookies:           cookies.NewStore(),
+		CookieInputs:      cookieInputs,
+		ExtractInputs:     extractInputs,
+		ExtractEditIdx:    -1,
+		AssertInput:       assertInput,
+		ContractInputs:    contractInputs,
+		BenchInputs:       benchInputs,
+		SaveBodyInput:     saveBodyInput,
+		HexInput:          hexInput,
+		HexMatch:          -1,
+		TablePathInput:    tablePathInput,
+		AssertEditIdx:     -1,
+		EnvironmentIdx:    -1,
+		LoadedRequestIdx:  -1,
 	}
 }
 
@@ -0,0 +1,568 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// node is a decoded JSON/YAML object
+type node = map[string]interface{}
+
+// maxExampleDepth stops recursive schemas from expanding forever
+const maxExampleDepth = 8
+
+// operationMethods lists the operation keys of a path item in display order
+var operationMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+// Document is a parsed OpenAPI 3 or Swagger 2 specification
+type Document struct {
+	root    node
+	swagger bool
+}
+
+// Load reads a specification from a YAML or JSON file
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a YAML or JSON specification
+func Parse(data []byte) (*Document, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid specification: %w", err)
+	}
+	root, ok := normalize(raw).(node)
+	if !ok {
+		return nil, fmt.Errorf("invalid specification: expected an object at the top level")
+	}
+
+	doc := &Document{root: root}
+	switch {
+	case str(root["openapi"]) != "":
+		if !strings.HasPrefix(str(root["openapi"]), "3.") {
+			return nil, fmt.Errorf("unsupported OpenAPI version %s", str(root["openapi"]))
+		}
+	case str(root["swagger"]) == "2.0" || str(root["swagger"]) == "2":
+		doc.swagger = true
+	default:
+		return nil, fmt.Errorf("not an OpenAPI 3 or Swagger 2 document")
+	}
+	return doc, nil
+}
+
+// normalize converts YAML maps with non-string keys into JSON-like objects
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = normalize(val)
+		}
+		return t
+	case map[interface{}]interface{}:
+		out := make(node, len(t))
+		for k, val := range t {
+			out[fmt.Sprint(k)] = normalize(val)
+		}
+		return out
+	case []interface{}:
+		for i, val := range t {
+			t[i] = normalize(val)
+		}
+		return t
+	}
+	return v
+}
+
+// ToCollection generates a collection with one request per operation,
+// grouped in folders by their first tag
+func (d *Document) ToCollection() *model.Collection {
+	info := obj(d.root["info"])
+	c := &model.Collection{
+		Name:        str(info["title"]),
+		Description: str(info["description"]),
+	}
+	if c.Name == "" {
+		c.Name = "Imported API"
+	}
+
+	vars := &variables{}
+	vars.add("baseUrl", d.baseURL())
+
+	folderIdx := make(map[string]int)
+	for _, t := range list(d.root["tags"]) {
+		name := str(obj(t)["name"])
+		if _, ok := folderIdx[name]; !ok && name != "" {
+			folderIdx[name] = len(c.Folders)
+			c.Folders = append(c.Folders, model.Folder{Name: name})
+		}
+	}
+
+	paths := obj(d.root["paths"])
+	pathNames := make([]string, 0, len(paths))
+	for p := range paths {
+		pathNames = append(pathNames, p)
+	}
+	sort.Strings(pathNames)
+
+	for _, path := range pathNames {
+		item := d.resolve(obj(paths[path]))
+		for _, method := range operationMethods {
+			op, ok := item[method].(node)
+			if !ok {
+				continue
+			}
+			req := d.buildRequest(path, method, item, op, vars)
+
+			tag := ""
+			if tags := list(op["tags"]); len(tags) > 0 {
+				tag = str(tags[0])
+			}
+			if tag == "" {
+				c.Requests = append(c.Requests, req)
+				continue
+			}
+			idx, ok := folderIdx[tag]
+			if !ok {
+				idx = len(c.Folders)
+				folderIdx[tag] = idx
+				c.Folders = append(c.Folders, model.Folder{Name: tag})
+			}
+			c.Folders[idx].Requests = append(c.Folders[idx].Requests, req)
+		}
+	}
+
+	// Drop tags declared without any operation
+	folders := c.Folders[:0]
+	for _, f := range c.Folders {
+		if len(f.Requests) > 0 {
+			folders = append(folders, f)
+		}
+	}
+	c.Folders = folders
+	c.Variables = vars.list
+	return c
+}
+
+func (d *Document) buildRequest(path, method string, item, op node, vars *variables) model.SavedRequest {
+	name := str(op["summary"])
+	if name == "" {
+		name = str(op["operationId"])
+	}
+	if name == "" {
+		name = strings.ToUpper(method) + " " + path
+	}
+
+	req := model.SavedRequest{Name: name}
+	req.Method = strings.ToUpper(method)
+
+	urlPath := path
+	var query []string
+	for _, p := range d.parameters(item, op) {
+		pname := str(p["name"])
+		switch str(p["in"]) {
+		case "path":
+			urlPath = strings.ReplaceAll(urlPath, "{"+pname+"}", "{{"+pname+"}}")
+			vars.add(pname, d.parameterExample(p))
+		case "query":
+			query = append(query, url.QueryEscape(pname)+"={{"+pname+"}}")
+			vars.add(pname, d.parameterExample(p))
+		case "header":
+			req.Headers = append(req.Headers, model.HeaderPair{Key: pname, Value: "{{" + pname + "}}"})
+			vars.add(pname, d.parameterExample(p))
+		case "body":
+			req.Body = d.exampleBody("application/json", obj(p["schema"]), nil)
+			req.Headers = append(req.Headers, model.HeaderPair{Key: "Content-Type", Value: d.swaggerConsumes(op)})
+		}
+	}
+	req.URL = "{{baseUrl}}" + urlPath
+	if len(query) > 0 {
+		req.URL += "?" + strings.Join(query, "&")
+	}
+
+	if body := d.resolve(obj(op["requestBody"])); len(body) > 0 {
+		contentType, media := pickMediaType(obj(body["content"]))
+		if contentType != "" {
+			req.Headers = append(req.Headers, model.HeaderPair{Key: "Content-Type", Value: contentType})
+			req.Body = d.exampleBody(contentType, obj(media["schema"]), media)
+		}
+	}
+
+	req.Auth = d.auth(op, vars)
+	return req
+}
+
+// parameters merges path-level and operation-level parameters, the latter
+// overriding the former
+func (d *Document) parameters(item, op node) []node {
+	var params []node
+	index := make(map[string]int)
+	for _, source := range [][]interface{}{list(item["parameters"]), list(op["parameters"])} {
+		for _, raw := range source {
+			p := d.resolve(obj(raw))
+			key := str(p["in"]) + ":" + str(p["name"])
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+func (d *Document) parameterExample(p node) string {
+	if v, ok := p["example"]; ok {
+		return scalar(v)
+	}
+	examples := obj(p["examples"])
+	for _, name := range sortedKeys(examples) {
+		if v, ok := d.resolve(obj(examples[name]))["value"]; ok {
+			return scalar(v)
+		}
+	}
+	schema := d.resolve(obj(p["schema"]))
+	if len(schema) == 0 {
+		// Swagger 2 puts the type on the parameter itself
+		schema = p
+	}
+	for _, key := range []string{"example", "default", "x-example"} {
+		if v, ok := schema[key]; ok {
+			return scalar(v)
+		}
+	}
+	if enum := list(schema["enum"]); len(enum) > 0 {
+		return scalar(enum[0])
+	}
+	return ""
+}
+
+func (d *Document) swaggerConsumes(op node) string {
+	for _, source := range []interface{}{op["consumes"], d.root["consumes"]} {
+		if types := list(source); len(types) > 0 {
+			return str(types[0])
+		}
+	}
+	return "application/json"
+}
+
+// pickMediaType prefers JSON content, then the first declared type
+func pickMediaType(content node) (string, node) {
+	if len(content) == 0 {
+		return "", nil
+	}
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if isJSON(t) {
+			return t, obj(content[t])
+		}
+	}
+	return types[0], obj(content[types[0]])
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(contentType, "json")
+}
+
+// exampleBody renders an example body for a content type, using the media
+// type examples when present and generating one from the schema otherwise
+func (d *Document) exampleBody(contentType string, schema, media node) string {
+	var value interface{}
+	found := false
+	if v, ok := media["example"]; ok {
+		value, found = v, true
+	} else {
+		examples := obj(media["examples"])
+		for _, name := range sortedKeys(examples) {
+			if v, ok := d.resolve(obj(examples[name]))["value"]; ok {
+				value, found = v, true
+				break
+			}
+		}
+	}
+	if !found {
+		value = d.Example(schema)
+	}
+
+	switch {
+	case isJSON(contentType):
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return ""
+		}
+		return string(out)
+	case contentType == "application/x-www-form-urlencoded":
+		form := url.Values{}
+		for k, v := range obj(value) {
+			form.Set(k, scalar(v))
+		}
+		return form.Encode()
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// Example generates an example value for a schema
+func (d *Document) Example(schema node) interface{} {
+	return d.example(schema, 0)
+}
+
+func (d *Document) example(schema node, depth int) interface{} {
+	schema = d.resolve(schema)
+	if len(schema) == 0 || depth > maxExampleDepth {
+		return nil
+	}
+	for _, key := range []string{"example", "default"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum := list(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if all := list(schema["allOf"]); len(all) > 0 {
+		merged := node{}
+		for _, s := range all {
+			for k, v := range obj(d.example(obj(s), depth+1)) {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := list(schema[key]); len(options) > 0 {
+			return d.example(obj(options[0]), depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		out := node{}
+		for name, prop := range obj(schema["properties"]) {
+			out[name] = d.example(obj(prop), depth+1)
+		}
+		return out
+	case "array":
+		return []interface{}{d.example(obj(schema["items"]), depth+1)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch str(schema["format"]) {
+		case "date-time":
+			return "1970-01-01T00:00:00Z"
+		case "date":
+			return "1970-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// schemaType returns the type of a schema, inferring object for schemas
+// that only declare properties. OpenAPI 3.1 type arrays use the first
+// non-null type.
+func schemaType(schema node) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if str(v) != "null" {
+				return str(v)
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// auth maps the first security requirement of an operation to auth settings
+func (d *Document) auth(op node, vars *variables) *model.Auth {
+	requirements, ok := op["security"].([]interface{})
+	if !ok {
+		requirements = list(d.root["security"])
+	}
+
+	schemes := obj(obj(d.root["components"])["securitySchemes"])
+	if d.swagger {
+		schemes = obj(d.root["securityDefinitions"])
+	}
+
+	for _, req := range requirements {
+		for _, name := range sortedKeys(obj(req)) {
+			scheme := d.resolve(obj(schemes[name]))
+			if auth := d.schemeAuth(scheme, vars); auth != nil {
+				return auth
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Document) schemeAuth(scheme node, vars *variables) *model.Auth {
+	switch strings.ToLower(str(scheme["type"])) {
+	case "basic":
+		vars.add("username", "")
+		vars.add("password", "")
+		return &model.Auth{Type: model.AuthBasic, Username: "{{username}}", Password: "{{password}}"}
+	case "http":
+		switch strings.ToLower(str(scheme["scheme"])) {
+		case "basic":
+			vars.add("username", "")
+			vars.add("password", "")
+			return &model.Auth{Type: model.AuthBasic, Username: "{{username}}", Password: "{{password}}"}
+		case "bearer":
+			vars.add("token", "")
+			return &model.Auth{Type: model.AuthBearer, Token: "{{token}}"}
+		}
+	case "apikey":
+		vars.add("apiKey", "")
+		name := str(scheme["name"])
+		switch str(scheme["in"]) {
+		case "query":
+			return &model.Auth{Type: model.AuthAPIKey, Key: name, Value: "{{apiKey}}", In: "query"}
+		case "cookie":
+			return &model.Auth{Type: model.AuthAPIKey, Key: "Cookie", Value: name + "={{apiKey}}", In: "header"}
+		}
+		return &model.Auth{Type: model.AuthAPIKey, Key: name, Value: "{{apiKey}}", In: "header"}
+	case "oauth2", "openidconnect":
+		vars.add("token", "")
+		return &model.Auth{Type: model.AuthBearer, Token: "{{token}}"}
+	}
+	return nil
+}
+
+// baseURL returns the URL of the first server, with server variables set
+// to their defaults
+func (d *Document) baseURL() string {
+	if d.swagger {
+		host := str(d.root["host"])
+		if host == "" {
+			return str(d.root["basePath"])
+		}
+		scheme := "https"
+		if schemes := list(d.root["schemes"]); len(schemes) > 0 {
+			scheme = str(schemes[0])
+		}
+		return strings.TrimSuffix(scheme+"://"+host+str(d.root["basePath"]), "/")
+	}
+
+	servers := list(d.root["servers"])
+	if len(servers) == 0 {
+		return ""
+	}
+	server := obj(servers[0])
+	base := str(server["url"])
+	for name, v := range obj(server["variables"]) {
+		base = strings.ReplaceAll(base, "{"+name+"}", scalar(obj(v)["default"]))
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// resolve follows a local $ref such as #/components/schemas/Pet
+func (d *Document) resolve(n node) node {
+	for i := 0; i < maxExampleDepth; i++ {
+		ref := str(n["$ref"])
+		if !strings.HasPrefix(ref, "#/") {
+			return n
+		}
+		var cur interface{} = d.root
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			cur = obj(cur)[part]
+		}
+		n = obj(cur)
+	}
+	return n
+}
+
+// variables collects collection variables, keeping the first value of each
+type variables struct {
+	list []model.Variable
+	seen map[string]bool
+}
+
+func (v *variables) add(key, value string) {
+	if v.seen == nil {
+		v.seen = make(map[string]bool)
+	}
+	if v.seen[key] {
+		return
+	}
+	v.seen[key] = true
+	v.list = append(v.list, model.Variable{Key: key, Value: value})
+}
+
+func obj(v interface{}) node {
+	if n, ok := v.(node); ok {
+		return n
+	}
+	return nil
+}
+
+// sortedKeys lists the keys of an object in order, so picking the first
+// example or scheme does not depend on map order
+func sortedKeys(n node) []string {
+	keys := make([]string, 0, len(n))
+	for k := range n {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func list(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	return nil
+}
+
+func str(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// scalar renders a value as it would appear in a URL or header
+func scalar(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case node, []interface{}:
+		out, _ := json.Marshal(v)
+		return string(out)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,218 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func findRequest(t *testing.T, c *model.Collection, name string) *model.SavedRequest {
+	t.Helper()
+	for _, e := range c.Entries() {
+		if e.Request.Name == name {
+			return e.Request
+		}
+	}
+	t.Fatalf("request %q not found", name)
+	return nil
+}
+
+func TestToCollection_OpenAPI3(t *testing.T) {
+	doc, err := Load("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := doc.ToCollection()
+
+	if c.Name != "Petstore" {
+		t.Errorf("expected name Petstore, got %s", c.Name)
+	}
+	if len(c.Folders) != 1 || c.Folders[0].Name != "pets" || len(c.Folders[0].Requests) != 3 {
+		t.Fatalf("expected a single pets folder with 3 requests, got %+v", c.Folders)
+	}
+	if len(c.Requests) != 1 || c.Requests[0].Name != "Health check" {
+		t.Errorf("expected untagged health check at the top level, got %+v", c.Requests)
+	}
+
+	vars := c.VariableMap()
+	if vars["baseUrl"] != "https://eu.petstore.example.com/v1" {
+		t.Errorf("unexpected baseUrl %q", vars["baseUrl"])
+	}
+	if vars["limit"] != "20" || vars["petId"] != "rex" {
+		t.Errorf("unexpected parameter variables: %v", vars)
+	}
+
+	list := findRequest(t, c, "List pets")
+	if list.URL != "{{baseUrl}}/pets?limit={{limit}}" {
+		t.Errorf("unexpected URL %q", list.URL)
+	}
+	if len(list.Headers) != 1 || list.Headers[0].Key != "X-Request-ID" {
+		t.Errorf("expected X-Request-ID header, got %v", list.Headers)
+	}
+	if list.Auth == nil || list.Auth.Type != model.AuthBearer || list.Auth.Token != "{{token}}" {
+		t.Errorf("expected bearer auth, got %+v", list.Auth)
+	}
+
+	get := findRequest(t, c, "Get a pet")
+	if get.URL != "{{baseUrl}}/pets/{{petId}}" {
+		t.Errorf("unexpected URL %q", get.URL)
+	}
+	if get.Auth == nil || get.Auth.Type != model.AuthAPIKey || get.Auth.In != "query" || get.Auth.Key != "api_key" {
+		t.Errorf("expected API key auth in query, got %+v", get.Auth)
+	}
+
+	if health := findRequest(t, c, "Health check"); health.Auth != nil {
+		t.Errorf("expected no auth when security is empty, got %+v", health.Auth)
+	}
+}
+
+func TestToCollection_ExampleBody(t *testing.T) {
+	doc, err := Load("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	create := findRequest(t, doc.ToCollection(), "createPet")
+
+	if create.Method != "POST" {
+		t.Errorf("expected POST, got %s", create.Method)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(create.Body), &body); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, create.Body)
+	}
+	if body["name"] != "Rex" || body["kind"] != "dog" {
+		t.Errorf("unexpected body: %v", body)
+	}
+	owner, _ := body["owner"].(map[string]interface{})
+	if owner["email"] != "user@example.com" || owner["since"] != "1970-01-01T00:00:00Z" {
+		t.Errorf("expected allOf properties to be merged, got %v", owner)
+	}
+	if tags, _ := body["tags"].([]interface{}); len(tags) != 1 {
+		t.Errorf("expected array example with one item, got %v", body["tags"])
+	}
+}
+
+func TestToCollection_Swagger2(t *testing.T) {
+	doc, err := Load("testdata/petstore-swagger.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := doc.ToCollection()
+
+	if c.VariableMap()["baseUrl"] != "http://petstore.example.com/api" {
+		t.Errorf("unexpected baseUrl %q", c.VariableMap()["baseUrl"])
+	}
+	update := findRequest(t, c, "Update a pet")
+	if update.URL != "{{baseUrl}}/pets/{{id}}" || c.VariableMap()["id"] != "7" {
+		t.Errorf("unexpected URL %q or id %q", update.URL, c.VariableMap()["id"])
+	}
+	if !strings.Contains(update.Body, `"age": 0`) {
+		t.Errorf("expected generated body, got %s", update.Body)
+	}
+	if update.Auth == nil || update.Auth.Type != model.AuthBasic {
+		t.Errorf("expected basic auth, got %+v", update.Auth)
+	}
+}
+
+func TestToCollection_NamedExamples(t *testing.T) {
+	spec := []byte(`openapi: 3.0.3
+info: {title: Orders}
+components:
+  securitySchemes:
+    token: {type: http, scheme: bearer}
+    apiKey: {type: apiKey, in: header, name: X-API-Key}
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      security:
+        - {token: [], apiKey: []}
+      parameters:
+        - name: region
+          in: query
+          examples:
+            west: {value: us-west}
+            east: {value: us-east}
+            north: {value: eu-north}
+      requestBody:
+        content:
+          application/json:
+            examples:
+              small: {value: {sku: S-1}}
+              large: {value: {sku: L-1}}
+              bulk: {value: {sku: B-1}}
+`)
+	// Map order is random, import several times to catch a changing pick
+	for i := 0; i < 20; i++ {
+		doc, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		c := doc.ToCollection()
+		create := findRequest(t, c, "createOrder")
+		if region := c.VariableMap()["region"]; region != "us-east" {
+			t.Fatalf("expected the first example by name, got %q", region)
+		}
+		if !strings.Contains(create.Body, `"B-1"`) {
+			t.Fatalf("expected the bulk example body, got %s", create.Body)
+		}
+		if create.Auth == nil || create.Auth.Type != model.AuthAPIKey {
+			t.Fatalf("expected the apiKey scheme, got %+v", create.Auth)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte(`{"hello": "world"}`)); err == nil {
+		t.Error("expected error for a document that isn't OpenAPI")
+	}
+	if _, err := Parse([]byte(`openapi: 4.0.0`)); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+func TestMatchOperation(t *testing.T) {
+	doc, err := Load("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		method, url string
+		expected    string
+	}{
+		{"GET", "https://eu.petstore.example.com/v1/pets?limit=5", "GET /pets"},
+		{"get", "https://eu.petstore.example.com/v1/pets/rex", "GET /pets/{petId}"},
+		{"POST", "http://localhost:8080/v1/pets", "POST /pets"},
+		{"GET", "https://eu.petstore.example.com/v1/health", "GET /health"},
+	}
+	for _, test := range tests {
+		op, err := doc.MatchOperation(test.method, test.url)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", test.method, test.url, err)
+			continue
+		}
+		if got := op.Method + " " + op.Path; got != test.expected {
+			t.Errorf("%s %s: expected %s, got %s", test.method, test.url, test.expected, got)
+		}
+	}
+	if _, err := doc.MatchOperation("DELETE", "https://eu.petstore.example.com/v1/pets/rex"); err == nil {
+		t.Error("expected no operation to match DELETE")
+	}
+
+	op, err := doc.FindOperation("createPet")
+	if err != nil || op.Path != "/pets" || op.Method != "POST" {
+		t.Fatalf("expected createPet to be POST /pets, got %+v, %v", op, err)
+	}
+	if op, err := doc.FindOperation("get /pets/{petId}"); err != nil || op.ID != "" {
+		t.Errorf("expected to find GET /pets/{petId}, got %+v, %v", op, err)
+	}
+	if r, ok := op.Response(201); !ok || r.Status != "201" {
+		t.Errorf("expected the 201 response, got %+v", r)
+	}
+	if _, ok := op.Response(500); ok {
+		t.Error("expected no response for 500")
+	}
+}
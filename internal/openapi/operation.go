@@ -0,0 +1,229 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation is an operation of the specification, with the path template
+// it is declared under
+type Operation struct {
+	Method string
+	Path   string
+	ID     string
+
+	doc  *Document
+	item node
+	op   node
+}
+
+// Response is what an operation declares for a status code
+type Response struct {
+	// Status is the key that matched: a code, a range such as 2XX, or default
+	Status string
+	// Content maps the declared media types to their schema, nil when a
+	// type declares no schema
+	Content map[string]interface{}
+	Headers []Header
+}
+
+// Header is a response header declared by an operation
+type Header struct {
+	Name     string
+	Required bool
+	Schema   interface{}
+}
+
+// Root returns the decoded document, which schema references resolve against
+func (d *Document) Root() map[string]interface{} {
+	return d.root
+}
+
+// Operations lists the operations in path order
+func (d *Document) Operations() []*Operation {
+	paths := obj(d.root["paths"])
+	names := make([]string, 0, len(paths))
+	for p := range paths {
+		names = append(names, p)
+	}
+	sort.Strings(names)
+
+	var ops []*Operation
+	for _, path := range names {
+		item := d.resolve(obj(paths[path]))
+		for _, method := range operationMethods {
+			op, ok := item[method].(node)
+			if !ok {
+				continue
+			}
+			ops = append(ops, &Operation{
+				Method: strings.ToUpper(method),
+				Path:   path,
+				ID:     str(op["operationId"]),
+				doc:    d,
+				item:   item,
+				op:     op,
+			})
+		}
+	}
+	return ops
+}
+
+// FindOperation looks an operation up by its operationId, or by method and
+// path template as in "GET /pets/{petId}"
+func (d *Document) FindOperation(ref string) (*Operation, error) {
+	ref = strings.TrimSpace(ref)
+	method, path, _ := strings.Cut(ref, " ")
+	for _, op := range d.Operations() {
+		if op.ID != "" && op.ID == ref {
+			return op, nil
+		}
+		if strings.EqualFold(op.Method, method) && op.Path == strings.TrimSpace(path) {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("no operation %s in the specification", ref)
+}
+
+// MatchOperation finds the operation a request calls from its method and
+// URL. The path of the server URL is stripped first, and literal segments
+// win over templated ones.
+func (d *Document) MatchOperation(method, rawURL string) (*Operation, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Path
+	if base, err := url.Parse(d.baseURL()); err == nil && base.Path != "" && base.Path != "/" {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/"))
+	}
+
+	var best *Operation
+	bestLiterals := -1
+	for _, op := range d.Operations() {
+		if !strings.EqualFold(op.Method, method) {
+			continue
+		}
+		if literals, ok := matchTemplate(op.Path, path); ok && literals > bestLiterals {
+			best, bestLiterals = op, literals
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no operation of the specification matches %s %s", strings.ToUpper(method), path)
+	}
+	return best, nil
+}
+
+// matchTemplate matches a path against a template such as /pets/{petId},
+// and counts the literal segments that matched
+func matchTemplate(template, path string) (int, bool) {
+	want := strings.Split(strings.Trim(template, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return 0, false
+	}
+	literals := 0
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if got[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if segment != got[i] {
+			return 0, false
+		}
+		literals++
+	}
+	return literals, true
+}
+
+// Statuses lists the status keys the operation declares responses for
+func (o *Operation) Statuses() []string {
+	var statuses []string
+	for status := range obj(o.op["responses"]) {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// Response returns the response declared for a status code, trying the
+// exact code, then its range such as 4XX, then default
+func (o *Operation) Response(status int) (*Response, bool) {
+	responses := obj(o.op["responses"])
+	code := strconv.Itoa(status)
+	keys := []string{code, code[:1] + "XX", code[:1] + "xx", "default"}
+	for _, key := range keys {
+		raw, ok := responses[key]
+		if !ok {
+			continue
+		}
+		return o.doc.response(key, o.doc.resolve(obj(raw)), o.op), true
+	}
+	return nil, false
+}
+
+func (d *Document) response(status string, r, op node) *Response {
+	resp := &Response{Status: status, Content: map[string]interface{}{}}
+	if d.swagger {
+		// Swagger 2 declares one schema for all the types the operation
+		// produces
+		if schema, ok := r["schema"]; ok {
+			produces := list(op["produces"])
+			if len(produces) == 0 {
+				produces = list(d.root["produces"])
+			}
+			if len(produces) == 0 {
+				produces = []interface{}{"application/json"}
+			}
+			for _, t := range produces {
+				resp.Content[str(t)] = schema
+			}
+		}
+	} else {
+		for t, media := range obj(r["content"]) {
+			resp.Content[t] = d.resolve(obj(media))["schema"]
+		}
+	}
+
+	for name, raw := range obj(r["headers"]) {
+		h := d.resolve(obj(raw))
+		header := Header{Name: name, Required: h["required"] == true, Schema: h["schema"]}
+		if d.swagger {
+			// Swagger 2 headers are schemas themselves
+			header.Schema = h
+		}
+		resp.Headers = append(resp.Headers, header)
+	}
+	sort.Slice(resp.Headers, func(i, j int) bool { return resp.Headers[i].Name < resp.Headers[j].Name })
+	return resp
+}
+
+// MediaType finds the declared media type a Content-Type matches, allowing
+// wildcards such as application/* and */*
+func (r *Response) MediaType(contentType string) (string, bool) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, candidate := range []string{mediaType, major + "/*", "*/*"} {
+		for t := range r.Content {
+			if strings.ToLower(strings.TrimSpace(strings.Split(t, ";")[0])) == candidate {
+				return t, true
+			}
+		}
+	}
+	return "", false
+}
+
+// MediaTypes lists the declared media types in order
+func (r *Response) MediaTypes() []string {
+	types := make([]string, 0, len(r.Content))
+	for t := range r.Content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
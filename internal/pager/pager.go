@@ -0,0 +1,202 @@
+// Package pager shows a window of a large text. The text is indexed by line
+// once, and only the lines in the window are highlighted and wrapped, so
+// scrolling does not depend on the size of the text.
+package pager
+
+import (
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/text"
+)
+
+// Model is a scrollable window of a text
+type Model struct {
+	Width  int
+	Height int
+	// NoWrap cuts long lines at the width instead of wrapping them
+	NoWrap bool
+	// Highlight colors a line before it is shown, when set
+	Highlight func(line string) string
+
+	content string
+	// starts are the offsets of the lines in content
+	starts []int
+	// line and row are the first row shown: a line, and one of its rows
+	// once wrapped
+	line int
+	row  int
+}
+
+// New returns a pager of the given size
+func New(width, height int) Model {
+	return Model{Width: width, Height: height}
+}
+
+// SetContent replaces the text shown, keeping the position when it still
+// fits
+func (m *Model) SetContent(s string) {
+	s = strings.TrimSuffix(s, "\n")
+	m.content = s
+	m.starts = []int{0}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			m.starts = append(m.starts, i+1)
+		}
+	}
+	m.clamp()
+}
+
+// LineCount returns the number of lines of the text, before wrapping
+func (m Model) LineCount() int {
+	return len(m.starts)
+}
+
+// Line returns a line of the text, as it is stored
+func (m Model) Line(i int) string {
+	end := len(m.content)
+	if i+1 < len(m.starts) {
+		end = m.starts[i+1] - 1
+	}
+	return strings.TrimSuffix(m.content[m.starts[i]:end], "\r")
+}
+
+// rows returns a line highlighted, and wrapped or cut to the width
+func (m Model) rows(i int) []string {
+	line := m.Line(i)
+	if m.Highlight != nil {
+		line = m.Highlight(line)
+	}
+	if m.NoWrap {
+		if m.Width > 0 {
+			line = text.Truncate(line, m.Width)
+		}
+		return []string{line}
+	}
+	return text.WrapLine(line, m.Width)
+}
+
+// bottom returns the first row shown once scrolled to the end
+func (m Model) bottom() (line, row int) {
+	if len(m.starts) == 0 {
+		return 0, 0
+	}
+	line = len(m.starts) - 1
+	row = len(m.rows(line)) - 1
+	for n := 1; n < m.Height; n++ {
+		if row > 0 {
+			row--
+			continue
+		}
+		if line == 0 {
+			break
+		}
+		line--
+		row = len(m.rows(line)) - 1
+	}
+	return line, row
+}
+
+// before tells whether a row comes before another
+func before(line, row, otherLine, otherRow int) bool {
+	return line < otherLine || line == otherLine && row < otherRow
+}
+
+// clamp keeps the position within the text, after it or the size changed
+func (m *Model) clamp() {
+	if len(m.starts) == 0 {
+		m.line, m.row = 0, 0
+		return
+	}
+	if m.line >= len(m.starts) {
+		m.line, m.row = len(m.starts)-1, 0
+	}
+	if rows := len(m.rows(m.line)); m.row >= rows {
+		m.row = rows - 1
+	}
+	if line, row := m.bottom(); before(line, row, m.line, m.row) {
+		m.line, m.row = line, row
+	}
+}
+
+// ScrollDown moves the window down by n rows, up to the end of the text
+func (m *Model) ScrollDown(n int) {
+	m.clamp()
+	line, row := m.bottom()
+	for ; n > 0 && before(m.line, m.row, line, row); n-- {
+		if m.row+1 < len(m.rows(m.line)) {
+			m.row++
+		} else {
+			m.line++
+			m.row = 0
+		}
+	}
+}
+
+// ScrollUp moves the window up by n rows
+func (m *Model) ScrollUp(n int) {
+	m.clamp()
+	for ; n > 0 && (m.line > 0 || m.row > 0); n-- {
+		if m.row > 0 {
+			m.row--
+		} else {
+			m.line--
+			m.row = len(m.rows(m.line)) - 1
+		}
+	}
+}
+
+// HalfPageDown moves the window down by half its height
+func (m *Model) HalfPageDown() {
+	m.ScrollDown(max(m.Height/2, 1))
+}
+
+// HalfPageUp moves the window up by half its height
+func (m *Model) HalfPageUp() {
+	m.ScrollUp(max(m.Height/2, 1))
+}
+
+// GotoTop moves the window to the start of the text
+func (m *Model) GotoTop() {
+	m.line, m.row = 0, 0
+}
+
+// GotoLine moves the window to the start of a line, or as close as the end
+// of the text allows
+func (m *Model) GotoLine(n int) {
+	m.line, m.row = max(n, 0), 0
+	m.clamp()
+}
+
+// GotoBottom moves the window to the end of the text
+func (m *Model) GotoBottom() {
+	m.line, m.row = m.bottom()
+}
+
+// ScrollPercent returns how far the window is into the text, from 0 to 1.
+// It counts lines rather than rows, which would need the whole text wrapped.
+func (m Model) ScrollPercent() float64 {
+	m.clamp()
+	line, row := m.bottom()
+	switch {
+	case !before(m.line, m.row, line, row):
+		return 1
+	case line == 0:
+		return float64(m.row) / float64(row)
+	}
+	return float64(m.line) / float64(line)
+}
+
+// View renders the rows in the window, padded to its height
+func (m Model) View() string {
+	m.clamp()
+	shown := make([]string, 0, max(m.Height, 0))
+	for line, row := m.line, m.row; len(shown) < m.Height && line < len(m.starts); line++ {
+		rows := m.rows(line)
+		shown = append(shown, rows[row:min(len(rows), row+m.Height-len(shown))]...)
+		row = 0
+	}
+	for len(shown) < m.Height {
+		shown = append(shown, "")
+	}
+	return strings.Join(shown, "\n")
+}
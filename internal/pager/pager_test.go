@@ -0,0 +1,73 @@
+package pager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 500000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	p := New(20, 3)
+	p.SetContent(b.String())
+	if p.LineCount() != 500000 {
+		t.Fatalf("expected 500000 lines, got %d", p.LineCount())
+	}
+	if view := p.View(); view != "line 0\nline 1\nline 2" {
+		t.Errorf("unexpected top view %q", view)
+	}
+	if p.ScrollPercent() != 0 {
+		t.Errorf("expected 0 at the top, got %f", p.ScrollPercent())
+	}
+
+	p.ScrollDown(2)
+	p.HalfPageDown()
+	if view := p.View(); view != "line 3\nline 4\nline 5" {
+		t.Errorf("unexpected view after scrolling %q", view)
+	}
+	p.GotoBottom()
+	if view := p.View(); view != "line 499997\nline 499998\nline 499999" {
+		t.Errorf("unexpected bottom view %q", view)
+	}
+	p.ScrollDown(10)
+	if p.ScrollPercent() != 1 {
+		t.Errorf("expected 1 at the bottom, got %f", p.ScrollPercent())
+	}
+	p.ScrollUp(1)
+	if view := p.View(); view != "line 499996\nline 499997\nline 499998" {
+		t.Errorf("unexpected view after scrolling up %q", view)
+	}
+}
+
+func TestPager_Wrap(t *testing.T) {
+	p := New(10, 3)
+	p.SetContent("short\nthis line is long enough to wrap\nend")
+	p.Highlight = strings.ToUpper
+	if view := p.View(); view != "SHORT\nTHIS LINE \nIS LONG " {
+		t.Errorf("unexpected wrapped view %q", view)
+	}
+	p.ScrollDown(2)
+	if view := p.View(); view != "IS LONG \nENOUGH TO \nWRAP" {
+		t.Errorf("expected to scroll through the rows of a line, got %q", view)
+	}
+	p.GotoBottom()
+	if view := p.View(); view != "ENOUGH TO \nWRAP\nEND" {
+		t.Errorf("unexpected bottom view %q", view)
+	}
+
+	p.NoWrap = true
+	if view := p.View(); view != "SHORT\nTHIS LINE \nEND" {
+		t.Errorf("expected long lines to be cut, got %q", view)
+	}
+
+	p.SetContent("one line")
+	if view := p.View(); view != "ONE LINE\n\n" {
+		t.Errorf("expected the view to be padded, got %q", view)
+	}
+	if p.ScrollPercent() != 1 {
+		t.Errorf("expected 1 when the text fits, got %f", p.ScrollPercent())
+	}
+}
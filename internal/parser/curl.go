@@ -1,94 +1,373 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tbourrel/apitty/internal/model"
 )
 
 // ParseCurlCommand parses a curl command and returns method, URL, and headers
 func ParseCurlCommand(curlCmd string) (method string, url string, headers []model.HeaderPair) {
-	// Remove leading "curl " and trim
-	curlCmd = strings.TrimSpace(curlCmd)
-	if strings.HasPrefix(curlCmd, "curl ") {
-		curlCmd = strings.TrimSpace(curlCmd[5:])
+	r, _ := ParseCurl(curlCmd)
+	if r.Method == "" {
+		return "GET", "", []model.HeaderPair{}
 	}
+	return r.Method, r.URL, r.Headers
+}
+
+// curlShortOptions maps the short options of curl to their long name
+var curlShortOptions = map[byte]string{
+	'X': "--request", 'H': "--header", 'd': "--data", 'u': "--user",
+	'b': "--cookie", 'A': "--user-agent", 'e': "--referer", 'E': "--cert",
+	'F': "--form", 'T': "--upload-file", 'm': "--max-time", 'k': "--insecure",
+	'L': "--location", 'G': "--get", 'I': "--head",
+	// Options that take a value but do not change the request
+	'o': "--output", 'x': "--proxy", 'w': "--write-out", 'c': "--cookie-jar",
+	'K': "--config", 'r': "--range", 'U': "--proxy-user", 'Y': "--speed-limit",
+	'y': "--speed-time", 'z': "--time-cond", 'C': "--continue-at", 'Q': "--quote",
+	'P': "--ftp-port", 't': "--telnet-option", 'D': "--dump-header",
+}
 
-	// Parse using a simple state machine
-	inQuote := false
-	inSingleQuote := false
-	var currentArg strings.Builder
-	var args []string
+// curlValueOptions lists the long options that take a value. Any other
+// option is a switch, so the argument after it is left alone.
+var curlValueOptions = map[string]bool{
+	"--request": true, "--header": true, "--data": true, "--data-ascii": true,
+	"--data-binary": true, "--data-raw": true, "--data-urlencode": true, "--json": true,
+	"--url": true, "--user": true, "--oauth2-bearer": true, "--cookie": true,
+	"--user-agent": true, "--referer": true, "--cert": true, "--key": true,
+	"--resolve": true, "--max-time": true, "--form": true, "--form-string": true,
+	"--upload-file": true, "--output": true, "--proxy": true, "--write-out": true,
+	"--cookie-jar": true, "--config": true, "--range": true, "--proxy-user": true,
+	"--speed-limit": true, "--speed-time": true, "--time-cond": true, "--continue-at": true,
+	"--quote": true, "--ftp-port": true, "--telnet-option": true, "--dump-header": true,
+	"--connect-timeout": true, "--cacert": true, "--capath": true, "--cert-type": true,
+	"--key-type": true, "--pass": true, "--ciphers": true, "--connect-to": true,
+	"--retry": true, "--retry-delay": true, "--retry-max-time": true, "--max-redirs": true,
+	"--max-filesize": true, "--limit-rate": true, "--interface": true, "--local-port": true,
+	"--dns-servers": true, "--unix-socket": true, "--abstract-unix-socket": true,
+	"--aws-sigv4": true, "--expect100-timeout": true, "--keepalive-time": true,
+	"--proxy-header": true, "--noproxy": true, "--output-dir": true, "--stderr": true,
+	"--trace": true, "--trace-ascii": true, "--variable": true, "--url-query": true,
+	"--tls-max": true, "--curves": true, "--happy-eyeballs-timeout-ms": true,
+	"--preproxy": true, "--proxy-cacert": true, "--proxy-cert": true, "--proxy-key": true,
+	"--socks5": true, "--socks5-hostname": true, "--login-options": true, "--sasl-authzid": true,
+	"--hsts": true, "--alt-svc": true, "--etag-compare": true, "--etag-save": true,
+	"--request-target": true, "--mail-from": true, "--mail-rcpt": true, "--netrc-file": true,
+	"--ip-tos": true, "--vlan-priority": true, "--create-file-mode": true,
+}
 
-	for i := 0; i < len(curlCmd); i++ {
-		ch := curlCmd[i]
+// curlOption is an option of a curl command and its value, if any
+type curlOption struct {
+	name  string
+	value string
+}
 
+// splitCurlOptions separates the options of a curl command, keyed by their
+// long name, from its URLs. Short options may be grouped (-sSL) and carry
+// their value (-XPOST).
+func splitCurlOptions(args []string) ([]curlOption, []string, error) {
+	var options []curlOption
+	var urls []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch {
-		case ch == '"' && !inSingleQuote:
-			inQuote = !inQuote
-		case ch == '\'' && !inQuote:
-			inSingleQuote = !inSingleQuote
-		case ch == ' ' && !inQuote && !inSingleQuote:
-			if currentArg.Len() > 0 {
-				args = append(args, currentArg.String())
-				currentArg.Reset()
+		case arg == "--":
+			return options, append(urls, args[i+1:]...), nil
+		case strings.HasPrefix(arg, "--"):
+			opt := curlOption{name: arg}
+			if curlValueOptions[arg] {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("%s needs a value", arg)
+				}
+				i++
+				opt.value = args[i]
+			}
+			options = append(options, opt)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j := 1; j < len(arg); j++ {
+				name, known := curlShortOptions[arg[j]]
+				if !known {
+					name = "-" + arg[j:j+1]
+				}
+				if !curlValueOptions[name] {
+					options = append(options, curlOption{name: name})
+					continue
+				}
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 >= len(args) {
+						return nil, nil, fmt.Errorf("-%c needs a value", arg[j])
+					}
+					i++
+					value = args[i]
+				}
+				options = append(options, curlOption{name: name, value: value})
+				break
 			}
-		case ch == '\\' && i+1 < len(curlCmd):
-			// Handle escape sequences
-			i++
-			currentArg.WriteByte(curlCmd[i])
 		default:
-			currentArg.WriteByte(ch)
+			urls = append(urls, arg)
 		}
 	}
+	return options, urls, nil
+}
 
-	if currentArg.Len() > 0 {
-		args = append(args, currentArg.String())
+// ParseCurl parses a curl command into a request. Data options become the
+// body and switch the method to POST unless -X says otherwise, and
+// transport options such as -k, --max-time or --resolve become settings.
+// Redirects are only followed with -L, as in curl.
+func ParseCurl(curlCmd string) (model.Request, error) {
+	args, err := splitShell(strings.TrimSpace(curlCmd))
+	if err != nil {
+		return model.Request{}, err
+	}
+	if len(args) > 0 && (args[0] == "curl" || args[0] == "curl.exe") {
+		args = args[1:]
+	}
+	options, urls, err := splitCurlOptions(args)
+	if err != nil {
+		return model.Request{}, err
 	}
 
-	// Parse arguments
-	method = "GET"
-	url = ""
-	headerMap := make(map[string]string)
-
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
+	r := model.Request{Headers: []model.HeaderPair{}}
+	if len(urls) > 0 {
+		r.URL = urls[0]
+	}
+	var settings model.Settings
+	var data, cookies, cookieFiles []string
+	isJSON, get, head, location := false, false, false, false
+	upload := ""
 
-		switch {
-		case arg == "-X" || arg == "--request":
-			if i+1 < len(args) {
-				method = strings.ToUpper(args[i+1])
-				i++
+	for _, opt := range options {
+		v := opt.value
+		switch opt.name {
+		case "--request":
+			r.Method = strings.ToUpper(v)
+		case "--header":
+			if h, ok := splitHeader(v); ok {
+				r.Headers = append(r.Headers, h)
 			}
-		case arg == "-H" || arg == "--header":
-			if i+1 < len(args) {
-				headerStr := args[i+1]
-				parts := strings.SplitN(headerStr, ":", 2)
-				if len(parts) == 2 {
-					headerMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		case "--data", "--data-ascii", "--data-binary":
+			if name, found := strings.CutPrefix(v, "@"); found {
+				content, err := readDataFile(name)
+				if err != nil {
+					return r, err
 				}
-				i++
+				// Only --data-binary keeps the file's line breaks
+				if opt.name != "--data-binary" {
+					content = strings.NewReplacer("\r", "", "\n", "").Replace(content)
+				}
+				v = content
 			}
-		case strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://"):
-			url = arg
-		case strings.HasPrefix(arg, "-"):
-			// Skip other flags we don't handle
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-				i++ // Skip the value too
+			data = append(data, v)
+		case "--data-raw":
+			data = append(data, v)
+		case "--data-urlencode":
+			// content, =content, name=content, @file or name@file
+			name, content := "", v
+			if idx := strings.IndexAny(v, "=@"); idx >= 0 {
+				name, content = v[:idx], v[idx+1:]
+				if v[idx] == '@' {
+					fileContent, err := readDataFile(content)
+					if err != nil {
+						return r, err
+					}
+					content = fileContent
+				}
 			}
-		default:
-			// If we haven't found a URL yet and this doesn't start with -, it might be the URL
-			if url == "" && !strings.HasPrefix(arg, "-") {
-				url = arg
+			if name != "" {
+				data = append(data, name+"="+url.QueryEscape(content))
+			} else {
+				data = append(data, url.QueryEscape(content))
+			}
+		case "--json":
+			if name, found := strings.CutPrefix(v, "@"); found {
+				content, err := readDataFile(name)
+				if err != nil {
+					return r, err
+				}
+				v = content
 			}
+			data = append(data, v)
+			isJSON = true
+		case "--upload-file":
+			upload = v
+		case "--form", "--form-string":
+			return r, errors.New("multipart forms (-F) are not supported, send the body with -d instead")
+		case "--url":
+			r.URL = v
+		case "--get":
+			get = true
+		case "--head":
+			head = true
+		case "--user":
+			user, pass, _ := strings.Cut(v, ":")
+			r.Auth = &model.Auth{Type: model.AuthBasic, Username: user, Password: pass}
+		case "--oauth2-bearer":
+			r.Auth = &model.Auth{Type: model.AuthBearer, Token: v}
+		case "--cookie":
+			switch {
+			case strings.Contains(v, "="):
+				cookies = append(cookies, v)
+			case v != "":
+				// Without a = the value is a cookie file
+				cookieFiles = append(cookieFiles, v)
+			}
+		case "--user-agent":
+			r.Headers = append(r.Headers, model.HeaderPair{Key: "User-Agent", Value: v})
+		case "--referer":
+			// ";auto" only matters when following redirects
+			if ref, _, _ := strings.Cut(v, ";auto"); ref != "" {
+				r.Headers = append(r.Headers, model.HeaderPair{Key: "Referer", Value: ref})
+			}
+		case "--insecure":
+			settings.Insecure = true
+		case "--location", "--location-trusted":
+			location = true
+		case "--compressed":
+			settings.Compressed = true
+		case "--cert":
+			settings.CertFile = certPath(v)
+		case "--key":
+			settings.KeyFile = v
+		case "--resolve":
+			settings.Resolve = append(settings.Resolve, strings.TrimPrefix(v, "+"))
+		case "--max-time":
+			seconds, err := strconv.ParseFloat(v, 64)
+			if err != nil || seconds < 0 {
+				return r, fmt.Errorf("--max-time: invalid number of seconds %q", v)
+			}
+			settings.TimeoutMs = int(seconds * 1000)
+		}
+	}
+
+	if r.URL == "" {
+		return r, errors.New("no URL found in curl command")
+	}
+
+	for _, name := range cookieFiles {
+		fileCookies, err := readCookieFile(name, r.URL)
+		if err != nil {
+			return r, err
+		}
+		cookies = append(cookies, fileCookies...)
+	}
+	if len(cookies) > 0 {
+		r.Headers = append(r.Headers, model.HeaderPair{Key: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+
+	switch {
+	case get && len(data) > 0:
+		// -G sends the data as the query string
+		sep := "?"
+		if strings.Contains(r.URL, "?") {
+			sep = "&"
+		}
+		r.URL += sep + strings.Join(data, "&")
+	case len(data) > 0:
+		r.Body = strings.Join(data, "&")
+		if r.Method == "" {
+			r.Method = "POST"
+		}
+		if isJSON {
+			r.Headers = setDefaultHeader(r.Headers, "Content-Type", "application/json")
+			r.Headers = setDefaultHeader(r.Headers, "Accept", "application/json")
+		} else {
+			r.Headers = setDefaultHeader(r.Headers, "Content-Type", "application/x-www-form-urlencoded")
+		}
+	case upload != "":
+		content, err := readDataFile(upload)
+		if err != nil {
+			return r, err
+		}
+		r.Body = content
+		if r.Method == "" {
+			r.Method = "PUT"
+		}
+	}
+	if r.Method == "" {
+		r.Method = "GET"
+		if head {
+			r.Method = "HEAD"
+		}
+	}
+	// curl returns redirect responses as they are unless -L is given
+	settings.NoRedirects = !location
+	if !settings.IsZero() {
+		r.Settings = &settings
+	}
+	return r, nil
+}
+
+// certPath drops the :password suffix curl accepts after a certificate
+// file, keeping Windows drive letters
+func certPath(v string) string {
+	if len(v) > 2 && v[1] == ':' && (v[2] == '\\' || v[2] == '/') {
+		if idx := strings.IndexByte(v[2:], ':'); idx >= 0 {
+			return v[:idx+2]
 		}
+		return v
+	}
+	path, _, _ := strings.Cut(v, ":")
+	return path
+}
+
+// readCookieFile reads the cookies of a Netscape cookie file, as written by
+// curl -c, that would be sent to rawURL
+func readCookieFile(name, rawURL string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading cookie file: %w", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Hostname()
+	path := u.Path
+	if path == "" {
+		path = "/"
 	}
 
-	// Convert header map to slice
-	headers = make([]model.HeaderPair, 0, len(headerMap))
-	for key, val := range headerMap {
-		headers = append(headers, model.HeaderPair{Key: key, Value: val})
+	var cookies []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#HttpOnly_"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		domain, cookiePath, secure, expires := fields[0], fields[2], fields[3], fields[4]
+		bare := strings.TrimPrefix(domain, ".")
+		if host != bare && !strings.HasSuffix(host, "."+bare) {
+			continue
+		}
+		if !strings.HasPrefix(path, cookiePath) || secure == "TRUE" && u.Scheme != "https" {
+			continue
+		}
+		if exp, err := strconv.ParseInt(expires, 10, 64); err == nil && exp > 0 && time.Unix(exp, 0).Before(time.Now()) {
+			continue
+		}
+		cookies = append(cookies, fields[5]+"="+fields[6])
 	}
+	return cookies, nil
+}
 
-	return method, url, headers
+// readDataFile reads the file of a @file data argument
+func readDataFile(name string) (string, error) {
+	if name == "-" {
+		return "", errors.New("@- reads from stdin, paste the data instead")
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("reading @%s: %w", name, err)
+	}
+	return string(data), nil
 }
@@ -0,0 +1,516 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// ParseFetch parses a JavaScript fetch() call, as produced by the browser's
+// "Copy as fetch", into a request
+func ParseFetch(input string) (model.Request, error) {
+	start := strings.Index(input, "fetch(")
+	if start < 0 {
+		return model.Request{}, errors.New("no fetch( call found")
+	}
+	p := &jsParser{s: input, pos: start + len("fetch(")}
+
+	target, err := p.value()
+	if err != nil {
+		return model.Request{}, err
+	}
+	rawURL, ok := target.(string)
+	if !ok || rawURL == "" {
+		return model.Request{}, errors.New("the first fetch argument must be a URL string")
+	}
+	r := model.Request{Method: "GET", URL: rawURL, Headers: []model.HeaderPair{}}
+
+	p.skip()
+	if !p.consume(',') {
+		return r, nil
+	}
+	p.skip()
+	if p.peek() == ')' {
+		return r, nil
+	}
+	opts, err := p.value()
+	if err != nil {
+		return model.Request{}, err
+	}
+	options, ok := opts.(jsObject)
+	if !ok {
+		return model.Request{}, errors.New("the second fetch argument must be an options object")
+	}
+
+	for _, f := range options {
+		switch f.key {
+		case "method":
+			method, ok := f.value.(string)
+			if !ok {
+				return model.Request{}, errors.New("method must be a string")
+			}
+			r.Method = strings.ToUpper(method)
+		case "headers":
+			headers, err := jsHeaders(f.value)
+			if err != nil {
+				return model.Request{}, err
+			}
+			r.Headers = append(r.Headers, headers...)
+		case "body":
+			switch body := f.value.(type) {
+			case nil:
+			case string:
+				r.Body = body
+			case jsForm:
+				r.Body = string(body)
+				r.Headers = setDefaultHeader(r.Headers, "Content-Type", "application/x-www-form-urlencoded")
+			default:
+				return model.Request{}, errors.New("body must be a string, JSON.stringify() or URLSearchParams")
+			}
+		}
+	}
+	return r, nil
+}
+
+// jsHeaders converts a headers object, a Headers instance or a list of
+// pairs into header pairs
+func jsHeaders(v any) ([]model.HeaderPair, error) {
+	var headers []model.HeaderPair
+	switch v := v.(type) {
+	case nil:
+	case jsObject:
+		for _, f := range v {
+			headers = append(headers, model.HeaderPair{Key: f.key, Value: jsText(f.value)})
+		}
+	case []any:
+		for _, item := range v {
+			pair, ok := item.([]any)
+			if !ok || len(pair) != 2 {
+				return nil, errors.New("header lists must contain [name, value] pairs")
+			}
+			headers = append(headers, model.HeaderPair{Key: jsText(pair[0]), Value: jsText(pair[1])})
+		}
+	default:
+		return nil, errors.New("headers must be an object")
+	}
+	return headers, nil
+}
+
+// jsField is a property of an object literal, kept in source order
+type jsField struct {
+	key   string
+	value any
+}
+
+// jsObject is an object literal
+type jsObject []jsField
+
+// jsNumber is a number literal, kept as written
+type jsNumber string
+
+// jsForm is an URLSearchParams body, already encoded
+type jsForm string
+
+// jsParser reads the subset of JavaScript literals found in fetch() calls:
+// strings, numbers, objects, arrays, JSON.stringify() and URLSearchParams
+type jsParser struct {
+	s   string
+	pos int
+}
+
+func (p *jsParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jsParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *jsParser) consume(ch byte) bool {
+	if p.peek() == ch {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skip moves past white space and comments
+func (p *jsParser) skip() {
+	for p.pos < len(p.s) {
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], "//"):
+			if end := strings.IndexByte(p.s[p.pos:], '\n'); end >= 0 {
+				p.pos += end
+			} else {
+				p.pos = len(p.s)
+			}
+		case strings.HasPrefix(p.s[p.pos:], "/*"):
+			if end := strings.Index(p.s[p.pos+2:], "*/"); end >= 0 {
+				p.pos += end + 4
+			} else {
+				p.pos = len(p.s)
+			}
+		case p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsParser) value() (any, error) {
+	p.skip()
+	switch ch := p.peek(); {
+	case ch == 0:
+		return nil, p.errorf("unexpected end of input")
+	case ch == '"' || ch == '\'' || ch == '`':
+		return p.string()
+	case ch == '{':
+		return p.object()
+	case ch == '[':
+		return p.array()
+	case ch == '-' || ch >= '0' && ch <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.s) && strings.IndexByte("0123456789.eE+-", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		return jsNumber(p.s[start:p.pos]), nil
+	case isIdentStart(ch):
+		return p.expression()
+	default:
+		return nil, p.errorf("unexpected %q", ch)
+	}
+}
+
+// expression handles the keywords and calls allowed as values
+func (p *jsParser) expression() (any, error) {
+	start := p.pos
+	ident := p.identifier()
+	for p.peek() == '.' {
+		p.pos++
+		ident += "." + p.identifier()
+	}
+
+	switch ident {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "undefined":
+		return nil, nil
+	case "JSON.stringify":
+		arg, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		return jsonText(arg), nil
+	case "new":
+		p.skip()
+		class := p.identifier()
+		arg, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		switch class {
+		case "Headers":
+			return arg, nil
+		case "URLSearchParams":
+			return urlSearchParams(arg)
+		}
+		return nil, fmt.Errorf("offset %d: unsupported constructor %q", start, class)
+	}
+	return nil, fmt.Errorf("offset %d: unsupported expression %q", start, ident)
+}
+
+// call reads a parenthesized single argument
+func (p *jsParser) call() (any, error) {
+	p.skip()
+	if !p.consume('(') {
+		return nil, p.errorf("expected (")
+	}
+	p.skip()
+	if p.consume(')') {
+		return nil, nil
+	}
+	arg, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if !p.consume(')') {
+		return nil, p.errorf("expected )")
+	}
+	return arg, nil
+}
+
+func (p *jsParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.s) && (isIdentStart(p.s[p.pos]) || p.s[p.pos] >= '0' && p.s[p.pos] <= '9') {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func (p *jsParser) string() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		switch {
+		case ch == quote:
+			p.pos++
+			return b.String(), nil
+		case ch == '$' && quote == '`' && strings.HasPrefix(p.s[p.pos:], "${"):
+			return "", p.errorf("template expressions are not supported")
+		case ch == '\n' && quote != '`':
+			return "", p.errorf("unterminated string")
+		case ch == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+			continue
+		default:
+			b.WriteByte(ch)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// escape decodes the escape sequence at p.pos, just after the backslash
+func (p *jsParser) escape(b *strings.Builder) error {
+	ch := p.s[p.pos]
+	p.pos++
+	switch ch {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'v':
+		b.WriteByte('\v')
+	case '0':
+		b.WriteByte(0)
+	case '\n':
+		// Line continuation
+	case 'x', 'u':
+		digits := 2
+		if ch == 'u' {
+			digits = 4
+			if p.peek() == '{' {
+				end := strings.IndexByte(p.s[p.pos:], '}')
+				if end < 0 {
+					return p.errorf("invalid unicode escape")
+				}
+				n, err := strconv.ParseUint(p.s[p.pos+1:p.pos+end], 16, 32)
+				if err != nil {
+					return p.errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(n))
+				p.pos += end + 1
+				return nil
+			}
+		}
+		if p.pos+digits > len(p.s) {
+			return p.errorf("invalid escape")
+		}
+		n, err := strconv.ParseUint(p.s[p.pos:p.pos+digits], 16, 32)
+		if err != nil {
+			return p.errorf("invalid escape")
+		}
+		p.pos += digits
+		r := rune(n)
+		// Join UTF-16 surrogate pairs
+		if utf16High(r) && strings.HasPrefix(p.s[p.pos:], "\\u") && p.pos+6 <= len(p.s) {
+			if low, err := strconv.ParseUint(p.s[p.pos+2:p.pos+6], 16, 32); err == nil && utf16Low(rune(low)) {
+				r = (r-0xD800)<<10 + (rune(low) - 0xDC00) + 0x10000
+				p.pos += 6
+			}
+		}
+		if !utf8.ValidRune(r) {
+			r = unicode.ReplacementChar
+		}
+		b.WriteRune(r)
+	default:
+		b.WriteByte(ch)
+	}
+	return nil
+}
+
+func utf16High(r rune) bool { return r >= 0xD800 && r < 0xDC00 }
+func utf16Low(r rune) bool  { return r >= 0xDC00 && r < 0xE000 }
+
+func (p *jsParser) object() (jsObject, error) {
+	p.pos++ // {
+	obj := jsObject{}
+	for {
+		p.skip()
+		if p.consume('}') {
+			return obj, nil
+		}
+
+		var key string
+		switch ch := p.peek(); {
+		case ch == '"' || ch == '\'':
+			k, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		case isIdentStart(ch) || ch >= '0' && ch <= '9':
+			key = p.identifier()
+		default:
+			return nil, p.errorf("expected a property name")
+		}
+
+		p.skip()
+		if !p.consume(':') {
+			return nil, p.errorf("expected : after %q", key)
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, jsField{key: key, value: v})
+
+		p.skip()
+		if !p.consume(',') {
+			p.skip()
+			if !p.consume('}') {
+				return nil, p.errorf("expected , or }")
+			}
+			return obj, nil
+		}
+	}
+}
+
+func (p *jsParser) array() ([]any, error) {
+	p.pos++ // [
+	list := []any{}
+	for {
+		p.skip()
+		if p.consume(']') {
+			return list, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+
+		p.skip()
+		if !p.consume(',') {
+			p.skip()
+			if !p.consume(']') {
+				return nil, p.errorf("expected , or ]")
+			}
+			return list, nil
+		}
+	}
+}
+
+// jsText returns the string form of a header or form value
+func jsText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case jsNumber:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	}
+	return jsonText(v)
+}
+
+// jsonText serializes a literal the way JSON.stringify would, keeping the
+// property order
+func jsonText(v any) string {
+	var b strings.Builder
+	writeJSON(&b, v)
+	return b.String()
+}
+
+func writeJSON(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case jsNumber:
+		b.WriteString(string(v))
+	case string:
+		b.WriteString(jsonString(v))
+	case jsForm:
+		writeJSON(b, string(v))
+	case jsObject:
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSON(b, f.key)
+			b.WriteByte(':')
+			writeJSON(b, f.value)
+		}
+		b.WriteByte('}')
+	case []any:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSON(b, item)
+		}
+		b.WriteByte(']')
+	}
+}
+
+// urlSearchParams encodes the argument of new URLSearchParams()
+func urlSearchParams(v any) (jsForm, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return jsForm(strings.TrimPrefix(v, "?")), nil
+	case jsObject:
+		var pairs []string
+		for _, f := range v {
+			pairs = append(pairs, url.QueryEscape(f.key)+"="+url.QueryEscape(jsText(f.value)))
+		}
+		return jsForm(strings.Join(pairs, "&")), nil
+	case []any:
+		var pairs []string
+		for _, item := range v {
+			pair, ok := item.([]any)
+			if !ok || len(pair) != 2 {
+				return "", errors.New("URLSearchParams lists must contain [name, value] pairs")
+			}
+			pairs = append(pairs, url.QueryEscape(jsText(pair[0]))+"="+url.QueryEscape(jsText(pair[1])))
+		}
+		return jsForm(strings.Join(pairs, "&")), nil
+	}
+	return "", errors.New("unsupported URLSearchParams argument")
+}
@@ -0,0 +1,210 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// httpieValueFlags lists the HTTPie options that take a value
+var httpieValueFlags = map[string]bool{
+	"--auth": true, "-a": true, "--auth-type": true, "-A": true,
+	"--session": true, "--session-read-only": true,
+	"--output": true, "-o": true, "--print": true, "-p": true,
+	"--pretty": true, "--style": true, "-s": true, "--format-options": true,
+	"--verify": true, "--cert": true, "--cert-key": true, "--cert-key-pass": true,
+	"--proxy": true, "--timeout": true, "--max-redirects": true,
+	"--boundary": true, "--response-charset": true, "--response-mime": true,
+	"--ssl": true, "--ciphers": true, "--default-scheme": true,
+	"--unix-socket": true, "--chunked-size": true, "--raw": true,
+}
+
+// ParseHTTPie parses an HTTPie command line (http, https or httpie) into a
+// request, building a JSON or form body from its request items
+func ParseHTTPie(cmd string) (model.Request, error) {
+	args, err := splitShell(strings.TrimSpace(cmd))
+	if err != nil {
+		return model.Request{}, err
+	}
+	if len(args) == 0 {
+		return model.Request{}, errors.New("empty command")
+	}
+
+	scheme := "http://"
+	switch args[0] {
+	case "https":
+		scheme = "https://"
+		args = args[1:]
+	case "http":
+		args = args[1:]
+	case "httpie":
+		// httpie http ... is the long form
+		args = args[1:]
+		if len(args) > 0 && (args[0] == "http" || args[0] == "https") {
+			if args[0] == "https" {
+				scheme = "https://"
+			}
+			args = args[1:]
+		}
+	}
+
+	r := model.Request{Headers: []model.HeaderPair{}}
+	form := false
+	var raw *string
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && httpieValueFlags[name] {
+			if i+1 >= len(args) {
+				return model.Request{}, fmt.Errorf("%s needs a value", name)
+			}
+			i++
+			value, hasValue = args[i], true
+		}
+
+		switch name {
+		case "--form", "-f", "--multipart":
+			form = true
+		case "--json", "-j":
+			form = false
+		case "--raw":
+			raw = &value
+		case "--auth", "-a":
+			user, pass, _ := strings.Cut(value, ":")
+			r.Auth = &model.Auth{Type: model.AuthBasic, Username: user, Password: pass}
+		}
+	}
+
+	// The method is optional and comes before the URL
+	if len(positional) > 1 && isMethod(positional[0]) {
+		r.Method = positional[0]
+		positional = positional[1:]
+	}
+	if len(positional) == 0 {
+		return model.Request{}, errors.New("no URL found in HTTPie command")
+	}
+	r.URL = httpieURL(positional[0], scheme)
+
+	var query []string
+	var fields []string
+	for _, item := range positional[1:] {
+		key, sep, value := splitItem(item)
+		switch sep {
+		case "==":
+			query = append(query, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		case ":":
+			r.Headers = append(r.Headers, model.HeaderPair{Key: key, Value: value})
+		case ";":
+			r.Headers = append(r.Headers, model.HeaderPair{Key: key, Value: ""})
+		case "=":
+			if form {
+				fields = append(fields, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			} else {
+				fields = append(fields, jsonString(key)+": "+jsonString(value))
+			}
+		case ":=":
+			if form {
+				return model.Request{}, fmt.Errorf("raw JSON field %q cannot be sent as a form", key)
+			}
+			if !json.Valid([]byte(value)) {
+				return model.Request{}, fmt.Errorf("field %q is not valid JSON: %s", key, value)
+			}
+			fields = append(fields, jsonString(key)+": "+value)
+		case "@", "=@", ":=@":
+			return model.Request{}, fmt.Errorf("file item %q is not supported, paste the file content instead", item)
+		default:
+			return model.Request{}, fmt.Errorf("unrecognized request item %q", item)
+		}
+	}
+
+	if len(query) > 0 {
+		if strings.Contains(r.URL, "?") {
+			r.URL += "&" + strings.Join(query, "&")
+		} else {
+			r.URL += "?" + strings.Join(query, "&")
+		}
+	}
+
+	switch {
+	case raw != nil:
+		r.Body = *raw
+	case len(fields) > 0 && form:
+		r.Body = strings.Join(fields, "&")
+		r.Headers = setDefaultHeader(r.Headers, "Content-Type", "application/x-www-form-urlencoded")
+	case len(fields) > 0:
+		r.Body = "{" + strings.Join(fields, ", ") + "}"
+		r.Headers = setDefaultHeader(r.Headers, "Content-Type", "application/json")
+	}
+	if raw != nil && !form {
+		r.Headers = setDefaultHeader(r.Headers, "Content-Type", "application/json")
+	}
+
+	if r.Method == "" {
+		r.Method = "GET"
+		if r.Body != "" {
+			r.Method = "POST"
+		}
+	}
+	return r, nil
+}
+
+// isMethod reports whether s is an HTTP method name
+func isMethod(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// httpieURL expands HTTPie's URL shorthands: ":3000/path" for localhost and
+// a missing scheme
+func httpieURL(raw, scheme string) string {
+	switch {
+	case raw == ":":
+		return scheme + "localhost"
+	case strings.HasPrefix(raw, ":/"):
+		return scheme + "localhost" + raw[1:]
+	case strings.HasPrefix(raw, ":"):
+		return scheme + "localhost" + raw
+	case strings.Contains(raw, "://"):
+		return raw
+	}
+	return scheme + raw
+}
+
+// splitItem splits a request item on its separator. The separator that
+// appears first wins, preferring the longest one at the same position.
+func splitItem(item string) (key, sep, value string) {
+	separators := []string{":=@", "==", "=@", ":=", "=", ":", ";", "@"}
+	best := -1
+	for _, s := range separators {
+		idx := strings.Index(item, s)
+		if idx > 0 && (best < 0 || idx < best || idx == best && len(s) > len(sep)) {
+			best, sep = idx, s
+		}
+	}
+	if best < 0 {
+		return item, "", ""
+	}
+	key, value = item[:best], item[best+len(sep):]
+	if sep == ";" && value != "" {
+		return item, "", ""
+	}
+	return key, sep, value
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// Format identifies the syntax of an imported command
+type Format string
+
+const (
+	FormatCurl       Format = "cURL"
+	FormatFetch      Format = "fetch"
+	FormatHTTPie     Format = "HTTPie"
+	FormatPowerShell Format = "PowerShell"
+)
+
+// ErrUnknownFormat is returned when the input is not a command we can import
+var ErrUnknownFormat = errors.New("unrecognized command: expected curl, fetch(), HTTPie (http/https) or PowerShell (Invoke-WebRequest/Invoke-RestMethod)")
+
+// Detect guesses the format of a pasted command
+func Detect(input string) (Format, bool) {
+	input = trimPrompt(input)
+	first := ""
+	if fields := strings.Fields(input); len(fields) > 0 {
+		first = strings.ToLower(fields[0])
+	}
+
+	switch {
+	case first == "curl" || first == "curl.exe":
+		return FormatCurl, true
+	case first == "http" || first == "https" || first == "httpie":
+		return FormatHTTPie, true
+	case strings.Contains(input, "fetch("):
+		return FormatFetch, true
+	case first == "iwr" || first == "irm" || strings.HasPrefix(first, "$") ||
+		strings.Contains(strings.ToLower(input), "invoke-webrequest") ||
+		strings.Contains(strings.ToLower(input), "invoke-restmethod"):
+		return FormatPowerShell, true
+	}
+	return "", false
+}
+
+// Parse detects the format of a command and parses it into a request
+func Parse(input string) (model.Request, Format, error) {
+	input = trimPrompt(input)
+	format, ok := Detect(input)
+	if !ok {
+		return model.Request{}, "", ErrUnknownFormat
+	}
+
+	var r model.Request
+	var err error
+	switch format {
+	case FormatCurl:
+		r, err = ParseCurl(input)
+	case FormatFetch:
+		r, err = ParseFetch(input)
+	case FormatHTTPie:
+		r, err = ParseHTTPie(input)
+	case FormatPowerShell:
+		r, err = ParsePowerShell(input)
+	}
+	if err != nil {
+		return model.Request{}, format, fmt.Errorf("%s: %w", format, err)
+	}
+	return r, format, nil
+}
+
+// trimPrompt removes surrounding space and a "$ " shell prompt copied along
+// with documentation examples
+func trimPrompt(input string) string {
+	input = strings.TrimSpace(input)
+	return strings.TrimSpace(strings.TrimPrefix(input, "$ "))
+}
+
+// setDefaultHeader appends a header unless one with the same name is present
+func setDefaultHeader(headers []model.HeaderPair, key, value string) []model.HeaderPair {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			return headers
+		}
+	}
+	return append(headers, model.HeaderPair{Key: key, Value: value})
+}
+
+// jsonString quotes a JSON string without escaping HTML characters
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,446 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Format
+	}{
+		{"curl https://example.com", FormatCurl},
+		{"$ curl https://example.com", FormatCurl},
+		{`fetch("https://example.com")`, FormatFetch},
+		{`await fetch("https://example.com", {})`, FormatFetch},
+		{"http POST example.com a=b", FormatHTTPie},
+		{"https example.com", FormatHTTPie},
+		{`Invoke-WebRequest -Uri "https://example.com"`, FormatPowerShell},
+		{"$session = New-Object Microsoft.PowerShell.Commands.WebRequestSession\nInvoke-WebRequest https://example.com", FormatPowerShell},
+	}
+	for _, tt := range tests {
+		got, ok := Detect(tt.input)
+		if !ok || got != tt.want {
+			t.Errorf("Detect(%q) = %q, %v; want %q", tt.input, got, ok, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "https://example.com", "wget https://example.com"} {
+		if _, ok := Detect(input); ok {
+			t.Errorf("expected %q not to be detected", input)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, _, err := Parse("https://example.com"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"curl -H 'Accept: */*'", "cURL: no URL found"},
+		{"curl 'https://example.com", "cURL: unterminated quoted string"},
+		{`fetch(url, {})`, `fetch: offset 6: unsupported expression "url"`},
+		{`fetch("https://example.com", {method: "POST"`, "fetch: offset 44: expected , or }"},
+		{"http -v", "HTTPie: no URL found"},
+		{"http example.com count:=abc", `HTTPie: field "count" is not valid JSON`},
+		{"Invoke-WebRequest -Method Post", "PowerShell: no -Uri found"},
+		{"Invoke-WebRequest -Uri $url", "PowerShell: offset 27: undefined variable $url"},
+	}
+	for _, tt := range tests {
+		_, _, err := Parse(tt.input)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestParseCurlBody(t *testing.T) {
+	r, err := ParseCurl(`curl 'https://api.example.com/items' \
+  -H 'Content-Type: application/json' \
+  --data-raw '{"name":"it'\''s"}'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.Request{
+		Method:   "POST",
+		URL:      "https://api.example.com/items",
+		Headers:  []model.HeaderPair{{Key: "Content-Type", Value: "application/json"}},
+		Body:     `{"name":"it's"}`,
+		Settings: &model.Settings{NoRedirects: true},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+
+	r, err = ParseCurl(`curl -XPUT https://example.com -d a=1 --data-urlencode 'q=a b'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != "PUT" || r.Body != "a=1&q=a+b" || r.Headers[0].Value != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected request %+v", r)
+	}
+
+	r, _ = ParseCurl(`curl --json '{"a":1}' https://example.com`)
+	if r.Method != "POST" || len(r.Headers) != 2 || r.Headers[1].Key != "Accept" {
+		t.Errorf("unexpected request %+v", r)
+	}
+}
+
+func TestSplitShell(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want []string
+	}{
+		{"continuations", "curl \\\n  -X POST \\  \r\n  https://example.com", []string{"curl", "-X", "POST", "https://example.com"}},
+		{"escaped space", `a\ b c`, []string{"a b", "c"}},
+		{"single quotes keep backslashes", `'a\nb'`, []string{`a\nb`}},
+		{"double quote escapes", `"a\"b\\c\d"`, []string{`a"b\c\d`}},
+		{"ANSI-C quoting", `$'it\'s\n\t\x41\101\u00e9\\'`, []string{"it's\n\tAAé\\"}},
+		{"adjacent quotes", `--data-raw $'{"a":'"1}"`, []string{"--data-raw", `{"a":1}`}},
+		{"empty argument", `'' x`, []string{"", "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShell(tt.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := splitShell(`$'unterminated`); err == nil {
+		t.Error("expected an error for an unterminated $'...' string")
+	}
+}
+
+func TestParseCurlDataFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "body.json")
+	if err := os.WriteFile(path, []byte("{\n  \"a\": 1\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ParseCurl("curl https://example.com -d @" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Body != `{  "a": 1}` {
+		t.Errorf("expected -d to strip line breaks, got %q", r.Body)
+	}
+
+	r, err = ParseCurl("curl https://example.com --data-binary @" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Body != "{\n  \"a\": 1\n}\n" {
+		t.Errorf("expected --data-binary to keep the file as is, got %q", r.Body)
+	}
+
+	r, err = ParseCurl("curl https://example.com --data-raw @" + path)
+	if err != nil || r.Body != "@"+path {
+		t.Errorf("expected --data-raw not to read the file, got %q, %v", r.Body, err)
+	}
+
+	r, err = ParseCurl("curl https://example.com --data-urlencode doc@" + path)
+	if err != nil || r.Body != "doc=%7B%0A++%22a%22%3A+1%0A%7D%0A" {
+		t.Errorf("unexpected --data-urlencode body %q, %v", r.Body, err)
+	}
+
+	if _, err := ParseCurl("curl https://example.com -d @" + filepath.Join(dir, "missing.json")); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("expected an error naming the missing file, got %v", err)
+	}
+	if _, err := ParseCurl("curl https://example.com -d @-"); err == nil {
+		t.Error("expected an error for stdin data")
+	}
+}
+
+func TestParseFetch(t *testing.T) {
+	// As copied from a browser's developer tools
+	r, err := ParseFetch(`fetch("https://api.example.com/items?x=1", {
+  "headers": {
+    "accept": "application/json",
+    "content-type": "application/json",
+    "sec-ch-ua": "\"Chromium\";v=\"118\""
+  },
+  "referrer": "https://example.com/",
+  "body": "{\"name\":\"café\"}",
+  "method": "POST",
+  "mode": "cors",
+  "credentials": "include"
+});`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.Request{
+		Method: "POST",
+		URL:    "https://api.example.com/items?x=1",
+		Headers: []model.HeaderPair{
+			{Key: "accept", Value: "application/json"},
+			{Key: "content-type", Value: "application/json"},
+			{Key: "sec-ch-ua", Value: `"Chromium";v="118"`},
+		},
+		Body: `{"name":"café"}`,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+
+	// Hand-written code with unquoted keys, trailing commas and helpers
+	r, err = ParseFetch(`const res = await fetch('https://example.com/login', {
+  method: 'post', // comment
+  headers: new Headers([["X-Id", 7]]),
+  body: JSON.stringify({user: "bob", tags: ["a", 'b'], admin: false, n: -1.5,}),
+});`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != "POST" || r.Body != `{"user":"bob","tags":["a","b"],"admin":false,"n":-1.5}` {
+		t.Errorf("unexpected request %+v", r)
+	}
+	if len(r.Headers) != 1 || r.Headers[0].Value != "7" {
+		t.Errorf("unexpected headers %+v", r.Headers)
+	}
+
+	r, err = ParseFetch(`fetch("https://example.com", {method: "POST", body: new URLSearchParams({q: "a b", n: 1})})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Body != "q=a+b&n=1" || r.Headers[0].Value != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected request %+v", r)
+	}
+
+	if r, err := ParseFetch("fetch(`https://example.com`)"); err != nil || r.Method != "GET" {
+		t.Errorf("unexpected result %+v, %v", r, err)
+	}
+}
+
+func TestParseHTTPie(t *testing.T) {
+	r, err := ParseHTTPie(`http -v PUT api.example.com/items/1 Authorization:'Bearer t' name=Widget count:=3 tags:='["a"]' page==2 X-Empty;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.Request{
+		Method: "PUT",
+		URL:    "http://api.example.com/items/1?page=2",
+		Headers: []model.HeaderPair{
+			{Key: "Authorization", Value: "Bearer t"},
+			{Key: "X-Empty", Value: ""},
+			{Key: "Content-Type", Value: "application/json"},
+		},
+		Body: `{"name": "Widget", "count": 3, "tags": ["a"]}`,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+
+	r, err = ParseHTTPie("https --form -a bob:secret :8080/login user=bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != "POST" || r.URL != "https://localhost:8080/login" || r.Body != "user=bob" {
+		t.Errorf("unexpected request %+v", r)
+	}
+	if r.Auth == nil || r.Auth.Username != "bob" || r.Auth.Password != "secret" {
+		t.Errorf("expected basic auth, got %+v", r.Auth)
+	}
+
+	// The code generator's output can be imported back
+	r, err = ParseHTTPie(`http --raw '{"a": 1}' POST 'https://example.com/x' \
+  'Accept:text/plain'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != "POST" || r.Body != `{"a": 1}` || len(r.Headers) != 2 {
+		t.Errorf("unexpected request %+v", r)
+	}
+}
+
+func TestParsePowerShell(t *testing.T) {
+	// As copied from a browser's developer tools
+	r, err := ParsePowerShell("$session = New-Object Microsoft.PowerShell.Commands.WebRequestSession\n" +
+		"$session.UserAgent = \"Mozilla/5.0\"\n" +
+		"$session.Cookies.Add((New-Object System.Net.Cookie(\"sid\", \"abc\", \"/\", \"example.com\")))\n" +
+		"Invoke-WebRequest -UseBasicParsing -Uri \"https://example.com/api\" `\n" +
+		"-Method \"POST\" `\n" +
+		"-WebSession $session `\n" +
+		"-Headers @{\n" +
+		"\"authority\"=\"example.com\"\n" +
+		"  \"method\"=\"POST\"\n" +
+		"  \"accept\"=\"*/*\"\n" +
+		"  \"sec-ch-ua\"=\"`\"Chromium`\";v=`\"118`\"\"\n" +
+		"} `\n" +
+		"-ContentType \"application/json\" `\n" +
+		"-Body ([System.Text.Encoding]::UTF8.GetBytes(\"{`\"name`\":`\"caf$([char]233)`\"}\"))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.Request{
+		Method: "POST",
+		URL:    "https://example.com/api",
+		Headers: []model.HeaderPair{
+			{Key: "accept", Value: "*/*"},
+			{Key: "sec-ch-ua", Value: `"Chromium";v="118"`},
+			{Key: "Content-Type", Value: "application/json"},
+			{Key: "User-Agent", Value: "Mozilla/5.0"},
+			{Key: "Cookie", Value: "sid=abc"},
+		},
+		Body: `{"name":"café"}`,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+
+	// The code generator's output can be imported back
+	r, err = ParsePowerShell(`$headers = @{
+    'Authorization' = 'Bearer it''s'
+}
+$body = @'
+{
+  "a": 1
+}
+'@
+
+$response = Invoke-RestMethod -Uri 'https://example.com/items' -Method Post -Headers $headers -ContentType 'application/json' -Body $body
+$response
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = model.Request{
+		Method: "POST",
+		URL:    "https://example.com/items",
+		Headers: []model.HeaderPair{
+			{Key: "Authorization", Value: "Bearer it's"},
+			{Key: "Content-Type", Value: "application/json"},
+		},
+		Body: "{\n  \"a\": 1\n}",
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+
+	if r, err := ParsePowerShell("iwr https://example.com"); err != nil || r.URL != "https://example.com" || r.Method != "GET" {
+		t.Errorf("unexpected result %+v, %v", r, err)
+	}
+}
+
+func TestParseCurlOptions(t *testing.T) {
+	r, err := ParseCurl(`curl -sSkL --compressed -u admin:s3cr:t -A 'apitty/1.0' -e 'https://ref.example.com;auto' \
+  -b 'a=1; b=2' --cert client.pem:pass --key client.key --resolve api.example.com:443:127.0.0.1 \
+  --max-time 2.5 --connect-timeout 3 -o /dev/null -w '%{http_code}' https://api.example.com/items`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.Request{
+		Method: "GET",
+		URL:    "https://api.example.com/items",
+		Headers: []model.HeaderPair{
+			{Key: "User-Agent", Value: "apitty/1.0"},
+			{Key: "Referer", Value: "https://ref.example.com"},
+			{Key: "Cookie", Value: "a=1; b=2"},
+		},
+		Auth: &model.Auth{Type: model.AuthBasic, Username: "admin", Password: "s3cr:t"},
+		Settings: &model.Settings{
+			Insecure:   true,
+			Compressed: true,
+			TimeoutMs:  2500,
+			CertFile:   "client.pem",
+			KeyFile:    "client.key",
+			Resolve:    []string{"api.example.com:443:127.0.0.1"},
+		},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v\nwant %+v", r, want)
+	}
+}
+
+func TestParseCurlBooleanFlagBeforeURL(t *testing.T) {
+	// An unknown switch must not swallow the URL that follows it
+	for _, cmd := range []string{
+		"curl -s https://example.com",
+		"curl --silent https://example.com",
+		"curl -v --fail-with-body example.com/path",
+	} {
+		r, err := ParseCurl(cmd)
+		if err != nil {
+			t.Errorf("%s: %v", cmd, err)
+			continue
+		}
+		if !strings.HasSuffix(r.URL, "example.com") && !strings.HasSuffix(r.URL, "example.com/path") {
+			t.Errorf("%s: got URL %q", cmd, r.URL)
+		}
+	}
+
+	if _, err := ParseCurl("curl https://example.com -H"); err == nil || err.Error() != "-H needs a value" {
+		t.Errorf("expected a missing value error, got %v", err)
+	}
+}
+
+func TestParseCurlGetAndUpload(t *testing.T) {
+	r, err := ParseCurl(`curl -G https://example.com/search?lang=en -d q=go --data-urlencode 'tag=a b'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != "GET" || r.URL != "https://example.com/search?lang=en&q=go&tag=a+b" || r.Body != "" || len(r.Headers) != 0 {
+		t.Errorf("expected -G to move the data into the query, got %+v", r)
+	}
+
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	if err := os.WriteFile(path, []byte("content\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = ParseCurl("curl -T " + path + " https://example.com/doc.txt")
+	if err != nil || r.Method != "PUT" || r.Body != "content\n" {
+		t.Errorf("expected an upload, got %+v, %v", r, err)
+	}
+
+	if r, _ := ParseCurl("curl -I https://example.com"); r.Method != "HEAD" {
+		t.Errorf("expected HEAD, got %s", r.Method)
+	}
+	if _, err := ParseCurl("curl -F file=@a.png https://example.com"); err == nil {
+		t.Error("expected multipart forms to be rejected")
+	}
+	if _, err := ParseCurl("curl -m soon https://example.com"); err == nil {
+		t.Error("expected an invalid --max-time to be rejected")
+	}
+}
+
+func TestParseCurlCookieFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	jar := "# Netscape HTTP Cookie File\n" +
+		".example.com\tTRUE\t/\tFALSE\t0\tsession\tabc\n" +
+		"#HttpOnly_api.example.com\tFALSE\t/v1\tTRUE\t0\ttoken\txyz\n" +
+		"other.com\tFALSE\t/\tFALSE\t0\tother\tno\n" +
+		"example.com\tFALSE\t/\tFALSE\t1\texpired\tno\n"
+	if err := os.WriteFile(path, []byte(jar), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ParseCurl("curl -b " + path + " -b 'x=1' https://api.example.com/v1/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Headers[len(r.Headers)-1]; got.Key != "Cookie" || got.Value != "x=1; session=abc; token=xyz" {
+		t.Errorf("unexpected cookie header %+v", got)
+	}
+
+	r, _ = ParseCurl("curl -b " + path + " http://api.example.com/v1/items")
+	if got := r.Headers[len(r.Headers)-1]; got.Value != "session=abc" {
+		t.Errorf("expected secure cookies to be left out over http, got %q", got.Value)
+	}
+}
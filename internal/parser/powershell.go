@@ -0,0 +1,547 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// ParsePowerShell parses an Invoke-WebRequest or Invoke-RestMethod call into
+// a request. Variables assigned earlier in the script are resolved, which
+// covers the $session user agent and cookies set up by the browser's "Copy as
+// PowerShell" and the $headers/$body variables of generated code.
+func ParsePowerShell(script string) (model.Request, error) {
+	p := &psParser{s: script, vars: map[string]psValue{}}
+	var r *model.Request
+
+	for {
+		p.skip(true)
+		if p.pos >= len(p.s) {
+			break
+		}
+
+		if p.peek() == '$' {
+			p.pos++
+			name := strings.ToLower(p.name())
+			p.skip(false)
+			switch {
+			case p.consume('='):
+				p.skip(false)
+				if isInvoke(p.peekWord()) {
+					p.word()
+					req, err := p.invoke()
+					if err != nil {
+						return model.Request{}, err
+					}
+					r = &req
+					continue
+				}
+				v, err := p.value()
+				if err != nil {
+					return model.Request{}, err
+				}
+				p.vars[name] = v
+				if strings.HasSuffix(name, ".useragent") {
+					p.userAgent = v.text
+				}
+			case strings.HasSuffix(name, ".cookies.add"):
+				if err := p.cookie(); err != nil {
+					return model.Request{}, err
+				}
+			}
+			p.skipStatement()
+			continue
+		}
+
+		if isInvoke(p.peekWord()) {
+			p.word()
+			req, err := p.invoke()
+			if err != nil {
+				return model.Request{}, err
+			}
+			r = &req
+			continue
+		}
+		p.skipStatement()
+	}
+
+	if r == nil {
+		return model.Request{}, errors.New("no Invoke-WebRequest or Invoke-RestMethod call found")
+	}
+	if p.userAgent != "" {
+		r.Headers = setDefaultHeader(r.Headers, "User-Agent", p.userAgent)
+	}
+	if len(p.cookies) > 0 {
+		r.Headers = setDefaultHeader(r.Headers, "Cookie", strings.Join(p.cookies, "; "))
+	}
+	return *r, nil
+}
+
+// isInvoke reports whether word is a web request cmdlet or its alias
+func isInvoke(word string) bool {
+	switch strings.ToLower(word) {
+	case "invoke-webrequest", "invoke-restmethod", "iwr", "irm":
+		return true
+	}
+	return false
+}
+
+// psPseudoHeaders are the HTTP/2 pseudo-headers browsers copy as plain headers
+var psPseudoHeaders = map[string]bool{"authority": true, "method": true, "path": true, "scheme": true}
+
+// psValue is a string or a hashtable
+type psValue struct {
+	text  string
+	table []model.HeaderPair
+}
+
+// psParser reads the subset of PowerShell used to call web request cmdlets
+type psParser struct {
+	s         string
+	pos       int
+	vars      map[string]psValue
+	userAgent string
+	cookies   []string
+}
+
+func (p *psParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *psParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *psParser) consume(ch byte) bool {
+	if p.peek() == ch {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skip moves past blanks, backtick line continuations and comments, and
+// also past statement separators when statements is set
+func (p *psParser) skip(statements bool) {
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		switch {
+		case ch == ' ' || ch == '\t':
+			p.pos++
+		case ch == '`' && (strings.HasPrefix(p.s[p.pos+1:], "\n") || strings.HasPrefix(p.s[p.pos+1:], "\r\n")):
+			p.pos = strings.IndexByte(p.s[p.pos:], '\n') + p.pos + 1
+		case ch == '#':
+			if end := strings.IndexByte(p.s[p.pos:], '\n'); end >= 0 {
+				p.pos += end
+			} else {
+				p.pos = len(p.s)
+			}
+		case statements && (ch == '\n' || ch == '\r' || ch == ';'):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// skipStatement moves to the end of the current statement
+func (p *psParser) skipStatement() {
+	depth := 0
+	for p.pos < len(p.s) {
+		switch ch := p.s[p.pos]; {
+		case ch == '\'' || ch == '"':
+			if _, err := p.value(); err != nil {
+				p.pos = len(p.s)
+			}
+			continue
+		case ch == '`':
+			p.pos++
+		case ch == '(' || ch == '{':
+			depth++
+		case ch == ')' || ch == '}':
+			depth--
+		case (ch == '\n' || ch == ';') && depth <= 0:
+			return
+		}
+		p.pos++
+	}
+}
+
+// name reads a variable name, including member access like session.UserAgent
+func (p *psParser) name() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		if ch != '_' && ch != '.' && ch != ':' && !isAlnum(ch) {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func isAlnum(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+// word reads a bare word such as a cmdlet name or unquoted argument
+func (p *psParser) word() string {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n;|(){}`", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *psParser) peekWord() string {
+	pos := p.pos
+	w := p.word()
+	p.pos = pos
+	return w
+}
+
+// invoke reads the parameters of a web request cmdlet
+func (p *psParser) invoke() (model.Request, error) {
+	r := model.Request{Method: "GET", Headers: []model.HeaderPair{}}
+	contentType := ""
+
+	for {
+		p.skip(false)
+		ch := p.peek()
+		if ch == 0 || ch == '\n' || ch == '\r' || ch == ';' || ch == '|' {
+			break
+		}
+
+		param := "uri"
+		if ch == '-' && p.pos+1 < len(p.s) && isAlnum(p.s[p.pos+1]) {
+			p.pos++
+			start := p.pos
+			for p.pos < len(p.s) && isAlnum(p.s[p.pos]) {
+				p.pos++
+			}
+			param = strings.ToLower(p.s[start:p.pos])
+			// -Name:value binds the value directly
+			if !p.consume(':') {
+				p.skip(false)
+				next := p.peek()
+				if next == 0 || next == '\n' || next == '\r' || next == ';' || next == '|' ||
+					next == '-' && p.pos+1 < len(p.s) && isAlnum(p.s[p.pos+1]) {
+					// A switch such as -UseBasicParsing
+					continue
+				}
+			}
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return model.Request{}, err
+		}
+		switch param {
+		case "uri":
+			if r.URL == "" {
+				r.URL = v.text
+			}
+		case "method":
+			r.Method = strings.ToUpper(v.text)
+		case "headers":
+			for _, h := range v.table {
+				if psPseudoHeaders[strings.ToLower(strings.TrimPrefix(h.Key, ":"))] {
+					continue
+				}
+				r.Headers = append(r.Headers, h)
+			}
+		case "contenttype":
+			contentType = v.text
+		case "useragent":
+			p.userAgent = v.text
+		case "body":
+			r.Body = v.text
+		}
+	}
+
+	if contentType != "" {
+		r.Headers = setDefaultHeader(r.Headers, "Content-Type", contentType)
+	}
+	if r.URL == "" {
+		return model.Request{}, errors.New("no -Uri found")
+	}
+	return r, nil
+}
+
+// cookie reads the name and value of a New-Object System.Net.Cookie(...)
+func (p *psParser) cookie() error {
+	start := p.pos
+	p.skipStatement()
+	statement := p.s[start:p.pos]
+	idx := strings.Index(strings.ToLower(statement), "system.net.cookie(")
+	if idx < 0 {
+		return nil
+	}
+	p.pos = start + idx + len("system.net.cookie(")
+	name, err := p.value()
+	if err != nil {
+		return err
+	}
+	p.skip(false)
+	if !p.consume(',') {
+		return p.errorf("expected , after the cookie name")
+	}
+	p.skip(false)
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	p.cookies = append(p.cookies, name.text+"="+value.text)
+	return nil
+}
+
+func (p *psParser) value() (psValue, error) {
+	p.skip(false)
+	switch {
+	case p.pos >= len(p.s):
+		return psValue{}, p.errorf("unexpected end of input")
+	case strings.HasPrefix(p.s[p.pos:], "@'") || strings.HasPrefix(p.s[p.pos:], `@"`):
+		return p.hereString()
+	case strings.HasPrefix(p.s[p.pos:], "@{"):
+		return p.hashtable()
+	}
+
+	switch p.peek() {
+	case '\'':
+		return p.singleQuoted()
+	case '"':
+		return p.doubleQuoted()
+	case '$':
+		p.pos++
+		name := p.name()
+		v, ok := p.vars[strings.ToLower(name)]
+		if !ok {
+			return psValue{}, p.errorf("undefined variable $%s", name)
+		}
+		return v, nil
+	case '[':
+		// A static call like [System.Text.Encoding]::UTF8.GetBytes("...")
+		end := strings.IndexByte(p.s[p.pos:], ']')
+		if end < 0 {
+			return psValue{}, p.errorf("unterminated type name")
+		}
+		p.pos += end + 1
+		p.name()
+		if p.peek() != '(' {
+			return psValue{}, p.errorf("unsupported expression")
+		}
+		return p.parenthesized()
+	case '(':
+		return p.parenthesized()
+	}
+	return psValue{text: p.word()}, nil
+}
+
+// parenthesized evaluates (...) to the first value inside it, which is
+// enough for wrappers like ([Text.Encoding]::UTF8.GetBytes("..."))
+func (p *psParser) parenthesized() (psValue, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\'', '"':
+			if _, err := p.value(); err != nil {
+				return psValue{}, err
+			}
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.pos++
+		if depth == 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		return psValue{}, p.errorf("unbalanced parentheses")
+	}
+
+	inner := &psParser{s: p.s[start+1 : p.pos-1], vars: p.vars}
+	for inner.pos < len(inner.s) && strings.IndexByte(`'"$@`, inner.s[inner.pos]) < 0 {
+		inner.pos++
+	}
+	if inner.pos >= len(inner.s) {
+		return psValue{}, fmt.Errorf("offset %d: unsupported expression", start)
+	}
+	return inner.value()
+}
+
+func (p *psParser) singleQuoted() (psValue, error) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		p.pos++
+		if ch == '\'' {
+			if p.consume('\'') {
+				b.WriteByte('\'')
+				continue
+			}
+			return psValue{text: b.String()}, nil
+		}
+		b.WriteByte(ch)
+	}
+	return psValue{}, p.errorf("unterminated string")
+}
+
+func (p *psParser) doubleQuoted() (psValue, error) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		p.pos++
+		switch {
+		case ch == '"':
+			if p.consume('"') {
+				b.WriteByte('"')
+				continue
+			}
+			return psValue{text: b.String()}, nil
+		case ch == '`' && p.pos < len(p.s):
+			if err := p.escape(&b); err != nil {
+				return psValue{}, err
+			}
+		case ch == '$' && strings.HasPrefix(strings.ToLower(p.s[p.pos:]), "([char]"):
+			// Browsers write non-ASCII characters as $([char]233)
+			end := strings.IndexByte(p.s[p.pos:], ')')
+			n, err := strconv.Atoi(p.s[p.pos+len("([char]") : p.pos+max(end, 0)])
+			if end < 0 || err != nil {
+				b.WriteByte(ch)
+				continue
+			}
+			b.WriteRune(rune(n))
+			p.pos += end + 1
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return psValue{}, p.errorf("unterminated string")
+}
+
+// escape decodes the backtick escape at p.pos
+func (p *psParser) escape(b *strings.Builder) error {
+	ch := p.s[p.pos]
+	p.pos++
+	switch ch {
+	case '0':
+		b.WriteByte(0)
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 'e':
+		b.WriteByte(0x1b)
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'v':
+		b.WriteByte('\v')
+	case 'u':
+		end := strings.IndexByte(p.s[p.pos:], '}')
+		if p.peek() != '{' || end < 0 {
+			return p.errorf("invalid unicode escape")
+		}
+		n, err := strconv.ParseUint(p.s[p.pos+1:p.pos+end], 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(n))
+		p.pos += end + 1
+	default:
+		b.WriteByte(ch)
+	}
+	return nil
+}
+
+// hereString reads @'...'@ or @"..."@, whose content starts on the next line
+func (p *psParser) hereString() (psValue, error) {
+	quote := p.s[p.pos+1]
+	p.pos += 2
+	nl := strings.IndexByte(p.s[p.pos:], '\n')
+	if nl < 0 {
+		return psValue{}, p.errorf("here-string must start on a new line")
+	}
+	p.pos += nl + 1
+	end := strings.Index(p.s[p.pos:], "\n"+string(quote)+"@")
+	if end < 0 {
+		return psValue{}, p.errorf("unterminated here-string")
+	}
+	content := strings.TrimSuffix(p.s[p.pos:p.pos+end], "\r")
+	p.pos += end + 3
+	if quote == '\'' {
+		return psValue{text: content}, nil
+	}
+
+	var b strings.Builder
+	sub := &psParser{s: content}
+	for sub.pos < len(sub.s) {
+		ch := sub.s[sub.pos]
+		sub.pos++
+		if ch == '`' && sub.pos < len(sub.s) {
+			if err := sub.escape(&b); err != nil {
+				return psValue{}, err
+			}
+			continue
+		}
+		b.WriteByte(ch)
+	}
+	return psValue{text: b.String()}, nil
+}
+
+// hashtable reads @{ key = value; ... } keeping the entry order
+func (p *psParser) hashtable() (psValue, error) {
+	p.pos += 2
+	v := psValue{table: []model.HeaderPair{}}
+	for {
+		p.skip(true)
+		if p.pos >= len(p.s) {
+			return psValue{}, p.errorf("unterminated hashtable")
+		}
+		if p.consume('}') {
+			return v, nil
+		}
+
+		var key string
+		if ch := p.peek(); ch == '\'' || ch == '"' {
+			k, err := p.value()
+			if err != nil {
+				return psValue{}, err
+			}
+			key = k.text
+		} else {
+			start := p.pos
+			for p.pos < len(p.s) && strings.IndexByte("= \t\r\n", p.s[p.pos]) < 0 {
+				p.pos++
+			}
+			key = p.s[start:p.pos]
+		}
+
+		p.skip(false)
+		if !p.consume('=') {
+			return psValue{}, p.errorf("expected = after %q", key)
+		}
+		val, err := p.value()
+		if err != nil {
+			return psValue{}, err
+		}
+		v.table = append(v.table, model.HeaderPair{Key: key, Value: val.text})
+	}
+}
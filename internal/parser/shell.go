@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// splitShell splits a POSIX shell command line into arguments, handling
+// single, double and $'...' quotes, backslash escapes and line continuations
+func splitShell(cmd string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	inQuote := false
+	inSingleQuote := false
+	inANSIQuote := false
+
+	for i := 0; i < len(cmd); i++ {
+		ch := cmd[i]
+
+		switch {
+		case inANSIQuote:
+			switch {
+			case ch == '\'':
+				inANSIQuote = false
+			case ch == '\\' && i+1 < len(cmd):
+				i = ansiEscape(cmd, i+1, &current)
+			default:
+				current.WriteByte(ch)
+			}
+		case inSingleQuote:
+			// Nothing is special inside single quotes
+			if ch == '\'' {
+				inSingleQuote = false
+			} else {
+				current.WriteByte(ch)
+			}
+		case inQuote:
+			switch {
+			case ch == '"':
+				inQuote = false
+			case ch == '\\' && i+1 < len(cmd) && strings.IndexByte("\"\\$`\n", cmd[i+1]) >= 0:
+				i++
+				if cmd[i] != '\n' {
+					current.WriteByte(cmd[i])
+				}
+			default:
+				current.WriteByte(ch)
+			}
+		case ch == '$' && i+1 < len(cmd) && cmd[i+1] == '\'':
+			i++
+			inANSIQuote = true
+			inArg = true
+		case ch == '\'':
+			inSingleQuote = true
+			inArg = true
+		case ch == '"':
+			inQuote = true
+			inArg = true
+		case ch == '\\' && i+1 < len(cmd):
+			i++
+			// Docs often leave blanks after the continuation backslash
+			if end := len(cmd[i:]) - len(strings.TrimLeft(cmd[i:], " \t\r")); i+end < len(cmd) && cmd[i+end] == '\n' {
+				i += end
+			}
+			if cmd[i] != '\n' {
+				current.WriteByte(cmd[i])
+				inArg = true
+			}
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(ch)
+			inArg = true
+		}
+	}
+
+	if inQuote || inSingleQuote || inANSIQuote {
+		return nil, errors.New("unterminated quoted string")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// ansiEscape decodes the escape sequence of a $'...' string starting at i,
+// just after the backslash, and returns the index of its last byte
+func ansiEscape(cmd string, i int, b *strings.Builder) int {
+	// readDigits parses up to n digits of the given base starting at j
+	readDigits := func(j, n, base int) (int, int) {
+		end := j
+		for end < len(cmd) && end-j < n {
+			if _, err := strconv.ParseUint(cmd[end:end+1], base, 8); err != nil {
+				break
+			}
+			end++
+		}
+		if end == j {
+			return -1, j
+		}
+		v, _ := strconv.ParseUint(cmd[j:end], base, 32)
+		return int(v), end
+	}
+
+	switch ch := cmd[i]; ch {
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 'e', 'E':
+		b.WriteByte(0x1b)
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'v':
+		b.WriteByte('\v')
+	case 'x':
+		if v, end := readDigits(i+1, 2, 16); v >= 0 {
+			b.WriteByte(byte(v))
+			return end - 1
+		}
+		b.WriteString("\\x")
+	case 'u', 'U':
+		n := 4
+		if ch == 'U' {
+			n = 8
+		}
+		if v, end := readDigits(i+1, n, 16); v >= 0 {
+			b.WriteRune(rune(v))
+			return end - 1
+		}
+		b.WriteByte('\\')
+		b.WriteByte(ch)
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		v, end := readDigits(i, 3, 8)
+		b.WriteByte(byte(v))
+		return end - 1
+	case '\\', '\'', '"', '?':
+		b.WriteByte(ch)
+	default:
+		b.WriteByte('\\')
+		b.WriteByte(ch)
+	}
+	return i
+}
+
+// splitHeader splits a "Key: Value" header, reporting false when there is no colon
+func splitHeader(s string) (model.HeaderPair, bool) {
+	key, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return model.HeaderPair{}, false
+	}
+	return model.HeaderPair{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)}, true
+}
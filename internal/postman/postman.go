@@ -0,0 +1,370 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/graphql"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/vars"
+)
+
+// collectionFile is a Postman v2.1 collection export
+type collectionFile struct {
+	Info struct {
+		Name        string          `json:"name"`
+		Schema      string          `json:"schema"`
+		Description json.RawMessage `json:"description"`
+	} `json:"info"`
+	Item     []item     `json:"item"`
+	Auth     *auth      `json:"auth"`
+	Event    []event    `json:"event"`
+	Variable []variable `json:"variable"`
+}
+
+// item is either a folder (with nested items) or a request
+type item struct {
+	Name    string   `json:"name"`
+	Item    []item   `json:"item"`
+	Request *request `json:"request"`
+	Auth    *auth    `json:"auth"`
+	Event   []event  `json:"event"`
+}
+
+type request struct {
+	Method string          `json:"method"`
+	URL    json.RawMessage `json:"url"`
+	Header []keyValue      `json:"header"`
+	Body   *body           `json:"body"`
+	Auth   *auth           `json:"auth"`
+}
+
+type body struct {
+	Mode       string     `json:"mode"`
+	Raw        string     `json:"raw"`
+	URLEncoded []keyValue `json:"urlencoded"`
+	FormData   []keyValue `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type keyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+type auth struct {
+	Type   string      `json:"type"`
+	Basic  []authParam `json:"basic"`
+	Bearer []authParam `json:"bearer"`
+	APIKey []authParam `json:"apikey"`
+	OAuth2 []authParam `json:"oauth2"`
+}
+
+// authParam is an auth setting; some values are booleans or objects
+type authParam struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type event struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec json.RawMessage `json:"exec"`
+	} `json:"script"`
+}
+
+type variable struct {
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Disabled bool            `json:"disabled"`
+}
+
+// environmentFile is a Postman environment export
+type environmentFile struct {
+	Name   string `json:"name"`
+	Values []struct {
+		Key     string          `json:"key"`
+		Value   json.RawMessage `json:"value"`
+		Enabled *bool           `json:"enabled"`
+	} `json:"values"`
+}
+
+// Load reads a Postman v2.1 collection export. The returned warnings
+// describe what could not be converted.
+func Load(path string) (*model.Collection, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Parse(data)
+}
+
+// Parse converts a Postman v2.1 collection export
+func Parse(data []byte) (*model.Collection, []string, error) {
+	var f collectionFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("invalid Postman collection: %w", err)
+	}
+	if f.Info.Schema == "" {
+		return nil, nil, fmt.Errorf("not a Postman collection: missing info.schema")
+	}
+	if !strings.Contains(f.Info.Schema, "v2.1") && !strings.Contains(f.Info.Schema, "v2.0") {
+		return nil, nil, fmt.Errorf("unsupported Postman collection schema %s", f.Info.Schema)
+	}
+
+	cv := &converter{}
+	c := &model.Collection{Name: f.Info.Name, Description: description(f.Info.Description)}
+	for _, v := range f.Variable {
+		if !v.Disabled {
+			c.Variables = append(c.Variables, model.Variable{Key: v.Key, Value: rawString(v.Value)})
+		}
+	}
+	cv.events(f.Info.Name, f.Event)
+	rootAuth := cv.auth(f.Info.Name, f.Auth, nil)
+	c.Folders, c.Requests = cv.items(nil, f.Item, rootAuth)
+	return c, cv.warnings, nil
+}
+
+// LoadEnvironment reads a Postman environment export
+func LoadEnvironment(path string) (model.Environment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.Environment{}, err
+	}
+	return ParseEnvironment(data)
+}
+
+// ParseEnvironment converts a Postman environment export
+func ParseEnvironment(data []byte) (model.Environment, error) {
+	var f environmentFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return model.Environment{}, fmt.Errorf("invalid Postman environment: %w", err)
+	}
+	if f.Name == "" && f.Values == nil {
+		return model.Environment{}, fmt.Errorf("not a Postman environment: missing name and values")
+	}
+	env := model.Environment{Name: f.Name}
+	for _, v := range f.Values {
+		if v.Enabled != nil && !*v.Enabled {
+			continue
+		}
+		env.Variables = append(env.Variables, model.Variable{Key: v.Key, Value: rawString(v.Value)})
+	}
+	return env, nil
+}
+
+// converter collects warnings while walking the collection
+type converter struct {
+	warnings []string
+}
+
+func (cv *converter) warn(format string, args ...interface{}) {
+	cv.warnings = append(cv.warnings, fmt.Sprintf(format, args...))
+}
+
+// items converts a list of items into folders and requests. Requests without
+// their own auth inherit the auth of their closest parent.
+func (cv *converter) items(path []string, items []item, inherited *model.Auth) ([]model.Folder, []model.SavedRequest) {
+	var folders []model.Folder
+	var requests []model.SavedRequest
+	for _, it := range items {
+		itemPath := append(append([]string{}, path...), it.Name)
+		name := strings.Join(itemPath, " › ")
+		cv.events(name, it.Event)
+
+		if it.Request == nil {
+			folderAuth := cv.auth(name, it.Auth, inherited)
+			sub, reqs := cv.items(itemPath, it.Item, folderAuth)
+			folders = append(folders, model.Folder{Name: it.Name, Folders: sub, Requests: reqs})
+			continue
+		}
+		requests = append(requests, cv.request(name, it.Name, it.Request, inherited))
+	}
+	return folders, requests
+}
+
+func (cv *converter) request(path, name string, r *request, inherited *model.Auth) model.SavedRequest {
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = "GET"
+	}
+	saved := model.SavedRequest{Name: name, Request: model.Request{Method: method, URL: requestURL(r.URL)}}
+	for _, h := range r.Header {
+		if !h.Disabled {
+			saved.Headers = append(saved.Headers, model.HeaderPair{Key: h.Key, Value: h.Value})
+		}
+	}
+	if r.Body != nil {
+		cv.body(path, &saved.Request, r.Body)
+	}
+	saved.Auth = cv.auth(path, r.Auth, inherited)
+	return saved
+}
+
+func (cv *converter) body(path string, r *model.Request, b *body) {
+	switch b.Mode {
+	case "", "raw":
+		r.Body = b.Raw
+		if b.Options.Raw.Language == "json" {
+			r.Headers = withContentType(r.Headers, "application/json")
+		}
+	case "urlencoded":
+		var pairs []string
+		for _, kv := range b.URLEncoded {
+			if !kv.Disabled {
+				pairs = append(pairs, vars.QueryEscape(kv.Key)+"="+vars.QueryEscape(kv.Value))
+			}
+		}
+		r.Body = strings.Join(pairs, "&")
+		r.Headers = withContentType(r.Headers, "application/x-www-form-urlencoded")
+	case "graphql":
+		if b.GraphQL == nil {
+			return
+		}
+		envelope, err := graphql.BuildEnvelope(b.GraphQL.Query, b.GraphQL.Variables)
+		if err != nil {
+			cv.warn("%s: GraphQL variables are not a JSON object, they were dropped", path)
+			envelope, _ = graphql.BuildEnvelope(b.GraphQL.Query, "")
+		}
+		r.Body = string(envelope)
+		r.Headers = withContentType(r.Headers, "application/json")
+	default:
+		cv.warn("%s: %s body is not supported", path, b.Mode)
+	}
+}
+
+// auth converts a Postman auth block. A missing block or the "inherit" type
+// keeps the inherited auth, "noauth" removes it.
+func (cv *converter) auth(path string, a *auth, inherited *model.Auth) *model.Auth {
+	if a == nil || a.Type == "inherit" {
+		return inherited
+	}
+	switch a.Type {
+	case "noauth":
+		return nil
+	case "basic":
+		return &model.Auth{Type: model.AuthBasic, Username: lookup(a.Basic, "username"), Password: lookup(a.Basic, "password")}
+	case "bearer":
+		return &model.Auth{Type: model.AuthBearer, Token: lookup(a.Bearer, "token")}
+	case "apikey":
+		in := "header"
+		if lookup(a.APIKey, "in") == "query" {
+			in = "query"
+		}
+		return &model.Auth{Type: model.AuthAPIKey, Key: lookup(a.APIKey, "key"), Value: lookup(a.APIKey, "value"), In: in}
+	case "oauth2":
+		if token := lookup(a.OAuth2, "accessToken"); token != "" {
+			cv.warn("%s: OAuth 2.0 converted to a bearer token, the token will not be refreshed", path)
+			return &model.Auth{Type: model.AuthBearer, Token: token}
+		}
+	}
+	cv.warn("%s: %s auth is not supported", path, a.Type)
+	return nil
+}
+
+// events reports the scripts attached to an item, which apitty cannot run
+func (cv *converter) events(path string, events []event) {
+	for _, e := range events {
+		if scriptLines(e.Script.Exec) == 0 {
+			continue
+		}
+		switch e.Listen {
+		case "prerequest":
+			cv.warn("%s: pre-request script was not converted", path)
+		case "test":
+			cv.warn("%s: test script was not converted", path)
+		default:
+			cv.warn("%s: %s script was not converted", path, e.Listen)
+		}
+	}
+}
+
+// requestURL returns the raw URL of a request, given as a string or an object
+func requestURL(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var u struct {
+		Raw string `json:"raw"`
+	}
+	if json.Unmarshal(raw, &u) == nil {
+		return u.Raw
+	}
+	return ""
+}
+
+// scriptLines counts the non-blank lines of a script, given as a string or a list
+func scriptLines(raw json.RawMessage) int {
+	var lines []string
+	if json.Unmarshal(raw, &lines) != nil {
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return 0
+		}
+		lines = strings.Split(s, "\n")
+	}
+	n := 0
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// description returns a description given as a string or a {content} object
+func description(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var d struct {
+		Content string `json:"content"`
+	}
+	_ = json.Unmarshal(raw, &d)
+	return d.Content
+}
+
+// rawString returns a JSON value as text, unquoting strings
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+func lookup(params []authParam, key string) string {
+	for _, p := range params {
+		if p.Key == key {
+			return rawString(p.Value)
+		}
+	}
+	return ""
+}
+
+// withContentType adds a Content-Type header unless one is already set
+func withContentType(headers []model.HeaderPair, contentType string) []model.HeaderPair {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			return headers
+		}
+	}
+	return append(headers, model.HeaderPair{Key: "Content-Type", Value: contentType})
+}
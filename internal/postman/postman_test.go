@@ -0,0 +1,107 @@
+package postman
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func findRequest(t *testing.T, c *model.Collection, name string) *model.SavedRequest {
+	t.Helper()
+	for _, e := range c.Entries() {
+		if e.Request.Name == name {
+			return e.Request
+		}
+	}
+	t.Fatalf("request %q not found", name)
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	c, warnings, err := Load("testdata/shop.postman_collection.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.Name != "Shop API" || c.Description != "Orders and products" {
+		t.Errorf("unexpected name/description %q/%q", c.Name, c.Description)
+	}
+	if len(c.Variables) != 1 || c.Variables[0].Key != "baseUrl" {
+		t.Errorf("expected only the enabled baseUrl variable, got %v", c.Variables)
+	}
+	if len(c.Folders) != 1 || c.Folders[0].Name != "Orders" || len(c.Folders[0].Folders) != 1 {
+		t.Fatalf("expected Orders folder with a nested Admin folder, got %+v", c.Folders)
+	}
+	if len(c.Entries()) != 6 {
+		t.Errorf("expected 6 requests, got %d", len(c.Entries()))
+	}
+
+	list := findRequest(t, c, "List orders")
+	if list.URL != "{{baseUrl}}/orders?limit=10" {
+		t.Errorf("unexpected URL %q", list.URL)
+	}
+	if len(list.Headers) != 1 || list.Headers[0].Key != "Accept" {
+		t.Errorf("expected disabled header to be skipped, got %v", list.Headers)
+	}
+	if list.Auth == nil || list.Auth.Type != model.AuthBearer || list.Auth.Token != "{{token}}" {
+		t.Errorf("expected bearer auth inherited from the collection, got %+v", list.Auth)
+	}
+
+	create := findRequest(t, c, "Create order")
+	if create.URL != "{{baseUrl}}/orders" || !strings.Contains(create.Body, `"{{productId}}"`) {
+		t.Errorf("unexpected create request %+v", create.Request)
+	}
+	if len(create.Headers) != 1 || create.Headers[0].Value != "application/json" {
+		t.Errorf("expected JSON content type from the raw language, got %v", create.Headers)
+	}
+
+	del := findRequest(t, c, "Delete order")
+	if del.Auth == nil || del.Auth.Type != model.AuthBasic || del.Auth.Username != "admin" || del.Auth.Password != "secret" {
+		t.Errorf("expected basic auth inherited from the Admin folder, got %+v", del.Auth)
+	}
+
+	login := findRequest(t, c, "Login")
+	if login.Auth != nil {
+		t.Errorf("expected noauth to remove the inherited auth, got %+v", login.Auth)
+	}
+	if login.Body != "user={{user}}&scope=read+write" {
+		t.Errorf("unexpected form body %q", login.Body)
+	}
+
+	search := findRequest(t, c, "Search")
+	if search.Body != `{"query":"query { products { id } }","variables":{"first":5}}` {
+		t.Errorf("unexpected GraphQL body %q", search.Body)
+	}
+
+	want := []string{
+		"Orders › List orders: test script was not converted",
+		"Upload: formdata body is not supported",
+		"Upload: digest auth is not supported",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, _, err := Parse([]byte(`{"openapi": "3.0.0"}`)); err == nil {
+		t.Error("expected error for a document without info.schema")
+	}
+	if _, _, err := Parse([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`)); err == nil {
+		t.Error("expected error for a v1 collection")
+	}
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	env, err := LoadEnvironment("testdata/staging.postman_environment.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Name != "Staging" || len(env.Variables) != 2 {
+		t.Fatalf("expected Staging with 2 enabled variables, got %+v", env)
+	}
+	if env.Variables[1].Key != "token" || env.Variables[1].Value != "staging-token" {
+		t.Errorf("unexpected variable %+v", env.Variables[1])
+	}
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+)
+
+// Dataset holds the rows of a data file, each run as one iteration of the
+// collection with its columns bound as variables
+type Dataset struct {
+	// Columns lists the variable names in the order of the file
+	Columns []string
+	Rows    []map[string]string
+}
+
+// LoadData reads a CSV file with a header row, or a JSON array of objects
+func LoadData(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var d *Dataset
+	trimmed := bytes.TrimSpace(data)
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(trimmed, []byte("[")) {
+		d, err = parseJSONData(trimmed)
+	} else {
+		d, err = parseCSVData(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(d.Rows) == 0 {
+		return nil, fmt.Errorf("%s has no rows", path)
+	}
+	return d, nil
+}
+
+func parseCSVData(data []byte) (*Dataset, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	d := &Dataset{}
+	for _, name := range records[0] {
+		d.Columns = append(d.Columns, strings.TrimSpace(name))
+	}
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(d.Columns))
+		for i, name := range d.Columns {
+			if name != "" {
+				row[name] = record[i]
+			}
+		}
+		d.Rows = append(d.Rows, row)
+	}
+	return d, nil
+}
+
+// parseJSONData reads an array of objects. Values other than strings are
+// bound as JSON, so a nested payload can be used as a request body.
+func parseJSONData(data []byte) (*Dataset, error) {
+	doc, err := jsonpath.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := doc.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array of objects")
+	}
+	d := &Dataset{}
+	seen := make(map[string]bool)
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("item %d is not an object", i)
+		}
+		// Objects are unordered once decoded, keep the names sorted
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		row := make(map[string]string, len(obj))
+		for _, name := range names {
+			row[name] = jsonpath.Format(obj[name])
+			if !seen[name] {
+				seen[name] = true
+				d.Columns = append(d.Columns, name)
+			}
+		}
+		d.Rows = append(d.Rows, row)
+	}
+	return d, nil
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadData(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	d, err := LoadData(write("tenants.csv", "\ufefftenant, id,note\nacme, 1,\"a, b\"\nglobex,2,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(d.Columns, ",") != "tenant,id,note" || len(d.Rows) != 2 {
+		t.Fatalf("unexpected dataset %+v", d)
+	}
+	if d.Rows[0]["id"] != "1" || d.Rows[0]["note"] != "a, b" || d.Rows[1]["tenant"] != "globex" {
+		t.Errorf("unexpected rows %v", d.Rows)
+	}
+
+	d, err = LoadData(write("payloads.json", `[{"name": "empty", "body": {}}, {"name": "long", "qty": 1e3, "body": {"items": [1, 2]}, "ok": false}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(d.Columns, ",") != "body,name,ok,qty" {
+		t.Errorf("unexpected columns %v", d.Columns)
+	}
+	if d.Rows[0]["body"] != "{}" || d.Rows[1]["body"] != `{"items":[1,2]}` || d.Rows[1]["qty"] != "1e3" || d.Rows[1]["ok"] != "false" {
+		t.Errorf("unexpected rows %v", d.Rows)
+	}
+	if _, ok := d.Rows[0]["qty"]; ok {
+		t.Error("expected missing keys to stay unset")
+	}
+
+	errors := map[string]string{
+		"ragged.csv":  "a,b\n1\n",
+		"header.csv":  "a,b\n",
+		"object.json": `{"a": 1}`,
+		"items.json":  `[1, 2]`,
+	}
+	wants := map[string]string{
+		"ragged.csv":  "wrong number of fields",
+		"header.csv":  "has no rows",
+		"object.json": "expected an array of objects",
+		"items.json":  "item 0 is not an object",
+	}
+	for name, content := range errors {
+		if _, err := LoadData(write(name, content)); err == nil || !strings.Contains(err.Error(), wants[name]) {
+			t.Errorf("%s: expected %q, got %v", name, wants[name], err)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/assert"
+)
+
+// Formats lists the report formats accepted by Write
+var Formats = []string{"text", "junit", "tap", "json"}
+
+// Write writes the report in one of Formats
+func Write(w io.Writer, r *Report, format string) error {
+	switch format {
+	case "", "text":
+		return WriteText(w, r)
+	case "junit":
+		return WriteJUnit(w, r)
+	case "tap":
+		return WriteTAP(w, r)
+	case "json":
+		return WriteJSON(w, r)
+	}
+	return fmt.Errorf("unknown report format %q, expected %s", format, strings.Join(Formats, ", "))
+}
+
+// Line summarizes the result of a request on one line, as the run goes
+func Line(res Result) string {
+	mark := "✓"
+	if !res.Passed() {
+		mark = "✗"
+	}
+	status := "no response"
+	if res.Entry != nil && res.Err == nil {
+		status = res.Entry.Response.Status
+	}
+	line := fmt.Sprintf("%s %s  %s  %s", mark, res.FullName(), status, res.Duration().Round(time.Millisecond))
+	if len(res.Assertions) > 0 {
+		line += fmt.Sprintf("  %d/%d assertions", len(res.Assertions)-assert.Failed(res.Assertions), len(res.Assertions))
+	}
+	if len(res.Violations) > 0 {
+		line += fmt.Sprintf("  %d contract violations", len(res.Violations))
+	}
+	return line
+}
+
+// IterationLabel names an iteration of a data-driven run after its first
+// columns, such as "Iteration 2/3 (tenant=acme, id=7)"
+func IterationLabel(d *Dataset, index int) string {
+	if d == nil {
+		return ""
+	}
+	label := fmt.Sprintf("Iteration %d/%d", index+1, len(d.Rows))
+	if index >= len(d.Rows) {
+		return label
+	}
+	var values []string
+	for _, col := range d.Columns {
+		if len(values) == 3 {
+			values = append(values, "…")
+			break
+		}
+		values = append(values, col+"="+truncate(d.Rows[index][col], 24))
+	}
+	if len(values) == 0 {
+		return label
+	}
+	return label + " (" + strings.Join(values, ", ") + ")"
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// Summary returns the totals of a run on one line
+func Summary(r *Report) string {
+	s := fmt.Sprintf("%d requests, %d passed, %d failed", len(r.Results), len(r.Results)-r.Failed(), r.Failed())
+	if r.Data != nil {
+		s = fmt.Sprintf("%d iterations, ", len(r.Data.Rows)) + s
+	}
+	if r.Skipped > 0 {
+		s += fmt.Sprintf(", %d skipped", r.Skipped)
+	}
+	return s + " in " + r.Duration.Round(time.Millisecond).String()
+}
+
+// WriteSummaryTable writes one row per iteration of a data-driven run, with
+// its data columns, counts and time
+func WriteSummaryTable(w io.Writer, r *Report) error {
+	if r.Data == nil {
+		return nil
+	}
+	header := append([]string{"#"}, r.Data.Columns...)
+	header = append(header, "Passed", "Failed", "Time")
+	rows := [][]string{header}
+	for _, it := range r.Iterations() {
+		row := []string{fmt.Sprint(it.Index + 1)}
+		for _, col := range r.Data.Columns {
+			row = append(row, truncate(it.Row[col], 24))
+		}
+		row = append(row,
+			fmt.Sprint(len(it.Results)-it.Failed()),
+			fmt.Sprint(it.Failed()),
+			it.Duration.Round(time.Millisecond).String())
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for j, cell := range row {
+			b.WriteString(cell)
+			if j < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-len([]rune(cell))+2))
+			}
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			for j, width := range widths {
+				b.WriteString(strings.Repeat("─", width))
+				if j < len(widths)-1 {
+					b.WriteString("  ")
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteText writes a readable summary, with the reasons of each failure,
+// grouped by iteration for data-driven runs
+func WriteText(w io.Writer, r *Report) error {
+	var b strings.Builder
+	for _, it := range r.Iterations() {
+		if r.Data != nil {
+			b.WriteString(IterationLabel(r.Data, it.Index) + "\n")
+		}
+		for _, res := range it.Results {
+			b.WriteString(Line(res))
+			b.WriteString("\n")
+			for _, f := range res.Failures() {
+				b.WriteString("    " + f + "\n")
+			}
+		}
+		if r.Data != nil {
+			b.WriteString("\n")
+		}
+	}
+	if r.Data != nil {
+		if err := WriteSummaryTable(&b, r); err != nil {
+			return err
+		}
+	}
+	b.WriteString("\n" + Summary(r) + "\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the report as JUnit XML, one test case per request and
+// one test suite per iteration
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitSuites{
+		Name:     r.Name,
+		Tests:    len(r.Results) + r.Skipped,
+		Failures: r.Failed(),
+		Skipped:  r.Skipped,
+		Time:     seconds(r.Duration),
+	}
+	for _, it := range r.Iterations() {
+		suite := junitSuite{
+			Name:      r.Name,
+			Tests:     len(it.Results),
+			Failures:  it.Failed(),
+			Time:      seconds(it.Duration),
+			Timestamp: r.Started.Format("2006-01-02T15:04:05"),
+		}
+		if r.Data != nil {
+			suite.Name += " - " + IterationLabel(r.Data, it.Index)
+		}
+		for _, res := range it.Results {
+			c := junitCase{
+				Name:      res.Name,
+				Classname: strings.Join(append([]string{r.Name}, res.Path...), "."),
+				Time:      seconds(res.Duration()),
+			}
+			if res.Entry != nil {
+				c.SystemOut = res.Entry.Request.Method + " " + res.Entry.Request.URL
+				if res.Err == nil {
+					c.SystemOut += " → " + res.Entry.Response.Status
+				}
+			}
+			if failures := res.Failures(); len(failures) > 0 {
+				c.Failure = &junitFailure{Message: failures[0], Text: strings.Join(failures, "\n")}
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if len(suites.Suites) > 0 {
+		last := &suites.Suites[len(suites.Suites)-1]
+		last.Skipped = r.Skipped
+		last.Tests += r.Skipped
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteTAP writes the report in the Test Anything Protocol, version 13
+func WriteTAP(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(r.Results)+r.Skipped)
+	for i, res := range r.Results {
+		name := res.FullName()
+		if r.Data != nil {
+			name = fmt.Sprintf("[%d] %s", res.Iteration+1, name)
+		}
+		failures := res.Failures()
+		if len(failures) == 0 {
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, name)
+			continue
+		}
+		fmt.Fprintf(&b, "not ok %d - %s\n", i+1, name)
+		b.WriteString("  ---\n  failures:\n")
+		for _, f := range failures {
+			fmt.Fprintf(&b, "    - %q\n", f)
+		}
+		if res.Entry != nil {
+			fmt.Fprintf(&b, "  request: %q\n", res.Entry.Request.Method+" "+res.Entry.Request.URL)
+		}
+		b.WriteString("  ...\n")
+	}
+	for i := 0; i < r.Skipped; i++ {
+		fmt.Fprintf(&b, "ok %d # SKIP bailed after a failure\n", len(r.Results)+i+1)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type jsonReport struct {
+	Name       string    `json:"name"`
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"durationMs"`
+	Total      int       `json:"total"`
+	Passed     int       `json:"passed"`
+	Failed     int       `json:"failed"`
+	Skipped    int       `json:"skipped"`
+	// Iterations summarizes data-driven runs
+	Iterations []jsonIteration `json:"iterations,omitempty"`
+	Requests   []jsonResult    `json:"requests"`
+}
+
+type jsonIteration struct {
+	Iteration  int               `json:"iteration"`
+	Data       map[string]string `json:"data"`
+	Passed     int               `json:"passed"`
+	Failed     int               `json:"failed"`
+	DurationMs int64             `json:"durationMs"`
+}
+
+type jsonResult struct {
+	// Iteration counts from 1 in data-driven runs
+	Iteration  int             `json:"iteration,omitempty"`
+	Name       string          `json:"name"`
+	Path       []string        `json:"path,omitempty"`
+	Method     string          `json:"method,omitempty"`
+	URL        string          `json:"url,omitempty"`
+	Status     int             `json:"status,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+	Reused     bool            `json:"reusedConnection"`
+	Passed     bool            `json:"passed"`
+	Error      string          `json:"error,omitempty"`
+	Assertions []jsonAssertion `json:"assertions,omitempty"`
+	Failures   []string        `json:"failures,omitempty"`
+}
+
+type jsonAssertion struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Actual    string `json:"actual,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// WriteJSON writes the report as a JSON document
+func WriteJSON(w io.Writer, r *Report) error {
+	out := jsonReport{
+		Name:       r.Name,
+		Started:    r.Started,
+		DurationMs: r.Duration.Milliseconds(),
+		Total:      len(r.Results) + r.Skipped,
+		Passed:     len(r.Results) - r.Failed(),
+		Failed:     r.Failed(),
+		Skipped:    r.Skipped,
+		Requests:   []jsonResult{},
+	}
+	if r.Data != nil {
+		for _, it := range r.Iterations() {
+			out.Iterations = append(out.Iterations, jsonIteration{
+				Iteration:  it.Index + 1,
+				Data:       it.Row,
+				Passed:     len(it.Results) - it.Failed(),
+				Failed:     it.Failed(),
+				DurationMs: it.Duration.Milliseconds(),
+			})
+		}
+	}
+	for _, res := range r.Results {
+		jr := jsonResult{
+			Name:       res.Name,
+			Path:       res.Path,
+			DurationMs: res.Duration().Milliseconds(),
+			Passed:     res.Passed(),
+			Failures:   res.Failures(),
+		}
+		if r.Data != nil {
+			jr.Iteration = res.Iteration + 1
+		}
+		if res.Entry != nil {
+			jr.Method, jr.URL = res.Entry.Request.Method, res.Entry.Request.URL
+			jr.Status = res.Entry.Response.StatusCode
+			jr.Reused = res.Entry.ReusedConnection
+		}
+		if res.Err != nil {
+			jr.Error = res.Err.Error()
+		}
+		for _, a := range res.Assertions {
+			jr.Assertions = append(jr.Assertions, jsonAssertion{
+				Assertion: assert.Format(a.Assertion),
+				Passed:    a.Passed,
+				Actual:    a.Actual,
+				Message:   a.Message,
+			})
+		}
+		out.Requests = append(out.Requests, jr)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
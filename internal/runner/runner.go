@@ -0,0 +1,294 @@
+// Package runner sends the requests of a collection one after the other,
+// without the TUI, and checks their assertions and contracts.
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tbourrel/apitty/internal/assert"
+	"github.com/tbourrel/apitty/internal/contract"
+	"github.com/tbourrel/apitty/internal/cookies"
+	"github.com/tbourrel/apitty/internal/extract"
+	"github.com/tbourrel/apitty/internal/http"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/schema"
+	"github.com/tbourrel/apitty/internal/vars"
+)
+
+// Options select what a run sends and how
+type Options struct {
+	// Environment is the name of the environment to use, none when empty
+	Environment string
+	// Folder limits the run to the requests of a folder, as a slash
+	// separated path such as "Orders/Admin"
+	Folder string
+	// Data runs the collection once per row, with the columns bound as
+	// variables. Nil runs it once.
+	Data *Dataset
+	// Variables override those of the collection, environment and data row
+	Variables map[string]string
+	// Bail stops the run at the first failed request
+	Bail bool
+	// Delay is the pause between two requests
+	Delay time.Duration
+	// Fresh opens a new connection for every request instead of
+	// reusing kept-alive ones
+	Fresh bool
+	// Progress, when set, is called after each request
+	Progress func(Result)
+}
+
+// Result is the outcome of one request of the run
+type Result struct {
+	// Iteration is the index of the data row, 0 without data
+	Iteration int
+	Name      string
+	// Path is the folder path of the request
+	Path  []string
+	Entry *model.HistoryEntry
+	// Err is set when the request could not be sent or got no response
+	Err        error
+	Assertions []model.AssertionResult
+	// Violations are where the response breaks the contract of the request
+	Violations []schema.Violation
+	// Extract lists the values the request stored for the following ones
+	Extract []extract.Result
+}
+
+// FullName returns the folder path and name of the request
+func (r Result) FullName() string {
+	return strings.Join(append(append([]string{}, r.Path...), r.Name), " / ")
+}
+
+// Failures lists why the request failed, empty when it passed
+func (r Result) Failures() []string {
+	var failures []string
+	if r.Err != nil {
+		failures = append(failures, r.Err.Error())
+	}
+	for _, a := range r.Assertions {
+		if !a.Passed {
+			failures = append(failures, assert.Format(a.Assertion)+": "+a.Message)
+		}
+	}
+	for _, v := range r.Violations {
+		failures = append(failures, "contract "+v.String())
+	}
+	for _, e := range r.Extract {
+		if e.Err != nil {
+			failures = append(failures, fmt.Sprintf("extract %s: %v", e.Rule.Variable, e.Err))
+		}
+	}
+	return failures
+}
+
+// Passed reports whether the request got a response that satisfied its
+// assertions, contract and extraction rules
+func (r Result) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Duration returns the time the request took
+func (r Result) Duration() time.Duration {
+	if r.Entry == nil {
+		return 0
+	}
+	return r.Entry.Timings.Total()
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Name    string
+	Started time.Time
+	// Duration is the wall time of the run, delays included
+	Duration time.Duration
+	Results  []Result
+	// Skipped counts the requests not sent after a bail
+	Skipped int
+	// Data is the dataset the run iterated over, nil for a single run
+	Data *Dataset
+}
+
+// Iteration is the outcome of one run of the collection over a data row
+type Iteration struct {
+	Index int
+	// Row holds the variables of the data row, nil without data
+	Row      map[string]string
+	Results  []Result
+	Duration time.Duration
+}
+
+// Failed counts the failed requests of the iteration
+func (it Iteration) Failed() int {
+	n := 0
+	for _, res := range it.Results {
+		if !res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Iterations groups the results by data row, in order
+func (r *Report) Iterations() []Iteration {
+	var iterations []Iteration
+	for _, res := range r.Results {
+		if len(iterations) == 0 || iterations[len(iterations)-1].Index != res.Iteration {
+			it := Iteration{Index: res.Iteration}
+			if r.Data != nil && res.Iteration < len(r.Data.Rows) {
+				it.Row = r.Data.Rows[res.Iteration]
+			}
+			iterations = append(iterations, it)
+		}
+		last := &iterations[len(iterations)-1]
+		last.Results = append(last.Results, res)
+		last.Duration += res.Duration()
+	}
+	return iterations
+}
+
+// Failed counts the failed requests
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Select returns the requests of a folder, in order, or those of the whole
+// collection when folder is empty
+func Select(c *model.Collection, folder string) ([]model.CollectionEntry, error) {
+	entries := c.Entries()
+	folder = strings.Trim(folder, "/")
+	if folder == "" {
+		return entries, nil
+	}
+	want := strings.Split(folder, "/")
+	var selected []model.CollectionEntry
+	for _, e := range entries {
+		if len(e.Path) < len(want) {
+			continue
+		}
+		match := true
+		for i, name := range want {
+			if !strings.EqualFold(e.Path[i], strings.TrimSpace(name)) {
+				match = false
+				break
+			}
+		}
+		if match {
+			selected = append(selected, e)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no requests in folder %q", folder)
+	}
+	return selected, nil
+}
+
+// environmentIndex finds an environment by name, -1 for none
+func environmentIndex(c *model.Collection, name string) (int, error) {
+	if name == "" {
+		return -1, nil
+	}
+	var names []string
+	for i, env := range c.Environments {
+		if strings.EqualFold(env.Name, name) {
+			return i, nil
+		}
+		names = append(names, env.Name)
+	}
+	if len(names) == 0 {
+		return -1, fmt.Errorf("unknown environment %q, the collection has none", name)
+	}
+	return -1, fmt.Errorf("unknown environment %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// Run sends the selected requests in order. Values extracted from a response
+// are available as {{variables}} to the following requests, and cookies set
+// by a response are sent with the following ones.
+func Run(c *model.Collection, opts Options) (*Report, error) {
+	entries, err := Select(c, opts.Folder)
+	if err != nil {
+		return nil, err
+	}
+	envIdx, err := environmentIndex(c, opts.Environment)
+	if err != nil {
+		return nil, err
+	}
+	rows := []map[string]string{nil}
+	if opts.Data != nil {
+		rows = opts.Data.Rows
+	}
+
+	report := &Report{Name: c.Name, Started: time.Now(), Data: opts.Data}
+	sent := 0
+	for iteration, row := range rows {
+		// Each iteration starts over, with its own variables and cookies
+		variables := c.EnvironmentVariableMap(envIdx)
+		for k, v := range row {
+			variables[k] = v
+		}
+		for k, v := range opts.Variables {
+			variables[k] = v
+		}
+		jar := cookies.NewJar()
+
+		for _, e := range entries {
+			if sent > 0 && opts.Delay > 0 {
+				time.Sleep(opts.Delay)
+			}
+			res := send(e, variables, jar, opts.Fresh)
+			res.Iteration = iteration
+			report.Results = append(report.Results, res)
+			sent++
+			if opts.Progress != nil {
+				opts.Progress(res)
+			}
+			if opts.Bail && !res.Passed() {
+				report.Skipped = len(rows)*len(entries) - sent
+				report.Duration = time.Since(report.Started)
+				return report, nil
+			}
+		}
+	}
+	report.Duration = time.Since(report.Started)
+	return report, nil
+}
+
+// send runs one request and stores its extracted values into variables
+func send(e model.CollectionEntry, variables map[string]string, jar *cookies.Jar, fresh bool) Result {
+	res := Result{Name: e.Request.Name, Path: e.Path}
+	req := vars.ExpandRequest(e.Request.Request, variables)
+	if fresh {
+		// Copy the settings, they are shared with the collection
+		settings := model.Settings{}
+		if req.Settings != nil {
+			settings = *req.Settings
+		}
+		settings.FreshConnection = true
+		req.Settings = &settings
+	}
+	entry, err := http.Send(req, jar)
+	res.Entry = entry
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Assertions = assert.Evaluate(req.Assertions, *entry)
+	if req.Contract != nil {
+		res.Violations = contract.Check(*req.Contract, *entry)
+	}
+	res.Extract = extract.Apply(req.Extract, entry.Request.URL, entry.Response, jar)
+	for _, r := range res.Extract {
+		if r.Err == nil {
+			variables[r.Rule.Variable] = r.Value
+		}
+	}
+	return res
+}
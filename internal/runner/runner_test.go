@@ -0,0 +1,272 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// shop serves a login that sets a session cookie and returns a token, and
+// an orders endpoint requiring both
+func shop(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-1", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token": "tok-1"}`))
+	})
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil || c.Value != "s-1" || r.Header.Get("Authorization") != "Bearer tok-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id": 1, "tenant": "` + r.URL.Query().Get("tenant") + `"}]`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func shopCollection(url string) *model.Collection {
+	return &model.Collection{
+		Name:         "Shop",
+		Variables:    []model.Variable{{Key: "base", Value: url}},
+		Environments: []model.Environment{{Name: "Staging", Variables: []model.Variable{{Key: "tenant", Value: "acme"}}}},
+		Folders: []model.Folder{
+			{Name: "Auth", Requests: []model.SavedRequest{{
+				Name: "Login",
+				Request: model.Request{
+					Method:     "POST",
+					URL:        "{{base}}/login",
+					Extract:    []model.ExtractRule{{Variable: "token", Source: model.ExtractJSON, Expression: "$.token"}},
+					Assertions: []model.Assertion{{Kind: model.AssertStatus, Op: "==", Value: "200"}},
+				},
+			}}},
+			{Name: "Orders", Requests: []model.SavedRequest{{
+				Name: "List",
+				Request: model.Request{
+					Method:  "GET",
+					URL:     "{{base}}/orders?tenant={{tenant}}",
+					Headers: []model.HeaderPair{{Key: "Authorization", Value: "Bearer {{token}}"}},
+					Assertions: []model.Assertion{
+						{Kind: model.AssertStatus, Op: "==", Value: "2xx"},
+						{Kind: model.AssertJSONPath, Target: "$[0].tenant", Op: "==", Value: "{{tenant}}"},
+					},
+				},
+			}, {
+				Name:    "Missing",
+				Request: model.Request{Method: "GET", URL: "{{base}}/missing", Assertions: []model.Assertion{{Kind: model.AssertStatus, Op: "==", Value: "200"}}},
+			}}},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	server := shop(t)
+	c := shopCollection(server.URL)
+
+	var progress []string
+	report, err := Run(c, Options{Environment: "staging", Progress: func(r Result) { progress = append(progress, r.Name) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 3 || strings.Join(progress, ",") != "Login,List,Missing" {
+		t.Fatalf("expected the three requests in order, got %v", progress)
+	}
+	// The token and cookie of the login were sent with the orders request
+	if !report.Results[0].Passed() || !report.Results[1].Passed() {
+		t.Errorf("expected the login and orders to pass, got %v, %v", report.Results[0].Failures(), report.Results[1].Failures())
+	}
+	if report.Failed() != 1 || report.Results[2].Failures()[0] != "status == 200: got 404" {
+		t.Errorf("expected the missing request to fail, got %v", report.Results[2].Failures())
+	}
+	// The collection itself is left untouched
+	if len(c.Variables) != 1 {
+		t.Errorf("expected the extracted token to stay out of the collection, got %v", c.Variables)
+	}
+
+	// Requests reuse the connection of the previous ones, unless fresh
+	if !report.Results[1].Entry.ReusedConnection {
+		t.Error("expected the orders request to reuse the connection of the login")
+	}
+	report, err = Run(c, Options{Environment: "Staging", Fresh: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range report.Results {
+		if res.Entry.ReusedConnection {
+			t.Errorf("expected %s to open a fresh connection", res.Name)
+		}
+	}
+	if c.Folders[0].Requests[0].Request.Settings != nil {
+		t.Error("expected the fresh setting to stay out of the collection")
+	}
+
+	// Without the login, the orders request fails
+	report, err = Run(c, Options{Environment: "Staging", Folder: "orders/", Bail: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 1 || report.Skipped != 1 || report.Results[0].Passed() {
+		t.Errorf("expected the run to bail after the first request, got %d results, %d skipped", len(report.Results), report.Skipped)
+	}
+
+	if _, err := Run(c, Options{Folder: "Admin"}); err == nil || !strings.Contains(err.Error(), `no requests in folder "Admin"`) {
+		t.Errorf("expected an unknown folder error, got %v", err)
+	}
+	if _, err := Run(c, Options{Environment: "Prod"}); err == nil || !strings.Contains(err.Error(), "expected one of Staging") {
+		t.Errorf("expected an unknown environment error, got %v", err)
+	}
+}
+
+func TestRunContract(t *testing.T) {
+	server := shop(t)
+	c := shopCollection(server.URL)
+	path := filepath.Join(t.TempDir(), "login.schema.json")
+	schema := `{"type": "object", "required": ["token", "expires"], "properties": {"token": {"type": "integer"}}}`
+	if err := os.WriteFile(path, []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.Folders[0].Requests[0].Contract = &model.Contract{Schema: path}
+
+	report, err := Run(c, Options{Folder: "Auth"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"contract $.expires: required property is missing",
+		"contract $.token: expected integer, got string",
+	}
+	if got := report.Results[0].Failures(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected failures %q", got)
+	}
+	if line := Line(report.Results[0]); !strings.Contains(line, "2 contract violations") {
+		t.Errorf("expected the violations in the line, got %q", line)
+	}
+}
+
+func TestReports(t *testing.T) {
+	server := shop(t)
+	report, err := Run(shopCollection(server.URL), Options{Environment: "Staging", Variables: map[string]string{"tenant": "globex"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var junit bytes.Buffer
+	if err := Write(&junit, report, "junit"); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, junit.String())
+	}
+	cases := suites.Suites[0].Cases
+	if suites.Tests != 3 || suites.Failures != 1 || len(cases) != 3 || cases[1].Classname != "Shop.Orders" || cases[2].Failure == nil {
+		t.Errorf("unexpected JUnit report:\n%s", junit.String())
+	}
+
+	var tap bytes.Buffer
+	if err := Write(&tap, report, "tap"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"TAP version 13\n1..3\n", "ok 1 - Auth / Login\n", "not ok 3 - Orders / Missing\n", `- "status == 200: got 404"`} {
+		if !strings.Contains(tap.String(), want) {
+			t.Errorf("expected %q in the TAP report:\n%s", want, tap.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, report, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded jsonReport
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Passed != 2 || decoded.Failed != 1 || decoded.Requests[1].Assertions[1].Actual != "globex" {
+		t.Errorf("unexpected JSON report:\n%s", out.String())
+	}
+
+	var text bytes.Buffer
+	if err := Write(&text, report, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "✗ Orders / Missing  404 Not Found") || !strings.Contains(text.String(), "3 requests, 2 passed, 1 failed") {
+		t.Errorf("unexpected text report:\n%s", text.String())
+	}
+
+	if err := Write(&text, report, "xml"); err == nil {
+		t.Error("expected an unknown format error")
+	}
+}
+
+func TestRunData(t *testing.T) {
+	server := shop(t)
+	data := &Dataset{
+		Columns: []string{"tenant"},
+		Rows:    []map[string]string{{"tenant": "acme"}, {"tenant": "globex"}, {"tenant": "initech"}},
+	}
+	c := shopCollection(server.URL)
+	// The expected tenant comes from the data row too
+	c.Folders[1].Requests = c.Folders[1].Requests[:1]
+
+	report, err := Run(c, Options{Environment: "Staging", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	iterations := report.Iterations()
+	if len(report.Results) != 6 || len(iterations) != 3 || report.Failed() != 0 {
+		t.Fatalf("expected 3 passing iterations of 2 requests, got %d results, %d failed", len(report.Results), report.Failed())
+	}
+	if iterations[2].Row["tenant"] != "initech" || iterations[2].Results[1].Assertions[1].Actual != "initech" {
+		t.Errorf("expected the row to be bound as variables, got %+v", iterations[2].Results[1].Assertions)
+	}
+
+	// -var overrides the data row
+	report, err = Run(c, Options{Data: data, Variables: map[string]string{"tenant": "umbrella"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Results[1].Assertions[1].Actual != "umbrella" {
+		t.Errorf("expected the override in the first iteration, got %+v", report.Results[1].Assertions)
+	}
+
+	var text strings.Builder
+	if err := WriteText(&text, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Iteration 1/3 (tenant=acme)\n", "#  tenant   Passed  Failed  Time\n─  ───────  ──────  ──────  ────\n1  acme     2       0", "3 iterations, 6 requests, 6 passed, 0 failed in "} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("expected %q in the text report:\n%s", want, text.String())
+		}
+	}
+
+	// A bail skips the rest of the iteration and the following ones
+	report, err = Run(c, Options{Data: data, Folder: "Orders", Bail: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 1 || report.Skipped != 2 {
+		t.Errorf("expected the run to stop after the first request, got %d results, %d skipped", len(report.Results), report.Skipped)
+	}
+	var junit bytes.Buffer
+	if err := WriteJUnit(&junit, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<testsuites name="Shop" tests="3" failures="1" skipped="2"`, `<testsuite name="Shop - Iteration 1/3 (tenant=acme)"`} {
+		if !strings.Contains(junit.String(), want) {
+			t.Errorf("expected %q in the JUnit report:\n%s", want, junit.String())
+		}
+	}
+}
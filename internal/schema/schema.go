@@ -0,0 +1,592 @@
+// Package schema validates JSON documents against JSON Schema draft 2020-12,
+// and against the schemas of OpenAPI 3.0, which add nullable.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"gopkg.in/yaml.v3"
+)
+
+// maxDepth stops schemas referencing themselves without going deeper in the
+// document
+const maxDepth = 64
+
+// Violation is a place where a document breaks its schema
+type Violation struct {
+	// Path is the JSONPath of the value, such as $.items[2].id
+	Path    string
+	Message string
+}
+
+// String renders the violation as the Contract tab lists it
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Validator checks documents against the schemas of a root document, a
+// schema file or an OpenAPI specification, which local $ref resolve against
+type Validator struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// New returns a validator resolving references against root
+func New(root any) *Validator {
+	return &Validator{root: root, patterns: map[string]*regexp.Regexp{}}
+}
+
+// Load reads a schema from a JSON or YAML file
+func Load(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a JSON or YAML schema
+func Parse(data []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var doc any
+	if err := d.Decode(&doc); err == nil {
+		return doc, nil
+	}
+	doc = nil
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return doc, nil
+}
+
+// Root returns the document the validator resolves references against
+func (v *Validator) Root() any {
+	return v.root
+}
+
+// Validate checks a document decoded with encoding/json against a schema
+func (v *Validator) Validate(schema, doc any) []Violation {
+	var out []Violation
+	v.validate(schema, doc, "$", 0, &out)
+	return out
+}
+
+// Valid tells whether a document matches a schema
+func (v *Validator) Valid(schema, doc any) bool {
+	return len(v.Validate(schema, doc)) == 0
+}
+
+func (v *Validator) validate(schema, doc any, path string, depth int, out *[]Violation) {
+	fail := func(format string, args ...any) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if depth > maxDepth {
+		fail("the schema references itself too deeply")
+		return
+	}
+	s, ok := schema.(map[string]any)
+	if !ok {
+		// A false schema allows nothing, a true one anything
+		if schema == false {
+			fail("no value is allowed here")
+		}
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			fail("%v", err)
+		} else {
+			v.validate(target, doc, path, depth+1, out)
+		}
+	}
+
+	if doc == nil && s["nullable"] == true {
+		return
+	}
+	if t, ok := s["type"]; ok && !matchesType(t, doc) {
+		fail("expected %s, got %s", typeNames(t), typeOf(doc))
+		// The other keywords would only repeat that the type is wrong
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			found = found || equal(e, doc)
+		}
+		if !found {
+			fail("%s is not one of %s", compact(doc), compact(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !equal(c, doc) {
+		fail("expected %s, got %s", compact(c), compact(doc))
+	}
+
+	switch d := doc.(type) {
+	case string:
+		v.validateString(s, d, fail)
+	case []any:
+		v.validateArray(s, d, path, depth, out, fail)
+	case map[string]any:
+		v.validateObject(s, d, path, depth, out, fail)
+	default:
+		if n, ok := number(doc); ok {
+			validateNumber(s, n, fail)
+		}
+	}
+
+	for _, sub := range list(s["allOf"]) {
+		v.validate(sub, doc, path, depth+1, out)
+	}
+	if anyOf := list(s["anyOf"]); len(anyOf) > 0 {
+		matched := false
+		for _, sub := range anyOf {
+			matched = matched || v.matches(sub, doc, depth)
+		}
+		if !matched {
+			fail("does not match any of the %d allowed schemas", len(anyOf))
+		}
+	}
+	if oneOf := list(s["oneOf"]); len(oneOf) > 0 {
+		matched := 0
+		for _, sub := range oneOf {
+			if v.matches(sub, doc, depth) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("matches %d of the %d schemas of oneOf, expected exactly one", matched, len(oneOf))
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(not, doc, depth) {
+		fail("matches a schema it must not")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.matches(cond, doc, depth) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, doc, path, depth+1, out)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.validate(els, doc, path, depth+1, out)
+		}
+	}
+}
+
+// matches tells whether a document matches a subschema, for the keywords
+// that only need a yes or no
+func (v *Validator) matches(schema, doc any, depth int) bool {
+	var out []Violation
+	v.validate(schema, doc, "$", depth+1, &out)
+	return len(out) == 0
+}
+
+func validateNumber(s map[string]any, n float64, fail func(string, ...any)) {
+	if bound, ok := number(s["minimum"]); ok {
+		// OpenAPI 3.0 makes the bound exclusive with a boolean
+		if s["exclusiveMinimum"] == true && n <= bound {
+			fail("%s is not greater than %s", format(n), format(bound))
+		} else if n < bound {
+			fail("%s is less than the minimum %s", format(n), format(bound))
+		}
+	}
+	if bound, ok := number(s["maximum"]); ok {
+		if s["exclusiveMaximum"] == true && n >= bound {
+			fail("%s is not less than %s", format(n), format(bound))
+		} else if n > bound {
+			fail("%s is greater than the maximum %s", format(n), format(bound))
+		}
+	}
+	if bound, ok := number(s["exclusiveMinimum"]); ok && n <= bound {
+		fail("%s is not greater than %s", format(n), format(bound))
+	}
+	if bound, ok := number(s["exclusiveMaximum"]); ok && n >= bound {
+		fail("%s is not less than %s", format(n), format(bound))
+	}
+	if m, ok := number(s["multipleOf"]); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("%s is not a multiple of %s", format(n), format(m))
+		}
+	}
+}
+
+func (v *Validator) validateString(s map[string]any, str string, fail func(string, ...any)) {
+	length := utf8.RuneCountInString(str)
+	if bound, ok := number(s["minLength"]); ok && float64(length) < bound {
+		fail("%d characters, expected at least %s", length, format(bound))
+	}
+	if bound, ok := number(s["maxLength"]); ok && float64(length) > bound {
+		fail("%d characters, expected at most %s", length, format(bound))
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		if re := v.regexp(pattern); re == nil {
+			fail("invalid pattern %q in the schema", pattern)
+		} else if !re.MatchString(str) {
+			fail("%q does not match %s", str, pattern)
+		}
+	}
+	if f, ok := s["format"].(string); ok && !validFormat(f, str) {
+		fail("%q is not a valid %s", str, f)
+	}
+}
+
+// uuidPattern matches the textual form of a UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validFormat checks the formats clients rely on, and accepts the others
+func validFormat(f, s string) bool {
+	switch f {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "email":
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Address == s
+	case "uuid":
+		return uuidPattern.MatchString(s)
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "ipv4":
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is4()
+	case "ipv6":
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is6()
+	}
+	return true
+}
+
+func (v *Validator) validateArray(s map[string]any, items []any, path string, depth int, out *[]Violation, fail func(string, ...any)) {
+	if bound, ok := number(s["minItems"]); ok && float64(len(items)) < bound {
+		fail("%d items, expected at least %s", len(items), format(bound))
+	}
+	if bound, ok := number(s["maxItems"]); ok && float64(len(items)) > bound {
+		fail("%d items, expected at most %s", len(items), format(bound))
+	}
+	if s["uniqueItems"] == true {
+	unique:
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if equal(items[i], items[j]) {
+					fail("items %d and %d are equal, expected unique items", i, j)
+					break unique
+				}
+			}
+		}
+	}
+
+	// Drafts before 2020-12, and OpenAPI 3.0, give tuples as an items array
+	prefix := list(s["prefixItems"])
+	rest, hasRest := s["items"]
+	if tuple, ok := rest.([]any); ok {
+		prefix, rest = tuple, s["additionalItems"]
+		_, hasRest = s["additionalItems"]
+	}
+	for i, item := range items {
+		itemPath := path + "[" + strconv.Itoa(i) + "]"
+		if i < len(prefix) {
+			v.validate(prefix[i], item, itemPath, depth+1, out)
+		} else if hasRest {
+			v.validate(rest, item, itemPath, depth+1, out)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		found := 0
+		for _, item := range items {
+			if v.matches(contains, item, depth) {
+				found++
+			}
+		}
+		bound := 1.0
+		if n, ok := number(s["minContains"]); ok {
+			bound = n
+		}
+		if float64(found) < bound {
+			fail("%d items match contains, expected at least %s", found, format(bound))
+		}
+		if bound, ok := number(s["maxContains"]); ok && float64(found) > bound {
+			fail("%d items match contains, expected at most %s", found, format(bound))
+		}
+	}
+}
+
+func (v *Validator) validateObject(s map[string]any, object map[string]any, path string, depth int, out *[]Violation, fail func(string, ...any)) {
+	if bound, ok := number(s["minProperties"]); ok && float64(len(object)) < bound {
+		fail("%d properties, expected at least %s", len(object), format(bound))
+	}
+	if bound, ok := number(s["maxProperties"]); ok && float64(len(object)) > bound {
+		fail("%d properties, expected at most %s", len(object), format(bound))
+	}
+	for _, r := range list(s["required"]) {
+		if name, ok := r.(string); ok {
+			if _, ok := object[name]; !ok {
+				*out = append(*out, Violation{Path: jsonpath.Child(path, name), Message: "required property is missing"})
+			}
+		}
+	}
+	for name, required := range obj(s["dependentRequired"]) {
+		if _, ok := object[name]; !ok {
+			continue
+		}
+		for _, r := range list(required) {
+			if other, ok := r.(string); ok {
+				if _, ok := object[other]; !ok {
+					*out = append(*out, Violation{Path: jsonpath.Child(path, other), Message: "required with " + name})
+				}
+			}
+		}
+	}
+
+	properties := obj(s["properties"])
+	patterns := obj(s["patternProperties"])
+	additional, hasAdditional := s["additionalProperties"]
+	names, hasNames := s["propertyNames"]
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		keyPath := jsonpath.Child(path, k)
+		if hasNames && !v.matches(names, k, depth) {
+			*out = append(*out, Violation{Path: keyPath, Message: "property name is not allowed"})
+		}
+		matched := false
+		if sub, ok := properties[k]; ok {
+			matched = true
+			v.validate(sub, object[k], keyPath, depth+1, out)
+		}
+		for pattern, sub := range patterns {
+			if re := v.regexp(pattern); re != nil && re.MatchString(k) {
+				matched = true
+				v.validate(sub, object[k], keyPath, depth+1, out)
+			}
+		}
+		if !matched && hasAdditional {
+			if additional == false {
+				*out = append(*out, Violation{Path: keyPath, Message: "property is not allowed"})
+			} else {
+				v.validate(additional, object[k], keyPath, depth+1, out)
+			}
+		}
+	}
+}
+
+// resolve follows a reference into the root document: # itself, a JSON
+// pointer such as #/components/schemas/Pet, or a $defs entry
+func (v *Validator) resolve(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("cannot follow %s, only references inside the document are supported", ref)
+	}
+	cur := v.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return cur, nil
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		part, _ = url.PathUnescape(part)
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		switch c := cur.(type) {
+		case map[string]any:
+			next, ok := c[part]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", ref)
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("%s does not exist", ref)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("%s does not exist", ref)
+		}
+	}
+	return cur, nil
+}
+
+// regexp compiles a pattern once
+func (v *Validator) regexp(pattern string) *regexp.Regexp {
+	re, ok := v.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		v.patterns[pattern] = re
+	}
+	return re
+}
+
+// matchesType tells whether a value has one of the types of a schema
+func matchesType(t, doc any) bool {
+	for _, name := range typeList(t) {
+		switch name {
+		case "null":
+			if doc == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := doc.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := doc.(string); ok {
+				return true
+			}
+		case "array":
+			if _, ok := doc.([]any); ok {
+				return true
+			}
+		case "object":
+			if _, ok := doc.(map[string]any); ok {
+				return true
+			}
+		case "number":
+			if _, ok := number(doc); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := number(doc); ok && n == math.Trunc(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeList(t any) []string {
+	if s, ok := t.(string); ok {
+		return []string{s}
+	}
+	var names []string
+	for _, v := range list(t) {
+		if s, ok := v.(string); ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+func typeNames(t any) string {
+	return strings.Join(typeList(t), " or ")
+}
+
+// typeOf names the JSON type of a value
+func typeOf(doc any) string {
+	switch doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if n, ok := number(doc); ok {
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", doc)
+}
+
+// number reads the numbers of documents and of schemas, decoded from JSON
+// or YAML
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// equal compares JSON values, numbers by value
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if w, ok := b[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// compact renders a value as JSON for a message
+func compact(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func format(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func obj(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func list(v any) []any {
+	l, _ := v.([]any)
+	return l
+}
@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+)
+
+func parse(t *testing.T, s string) any {
+	t.Helper()
+	doc, err := Parse([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func violations(t *testing.T, schema, body string) string {
+	t.Helper()
+	root := parse(t, schema)
+	doc, err := jsonpath.Decode([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, v := range New(root).Validate(root, doc) {
+		lines = append(lines, v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestValidate(t *testing.T) {
+	const pets = `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "array",
+		"items": {"$ref": "#/$defs/pet"},
+		"$defs": {
+			"pet": {
+				"type": "object",
+				"required": ["id", "name"],
+				"properties": {
+					"id": {"type": "integer", "minimum": 1},
+					"name": {"type": "string", "minLength": 1},
+					"tag": {"type": ["string", "null"], "enum": ["cat", "dog", null]},
+					"born": {"type": "string", "format": "date"},
+					"owner-email": {"type": "string", "format": "email"}
+				},
+				"additionalProperties": false
+			}
+		}
+	}`
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{`[{"id": 1, "name": "Rex", "tag": "dog", "born": "2020-02-29"}, {"id": 2, "name": "Tom", "tag": null}]`, ""},
+		{`{"id": 1}`, "$: expected array, got object"},
+		{`[{"id": 0, "name": ""}]`, "$[0].id: 0 is less than the minimum 1\n$[0].name: 0 characters, expected at least 1"},
+		{`[{"id": 1.5, "name": "Rex"}]`, "$[0].id: expected integer, got number"},
+		{`[{"name": "Rex", "color": "red"}]`, "$[0].id: required property is missing\n$[0].color: property is not allowed"},
+		{`[{"id": 1, "name": "Rex", "tag": "bird"}]`, `$[0].tag: "bird" is not one of ["cat","dog",null]`},
+		{`[{"id": 1, "name": "Rex", "born": "yesterday", "owner-email": "nobody"}]`, "$[0].born: \"yesterday\" is not a valid date\n$[0]['owner-email']: \"nobody\" is not a valid email"},
+	}
+	for _, test := range tests {
+		if got := violations(t, pets, test.body); got != test.expected {
+			t.Errorf("%s:\ngot      %q\nexpected %q", test.body, got, test.expected)
+		}
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	const shape = `
+type: object
+oneOf:
+  - properties: {kind: {const: circle}, radius: {type: number, exclusiveMinimum: 0}}
+    required: [kind, radius]
+  - properties: {kind: {const: square}, side: {type: number, multipleOf: 0.5}}
+    required: [kind, side]
+not: {required: [deleted]}
+`
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{`{"kind": "circle", "radius": 2}`, ""},
+		{`{"kind": "square", "side": 1.5}`, ""},
+		{`{"kind": "square", "side": 1.2}`, "$: matches 0 of the 2 schemas of oneOf, expected exactly one"},
+		{`{"kind": "circle", "radius": 1, "deleted": true}`, "$: matches a schema it must not"},
+	}
+	for _, test := range tests {
+		if got := violations(t, shape, test.body); got != test.expected {
+			t.Errorf("%s:\ngot      %q\nexpected %q", test.body, got, test.expected)
+		}
+	}
+}
+
+func TestOpenAPI30(t *testing.T) {
+	// OpenAPI 3.0 spells nullable types and exclusive bounds differently
+	const spec = `
+components:
+  schemas:
+    Price:
+      type: object
+      properties:
+        amount: {type: number, minimum: 0, exclusiveMinimum: true}
+        currency: {type: string, nullable: true, pattern: "^[A-Z]{3}$"}
+        tags: {type: array, uniqueItems: true, maxItems: 2, items: {type: string}}
+`
+	root := parse(t, spec)
+	price := root.(map[string]any)["components"].(map[string]any)["schemas"].(map[string]any)["Price"]
+	v := New(root)
+
+	doc, _ := jsonpath.Decode([]byte(`{"amount": 0, "currency": "eur", "tags": ["a", "a", "b"]}`))
+	var got []string
+	for _, violation := range v.Validate(price, doc) {
+		got = append(got, violation.String())
+	}
+	expected := []string{
+		"$.amount: 0 is not greater than 0",
+		`$.currency: "eur" does not match ^[A-Z]{3}$`,
+		"$.tags: 3 items, expected at most 2",
+		"$.tags: items 0 and 1 are equal, expected unique items",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected violations:\n%s", strings.Join(got, "\n"))
+	}
+
+	doc, _ = jsonpath.Decode([]byte(`{"amount": 9.99, "currency": null, "tags": []}`))
+	if !v.Valid(price, doc) {
+		t.Errorf("expected a valid price, got %v", v.Validate(price, doc))
+	}
+
+	// References that lead nowhere are reported, not followed
+	broken := parse(t, `{"$ref": "#/definitions/missing"}`)
+	if got := New(broken).Validate(broken, nil); len(got) != 1 || got[0].Message != "#/definitions/missing does not exist" {
+		t.Errorf("unexpected violations %v", got)
+	}
+	recursive := parse(t, `{"$ref": "#"}`)
+	if got := New(recursive).Validate(recursive, nil); len(got) != 1 || !strings.Contains(got[0].Message, "too deeply") {
+		t.Errorf("unexpected violations %v", got)
+	}
+}
@@ -0,0 +1,227 @@
+// Package table lays out an array of JSON objects in rows and columns, for
+// list endpoints that are easier to read as a table than as a tree.
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/jsonpath"
+)
+
+// MaxWidth is the widest a column is laid out, longer cells are cut
+const MaxWidth = 30
+
+// ErrNotObjects reports a value that is not an array of objects
+var ErrNotObjects = errors.New("not an array of objects")
+
+// Table is an array of objects, one row per object and one column per key
+// found in any of them
+type Table struct {
+	// Columns are the union of the keys of the objects, sorted
+	Columns []string
+	// Widths are the widths the columns are laid out at
+	Widths []int
+	// Rows are the objects, in the order they are sorted
+	Rows []map[string]any
+	// SortColumn is the column the rows are sorted by, or -1
+	SortColumn int
+	Descending bool
+}
+
+// New lays out an array of objects. Values other than objects in the array
+// are refused, except null.
+func New(v any) (*Table, error) {
+	items, ok := v.([]any)
+	if !ok || len(items) == 0 {
+		return nil, ErrNotObjects
+	}
+	t := &Table{SortColumn: -1}
+	keys := map[string]bool{}
+	for _, item := range items {
+		if item == nil {
+			t.Rows = append(t.Rows, map[string]any{})
+			continue
+		}
+		object, ok := item.(map[string]any)
+		if !ok {
+			return nil, ErrNotObjects
+		}
+		for k := range object {
+			if !keys[k] {
+				keys[k] = true
+				t.Columns = append(t.Columns, k)
+			}
+		}
+		t.Rows = append(t.Rows, object)
+	}
+	sort.Strings(t.Columns)
+
+	t.Widths = make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		t.Widths[i] = min(max(utf8.RuneCountInString(c), 1), MaxWidth)
+		for row := range t.Rows {
+			t.Widths[i] = max(t.Widths[i], min(utf8.RuneCountInString(t.Cell(row, i)), MaxWidth))
+		}
+	}
+	return t, nil
+}
+
+// Find returns the path of the first array of objects in a document: the
+// document itself, or the shallowest field holding one
+func Find(doc any) (string, bool) {
+	type node struct {
+		path  string
+		value any
+	}
+	queue := []node{{"$", doc}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if _, err := New(n.value); err == nil {
+			return n.path, true
+		}
+		if object, ok := n.value.(map[string]any); ok {
+			keys := make([]string, 0, len(object))
+			for k := range object {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				queue = append(queue, node{jsonpath.Child(n.path, k), object[k]})
+			}
+		}
+	}
+	return "", false
+}
+
+// Value returns the value of a cell, and false when its object lacks the key
+func (t *Table) Value(row, col int) (any, bool) {
+	v, ok := t.Rows[row][t.Columns[col]]
+	return v, ok
+}
+
+// Cell renders a cell on one line: strings as is, other values as compact
+// JSON, and nothing for a missing key
+func (t *Table) Cell(row, col int) string {
+	v, ok := t.Value(row, col)
+	if !ok {
+		return ""
+	}
+	return strings.Join(strings.Fields(jsonpath.Format(v)), " ")
+}
+
+// Detail renders a cell in full: strings as is, other values as indented JSON
+func (t *Table) Detail(row, col int) string {
+	v, ok := t.Value(row, col)
+	if !ok {
+		return "(missing)"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Sort sorts the rows by a column, or reverses them when they already are.
+// Numbers sort by value, and missing cells come last.
+func (t *Table) Sort(col int) {
+	if col == t.SortColumn {
+		t.Descending = !t.Descending
+	} else {
+		t.SortColumn, t.Descending = col, false
+	}
+	key := t.Columns[col]
+	sort.SliceStable(t.Rows, func(i, j int) bool {
+		a, aok := t.Rows[i][key]
+		b, bok := t.Rows[j][key]
+		if !aok || !bok {
+			// Missing cells stay last in both directions
+			return aok && !bok
+		}
+		if t.Descending {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// less orders numbers by value and other values by their text, with nulls
+// first
+func less(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x < y
+		}
+	}
+	return jsonpath.Format(a) < jsonpath.Format(b)
+}
+
+// number returns the value of a JSON number
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// WriteCSV writes the table as CSV, with a header row of the columns
+func (t *Table) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(t.Columns); err != nil {
+		return err
+	}
+	for row := range t.Rows {
+		record := make([]string, len(t.Columns))
+		for col := range t.Columns {
+			if v, ok := t.Value(row, col); ok && v != nil {
+				record[col] = jsonpath.Format(v)
+			}
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// ExportedMsg reports the result of an export
+type ExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// ExportCmd writes the table as CSV, in the order its rows are sorted when
+// the command is made
+func ExportCmd(path string, t *Table) tea.Cmd {
+	var b bytes.Buffer
+	err := t.WriteCSV(&b)
+	return func() tea.Msg {
+		if err == nil {
+			err = os.WriteFile(path, b.Bytes(), 0o644)
+		}
+		return ExportedMsg{Path: path, Err: err}
+	}
+}
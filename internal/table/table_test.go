@@ -0,0 +1,122 @@
+package table
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/jsonpath"
+)
+
+func decode(t *testing.T, body string) any {
+	t.Helper()
+	doc, err := jsonpath.Decode([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestNew(t *testing.T) {
+	tbl, err := New(decode(t, `[{"id": 2, "name": "Bob"}, {"id": 10, "tags": ["a", "b"]}, null]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tbl.Columns, ",") != "id,name,tags" {
+		t.Errorf("unexpected columns %v", tbl.Columns)
+	}
+	if len(tbl.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(tbl.Rows))
+	}
+	if cell := tbl.Cell(1, 2); cell != `["a","b"]` {
+		t.Errorf("unexpected cell %q", cell)
+	}
+	if cell := tbl.Cell(1, 1); cell != "" {
+		t.Errorf("expected an empty cell for a missing key, got %q", cell)
+	}
+	if detail := tbl.Detail(1, 2); detail != "[\n  \"a\",\n  \"b\"\n]" {
+		t.Errorf("unexpected detail %q", detail)
+	}
+	if detail := tbl.Detail(2, 0); detail != "(missing)" {
+		t.Errorf("unexpected detail %q", detail)
+	}
+	if w := tbl.Widths; w[0] != 2 || w[1] != 4 || w[2] != 9 {
+		t.Errorf("unexpected widths %v", w)
+	}
+
+	for _, body := range []string{`[]`, `[1, 2]`, `{"id": 1}`, `"text"`, `[{"id": 1}, "text"]`} {
+		if _, err := New(decode(t, body)); err != ErrNotObjects {
+			t.Errorf("expected %s to be refused, got %v", body, err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		body string
+		path string
+	}{
+		{`[{"id": 1}]`, "$"},
+		{`{"meta": {"total": 1}, "data": {"items": [{"id": 1}]}}`, "$.data.items"},
+		{`{"b": {"list": [{"id": 1}]}, "a": [{"id": 2}]}`, "$.a"},
+		{`{"page-1": [{"id": 1}]}`, "$['page-1']"},
+		{`{"it's": [{"id": 1}]}`, `$['it\'s']`},
+	}
+	for _, test := range tests {
+		doc := decode(t, test.body)
+		path, ok := Find(doc)
+		if !ok || path != test.path {
+			t.Errorf("Find(%s) = %q, %v, expected %q", test.body, path, ok, test.path)
+			continue
+		}
+		// The path finds the array back
+		matches, err := jsonpath.Query(doc, path)
+		if err != nil || len(matches) != 1 {
+			t.Errorf("path %q does not query the array: %v", path, err)
+		}
+	}
+	if _, ok := Find(decode(t, `{"ids": [1, 2]}`)); ok {
+		t.Error("expected no array of objects")
+	}
+}
+
+func TestSort(t *testing.T) {
+	tbl, err := New(decode(t, `[{"n": 10, "s": "b"}, {"s": "a"}, {"n": 9, "s": "c"}, {"n": null}, {"n": 100}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	column := func(col int) string {
+		var cells []string
+		for row := range tbl.Rows {
+			cells = append(cells, tbl.Cell(row, col))
+		}
+		return strings.Join(cells, ",")
+	}
+
+	tbl.Sort(0)
+	if got := column(0); got != "null,9,10,100," {
+		t.Errorf("unexpected ascending order %q", got)
+	}
+	tbl.Sort(0)
+	if !tbl.Descending || column(0) != "100,10,9,null," {
+		t.Errorf("unexpected descending order %q", column(0))
+	}
+	tbl.Sort(1)
+	if tbl.Descending || tbl.SortColumn != 1 || column(1) != "a,b,c,," {
+		t.Errorf("unexpected order by text %q", column(1))
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tbl, err := New(decode(t, `[{"id": 1, "name": "Alice, Jr.", "meta": {"admin": true}}, {"id": 2, "name": null}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := tbl.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,meta,name\n1,\"{\"\"admin\"\":true}\",\"Alice, Jr.\"\n2,,\n"
+	if b.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+}
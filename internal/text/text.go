@@ -11,55 +11,75 @@ func WrapText(text string, width int) string {
 	}
 
 	var result strings.Builder
-	lines := strings.Split(text, "\n")
-
-	for _, line := range lines {
-		// Handle ANSI color codes - strip them for length calculation
-		visibleLen := VisibleLength(line)
-
-		if visibleLen <= width {
-			result.WriteString(line)
+	for _, line := range strings.Split(text, "\n") {
+		for _, row := range WrapLine(line, width) {
+			result.WriteString(row)
 			result.WriteByte('\n')
-			continue
 		}
+	}
+
+	return result.String()
+}
 
-		// Wrap long lines
-		currentPos := 0
-		for currentPos < len(line) {
-			// Find how many characters fit in width
-			chunkEnd := findChunkEnd(line, currentPos, width)
+// WrapLine splits a line into rows that fit within the given width
+func WrapLine(line string, width int) []string {
+	// Handle ANSI color codes - strip them for length calculation
+	if width <= 0 || VisibleLength(line) <= width {
+		return []string{line}
+	}
 
-			if chunkEnd <= currentPos {
-				break
-			}
+	// Wrap long lines
+	var rows []string
+	currentPos := 0
+	for currentPos < len(line) {
+		// Find how many characters fit in width
+		chunkEnd := findChunkEnd(line, currentPos, width)
+
+		if chunkEnd <= currentPos {
+			break
+		}
 
-			// Extract chunk
-			chunk := line[currentPos:chunkEnd]
-
-			// Try to break at a good position (space, comma, etc.)
-			if chunkEnd < len(line) {
-				// Look back for a good break point
-				for i := len(chunk) - 1; i >= max(0, len(chunk)-15); i-- {
-					if chunk[i] == ' ' || chunk[i] == ',' || chunk[i] == ':' {
-						chunk = chunk[:i+1]
-						chunkEnd = currentPos + len(chunk)
-						break
-					}
+		// Extract chunk
+		chunk := line[currentPos:chunkEnd]
+
+		// Try to break at a good position (space, comma, etc.)
+		if chunkEnd < len(line) {
+			// Look back for a good break point
+			for i := len(chunk) - 1; i >= max(0, len(chunk)-15); i-- {
+				if chunk[i] == ' ' || chunk[i] == ',' || chunk[i] == ':' {
+					chunk = chunk[:i+1]
+					chunkEnd = currentPos + len(chunk)
+					break
 				}
 			}
+		}
 
-			result.WriteString(chunk)
-			result.WriteByte('\n')
+		rows = append(rows, chunk)
 
-			// Skip leading spaces on next line
-			currentPos = chunkEnd
-			for currentPos < len(line) && line[currentPos] == ' ' {
-				currentPos++
-			}
+		// Skip leading spaces on next line
+		currentPos = chunkEnd
+		for currentPos < len(line) && line[currentPos] == ' ' {
+			currentPos++
 		}
 	}
+	if len(rows) == 0 {
+		return []string{""}
+	}
 
-	return result.String()
+	return rows
+}
+
+// Truncate cuts a line to the given width, resetting its colors when it
+// cuts through ANSI color codes
+func Truncate(line string, width int) string {
+	end := findChunkEnd(line, 0, width)
+	if end >= len(line) {
+		return line
+	}
+	if strings.Contains(line[:end], "\x1b") {
+		return line[:end] + "\x1b[0m"
+	}
+	return line[:end]
 }
 
 // VisibleLength returns the length of string without ANSI codes
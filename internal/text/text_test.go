@@ -70,3 +70,39 @@ func TestVisibleLength(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapLine(t *testing.T) {
+	rows := WrapLine("This is a very long line that should be wrapped", 10)
+	if len(rows) < 5 {
+		t.Fatalf("expected at least 5 rows, got %q", rows)
+	}
+	for _, row := range rows {
+		if VisibleLength(row) > 10 {
+			t.Errorf("row %q is wider than 10", row)
+		}
+	}
+	if rows := WrapLine("", 10); len(rows) != 1 || rows[0] != "" {
+		t.Errorf("expected an empty line to keep one row, got %q", rows)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected string
+	}{
+		{"Short line", "Hello", 10, "Hello"},
+		{"Long line", "Hello World", 5, "Hello"},
+		{"Colored line", "\x1b[31mRed Text\x1b[0m", 3, "\x1b[31mRed\x1b[0m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Truncate(tt.input, tt.width); result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
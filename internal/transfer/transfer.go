@@ -0,0 +1,91 @@
+// Package transfer tracks the progress of a response body being received.
+package transfer
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultPreview is the start of a body kept in memory, 1 MiB
+const DefaultPreview = 1 << 20
+
+// Transfer tracks the bytes received for a response and lets the request
+// be cancelled. The body is kept in a file, only Preview bytes in memory.
+type Transfer struct {
+	// Preview is the number of bytes kept in memory
+	Preview int64
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  time.Time
+	received atomic.Int64
+	total    atomic.Int64
+}
+
+// New prepares a transfer keeping preview bytes in memory, DefaultPreview
+// when not positive
+func New(preview int64) *Transfer {
+	if preview <= 0 {
+		preview = DefaultPreview
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transfer{Preview: preview, ctx: ctx, cancel: cancel, started: time.Now()}
+	t.total.Store(-1)
+	return t
+}
+
+// Context is done once the transfer is cancelled
+func (t *Transfer) Context() context.Context {
+	return t.ctx
+}
+
+// Cancel aborts the request, or the transfer of its body. It is also
+// called once the body is received, to release the context.
+func (t *Transfer) Cancel() {
+	t.cancel()
+}
+
+// SetTotal records the expected size of the body, -1 when unknown
+func (t *Transfer) SetTotal(n int64) {
+	t.total.Store(n)
+}
+
+// Reader counts the bytes read from r as received
+func (t *Transfer) Reader(r io.Reader) io.Reader {
+	return countingReader{r: r, t: t}
+}
+
+// Progress returns the bytes received so far, the expected total or -1 when
+// unknown, and the rate in bytes per second
+func (t *Transfer) Progress() (received, total int64, rate float64) {
+	received, total = t.received.Load(), t.total.Load()
+	if elapsed := time.Since(t.started).Seconds(); elapsed > 0 {
+		rate = float64(received) / elapsed
+	}
+	return received, total, rate
+}
+
+type countingReader struct {
+	r io.Reader
+	t *Transfer
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.t.received.Add(int64(n))
+	return n, err
+}
+
+// TickMsg asks the TUI to refresh the progress of a transfer
+type TickMsg struct{}
+
+// TickCmd schedules the next refresh of the progress
+func TickCmd() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return TickMsg{}
+	})
+}
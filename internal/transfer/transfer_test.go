@@ -0,0 +1,30 @@
+package transfer
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTransfer(t *testing.T) {
+	tr := New(0)
+	if tr.Preview != DefaultPreview {
+		t.Errorf("expected the default preview, got %d", tr.Preview)
+	}
+	if received, total, _ := tr.Progress(); received != 0 || total != -1 {
+		t.Errorf("expected nothing received of an unknown total, got %d of %d", received, total)
+	}
+
+	tr.SetTotal(11)
+	if _, err := io.Copy(io.Discard, tr.Reader(strings.NewReader("hello world"))); err != nil {
+		t.Fatal(err)
+	}
+	if received, total, rate := tr.Progress(); received != 11 || total != 11 || rate <= 0 {
+		t.Errorf("expected 11 of 11 bytes received, got %d of %d at %f/s", received, total, rate)
+	}
+
+	tr.Cancel()
+	if tr.Context().Err() == nil {
+		t.Error("expected the context to be cancelled")
+	}
+}
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/assert"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+var (
+	passStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575")).Bold(true)
+	failStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Bold(true)
+)
+
+func openAssertForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowAssertForm = true
+	m.AssertEditIdx = -1
+	m.AssertIdx = min(m.AssertIdx, max(len(m.Assertions)-1, 0))
+	m.StatusMessage = ""
+	return m, nil
+}
+
+// editAssertion opens the assertion at idx in the editor, or a new one when
+// idx is len(m.Assertions)
+func editAssertion(m model.Model, idx int) (model.Model, tea.Cmd) {
+	m.AssertEditIdx = idx
+	m.AssertInput.SetValue("")
+	if idx < len(m.Assertions) {
+		m.AssertInput.SetValue(assert.Format(m.Assertions[idx]))
+	}
+	m.AssertInput.CursorEnd()
+	m.StatusMessage = ""
+	return m, m.AssertInput.Focus()
+}
+
+func updateAssertForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.AssertEditIdx < 0 {
+		switch msg.String() {
+		case "esc", "ctrl+c", "q":
+			m.ShowAssertForm = false
+		case "j", "down":
+			if m.AssertIdx < len(m.Assertions)-1 {
+				m.AssertIdx++
+			}
+		case "k", "up":
+			if m.AssertIdx > 0 {
+				m.AssertIdx--
+			}
+		case "a", "n":
+			return editAssertion(m, len(m.Assertions))
+		case "e", "enter":
+			if len(m.Assertions) > 0 {
+				return editAssertion(m, m.AssertIdx)
+			}
+		case "d", "x", "backspace", "delete":
+			if len(m.Assertions) > 0 {
+				m.Assertions = append(append([]model.Assertion{}, m.Assertions[:m.AssertIdx]...), m.Assertions[m.AssertIdx+1:]...)
+				m.AssertIdx = min(m.AssertIdx, max(len(m.Assertions)-1, 0))
+				m.TestResults = nil
+				UpdateViewportContent(&m)
+			}
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.AssertEditIdx = -1
+		m.AssertInput.Blur()
+		m.StatusMessage = ""
+		return m, nil
+
+	case "ctrl+c":
+		m.AssertEditIdx = -1
+		m.AssertInput.Blur()
+		m.ShowAssertForm = false
+		return m, nil
+
+	case "enter":
+		a, err := assert.Parse(m.AssertInput.Value())
+		if err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		if m.AssertEditIdx < len(m.Assertions) {
+			m.Assertions = append([]model.Assertion{}, m.Assertions...)
+			m.Assertions[m.AssertEditIdx] = a
+		} else {
+			m.Assertions = append(append([]model.Assertion{}, m.Assertions...), a)
+		}
+		// Results of the last response no longer line up with the list
+		m.TestResults = nil
+		m.AssertIdx = m.AssertEditIdx
+		m.AssertEditIdx = -1
+		m.AssertInput.Blur()
+		m.StatusMessage = ""
+		UpdateViewportContent(&m)
+		return m, nil
+	}
+
+	m.AssertInput, cmd = m.AssertInput.Update(msg)
+	return m, cmd
+}
+
+// runAssertions checks the assertions of the request against a response
+func runAssertions(m model.Model, entry *model.HistoryEntry) model.Model {
+	m.TestResults = nil
+	if len(m.SentAssertions) == 0 || entry == nil {
+		return m
+	}
+	m.TestResults = assert.Evaluate(m.SentAssertions, *entry)
+	return m
+}
+
+// testBadge summarizes the results of the assertions for the response label
+func testBadge(m model.Model) string {
+	if len(m.TestResults) == 0 {
+		return ""
+	}
+	if failed := assert.Failed(m.TestResults); failed > 0 {
+		return failStyle.Render(fmt.Sprintf("✗ %d/%d failed", failed, len(m.TestResults)))
+	}
+	return passStyle.Render(fmt.Sprintf("✓ %d/%d passed", len(m.TestResults), len(m.TestResults)))
+}
+
+// testReport renders the Tests tab
+func testReport(m model.Model) string {
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	if len(m.Assertions) == 0 {
+		return muted.Italic(true).Render("No assertions yet. Press 'T' to add some, such as status == 200.")
+	}
+	if len(m.TestResults) == 0 {
+		var b strings.Builder
+		b.WriteString(muted.Italic(true).Render("Send the request to run the assertions:"))
+		for _, a := range m.Assertions {
+			b.WriteString("\n  • " + assert.Format(a))
+		}
+		return b.String()
+	}
+
+	var b strings.Builder
+	for _, r := range m.TestResults {
+		line := assert.Format(r.Assertion)
+		if r.Passed {
+			b.WriteString(passStyle.Render("✓ ") + line)
+		} else {
+			b.WriteString(failStyle.Render("✗ ") + line + muted.Render(" — "+r.Message))
+		}
+		b.WriteString("\n")
+	}
+	failed := assert.Failed(m.TestResults)
+	b.WriteString("\n")
+	b.WriteString(muted.Render(fmt.Sprintf("%d passed, %d failed • T: edit assertions", len(m.TestResults)-failed, failed)))
+	return b.String()
+}
+
+// RenderAssertForm renders the assertions of the request
+func RenderAssertForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Assertions"))
+	content.WriteString("\n\n")
+	content.WriteString(muted.Render("Checked against every response, results are shown in the Tests tab"))
+	content.WriteString("\n\n")
+
+	if m.AssertEditIdx < 0 {
+		if len(m.Assertions) == 0 {
+			content.WriteString(muted.Italic(true).Render("No assertions yet. Press 'a' to add one."))
+			content.WriteString("\n")
+		}
+		for i, a := range m.Assertions {
+			line := lipgloss.NewStyle().MaxWidth(max(m.Width-36, 20)).Render(assert.Format(a))
+			if i == m.AssertIdx {
+				content.WriteString(focused.Render("➤ " + line))
+			} else {
+				content.WriteString("  " + line)
+			}
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+		content.WriteString(muted.Render("j/k: navigate • a: add • enter/e: edit • d: delete • esc: close"))
+	} else {
+		content.WriteString(focused.Render("Assertion: "))
+		content.WriteString(m.AssertInput.View())
+		content.WriteString("\n\n")
+		for _, example := range []string{
+			"status == 200             status == 2xx",
+			"header Content-Type contains json",
+			"jsonpath $.id type number          (string, number, boolean, object, array, null)",
+			`jsonpath $.items[0].name == "Widget"`,
+			"jsonpath $.error !exists           (exists, !exists, matches, <, >, ...)",
+			"time < 500ms              size <= 10kb",
+		} {
+			content.WriteString(muted.Render("  " + example))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+		if m.StatusMessage != "" {
+			content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+			content.WriteString("\n\n")
+		}
+		content.WriteString(muted.Render("enter: save • esc: back"))
+	}
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
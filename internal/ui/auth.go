@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// authTypes lists the auth types in the order the selector cycles through them
+var authTypes = []model.AuthType{model.AuthNone, model.AuthBasic, model.AuthBearer, model.AuthAPIKey}
+
+// authFieldLabels returns the labels of the inputs used by an auth type
+func authFieldLabels(t model.AuthType) []string {
+	switch t {
+	case model.AuthBasic:
+		return []string{"Username", "Password"}
+	case model.AuthBearer:
+		return []string{"Token"}
+	case model.AuthAPIKey:
+		return []string{"Key name", "Value", "In (header/query)"}
+	}
+	return nil
+}
+
+// authTypeLabel returns the display name of an auth type
+func authTypeLabel(t model.AuthType) string {
+	switch t {
+	case model.AuthBasic:
+		return "Basic"
+	case model.AuthBearer:
+		return "Bearer"
+	case model.AuthAPIKey:
+		return "API Key"
+	}
+	return "None"
+}
+
+func openAuthForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowAuthForm = true
+	m.AuthFocusField = 0
+	loadAuthInputs(&m)
+	return m, nil
+}
+
+// loadAuthInputs copies the auth settings into the form inputs
+func loadAuthInputs(m *model.Model) {
+	var values []string
+	switch m.Auth.Type {
+	case model.AuthBasic:
+		values = []string{m.Auth.Username, m.Auth.Password}
+	case model.AuthBearer:
+		values = []string{m.Auth.Token}
+	case model.AuthAPIKey:
+		in := m.Auth.In
+		if in == "" {
+			in = "header"
+		}
+		values = []string{m.Auth.Key, m.Auth.Value, in}
+	}
+	for i := range m.AuthInputs {
+		m.AuthInputs[i].Blur()
+		m.AuthInputs[i].SetValue("")
+		if i < len(values) {
+			m.AuthInputs[i].SetValue(values[i])
+		}
+	}
+}
+
+// saveAuthInputs copies the form inputs back into the auth settings
+func saveAuthInputs(m *model.Model) {
+	val := func(i int) string {
+		return strings.TrimSpace(m.AuthInputs[i].Value())
+	}
+	auth := model.Auth{Type: m.Auth.Type}
+	switch auth.Type {
+	case model.AuthBasic:
+		auth.Username, auth.Password = val(0), val(1)
+	case model.AuthBearer:
+		auth.Token = val(0)
+	case model.AuthAPIKey:
+		auth.Key, auth.Value, auth.In = val(0), val(1), strings.ToLower(val(2))
+		if auth.In != "query" {
+			auth.In = "header"
+		}
+	}
+	m.Auth = auth
+}
+
+func updateAuthForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	fields := len(authFieldLabels(m.Auth.Type))
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowAuthForm = false
+		loadAuthInputs(&m)
+		return m, nil
+
+	case "enter":
+		saveAuthInputs(&m)
+		m.ShowAuthForm = false
+		loadAuthInputs(&m)
+		return m, nil
+
+	case "tab", "shift+tab":
+		if msg.String() == "tab" {
+			m.AuthFocusField = (m.AuthFocusField + 1) % (fields + 1)
+		} else {
+			m.AuthFocusField = (m.AuthFocusField + fields) % (fields + 1)
+		}
+		for i := range m.AuthInputs {
+			m.AuthInputs[i].Blur()
+		}
+		if m.AuthFocusField > 0 {
+			m.AuthInputs[m.AuthFocusField-1].Focus()
+			return m, textinput.Blink
+		}
+		return m, nil
+	}
+
+	if m.AuthFocusField == 0 {
+		idx := 0
+		for i, t := range authTypes {
+			if t == m.Auth.Type {
+				idx = i
+			}
+		}
+		switch msg.String() {
+		case "j", "down", "l", "right":
+			idx = (idx + 1) % len(authTypes)
+		case "k", "up", "h", "left":
+			idx = (idx - 1 + len(authTypes)) % len(authTypes)
+		default:
+			return m, nil
+		}
+		saveAuthInputs(&m)
+		m.Auth.Type = authTypes[idx]
+		loadAuthInputs(&m)
+		return m, nil
+	}
+
+	m.AuthInputs[m.AuthFocusField-1], cmd = m.AuthInputs[m.AuthFocusField-1].Update(msg)
+	return m, cmd
+}
+
+// RenderAuthForm renders the request auth settings form
+func RenderAuthForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+
+	content.WriteString(TitleStyle.Render("Authorization"))
+	content.WriteString("\n\n")
+
+	typeLabel := "Type:  "
+	if m.AuthFocusField == 0 {
+		typeLabel = focused.Render(typeLabel)
+	}
+	var types []string
+	for _, t := range authTypes {
+		if t == m.Auth.Type {
+			types = append(types, SelectedMethodStyle.Render(authTypeLabel(t)))
+		} else {
+			types = append(types, MethodStyle.Render(authTypeLabel(t)))
+		}
+	}
+	content.WriteString(typeLabel + strings.Join(types, " ") + "\n\n")
+
+	labels := authFieldLabels(m.Auth.Type)
+	width := 0
+	for _, l := range labels {
+		width = max(width, len(l))
+	}
+	for i, l := range labels {
+		label := l + ":" + strings.Repeat(" ", width-len(l)+1)
+		if m.AuthFocusField == i+1 {
+			label = focused.Render(label)
+		}
+		content.WriteString(label + m.AuthInputs[i].View() + "\n")
+	}
+	if len(labels) > 0 {
+		content.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262")).
+			Italic(true).
+			Render("Values may reference variables, e.g. {{token}}"))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Render("tab: next field • j/k: change type • enter: save • esc: cancel"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
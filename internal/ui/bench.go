@@ -0,0 +1,370 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/bench"
+	"github.com/tbourrel/apitty/internal/http"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// benchInputLabels lists the labels of model.BenchInputs
+var benchInputLabels = []string{"Requests:    ", "Duration:    ", "Concurrency: ", "Rate (rps):  "}
+
+func openBench(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowBench = true
+	m.StatusMessage = ""
+	if m.Bench != nil {
+		return m, nil
+	}
+	return focusBenchInput(m, m.BenchFocusField)
+}
+
+func focusBenchInput(m model.Model, idx int) (model.Model, tea.Cmd) {
+	m.BenchFocusField = idx
+	for i := range m.BenchInputs {
+		m.BenchInputs[i].Blur()
+	}
+	return m, m.BenchInputs[idx].Focus()
+}
+
+// benchConfig reads the benchmark form
+func benchConfig(m model.Model) (bench.Config, error) {
+	var cfg bench.Config
+	var err error
+	if v := strings.TrimSpace(m.BenchInputs[0].Value()); v != "" {
+		if cfg.Requests, err = strconv.Atoi(v); err != nil {
+			return cfg, fmt.Errorf("invalid number of requests %q", v)
+		}
+	}
+	if v := strings.TrimSpace(m.BenchInputs[1].Value()); v != "" {
+		if cfg.Duration, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid duration %q, expected a value such as 30s or 2m", v)
+		}
+	}
+	if v := strings.TrimSpace(m.BenchInputs[2].Value()); v != "" {
+		if cfg.Concurrency, err = strconv.Atoi(v); err != nil {
+			return cfg, fmt.Errorf("invalid concurrency %q", v)
+		}
+	}
+	if v := strings.TrimSpace(m.BenchInputs[3].Value()); v != "" {
+		if cfg.RPS, err = strconv.ParseFloat(v, 64); err != nil {
+			return cfg, fmt.Errorf("invalid rate %q", v)
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// benchRequest returns the request exactly as sendRequest sends it
+func benchRequest(m model.Model) (model.Request, error) {
+	req := currentRequest(m)
+	if m.BodyMode == model.BodyGraphQL {
+		return http.GraphQLRequest(req, m.GraphQLQuery.Value(), m.GraphQLVariables.Value())
+	}
+	return req, nil
+}
+
+func startBench(m model.Model) (model.Model, tea.Cmd) {
+	cfg, err := benchConfig(m)
+	if err != nil {
+		m.StatusMessage = err.Error()
+		return m, nil
+	}
+	req, err := benchRequest(m)
+	if err != nil {
+		m.StatusMessage = err.Error()
+		return m, nil
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		m.StatusMessage = "Enter a URL to benchmark"
+		return m, nil
+	}
+
+	// The benchmark keeps a connection per worker alive in a pool of its
+	// own, closed when it ends, so other requests keep their connections
+	options := http.DefaultPoolOptions
+	options.MaxIdleConnsPerHost = max(options.MaxIdleConnsPerHost, cfg.Concurrency)
+	pool := http.NewPool(options)
+
+	jar := cookieJar(m)
+	m.Bench = bench.New(cfg, func() bench.Result {
+		entry, err := pool.Send(req, jar)
+		if err != nil {
+			return bench.Result{Err: err}
+		}
+		return bench.Result{Status: entry.Response.StatusCode, Bytes: len(entry.Response.Body), Reused: entry.ReusedConnection}
+	})
+	m.BenchSummary = m.Bench.Snapshot()
+	m.BenchTarget = req.Method + " " + req.URL
+	m.StatusMessage = ""
+	for i := range m.BenchInputs {
+		m.BenchInputs[i].Blur()
+	}
+	run := bench.RunCmd(m.Bench)
+	return m, tea.Batch(func() tea.Msg {
+		msg := run()
+		pool.CloseIdleConnections()
+		return msg
+	}, bench.TickCmd())
+}
+
+func updateBench(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.Bench == nil {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.ShowBench = false
+			m.StatusMessage = ""
+			return m, nil
+		case "tab", "down":
+			return focusBenchInput(m, (m.BenchFocusField+1)%len(m.BenchInputs))
+		case "shift+tab", "up":
+			return focusBenchInput(m, (m.BenchFocusField+len(m.BenchInputs)-1)%len(m.BenchInputs))
+		case "enter":
+			return startBench(m)
+		}
+		m.BenchInputs[m.BenchFocusField], cmd = m.BenchInputs[m.BenchFocusField].Update(msg)
+		return m, cmd
+	}
+
+	running := m.BenchSummary.Running
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		if running {
+			m.Bench.Stop()
+			return m, nil
+		}
+		m.ShowBench = false
+	case "x":
+		if !running {
+			path := "apitty-bench-" + time.Now().Format("20060102-150405") + ".json"
+			return m, bench.ExportCmd(path, m.BenchSummary)
+		}
+	case "n":
+		if !running {
+			m.Bench = nil
+			m.StatusMessage = ""
+			return focusBenchInput(m, m.BenchFocusField)
+		}
+	case "r":
+		if !running {
+			return startBench(m)
+		}
+	}
+	return m, nil
+}
+
+// updateBenchMsg refreshes the dashboard with the progress of the benchmark
+func updateBenchMsg(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case bench.TickMsg:
+		if m.Bench == nil || !m.BenchSummary.Running {
+			return m, nil
+		}
+		m.BenchSummary = m.Bench.Snapshot()
+		return m, bench.TickCmd()
+
+	case bench.DoneMsg:
+		// A new benchmark may have replaced the one that ended
+		if msg.Runner != m.Bench {
+			return m, nil
+		}
+		m.BenchSummary = m.Bench.Snapshot()
+		s := m.BenchSummary
+		m.StatusMessage = fmt.Sprintf("Benchmark done: %d requests, %.1f req/s, p99 %s", s.Requests, s.RPS, formatLatency(s.P99))
+
+	case bench.ExportedMsg:
+		if msg.Err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not export the benchmark: %v", msg.Err)
+		} else {
+			m.StatusMessage = "Exported the benchmark to " + msg.Path
+		}
+	}
+	return m, nil
+}
+
+// formatLatency rounds a latency for display
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= 10*time.Millisecond:
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// benchProgress describes how far the benchmark is from its end
+func benchProgress(s bench.Summary) string {
+	var parts []string
+	if s.Config.Requests > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d requests", s.Requests, s.Config.Requests))
+	}
+	if s.Config.Duration > 0 {
+		parts = append(parts, fmt.Sprintf("%s/%s", s.Elapsed.Round(100*time.Millisecond), s.Config.Duration))
+	}
+	parts = append(parts, fmt.Sprintf("%d workers", s.Config.Concurrency))
+	if s.Config.RPS > 0 {
+		parts = append(parts, fmt.Sprintf("capped at %g req/s", s.Config.RPS))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// renderBenchDashboard renders the live statistics of a benchmark
+func renderBenchDashboard(m model.Model, width int) string {
+	s := m.BenchSummary
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	value := lipgloss.NewStyle().Bold(true)
+	var b strings.Builder
+
+	state := passStyle.Render("Done")
+	if s.Running {
+		state = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C")).Bold(true).Render("Running")
+	}
+	b.WriteString(state + "  " + muted.Render(benchProgress(s)) + "\n\n")
+
+	rate := fmt.Sprintf("%.1f", s.RPS)
+	if s.Running {
+		rate += fmt.Sprintf(" (now %.0f)", s.CurrentRPS)
+	}
+	fmt.Fprintf(&b, "Requests %s   Errors %s   Req/s %s   Received %s   Elapsed %s\n",
+		value.Render(strconv.Itoa(s.Requests)), value.Render(strconv.Itoa(s.Errors)), value.Render(rate),
+		value.Render(formatBytes(s.Bytes)), value.Render(formatLatency(s.Elapsed)))
+	if s.Requests > 0 {
+		fmt.Fprintf(&b, "Reused connections %s\n", value.Render(fmt.Sprintf("%d (%.0f%%)", s.Reused, 100*float64(s.Reused)/float64(s.Requests))))
+	}
+	fmt.Fprintf(&b, "Latency  min %s   mean %s   p50 %s   p90 %s   p99 %s   max %s\n\n",
+		value.Render(formatLatency(s.Min)), value.Render(formatLatency(s.Mean)), value.Render(formatLatency(s.P50)),
+		value.Render(formatLatency(s.P90)), value.Render(formatLatency(s.P99)), value.Render(formatLatency(s.Max)))
+
+	// Histogram bars scale to the largest bucket
+	b.WriteString(LabelStyle.Render("Latency histogram") + "\n")
+	if len(s.Histogram) == 0 {
+		b.WriteString(muted.Italic(true).Render("  Waiting for responses...") + "\n")
+	}
+	peak := 0
+	for _, bucket := range s.Histogram {
+		peak = max(peak, bucket.Count)
+	}
+	barWidth := max(width-40, 10)
+	for _, bucket := range s.Histogram {
+		bar := strings.Repeat("█", bucket.Count*barWidth/max(peak, 1))
+		if bar == "" && bucket.Count > 0 {
+			bar = "▏"
+		}
+		label := fmt.Sprintf("%9s – %-9s", formatLatency(bucket.From), formatLatency(bucket.To))
+		b.WriteString("  " + label + " " + lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Render(bar) + " " + strconv.Itoa(bucket.Count) + "\n")
+	}
+	b.WriteString("\n")
+
+	// Status codes and error kinds side by side
+	var statuses, errs []string
+	codes := make([]int, 0, len(s.Statuses))
+	for code := range s.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		style := passStyle
+		if code >= 400 {
+			style = failStyle
+		}
+		statuses = append(statuses, fmt.Sprintf("  %s  %d (%.0f%%)", style.Render(strconv.Itoa(code)), s.Statuses[code], 100*float64(s.Statuses[code])/float64(max(s.Requests, 1))))
+	}
+	kinds := make([]string, 0, len(s.ErrorKinds))
+	for kind := range s.ErrorKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		errs = append(errs, fmt.Sprintf("  %s  %d", failStyle.Render(kind), s.ErrorKinds[kind]))
+	}
+	if len(errs) == 0 {
+		errs = []string{muted.Render("  none")}
+	}
+	if len(statuses) == 0 {
+		statuses = []string{muted.Render("  none")}
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(32).Render(LabelStyle.Render("Status codes")+"\n"+strings.Join(statuses, "\n")),
+		LabelStyle.Render("Errors")+"\n"+strings.Join(errs, "\n"),
+	))
+	b.WriteString("\n\n")
+
+	if s.Running {
+		b.WriteString(muted.Render("esc: stop"))
+	} else {
+		b.WriteString(muted.Render("x: export JSON • r: run again • n: new benchmark • esc: close"))
+	}
+	return b.String()
+}
+
+// RenderBench renders the benchmark form or dashboard
+func RenderBench(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Benchmark"))
+	content.WriteString("\n\n")
+
+	if m.Bench != nil {
+		content.WriteString(muted.Render(m.BenchTarget))
+		content.WriteString("\n\n")
+		content.WriteString(renderBenchDashboard(m, m.Width-12))
+	} else {
+		target := model.Methods[m.MethodIdx] + " " + currentRequest(m).URL
+		if m.BodyMode == model.BodyGraphQL {
+			target = "POST " + currentRequest(m).URL + " (GraphQL)"
+		}
+		content.WriteString(muted.Render("Sends " + target + " as configured, with its headers, auth and settings"))
+		content.WriteString("\n\n")
+		for i, label := range benchInputLabels {
+			if m.BenchFocusField == i {
+				label = focused.Render(label)
+			}
+			content.WriteString(label + m.BenchInputs[i].View() + "\n")
+		}
+		content.WriteString("\n")
+		content.WriteString(muted.Render("The benchmark stops after the requests or the duration, whichever comes first"))
+		if m.Settings.FreshConnection {
+			content.WriteString("\n")
+			content.WriteString(muted.Render("Every request opens a fresh connection (request settings)"))
+		}
+		content.WriteString("\n\n")
+		if m.StatusMessage != "" {
+			content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+			content.WriteString("\n\n")
+		}
+		content.WriteString(muted.Render("tab: next field • enter: start • esc: close"))
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/format"
+	"github.com/tbourrel/apitty/internal/http"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/transfer"
+)
+
+// previewSize returns the start of a body kept in memory and shown
+func previewSize(s model.Settings) int64 {
+	if s.PreviewKB > 0 {
+		return int64(s.PreviewKB) << 10
+	}
+	return transfer.DefaultPreview
+}
+
+// keepBody records the raw body of a response, and removes the file of the
+// previous one
+func keepBody(m model.Model, msg model.ResponseMsg) model.Model {
+	if m.BodyFile != "" && m.BodyFile != msg.BodyFile {
+		_ = os.Remove(m.BodyFile)
+	}
+	m.BodyFile = msg.BodyFile
+	if m.Transfer != nil {
+		// The request is over, release its context
+		m.Transfer.Cancel()
+		m.Transfer = nil
+	}
+	m.ResponseBody, m.ResponseSize, m.ResponseType = "", 0, ""
+	m.ResponseRequest = model.Request{}
+	m.ResponseStatusCode, m.ResponseHeaderList = 0, nil
+	if msg.Entry != nil && msg.Err == nil {
+		m.ResponseRequest = msg.Entry.Request
+		m.ResponseStatusCode, m.ResponseHeaderList = msg.Entry.Response.StatusCode, msg.Entry.Response.Headers
+		r := msg.Entry.Response
+		m.ResponseBody = r.Body
+		m.ResponseSize = max(r.Size, int64(len(r.Body)))
+		m.ResponseType = mediaType(r.Headers)
+	}
+	return m
+}
+
+// wholeEntry returns the entry of a response with its whole body, read back
+// from the file it was streamed to when only its start was kept, so
+// assertions, contracts and extraction rules see all of it
+func wholeEntry(m model.Model, entry *model.HistoryEntry) *model.HistoryEntry {
+	if entry == nil || m.BodyFile == "" || int64(len(entry.Response.Body)) >= entry.Response.Size {
+		return entry
+	}
+	data, err := os.ReadFile(m.BodyFile)
+	if err != nil {
+		return entry
+	}
+	e := *entry
+	e.Response.Body, e.Response.Truncated = string(data), false
+	return &e
+}
+
+// wholeBody returns the whole body of the last response, read back from the
+// file it was streamed to when only its start was kept
+func wholeBody(m model.Model) string {
+	if m.BodyFile == "" || int64(len(m.ResponseBody)) >= m.ResponseSize {
+		return m.ResponseBody
+	}
+	data, err := os.ReadFile(m.BodyFile)
+	if err != nil {
+		return m.ResponseBody
+	}
+	return string(data)
+}
+
+// Close removes the temporary files of the session
+func Close(m model.Model) {
+	if m.BodyFile != "" {
+		_ = os.Remove(m.BodyFile)
+	}
+}
+
+// mediaType returns the lowercased media type of the Content-Type header
+func mediaType(headers []model.HeaderPair) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			return format.MediaType(h.Value)
+		}
+	}
+	return ""
+}
+
+// previewNotice tells that only the start of the body is shown
+func previewNotice(m model.Model) string {
+	if m.ResponseSize <= int64(len(m.ResponseBody)) {
+		return ""
+	}
+	notice := fmt.Sprintf("Showing the first %s of %s", formatBytes(int64(len(m.ResponseBody))), formatBytes(m.ResponseSize))
+	if m.BodyFile != "" {
+		notice += " • S: save the whole body"
+	} else {
+		notice += ", the history only keeps the start of large bodies"
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C")).Render(notice)
+}
+
+// statusText returns the status of the response, or the progress of its
+// body while it is received
+func statusText(m model.Model) string {
+	if !m.Loading || m.Transfer == nil {
+		return m.StatusCode
+	}
+	received, total, rate := m.Transfer.Progress()
+	if received == 0 {
+		return m.StatusCode + " • esc: cancel"
+	}
+	status := "Receiving " + formatBytes(received)
+	if total > 0 {
+		status += fmt.Sprintf(" of %s (%.0f%%)", formatBytes(total), 100*float64(received)/float64(total))
+	}
+	return status + fmt.Sprintf(" • %s/s • esc: cancel", formatBytes(int64(rate)))
+}
+
+// cancelled tells whether a request failed because it was cancelled
+func cancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// bodyFileName suggests a file name for a body of a media type
+func bodyFileName(mediaType string) string {
+	ext := ".txt"
+	switch {
+	case mediaType == "":
+	case strings.HasSuffix(mediaType, "/json"), strings.HasSuffix(mediaType, "+json"):
+		ext = ".json"
+	case strings.HasSuffix(mediaType, "/xml"), strings.HasSuffix(mediaType, "+xml"):
+		ext = ".xml"
+	case mediaType == "text/html":
+		ext = ".html"
+	case mediaType == "text/csv":
+		ext = ".csv"
+	case strings.HasSuffix(mediaType, "yaml"):
+		ext = ".yaml"
+	case strings.HasPrefix(mediaType, "text/"):
+	default:
+		ext = ".bin"
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return "response" + ext
+}
+
+func openSaveBody(m model.Model) (model.Model, tea.Cmd) {
+	if m.ResponseBody == "" && m.BodyFile == "" {
+		m.StatusMessage = "No response body to save"
+		return m, nil
+	}
+	m.ShowSaveBody = true
+	m.StatusMessage = ""
+	m.SaveBodyInput.SetValue(bodyFileName(m.ResponseType))
+	m.SaveBodyInput.CursorEnd()
+	return m, m.SaveBodyInput.Focus()
+}
+
+func updateSaveBody(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowSaveBody = false
+		m.SaveBodyInput.Blur()
+		m.StatusMessage = ""
+		return m, nil
+
+	case "enter":
+		path := strings.TrimSpace(m.SaveBodyInput.Value())
+		if path == "" {
+			m.StatusMessage = "Enter a file name"
+			return m, nil
+		}
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, rest)
+			}
+		}
+		m.ShowSaveBody = false
+		m.SaveBodyInput.Blur()
+		m.StatusMessage = ""
+		return m, http.SaveBodyCmd(m.BodyFile, m.ResponseBody, path)
+	}
+
+	m.SaveBodyInput, cmd = m.SaveBodyInput.Update(msg)
+	return m, cmd
+}
+
+// RenderSaveBody renders the prompt for the file to save the body into
+func RenderSaveBody(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Save Response Body"))
+	content.WriteString("\n\n")
+	size := m.ResponseSize
+	if size == 0 {
+		size = int64(len(m.ResponseBody))
+	}
+	content.WriteString(muted.Render(fmt.Sprintf("Writes the whole body, %s", formatBytes(size))))
+	content.WriteString("\n\n")
+	content.WriteString("File: " + m.SaveBodyInput.View() + "\n\n")
+	if m.StatusMessage != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+		content.WriteString("\n\n")
+	}
+	content.WriteString(muted.Render("enter: save • esc: cancel"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
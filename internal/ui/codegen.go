@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/tbourrel/apitty/internal/codegen"
+	"github.com/tbourrel/apitty/internal/http"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// codegenRequest returns the request the generated code sends: the editor
+// request, or the GraphQL envelope in GraphQL mode
+func codegenRequest(m model.Model) (model.Request, error) {
+	r := currentRequest(m)
+	if m.BodyMode == model.BodyGraphQL {
+		return http.GraphQLRequest(r, m.GraphQLQuery.Value(), m.GraphQLVariables.Value())
+	}
+	return r, nil
+}
+
+// generatedCode returns the code of the selected target, or why the
+// request cannot be built
+func generatedCode(m model.Model) (codegen.Generator, string, error) {
+	generators := codegen.Generators()
+	g := generators[m.CodegenIdx%len(generators)]
+	r, err := codegenRequest(m)
+	if err != nil {
+		return g, "", err
+	}
+	return g, g.Generate(r), nil
+}
+
+func updateCodegen(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	n := len(codegen.Generators())
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.ShowCodegen = false
+		return m, nil
+
+	case "tab", "l", "right":
+		m.CodegenIdx = (m.CodegenIdx + 1) % n
+		m.CodegenOffset = 0
+		return m, nil
+
+	case "shift+tab", "h", "left":
+		m.CodegenIdx = (m.CodegenIdx - 1 + n) % n
+		m.CodegenOffset = 0
+		return m, nil
+
+	case "j", "down":
+		_, code, _ := generatedCode(m)
+		if m.CodegenOffset < strings.Count(code, "\n")-1 {
+			m.CodegenOffset++
+		}
+		return m, nil
+
+	case "k", "up":
+		if m.CodegenOffset > 0 {
+			m.CodegenOffset--
+		}
+		return m, nil
+
+	case "y":
+		g, code, err := generatedCode(m)
+		if err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		if err := clipboard.WriteAll(code); err != nil {
+			// No system clipboard, ask the terminal to copy it instead
+			termenv.Copy(code)
+			m.StatusMessage = fmt.Sprintf("Copied %s code through the terminal", g.Name())
+		} else {
+			m.StatusMessage = fmt.Sprintf("Copied %s code to the clipboard", g.Name())
+		}
+		m.ShowCodegen = false
+		return m, nil
+
+	case "w":
+		g, code, err := generatedCode(m)
+		if err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		path := "apitty-" + time.Now().Format("20060102-150405") + "." + g.Extension()
+		if err := createFile(path, []byte(code)); err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not save code: %v", err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Saved %s code to %s", g.Name(), path)
+		}
+		m.ShowCodegen = false
+		return m, nil
+	}
+	return m, nil
+}
+
+// createFile writes data to a new file, and fails rather than replace an
+// existing one
+func createFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// RenderCodegen renders the code generation modal
+func RenderCodegen(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Generate Code"))
+	content.WriteString("\n\n")
+
+	var targets []string
+	for i, g := range codegen.Generators() {
+		if i == m.CodegenIdx%len(codegen.Generators()) {
+			targets = append(targets, SelectedMethodStyle.Render(g.Name()))
+		} else {
+			targets = append(targets, MethodStyle.Render(g.Name()))
+		}
+	}
+	content.WriteString(strings.Join(targets, " "))
+	content.WriteString("\n\n")
+
+	_, code, err := generatedCode(m)
+	if err != nil {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + err.Error()))
+		content.WriteString("\n\n")
+		content.WriteString(muted.Render("Fix the GraphQL query or variables to generate code • esc/q: close"))
+		return "\n" + codegenBox(m).Render(content.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	visible := max(m.Height-14, 3)
+	start := min(m.CodegenOffset, max(len(lines)-1, 0))
+	end := min(start+visible, len(lines))
+	codeBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#626262")).
+		Padding(0, 1).
+		Width(m.Width - 14)
+	content.WriteString(codeBox.Render(strings.Join(lines[start:end], "\n")))
+	content.WriteString("\n")
+	if len(lines) > visible {
+		content.WriteString(muted.Render(fmt.Sprintf("lines %d-%d of %d", start+1, end, len(lines))))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(muted.Render("tab/h/l: change target • j/k: scroll • y: copy • w: save to file • esc/q: close"))
+
+	return "\n" + codegenBox(m).Render(content.String())
+}
+
+func codegenBox(m model.Model) lipgloss.Style {
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+}
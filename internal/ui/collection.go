@@ -0,0 +1,282 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/collection"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func openCollection(m model.Model) (model.Model, tea.Cmd) {
+	if m.Collection == nil {
+		m.StatusMessage = "No collection loaded. Start apitty with a collection file: apitty <collection.json>"
+		return m, nil
+	}
+	m.ShowCollection = true
+	if m.CollectionIdx >= len(m.Collection.Entries()) {
+		m.CollectionIdx = 0
+	}
+	return m, nil
+}
+
+func updateCollection(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	entries := m.Collection.Entries()
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.ShowCollection = false
+		return m, nil
+
+	case "j", "down":
+		if len(entries) > 0 {
+			m.CollectionIdx = (m.CollectionIdx + 1) % len(entries)
+		}
+		return m, nil
+
+	case "k", "up":
+		if len(entries) > 0 {
+			m.CollectionIdx = (m.CollectionIdx - 1 + len(entries)) % len(entries)
+		}
+		return m, nil
+
+	case "enter":
+		if m.CollectionIdx < len(entries) {
+			m = loadRequest(m, entries[m.CollectionIdx].Request)
+			m.LoadedRequestIdx = m.CollectionIdx
+			m.ShowCollection = false
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// cycleEnvironment selects the next environment of the collection, going
+// back to no environment after the last one
+func cycleEnvironment(m model.Model) model.Model {
+	if m.Collection == nil || len(m.Collection.Environments) == 0 {
+		m.StatusMessage = "The loaded collection has no environments"
+		return m
+	}
+	m.EnvironmentIdx++
+	if m.EnvironmentIdx >= len(m.Collection.Environments) {
+		m.EnvironmentIdx = -1
+	}
+	m.StatusMessage = "Environment: " + environmentName(m)
+	return m
+}
+
+// environmentName returns the name of the active environment
+func environmentName(m model.Model) string {
+	if m.Collection == nil || m.EnvironmentIdx < 0 || m.EnvironmentIdx >= len(m.Collection.Environments) {
+		return "None"
+	}
+	return m.Collection.Environments[m.EnvironmentIdx].Name
+}
+
+// exampleHeaders lists the response headers that describe a single
+// transfer, and are left out of examples
+var exampleHeaders = []string{"Date", "Content-Length", "Content-Encoding", "Transfer-Encoding", "Connection", "Keep-Alive"}
+
+// saveExample adds the last response to the examples of the loaded request,
+// which apitty mock serves
+func saveExample(m model.Model) model.Model {
+	entries := m.Collection.Entries()
+	switch {
+	case m.LoadedRequestIdx < 0 || m.LoadedRequestIdx >= len(entries):
+		m.StatusMessage = "Load a request from the collection (c) to save examples of it"
+		return m
+	case m.HTTPFile != nil:
+		m.StatusMessage = "Examples are not stored in .http files"
+		return m
+	case m.ResponseStatusCode == 0:
+		m.StatusMessage = "Send the request to save its response as an example"
+		return m
+	case m.ResponseBinary:
+		m.StatusMessage = "Binary bodies cannot be saved as examples"
+		return m
+	}
+
+	body := m.ResponseBody
+	if int64(len(body)) < m.ResponseSize {
+		data, err := os.ReadFile(m.BodyFile)
+		if m.BodyFile == "" || err != nil {
+			m.StatusMessage = "Only the start of the body was kept, the example needs all of it"
+			return m
+		}
+		body = string(data)
+	}
+	ex := model.Example{Status: m.ResponseStatusCode, Body: body}
+	ex.Name = strings.TrimSpace(strings.TrimPrefix(m.StatusCode, strconv.Itoa(ex.Status)))
+	for _, h := range m.ResponseHeaderList {
+		if !slices.ContainsFunc(exampleHeaders, func(name string) bool { return strings.EqualFold(name, h.Key) }) {
+			ex.Headers = append(ex.Headers, h)
+		}
+	}
+
+	saved := entries[m.LoadedRequestIdx].Request
+	saved.Examples = append(saved.Examples, ex)
+	if err := collection.Save(m.Collection, m.CollectionPath); err != nil {
+		saved.Examples = saved.Examples[:len(saved.Examples)-1]
+		m.StatusMessage = fmt.Sprintf("Could not save %s: %v", filepath.Base(m.CollectionPath), err)
+		return m
+	}
+	m.StatusMessage = fmt.Sprintf("Saved the response as example %d of %q", len(saved.Examples), saved.Name)
+	return m
+}
+
+// saveLoadedRequest writes the editor request back over the request it was
+// loaded from, in the collection file or the .http file
+func saveLoadedRequest(m model.Model) model.Model {
+	entries := m.Collection.Entries()
+	if m.LoadedRequestIdx < 0 || m.LoadedRequestIdx >= len(entries) {
+		m.StatusMessage = "Load a request from the collection (c) before saving it"
+		return m
+	}
+	if m.HTTPFile != nil {
+		return saveToHTTPFile(m)
+	}
+
+	saved := entries[m.LoadedRequestIdx].Request
+	saved.Method = model.Methods[m.MethodIdx]
+	saved.URL = m.URLInput.Value()
+	saved.Headers = append([]model.HeaderPair{}, m.RequestHeaders...)
+	saved.Body = m.Body
+	saved.Auth = nil
+	if m.Auth.Type != model.AuthNone {
+		auth := m.Auth
+		saved.Auth = &auth
+	}
+	saved.Settings = nil
+	if !m.Settings.IsZero() {
+		settings := m.Settings
+		saved.Settings = &settings
+	}
+	saved.Extract = append([]model.ExtractRule(nil), m.Extract...)
+	saved.Assertions = append([]model.Assertion(nil), m.Assertions...)
+	saved.Contract = nil
+	if !m.Contract.IsZero() {
+		c := m.Contract
+		saved.Contract = &c
+	}
+	if err := collection.Save(m.Collection, m.CollectionPath); err != nil {
+		m.StatusMessage = fmt.Sprintf("Could not save %s: %v", filepath.Base(m.CollectionPath), err)
+		return m
+	}
+	m.StatusMessage = fmt.Sprintf("Saved %q to %s", saved.Name, filepath.Base(m.CollectionPath))
+	return m
+}
+
+// loadRequest copies a saved request into the request editor
+func loadRequest(m model.Model, r *model.SavedRequest) model.Model {
+	m.StatusMessage = fmt.Sprintf("Loaded %q", r.Name)
+	found := false
+	for idx, meth := range model.Methods {
+		if strings.EqualFold(meth, r.Method) {
+			m.MethodIdx = idx
+			found = true
+			break
+		}
+	}
+	if !found {
+		m.MethodIdx = 0
+		m.StatusMessage = fmt.Sprintf("Loaded %q (method %s is not supported, using GET)", r.Name, r.Method)
+	}
+
+	m.URLInput.SetValue(r.URL)
+	m.RequestHeaders = append([]model.HeaderPair{}, r.Headers...)
+	m.Body = r.Body
+	m.BodyMode = model.BodyRaw
+	m.Auth = model.Auth{}
+	if r.Auth != nil {
+		m.Auth = *r.Auth
+	}
+	loadAuthInputs(&m)
+	m.Settings = model.Settings{}
+	if r.Settings != nil {
+		m.Settings = *r.Settings
+	}
+	loadSettingsInputs(&m)
+	m.Extract = append([]model.ExtractRule(nil), r.Extract...)
+	m.ExtractIdx = 0
+	m.Assertions = append([]model.Assertion(nil), r.Assertions...)
+	m.AssertIdx = 0
+	m.TestResults = nil
+	m.Contract = model.Contract{}
+	if r.Contract != nil {
+		m.Contract = *r.Contract
+	}
+	loadContractInputs(&m)
+	m.Violations, m.ContractChecked = nil, false
+	return m
+}
+
+// RenderCollection renders the collection browser
+func RenderCollection(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Collection: " + m.Collection.Name))
+	content.WriteString("\n")
+	if m.CollectionPath != "" {
+		content.WriteString(muted.Render(m.CollectionPath))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+
+	entries := m.Collection.Entries()
+	if len(entries) == 0 {
+		content.WriteString(muted.Italic(true).Render("This collection has no requests."))
+		content.WriteString("\n")
+	}
+
+	// Build the lines first so folder headers count toward the visible window
+	var lines []string
+	selectedLine := 0
+	var lastPath string
+	for i, e := range entries {
+		path := strings.Join(e.Path, " › ")
+		if path != lastPath {
+			lines = append(lines, LabelStyle.Render("▸ "+path))
+			lastPath = path
+		}
+		indent := strings.Repeat("  ", len(e.Path))
+		line := fmt.Sprintf("%-7s %s", strings.ToUpper(e.Request.Method), e.Request.Name)
+		if i == m.CollectionIdx {
+			selectedLine = len(lines)
+			lines = append(lines, indent+lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF00FF")).
+				Bold(true).
+				Render("➤ "+line))
+		} else {
+			lines = append(lines, indent+"  "+line)
+		}
+	}
+
+	visible := max(m.Height-14, 3)
+	start := 0
+	if selectedLine >= visible {
+		start = selectedLine - visible + 1
+	}
+	for i := start; i < len(lines) && i < start+visible; i++ {
+		content.WriteString(lines[i] + "\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(muted.Render("j/k: navigate • enter: load request • esc/q: close"))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/contract"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// contractInputLabels lists the labels of model.ContractInputs
+var contractInputLabels = []string{"JSON Schema", "OpenAPI", "Operation"}
+
+func openContractForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowContractForm = true
+	m.ContractFocusField = 0
+	m.StatusMessage = ""
+	loadContractInputs(&m)
+	m.ContractInputs[0].Focus()
+	return m, textinput.Blink
+}
+
+// loadContractInputs copies the contract of the request into the form inputs
+func loadContractInputs(m *model.Model) {
+	values := []string{m.Contract.Schema, m.Contract.OpenAPI, m.Contract.Operation}
+	for i := range m.ContractInputs {
+		m.ContractInputs[i].Blur()
+		m.ContractInputs[i].SetValue(values[i])
+	}
+}
+
+// saveContractInputs copies the form inputs back into the contract. The
+// files must exist, so a typo does not pass for a broken contract on every
+// response.
+func saveContractInputs(m *model.Model) error {
+	val := func(i int) string {
+		return strings.TrimSpace(m.ContractInputs[i].Value())
+	}
+	c := model.Contract{Schema: val(0), OpenAPI: val(1), Operation: val(2)}
+	for _, path := range []string{c.Schema, c.OpenAPI} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("cannot read %s", path)
+		}
+	}
+	if c.Operation != "" && c.OpenAPI == "" {
+		return fmt.Errorf("an operation needs an OpenAPI specification")
+	}
+	m.Contract = c
+	return nil
+}
+
+func updateContractForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	fields := len(m.ContractInputs)
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowContractForm = false
+		m.StatusMessage = ""
+		loadContractInputs(&m)
+		return m, nil
+
+	case "enter":
+		if err := saveContractInputs(&m); err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		m.ShowContractForm = false
+		m.StatusMessage = ""
+		// Violations of the last response no longer match the contract
+		m.Violations, m.ContractChecked = nil, false
+		loadContractInputs(&m)
+		UpdateViewportContent(&m)
+		return m, nil
+
+	case "tab", "shift+tab", "down", "up":
+		m.ContractInputs[m.ContractFocusField].Blur()
+		if msg.String() == "tab" || msg.String() == "down" {
+			m.ContractFocusField = (m.ContractFocusField + 1) % fields
+		} else {
+			m.ContractFocusField = (m.ContractFocusField + fields - 1) % fields
+		}
+		m.ContractInputs[m.ContractFocusField].Focus()
+		return m, textinput.Blink
+	}
+
+	m.ContractInputs[m.ContractFocusField], cmd = m.ContractInputs[m.ContractFocusField].Update(msg)
+	return m, cmd
+}
+
+// runContract checks a response against the contract of the request
+func runContract(m model.Model, entry *model.HistoryEntry) model.Model {
+	m.Violations, m.ContractChecked = nil, false
+	if m.Contract.IsZero() || entry == nil {
+		return m
+	}
+	m.Violations = contract.Check(m.Contract, *entry)
+	m.ContractChecked = true
+	return m
+}
+
+// contractBadge summarizes the check of the contract for the response label
+func contractBadge(m model.Model) string {
+	if !m.ContractChecked {
+		return ""
+	}
+	if n := len(m.Violations); n > 0 {
+		noun := "violations"
+		if n == 1 {
+			noun = "violation"
+		}
+		return failStyle.Render(fmt.Sprintf("✗ %d contract %s", n, noun))
+	}
+	return passStyle.Render("✓ contract")
+}
+
+// contractSummary describes the contract for the request bar
+func contractSummary(c model.Contract) string {
+	var parts []string
+	if c.Schema != "" {
+		parts = append(parts, c.Schema)
+	}
+	if c.OpenAPI != "" {
+		spec := c.OpenAPI
+		if c.Operation != "" {
+			spec += " " + c.Operation
+		}
+		parts = append(parts, spec)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// contractReport renders the Contract tab
+func contractReport(m model.Model) string {
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	if m.Contract.IsZero() {
+		return muted.Italic(true).Render("No contract yet. Press 'K' to link a JSON Schema or an OpenAPI operation.")
+	}
+	if !m.ContractChecked {
+		return muted.Italic(true).Render("Send the request to check the response against " + contractSummary(m.Contract) + ".")
+	}
+	if len(m.Violations) == 0 {
+		return passStyle.Render("✓ ") + "The response follows " + contractSummary(m.Contract) + "\n\n" +
+			muted.Render("K: edit contract")
+	}
+
+	var b strings.Builder
+	for _, v := range m.Violations {
+		b.WriteString(failStyle.Render("✗ ") + v.Path + muted.Render(" — "+v.Message))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(muted.Render(fmt.Sprintf("%d violations of %s • K: edit contract", len(m.Violations), contractSummary(m.Contract))))
+	return b.String()
+}
+
+// RenderContractForm renders the contract of the request
+func RenderContractForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Contract"))
+	content.WriteString("\n\n")
+	content.WriteString(muted.Render("Every response is validated, violations are listed in the Contract tab"))
+	content.WriteString("\n\n")
+
+	width := 0
+	for _, l := range contractInputLabels {
+		width = max(width, len(l))
+	}
+	for i, l := range contractInputLabels {
+		label := l + ":" + strings.Repeat(" ", width-len(l)+1)
+		if m.ContractFocusField == i {
+			label = focused.Render(label)
+		}
+		content.WriteString(label + m.ContractInputs[i].View() + "\n")
+	}
+	content.WriteString(muted.Italic(true).Render("The JSON Schema checks the body, as JSON or YAML"))
+	content.WriteString("\n")
+	content.WriteString(muted.Italic(true).Render("The OpenAPI operation checks the status, content type, headers and body"))
+	content.WriteString("\n")
+	content.WriteString(muted.Italic(true).Render("Leave every field empty to remove the contract"))
+	content.WriteString("\n\n")
+	if m.StatusMessage != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(muted.Render("tab/↑↓: next field • enter: save • esc: cancel"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
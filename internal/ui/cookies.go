@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/cookies"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// cookieInputLabels lists the labels of model.CookieInputs
+var cookieInputLabels = []string{"Name", "Value", "Domain", "Path", "Expires", "SameSite"}
+
+// cookieToggles lists the on/off flags, shown after the text inputs
+var cookieToggles = []string{"Secure", "HttpOnly"}
+
+// expiryLayouts are the accepted formats of the Expires field, in local time
+var expiryLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// cookieEnv returns the key of the active environment jar, empty when no
+// environment is selected
+func cookieEnv(m model.Model) string {
+	if m.Collection == nil || m.EnvironmentIdx < 0 || m.EnvironmentIdx >= len(m.Collection.Environments) {
+		return ""
+	}
+	return m.Collection.Environments[m.EnvironmentIdx].Name
+}
+
+// cookieJar returns the cookie jar of the active environment
+func cookieJar(m model.Model) *cookies.Jar {
+	return m.Cookies.Jar(cookieEnv(m))
+}
+
+// saveCookies writes the cookies to disk when they are persisted
+func saveCookies(m model.Model) tea.Cmd {
+	if !m.PersistCookies || m.CookiesPath == "" {
+		return nil
+	}
+	return cookies.SaveCmd(m.CookiesPath, m.Cookies)
+}
+
+// handleCookieKeys handles the keys of the Cookies tab. The last result is
+// false for keys it leaves to the response box.
+func handleCookieKeys(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd, bool) {
+	list := cookieJar(m).List()
+	m.CookieIdx = min(m.CookieIdx, max(len(list)-1, 0))
+
+	switch msg.String() {
+	case "j", "down":
+		if m.CookieIdx < len(list)-1 {
+			m.CookieIdx++
+		}
+	case "k", "up":
+		if m.CookieIdx > 0 {
+			m.CookieIdx--
+		}
+	case "n":
+		m, cmd := openCookieForm(m, nil)
+		return m, cmd, true
+	case "enter":
+		if len(list) == 0 {
+			return m, nil, true
+		}
+		m, cmd := openCookieForm(m, &list[m.CookieIdx])
+		return m, cmd, true
+	case "d", "x":
+		if len(list) == 0 {
+			return m, nil, true
+		}
+		c := list[m.CookieIdx]
+		cookieJar(m).Delete(c)
+		m.CookieIdx = min(m.CookieIdx, max(len(list)-2, 0))
+		m.StatusMessage = fmt.Sprintf("Deleted cookie %s for %s", c.Name, c.Domain)
+		return m, saveCookies(m), true
+	case "D":
+		cookieJar(m).Clear()
+		m.CookieIdx = 0
+		m.StatusMessage = "Cleared the cookie jar of environment " + environmentName(m)
+		return m, saveCookies(m), true
+	case "p":
+		if m.CookiesPath == "" {
+			m.StatusMessage = "No location to save cookies to"
+			return m, nil, true
+		}
+		m.PersistCookies = !m.PersistCookies
+		if m.PersistCookies {
+			m.StatusMessage = "Cookies are saved to " + m.CookiesPath
+			return m, cookies.SaveCmd(m.CookiesPath, m.Cookies), true
+		}
+		m.StatusMessage = "Cookies are kept for this session only"
+		return m, cookies.RemoveCmd(m.CookiesPath), true
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// openCookieForm opens the cookie editor on c, or on a new cookie for the
+// host of the URL when c is nil
+func openCookieForm(m model.Model, c *cookies.Cookie) (model.Model, tea.Cmd) {
+	draft := cookies.Cookie{Path: "/"}
+	if c != nil {
+		draft = *c
+	} else if u, err := url.Parse(currentRequest(m).URL); err == nil {
+		draft.Domain = strings.ToLower(u.Hostname())
+	}
+	m.CookieDraft = draft
+	m.CookieEditing = c
+	m.ShowCookieForm = true
+	m.CookieFocusField = 0
+	m.StatusMessage = ""
+
+	expires := ""
+	if !draft.Expires.IsZero() {
+		expires = draft.Expires.Local().Format("2006-01-02 15:04:05")
+	}
+	values := []string{draft.Name, draft.Value, draft.Domain, draft.Path, expires, draft.SameSite}
+	for i := range m.CookieInputs {
+		m.CookieInputs[i].Blur()
+		m.CookieInputs[i].SetValue(values[i])
+	}
+	return m, m.CookieInputs[0].Focus()
+}
+
+// saveCookieForm builds the cookie described by the form
+func saveCookieForm(m model.Model) (cookies.Cookie, error) {
+	val := func(i int) string {
+		return strings.TrimSpace(m.CookieInputs[i].Value())
+	}
+	c := m.CookieDraft
+	c.Name, c.Value, c.Domain, c.Path = val(0), m.CookieInputs[1].Value(), val(2), val(3)
+	if c.Name == "" {
+		return c, fmt.Errorf("the cookie needs a name")
+	}
+	if c.Domain == "" {
+		return c, fmt.Errorf("the cookie needs a domain")
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+
+	c.Expires = time.Time{}
+	if v := val(4); v != "" && !strings.EqualFold(v, "session") {
+		var err error
+		for _, layout := range expiryLayouts {
+			if c.Expires, err = time.ParseInLocation(layout, v, time.Local); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return c, fmt.Errorf("invalid expiry %q, expected 2006-01-02 15:04", v)
+		}
+	}
+
+	switch s := strings.ToLower(val(5)); s {
+	case "":
+		c.SameSite = ""
+	case "lax", "strict", "none":
+		c.SameSite = strings.ToUpper(s[:1]) + s[1:]
+	default:
+		return c, fmt.Errorf("invalid SameSite %q, expected Lax, Strict or None", val(5))
+	}
+	return c, nil
+}
+
+func updateCookieForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	fields := len(m.CookieInputs) + len(cookieToggles)
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowCookieForm = false
+		m.CookieEditing = nil
+		return m, nil
+
+	case "enter":
+		c, err := saveCookieForm(m)
+		if err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		jar := cookieJar(m)
+		if m.CookieEditing != nil {
+			jar.Delete(*m.CookieEditing)
+		}
+		jar.Set(c)
+		m.ShowCookieForm = false
+		m.CookieEditing = nil
+		m.StatusMessage = fmt.Sprintf("Saved cookie %s for %s", c.Name, c.Domain)
+		// Select the saved cookie, its position depends on the sort order
+		for i, saved := range jar.List() {
+			if saved.Name == c.Name && saved.Domain == c.Domain && saved.Path == c.Path {
+				m.CookieIdx = i
+			}
+		}
+		return m, saveCookies(m)
+
+	case "tab", "shift+tab", "down", "up":
+		if msg.String() == "tab" || msg.String() == "down" {
+			m.CookieFocusField = (m.CookieFocusField + 1) % fields
+		} else {
+			m.CookieFocusField = (m.CookieFocusField + fields - 1) % fields
+		}
+		for i := range m.CookieInputs {
+			m.CookieInputs[i].Blur()
+		}
+		if m.CookieFocusField < len(m.CookieInputs) {
+			return m, m.CookieInputs[m.CookieFocusField].Focus()
+		}
+		return m, nil
+	}
+
+	if m.CookieFocusField >= len(m.CookieInputs) {
+		switch msg.String() {
+		case " ", "x", "h", "l", "left", "right":
+			if m.CookieFocusField == len(m.CookieInputs) {
+				m.CookieDraft.Secure = !m.CookieDraft.Secure
+			} else {
+				m.CookieDraft.HttpOnly = !m.CookieDraft.HttpOnly
+			}
+		}
+		return m, nil
+	}
+
+	m.CookieInputs[m.CookieFocusField], cmd = m.CookieInputs[m.CookieFocusField].Update(msg)
+	return m, cmd
+}
+
+// renderCookieTable lists the cookies of the active environment in the
+// response box
+func renderCookieTable(m model.Model, width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	list := cookieJar(m).List()
+	if len(list) == 0 {
+		return muted.Italic(true).Render("No cookies for environment " + environmentName(m) + " yet. n: add a cookie")
+	}
+
+	cell := func(s string, w int) string {
+		s = strings.ReplaceAll(s, "\n", " ")
+		if len([]rune(s)) > w {
+			s = string([]rune(s)[:max(w-1, 0)]) + "…"
+		}
+		return s + strings.Repeat(" ", max(w-len([]rune(s)), 0))
+	}
+	// Name, value, domain, path and expiry get fixed shares, flags the rest
+	nameW, domainW, pathW, expiresW := 16, 22, 10, 16
+	valueW := max((width-nameW-domainW-pathW-expiresW-2)/2, 8)
+	row := func(c [6]string) string {
+		return cell(c[0], nameW) + " " + cell(c[1], valueW) + " " + cell(c[2], domainW) + " " +
+			cell(c[3], pathW) + " " + cell(c[4], expiresW) + " " + c[5]
+	}
+
+	idx := min(m.CookieIdx, len(list)-1)
+	visible := max(height-3, 1)
+	start := 0
+	if idx >= visible {
+		start = idx - visible + 1
+	}
+
+	var b strings.Builder
+	b.WriteString(LabelStyle.Render(row([6]string{"Name", "Value", "Domain", "Path", "Expires", "Flags"})))
+	b.WriteString("\n")
+	for i := start; i < len(list) && i < start+visible; i++ {
+		c := list[i]
+		expires := "session"
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Local().Format("2006-01-02 15:04")
+		}
+		line := lipgloss.NewStyle().MaxWidth(width - 2).Render(row([6]string{c.Name, c.Value, c.Domain, c.Path, expires, c.Flags()}))
+		if i == idx {
+			b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true).Render("➤ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	persisted := "session only"
+	if m.PersistCookies {
+		persisted = "saved to disk"
+	}
+	b.WriteString(muted.Render(fmt.Sprintf("%d cookies, %s • enter: edit • n: new • d: delete • D: clear • p: toggle saving", len(list), persisted)))
+	return b.String()
+}
+
+// RenderCookieForm renders the cookie editor
+func RenderCookieForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	title := "New Cookie"
+	if m.CookieEditing != nil {
+		title = "Edit Cookie"
+	}
+	content.WriteString(TitleStyle.Render(fmt.Sprintf("%s (%s)", title, environmentName(m))))
+	content.WriteString("\n\n")
+
+	for i, l := range cookieInputLabels {
+		label := l + ":" + strings.Repeat(" ", 10-len(l))
+		if m.CookieFocusField == i {
+			label = focused.Render(label)
+		}
+		content.WriteString(label + m.CookieInputs[i].View() + "\n")
+	}
+	content.WriteString("\n")
+	for i, l := range cookieToggles {
+		label := l + ":" + strings.Repeat(" ", 10-len(l))
+		if m.CookieFocusField == len(m.CookieInputs)+i {
+			label = focused.Render(label)
+		}
+		on := m.CookieDraft.Secure
+		if i == 1 {
+			on = m.CookieDraft.HttpOnly
+		}
+		state := MethodStyle.Render("off")
+		if on {
+			state = SelectedMethodStyle.Render("on")
+		}
+		content.WriteString(label + state + "\n")
+	}
+	content.WriteString("\n")
+	if m.StatusMessage != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(muted.Render("tab/↑↓: next field • space: toggle • enter: save • esc: cancel"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
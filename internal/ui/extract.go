@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/extract"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// extractSources lists the rule sources in the order the selector cycles through them
+var extractSources = []model.ExtractSource{model.ExtractJSON, model.ExtractHeader, model.ExtractRegex, model.ExtractCookie}
+
+// extractSourceLabel returns the display name of a rule source
+func extractSourceLabel(s model.ExtractSource) string {
+	switch s {
+	case model.ExtractHeader:
+		return "Header"
+	case model.ExtractRegex:
+		return "Regex"
+	case model.ExtractCookie:
+		return "Cookie"
+	}
+	return "JSONPath"
+}
+
+// extractPlaceholder hints at the expression expected by a source
+func extractPlaceholder(s model.ExtractSource) string {
+	switch s {
+	case model.ExtractHeader:
+		return "Location"
+	case model.ExtractRegex:
+		return `token=(\w+)`
+	case model.ExtractCookie:
+		return "session"
+	}
+	return "$.access_token"
+}
+
+func openExtractForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowExtractForm = true
+	m.ExtractEditIdx = -1
+	m.ExtractIdx = min(m.ExtractIdx, max(len(m.Extract)-1, 0))
+	m.StatusMessage = ""
+	return m, nil
+}
+
+// editExtractRule opens the rule at idx in the editor, or a new rule when
+// idx is len(m.Extract)
+func editExtractRule(m model.Model, idx int) (model.Model, tea.Cmd) {
+	rule := model.ExtractRule{Source: model.ExtractJSON}
+	if idx < len(m.Extract) {
+		rule = m.Extract[idx]
+	}
+	m.ExtractEditIdx = idx
+	m.ExtractSource = rule.Source
+	m.ExtractFocusField = 0
+	m.ExtractInputs[0].SetValue(rule.Variable)
+	m.ExtractInputs[1].SetValue(rule.Expression)
+	m.ExtractInputs[1].Placeholder = extractPlaceholder(rule.Source)
+	m.ExtractInputs[1].Blur()
+	m.StatusMessage = ""
+	return m, m.ExtractInputs[0].Focus()
+}
+
+func updateExtractForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.ExtractEditIdx < 0 {
+		switch msg.String() {
+		case "esc", "ctrl+c", "q":
+			m.ShowExtractForm = false
+		case "j", "down":
+			if m.ExtractIdx < len(m.Extract)-1 {
+				m.ExtractIdx++
+			}
+		case "k", "up":
+			if m.ExtractIdx > 0 {
+				m.ExtractIdx--
+			}
+		case "a", "n":
+			return editExtractRule(m, len(m.Extract))
+		case "e", "enter":
+			if len(m.Extract) > 0 {
+				return editExtractRule(m, m.ExtractIdx)
+			}
+		case "d", "x", "backspace", "delete":
+			if len(m.Extract) > 0 {
+				m.Extract = append(append([]model.ExtractRule{}, m.Extract[:m.ExtractIdx]...), m.Extract[m.ExtractIdx+1:]...)
+				m.ExtractIdx = min(m.ExtractIdx, max(len(m.Extract)-1, 0))
+			}
+		}
+		return m, nil
+	}
+
+	// Fields: variable input, source selector, expression input
+	switch msg.String() {
+	case "esc":
+		m.ExtractEditIdx = -1
+		m.StatusMessage = ""
+		return m, nil
+
+	case "ctrl+c":
+		m.ExtractEditIdx = -1
+		m.ShowExtractForm = false
+		return m, nil
+
+	case "tab", "shift+tab":
+		if msg.String() == "tab" {
+			m.ExtractFocusField = (m.ExtractFocusField + 1) % 3
+		} else {
+			m.ExtractFocusField = (m.ExtractFocusField + 2) % 3
+		}
+		m.ExtractInputs[0].Blur()
+		m.ExtractInputs[1].Blur()
+		switch m.ExtractFocusField {
+		case 0:
+			return m, m.ExtractInputs[0].Focus()
+		case 2:
+			return m, m.ExtractInputs[1].Focus()
+		}
+		return m, nil
+
+	case "enter":
+		rule := model.ExtractRule{
+			Variable:   strings.TrimSpace(m.ExtractInputs[0].Value()),
+			Source:     m.ExtractSource,
+			Expression: strings.TrimSpace(m.ExtractInputs[1].Value()),
+		}
+		if err := extract.Validate(rule); err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		if m.ExtractEditIdx < len(m.Extract) {
+			m.Extract = append([]model.ExtractRule{}, m.Extract...)
+			m.Extract[m.ExtractEditIdx] = rule
+		} else {
+			m.Extract = append(append([]model.ExtractRule{}, m.Extract...), rule)
+		}
+		m.ExtractIdx = m.ExtractEditIdx
+		m.ExtractEditIdx = -1
+		m.ExtractInputs[0].Blur()
+		m.ExtractInputs[1].Blur()
+		m.StatusMessage = ""
+		return m, nil
+	}
+
+	if m.ExtractFocusField == 1 {
+		step := 0
+		switch msg.String() {
+		case "j", "down", "l", "right", " ":
+			step = 1
+		case "k", "up", "h", "left":
+			step = len(extractSources) - 1
+		}
+		if step != 0 {
+			idx := 0
+			for i, s := range extractSources {
+				if s == m.ExtractSource {
+					idx = i
+				}
+			}
+			m.ExtractSource = extractSources[(idx+step)%len(extractSources)]
+			m.ExtractInputs[1].Placeholder = extractPlaceholder(m.ExtractSource)
+		}
+		return m, nil
+	}
+
+	input := 0
+	if m.ExtractFocusField == 2 {
+		input = 1
+	}
+	m.ExtractInputs[input], cmd = m.ExtractInputs[input].Update(msg)
+	return m, cmd
+}
+
+// applyExtraction stores the values extracted from a response into the
+// active environment, or the collection variables when none is selected
+func applyExtraction(m model.Model, entry *model.HistoryEntry) model.Model {
+	if len(m.Extract) == 0 || entry == nil || entry.Error != "" {
+		return m
+	}
+	if m.Collection == nil {
+		// Extracted values need somewhere to live for {{references}}
+		m.Collection = &model.Collection{Name: "Scratch"}
+	}
+
+	var stored, failed []string
+	for _, r := range extract.Apply(m.Extract, entry.Request.URL, entry.Response, cookieJar(m)) {
+		if r.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Rule.Variable, r.Err))
+			continue
+		}
+		m.Collection.SetVariable(m.EnvironmentIdx, r.Rule.Variable, r.Value)
+		stored = append(stored, r.Rule.Variable)
+	}
+
+	var status []string
+	if len(stored) > 0 {
+		status = append(status, "Extracted "+strings.Join(stored, ", "))
+	}
+	if len(failed) > 0 {
+		status = append(status, "Extraction failed for "+strings.Join(failed, "; "))
+	}
+	m.StatusMessage = strings.Join(status, " • ")
+	return m
+}
+
+// RenderExtractForm renders the extraction rules of the request
+func RenderExtractForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	target := "collection variables"
+	if name := environmentName(m); name != "None" {
+		target = "environment " + name
+	}
+	content.WriteString(TitleStyle.Render("Extract Response Values"))
+	content.WriteString("\n\n")
+	content.WriteString(muted.Render("After each response, values are stored into " + target + ", for use as {{variable}}"))
+	content.WriteString("\n\n")
+
+	if m.ExtractEditIdx < 0 {
+		if len(m.Extract) == 0 {
+			content.WriteString(muted.Italic(true).Render("No rules yet. Press 'a' to add one."))
+			content.WriteString("\n")
+		}
+		for i, r := range m.Extract {
+			line := fmt.Sprintf("%-20s ← %-8s %s", r.Variable, extractSourceLabel(r.Source), r.Expression)
+			line = lipgloss.NewStyle().MaxWidth(max(m.Width-36, 20)).Render(line)
+			if i == m.ExtractIdx {
+				content.WriteString(focused.Render("➤ " + line))
+			} else {
+				content.WriteString("  " + line)
+			}
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+		content.WriteString(muted.Render("j/k: navigate • a: add • enter/e: edit • d: delete • esc: close"))
+	} else {
+		labels := []string{"Variable:   ", "Source:     ", "Expression: "}
+		for i, l := range labels {
+			if m.ExtractFocusField == i {
+				l = focused.Render(l)
+			}
+			content.WriteString(l)
+			switch i {
+			case 0:
+				content.WriteString(m.ExtractInputs[0].View())
+			case 1:
+				for _, s := range extractSources {
+					if s == m.ExtractSource {
+						content.WriteString(SelectedMethodStyle.Render(extractSourceLabel(s)))
+					} else {
+						content.WriteString(MethodStyle.Render(extractSourceLabel(s)))
+					}
+					content.WriteString(" ")
+				}
+			case 2:
+				content.WriteString(m.ExtractInputs[1].View())
+			}
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+		if m.StatusMessage != "" {
+			content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+			content.WriteString("\n\n")
+		}
+		content.WriteString(muted.Render("tab: next field • j/k: change source • enter: save • esc: back"))
+	}
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
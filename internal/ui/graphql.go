@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/graphql"
+	"github.com/tbourrel/apitty/internal/http"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func openGraphQLForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowGraphQLForm = true
+	m.URLInput.Blur()
+	m.GraphQLSuggestions = nil
+	m.GraphQLQuery.SetWidth(m.Width - 14)
+	m.GraphQLVariables.SetWidth(m.Width - 14)
+	m.GraphQLQuery.SetHeight(max(m.Height-26, 5))
+	m.GraphQLVariables.Blur()
+	m.GraphQLFocusField = 0
+	return m, m.GraphQLQuery.Focus()
+}
+
+func updateGraphQLForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	key := msg.String()
+	if key != "ctrl+n" && key != "ctrl+y" {
+		m.GraphQLSuggestions = nil
+	}
+
+	switch key {
+	case "esc", "ctrl+c":
+		m.ShowGraphQLForm = false
+		m.GraphQLQuery.Blur()
+		m.GraphQLVariables.Blur()
+		return m, nil
+
+	case "tab":
+		if m.GraphQLFocusField == 0 {
+			m.GraphQLFocusField = 1
+			m.GraphQLQuery.Blur()
+			return m, m.GraphQLVariables.Focus()
+		}
+		m.GraphQLFocusField = 0
+		m.GraphQLVariables.Blur()
+		return m, m.GraphQLQuery.Focus()
+
+	case "ctrl+t":
+		if m.BodyMode == model.BodyGraphQL {
+			m.BodyMode = model.BodyRaw
+		} else {
+			m.BodyMode = model.BodyGraphQL
+		}
+		return m, nil
+
+	case "ctrl+r":
+		if m.URLInput.Value() == "" {
+			m.GraphQLStatus = "Enter the endpoint URL first"
+			return m, nil
+		}
+		m.GraphQLStatus = "Introspecting..."
+		return m, http.IntrospectCmd(currentRequest(m), cookieJar(m))
+
+	case "ctrl+e":
+		if m.GraphQLSchema == nil {
+			m.GraphQLStatus = "No schema loaded. Press ctrl+r to introspect."
+			return m, nil
+		}
+		m.ShowSchemaExplorer = true
+		return m, nil
+
+	case "ctrl+n":
+		if m.GraphQLFocusField != 0 {
+			return m, nil
+		}
+		if len(m.GraphQLSuggestions) > 0 {
+			m.GraphQLSuggestionIdx = (m.GraphQLSuggestionIdx + 1) % len(m.GraphQLSuggestions)
+			return m, nil
+		}
+		prefix, candidates := graphql.Complete(m.GraphQLSchema, m.GraphQLQuery.Value(), textareaOffset(m.GraphQLQuery))
+		switch len(candidates) {
+		case 0:
+			if m.GraphQLSchema == nil {
+				m.GraphQLStatus = "No schema loaded. Press ctrl+r to introspect."
+			}
+		case 1:
+			acceptCompletion(&m.GraphQLQuery, prefix, candidates[0])
+		default:
+			m.GraphQLSuggestions = candidates
+			m.GraphQLSuggestionIdx = 0
+		}
+		return m, nil
+
+	case "ctrl+y":
+		if len(m.GraphQLSuggestions) > 0 {
+			prefix, _ := graphql.Complete(m.GraphQLSchema, m.GraphQLQuery.Value(), textareaOffset(m.GraphQLQuery))
+			acceptCompletion(&m.GraphQLQuery, prefix, m.GraphQLSuggestions[m.GraphQLSuggestionIdx])
+			m.GraphQLSuggestions = nil
+		}
+		return m, nil
+
+	case "ctrl+s":
+		m.ShowGraphQLForm = false
+		m.GraphQLQuery.Blur()
+		m.GraphQLVariables.Blur()
+		if m.URLInput.Value() != "" {
+			m.BodyMode = model.BodyGraphQL
+			return sendRequest(m)
+		}
+		return m, nil
+	}
+
+	if m.GraphQLFocusField == 0 {
+		m.GraphQLQuery, cmd = m.GraphQLQuery.Update(msg)
+	} else {
+		m.GraphQLVariables, cmd = m.GraphQLVariables.Update(msg)
+	}
+	return m, cmd
+}
+
+// textareaOffset returns the byte offset of the cursor in the textarea value
+func textareaOffset(ta textarea.Model) int {
+	lines := strings.Split(ta.Value(), "\n")
+	row := ta.Line()
+	offset := 0
+	for i := 0; i < row && i < len(lines); i++ {
+		offset += len(lines[i]) + 1
+	}
+	if row < len(lines) {
+		info := ta.LineInfo()
+		runes := []rune(lines[row])
+		col := min(info.StartColumn+info.CharOffset, len(runes))
+		offset += len(string(runes[:col]))
+	}
+	return offset
+}
+
+// acceptCompletion replaces the partial identifier before the cursor with a candidate
+func acceptCompletion(ta *textarea.Model, prefix, candidate string) {
+	for range []rune(prefix) {
+		*ta, _ = ta.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	ta.InsertString(candidate)
+}
+
+func updateSchemaExplorer(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	_, targets := schemaExplorerEntries(m)
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.ShowSchemaExplorer = false
+		return m, nil
+
+	case "j", "down":
+		if len(targets) > 0 {
+			m.SchemaExplorerIdx = (m.SchemaExplorerIdx + 1) % len(targets)
+		}
+		return m, nil
+
+	case "k", "up":
+		if len(targets) > 0 {
+			m.SchemaExplorerIdx = (m.SchemaExplorerIdx - 1 + len(targets)) % len(targets)
+		}
+		return m, nil
+
+	case "enter", "l", "right":
+		if m.SchemaExplorerIdx < len(targets) && m.GraphQLSchema.Type(targets[m.SchemaExplorerIdx]) != nil {
+			m.SchemaExplorerPath = append(m.SchemaExplorerPath, targets[m.SchemaExplorerIdx])
+			m.SchemaExplorerIdx = 0
+		}
+		return m, nil
+
+	case "backspace", "h", "left":
+		if len(m.SchemaExplorerPath) > 0 {
+			m.SchemaExplorerPath = m.SchemaExplorerPath[:len(m.SchemaExplorerPath)-1]
+			m.SchemaExplorerIdx = 0
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// schemaExplorerEntries returns the lines listed at the current explorer
+// location and the type each of them navigates to
+func schemaExplorerEntries(m model.Model) (entries []string, targets []string) {
+	schema := m.GraphQLSchema
+	if schema == nil {
+		return nil, nil
+	}
+
+	if len(m.SchemaExplorerPath) == 0 {
+		for _, t := range schema.UserTypes() {
+			label := t.Name
+			switch t.Name {
+			case schema.QueryType:
+				label += " (query)"
+			case schema.MutationType:
+				label += " (mutation)"
+			case schema.SubscriptionType:
+				label += " (subscription)"
+			}
+			entries = append(entries, fmt.Sprintf("%-8s %s", strings.ToLower(t.Kind), label))
+			targets = append(targets, t.Name)
+		}
+		return entries, targets
+	}
+
+	t := schema.Type(m.SchemaExplorerPath[len(m.SchemaExplorerPath)-1])
+	if t == nil {
+		return nil, nil
+	}
+	entries = graphql.Members(t)
+	for _, f := range t.Fields {
+		targets = append(targets, f.Type.Named())
+	}
+	for _, f := range t.InputFields {
+		targets = append(targets, f.Type.Named())
+	}
+	for range t.EnumValues {
+		targets = append(targets, "")
+	}
+	return entries, targets
+}
+
+// RenderGraphQLForm renders the GraphQL query and variables editor
+func RenderGraphQLForm(m model.Model) string {
+	var content strings.Builder
+
+	content.WriteString(TitleStyle.Render("GraphQL"))
+	content.WriteString("\n\n")
+
+	mode := "Raw (ctrl+s sends as GraphQL)"
+	if m.BodyMode == model.BodyGraphQL {
+		mode = "GraphQL"
+	}
+	content.WriteString(LabelStyle.Render("Body mode: ") + mode)
+	content.WriteString("\n")
+	status := m.GraphQLStatus
+	if status == "" {
+		status = "No schema loaded"
+	}
+	content.WriteString(LabelStyle.Render("Schema: ") + status)
+	content.WriteString("\n\n")
+
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	queryLabel := LabelStyle.Render("Query:")
+	varsLabel := LabelStyle.Render("Variables (JSON):")
+	if m.GraphQLFocusField == 0 {
+		queryLabel = focused.Render("Query:")
+	} else {
+		varsLabel = focused.Render("Variables (JSON):")
+	}
+	content.WriteString(queryLabel + "\n" + m.GraphQLQuery.View() + "\n")
+
+	if len(m.GraphQLSuggestions) > 0 {
+		var items []string
+		for i, s := range m.GraphQLSuggestions {
+			if i == m.GraphQLSuggestionIdx {
+				items = append(items, focused.Render("➤ "+s))
+			} else {
+				items = append(items, s)
+			}
+		}
+		content.WriteString(strings.Join(items, "  ") + "\n")
+	}
+
+	content.WriteString("\n" + varsLabel + "\n" + m.GraphQLVariables.View() + "\n\n")
+
+	instructions := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Render("tab: switch field • ctrl+n: complete • ctrl+y: accept • ctrl+r: introspect • ctrl+e: explore schema • ctrl+t: toggle mode • ctrl+s: send • esc: close")
+	content.WriteString(instructions)
+
+	formBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + formBox.Render(content.String())
+}
+
+// RenderSchemaExplorer renders the browsable GraphQL schema
+func RenderSchemaExplorer(m model.Model) string {
+	var content strings.Builder
+
+	content.WriteString(TitleStyle.Render("Schema Explorer"))
+	content.WriteString("\n\n")
+
+	location := "Types"
+	if len(m.SchemaExplorerPath) > 0 {
+		location = "Types › " + strings.Join(m.SchemaExplorerPath, " › ")
+		if t := m.GraphQLSchema.Type(m.SchemaExplorerPath[len(m.SchemaExplorerPath)-1]); t != nil && t.Description != "" {
+			location += "\n" + lipgloss.NewStyle().Italic(true).Render(t.Description)
+		}
+	}
+	content.WriteString(LabelStyle.Render(location))
+	content.WriteString("\n\n")
+
+	entries, _ := schemaExplorerEntries(m)
+	visible := max(m.Height-14, 3)
+	start := 0
+	if m.SchemaExplorerIdx >= visible {
+		start = m.SchemaExplorerIdx - visible + 1
+	}
+	for i := start; i < len(entries) && i < start+visible; i++ {
+		if i == m.SchemaExplorerIdx {
+			content.WriteString(lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF00FF")).
+				Bold(true).
+				Render("➤ " + entries[i]))
+		} else {
+			content.WriteString("  " + entries[i])
+		}
+		content.WriteString("\n")
+	}
+	if len(entries) == 0 {
+		content.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262")).
+			Italic(true).
+			Render("Nothing to show"))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Render("j/k: navigate • enter/l: open type • backspace/h: back • esc/q: close"))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
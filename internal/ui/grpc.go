@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/grpc"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/transfer"
+)
+
+func openGRPCForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowGRPCForm = true
+	m.URLInput.Blur()
+	m.GRPCFocusField = 0
+	m.GRPCProtoInput.Blur()
+	m.GRPCRequest.Blur()
+	m.GRPCProtoInput.Width = m.Width - 24
+	m.GRPCRequest.SetWidth(m.Width - 14)
+	return m, nil
+}
+
+func updateGRPCForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowGRPCForm = false
+		m.GRPCProtoInput.Blur()
+		m.GRPCRequest.Blur()
+		return m, nil
+
+	case "tab":
+		m.GRPCFocusField = (m.GRPCFocusField + 1) % 3
+		return focusGRPCField(m)
+
+	case "shift+tab":
+		m.GRPCFocusField = (m.GRPCFocusField + 2) % 3
+		return focusGRPCField(m)
+
+	case "ctrl+l":
+		if m.URLInput.Value() == "" && m.GRPCProtoInput.Value() == "" {
+			m.GRPCStatus = "Enter the server address (host:port) in the URL field first"
+			return m, nil
+		}
+		m.GRPCStatus = "Loading services..."
+		return m, grpc.LoadDescriptorsCmd(m.URLInput.Value(), splitPaths(m.GRPCProtoInput.Value()))
+
+	case "ctrl+s":
+		if m.GRPCMethod == "" {
+			m.GRPCStatus = "Select a method first"
+			return m, nil
+		}
+		if m.URLInput.Value() == "" {
+			m.GRPCStatus = "Enter the server address (host:port) in the URL field first"
+			return m, nil
+		}
+		m.ShowGRPCForm = false
+		m.GRPCProtoInput.Blur()
+		m.GRPCRequest.Blur()
+		m.Response = ""
+		m.StatusCode = "Sending..."
+		m.Loading = true
+		// The transfer only lets esc cancel the call, its body is not streamed
+		m.Transfer = transfer.New(0)
+		return m, grpc.InvokeCmd(m.Transfer.Context(), m.URLInput.Value(), m.GRPCFiles, m.GRPCMethod, m.GRPCRequest.Value(), m.RequestHeaders)
+	}
+
+	switch m.GRPCFocusField {
+	case 0:
+		switch msg.String() {
+		case "j", "down":
+			if len(m.GRPCMethods) > 0 {
+				m.GRPCMethodIdx = (m.GRPCMethodIdx + 1) % len(m.GRPCMethods)
+			}
+		case "k", "up":
+			if len(m.GRPCMethods) > 0 {
+				m.GRPCMethodIdx = (m.GRPCMethodIdx - 1 + len(m.GRPCMethods)) % len(m.GRPCMethods)
+			}
+		case "enter":
+			if m.GRPCMethodIdx < len(m.GRPCMethods) {
+				return selectGRPCMethod(m, m.GRPCMethods[m.GRPCMethodIdx])
+			}
+		}
+		return m, nil
+	case 1:
+		if msg.String() == "enter" {
+			m.GRPCStatus = "Loading services..."
+			return m, grpc.LoadDescriptorsCmd(m.URLInput.Value(), splitPaths(m.GRPCProtoInput.Value()))
+		}
+		m.GRPCProtoInput, cmd = m.GRPCProtoInput.Update(msg)
+	default:
+		m.GRPCRequest, cmd = m.GRPCRequest.Update(msg)
+	}
+	return m, cmd
+}
+
+func focusGRPCField(m model.Model) (model.Model, tea.Cmd) {
+	m.GRPCProtoInput.Blur()
+	m.GRPCRequest.Blur()
+	switch m.GRPCFocusField {
+	case 1:
+		m.GRPCProtoInput.Focus()
+		return m, textinput.Blink
+	case 2:
+		return m, m.GRPCRequest.Focus()
+	}
+	return m, nil
+}
+
+// selectGRPCMethod picks a method and fills the request editor with a template
+func selectGRPCMethod(m model.Model, name string) (model.Model, tea.Cmd) {
+	md, err := grpc.FindMethod(m.GRPCFiles, name)
+	if err != nil {
+		m.GRPCStatus = err.Error()
+		return m, nil
+	}
+	m.GRPCMethod = name
+	m.GRPCRequest.SetValue(grpc.Template(md.Input()))
+	kind := "unary"
+	switch {
+	case md.IsStreamingClient():
+		kind = "client streaming (not supported)"
+	case md.IsStreamingServer():
+		kind = "server streaming"
+	}
+	m.GRPCStatus = fmt.Sprintf("%s · %s → %s", kind, md.Input().FullName(), md.Output().FullName())
+	m.GRPCFocusField = 2
+	return focusGRPCField(m)
+}
+
+// splitPaths splits the comma separated descriptor paths
+func splitPaths(s string) []string {
+	var paths []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// RenderGRPCForm renders the gRPC method picker and request editor
+func RenderGRPCForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("gRPC"))
+	content.WriteString("\n\n")
+
+	target := m.URLInput.Value()
+	if target == "" {
+		target = muted.Italic(true).Render("set host:port in the URL field")
+	}
+	content.WriteString(LabelStyle.Render("Server: ") + target + "\n")
+	if m.GRPCStatus != "" {
+		content.WriteString(LabelStyle.Render("Status: ") + m.GRPCStatus + "\n")
+	}
+	content.WriteString("\n")
+
+	label := func(idx int, text string) string {
+		if m.GRPCFocusField == idx {
+			return focused.Render(text)
+		}
+		return LabelStyle.Render(text)
+	}
+
+	content.WriteString(label(0, "Methods:") + "\n")
+	if len(m.GRPCMethods) == 0 {
+		content.WriteString(muted.Italic(true).Render("No services loaded. Press ctrl+l to load them."))
+		content.WriteString("\n")
+	}
+	visible := max(m.Height-m.GRPCRequest.Height()-22, 3)
+	start := 0
+	if m.GRPCMethodIdx >= visible {
+		start = m.GRPCMethodIdx - visible + 1
+	}
+	for i := start; i < len(m.GRPCMethods) && i < start+visible; i++ {
+		name := m.GRPCMethods[i]
+		prefix := "  "
+		if name == m.GRPCMethod {
+			prefix = "● "
+		}
+		if i == m.GRPCMethodIdx && m.GRPCFocusField == 0 {
+			content.WriteString(focused.Render("➤ " + name))
+		} else {
+			content.WriteString(prefix + name)
+		}
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n" + label(1, "Descriptors: ") + m.GRPCProtoInput.View() + "\n\n")
+	content.WriteString(label(2, "Request (JSON):") + "\n" + m.GRPCRequest.View() + "\n\n")
+
+	content.WriteString(muted.Render("tab: switch field • j/k: navigate • enter: select method • ctrl+l: load services • ctrl+s: invoke • esc: close"))
+
+	formBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + formBox.Render(content.String())
+}
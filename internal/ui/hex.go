@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/hexdump"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// showBinary replaces a binary body with its hex dump, under a summary of
+// its type and size
+func showBinary(m *model.Model) {
+	m.ResponseBinary = m.ResponseBody != "" && hexdump.IsBinary(m.ResponseType, []byte(m.ResponseBody))
+	m.HexPattern, m.HexMatch = nil, -1
+	if !m.ResponseBinary {
+		return
+	}
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	summary := fmt.Sprintf("Binary body · %s · %s (%d bytes)",
+		hexdump.Detect(m.ResponseType, []byte(m.ResponseBody)), formatBytes(m.ResponseSize), m.ResponseSize)
+	m.Response = summary + muted.Render("  : jump to offset • /: search bytes • n: next match") +
+		"\n\n" + hexdump.Dump([]byte(m.ResponseBody))
+}
+
+// hexLine returns the line of the response showing an offset of the body
+func hexLine(m model.Model, offset int) int {
+	// The summary and a blank line, after the preview notice
+	lines := 2
+	if previewNotice(m) != "" {
+		lines += 2
+	}
+	return lines + offset/hexdump.Width
+}
+
+// highlightHex dims the offsets of the dump, and highlights the line of the
+// last match
+func highlightHex(match int) func(string) string {
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	found := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	matchOffset := ""
+	if match >= 0 {
+		matchOffset = fmt.Sprintf("%08x  ", match-match%hexdump.Width)
+	}
+	return func(line string) string {
+		if len(line) < 10 || line[8:10] != "  " || strings.Contains(line, "\x1b") {
+			return line
+		}
+		if matchOffset != "" && strings.HasPrefix(line, matchOffset) {
+			return found.Render(line)
+		}
+		return muted.Render(line[:8]) + line[8:]
+	}
+}
+
+// handleHexKeys handles the keys of the hex view of a binary body
+func handleHexKeys(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd, bool) {
+	switch msg.String() {
+	case ":", "/":
+		m.ShowHexPrompt = true
+		m.HexSearch = msg.String() == "/"
+		m.StatusMessage = ""
+		m.HexInput.SetValue("")
+		m.HexInput.Placeholder = "0x1000"
+		if m.HexSearch {
+			m.HexInput.Placeholder = `89 50 4e 47 or "text"`
+		}
+		return m, m.HexInput.Focus(), true
+	case "n":
+		if m.HexPattern == nil {
+			m.StatusMessage = "Search bytes with / first"
+			return m, nil, true
+		}
+		return findBytes(m), nil, true
+	}
+	return m, nil, false
+}
+
+// findBytes moves to the next match of HexPattern, from the start once the
+// last one is passed
+func findBytes(m model.Model) model.Model {
+	pattern := string(m.HexPattern)
+	from := m.HexMatch + 1
+	match := strings.Index(m.ResponseBody[min(from, len(m.ResponseBody)):], pattern)
+	if match >= 0 {
+		match += from
+	} else if from > 0 {
+		match = strings.Index(m.ResponseBody, pattern)
+	}
+	if match < 0 {
+		m.StatusMessage = fmt.Sprintf("No match for %x", m.HexPattern)
+		return m
+	}
+	if match < from {
+		m.StatusMessage = fmt.Sprintf("Found at 0x%x (%d), from the start • n: next match", match, match)
+	} else {
+		m.StatusMessage = fmt.Sprintf("Found at 0x%x (%d) • n: next match", match, match)
+	}
+	m.HexMatch = match
+	m.Viewport.Highlight = highlightHex(match)
+	m.Viewport.GotoLine(hexLine(m, match))
+	return m
+}
+
+func updateHexPrompt(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowHexPrompt = false
+		m.HexInput.Blur()
+		m.StatusMessage = ""
+		return m, nil
+
+	case "enter":
+		if m.HexSearch {
+			pattern, err := hexdump.ParsePattern(m.HexInput.Value())
+			if err != nil {
+				m.StatusMessage = err.Error()
+				return m, nil
+			}
+			m.ShowHexPrompt = false
+			m.HexInput.Blur()
+			m.HexPattern, m.HexMatch = pattern, -1
+			return findBytes(m), nil
+		}
+
+		offset, err := hexdump.ParseOffset(m.HexInput.Value())
+		if err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		if offset >= len(m.ResponseBody) {
+			m.StatusMessage = fmt.Sprintf("offset 0x%x is past the %d bytes shown", offset, len(m.ResponseBody))
+			return m, nil
+		}
+		m.ShowHexPrompt = false
+		m.HexInput.Blur()
+		m.StatusMessage = fmt.Sprintf("Offset 0x%x (%d)", offset, offset)
+		m.Viewport.GotoLine(hexLine(m, offset))
+		return m, nil
+	}
+
+	m.HexInput, cmd = m.HexInput.Update(msg)
+	return m, cmd
+}
+
+// RenderHexPrompt renders the prompt for an offset to jump to, or bytes to
+// search
+func RenderHexPrompt(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	if m.HexSearch {
+		content.WriteString(TitleStyle.Render("Search Bytes"))
+		content.WriteString("\n\n")
+		content.WriteString(muted.Render(`Hex bytes like 89 50 4e 47, or text between double quotes like "IHDR"`))
+	} else {
+		content.WriteString(TitleStyle.Render("Jump to Offset"))
+		content.WriteString("\n\n")
+		content.WriteString(muted.Render(fmt.Sprintf("A decimal offset, or hex with 0x, below %d", len(m.ResponseBody))))
+	}
+	content.WriteString("\n\n")
+	content.WriteString(m.HexInput.View() + "\n\n")
+	if m.StatusMessage != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+		content.WriteString("\n\n")
+	}
+	content.WriteString(muted.Render("enter: go • esc: cancel"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
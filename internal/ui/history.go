@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/har"
+	"github.com/tbourrel/apitty/internal/http"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func openHistory(m model.Model) (model.Model, tea.Cmd) {
+	if len(m.History) == 0 {
+		m.StatusMessage = "The history is empty, send a request first"
+		return m, nil
+	}
+	m.ShowHistory = true
+	m.HistoryIdx = 0
+	return m, nil
+}
+
+// historyEntry returns the entry shown at idx in the browser, newest first
+func historyEntry(m model.Model, idx int) model.HistoryEntry {
+	return m.History[len(m.History)-1-idx]
+}
+
+func updateHistory(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	n := len(m.History)
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.ShowHistory = false
+		return m, nil
+
+	case "j", "down":
+		if n > 0 {
+			m.HistoryIdx = (m.HistoryIdx + 1) % n
+		}
+		return m, nil
+
+	case "k", "up":
+		if n > 0 {
+			m.HistoryIdx = (m.HistoryIdx - 1 + n) % n
+		}
+		return m, nil
+
+	case "enter":
+		if m.HistoryIdx < n {
+			m = loadHistoryEntry(m, historyEntry(m, m.HistoryIdx))
+			m.ShowHistory = false
+		}
+		return m, nil
+
+	case "x":
+		path, err := exportHistory(m.History)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not export history: %v", err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Exported %d requests to %s", n, path)
+		}
+		m.ShowHistory = false
+		return m, nil
+	}
+	return m, nil
+}
+
+// loadHistoryEntry loads the request of an entry into the editor and shows its response
+func loadHistoryEntry(m model.Model, e model.HistoryEntry) model.Model {
+	m = loadRequest(m, &model.SavedRequest{Name: e.Request.Method + " " + e.Request.URL, Request: e.Request})
+	m.LoadedRequestIdx = -1
+
+	msg := model.ResponseMsg{
+		Headers: http.FormatHeaders(e.Response.Headers),
+		Status:  e.Response.Status,
+	}
+	msg.Resp, msg.Format = http.FormatBody(e.Response)
+	m = keepBody(m, model.ResponseMsg{Entry: &e})
+	if e.Error != "" {
+		msg.Err = errors.New(e.Error)
+	}
+	showResponse(&m, msg)
+	return m
+}
+
+// exportHistory writes the history as a HAR file in the current directory
+func exportHistory(entries []model.HistoryEntry) (string, error) {
+	data, err := har.Marshal(har.FromHistory(entries))
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("apitty-%s.har", time.Now().Format("20060102-150405"))
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// RenderHistory renders the history browser
+func RenderHistory(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render(fmt.Sprintf("History (%d)", len(m.History))))
+	content.WriteString("\n\n")
+
+	visible := max(m.Height-12, 3)
+	start := 0
+	if m.HistoryIdx >= visible {
+		start = m.HistoryIdx - visible + 1
+	}
+	for i := start; i < len(m.History) && i < start+visible; i++ {
+		e := historyEntry(m, i)
+		status := fmt.Sprint(e.Response.StatusCode)
+		if e.Error != "" {
+			status = "ERR"
+		}
+		line := fmt.Sprintf("%s  %-7s %-3s %6s  %s",
+			e.Time.Local().Format("Jan 02 15:04:05"),
+			e.Request.Method,
+			status,
+			e.Timings.Total().Round(time.Millisecond),
+			e.Request.URL)
+		line = lipgloss.NewStyle().MaxWidth(max(m.Width-16, 20)).Render(line)
+		if i == m.HistoryIdx {
+			content.WriteString(lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF00FF")).
+				Bold(true).
+				Render("➤ " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(muted.Render("j/k: navigate • enter: load request and response • x: export as HAR • esc/q: close"))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/tbourrel/apitty/internal/httpfile"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// OpenHTTPFile loads a .http or .rest file, along with the environments of
+// http-client.env.json next to it, as the collection of the model
+func OpenHTTPFile(m model.Model, path string) (model.Model, error) {
+	f, err := httpfile.Load(path)
+	if err != nil {
+		return m, err
+	}
+	envs, err := httpfile.LoadEnvironments(filepath.Dir(path))
+	if err != nil {
+		return m, err
+	}
+
+	c := httpFileCollection(f, filepath.Base(path))
+	for _, env := range envs {
+		e := model.Environment{Name: env.Name}
+		for _, v := range env.Variables {
+			e.Variables = append(e.Variables, model.Variable{Key: v.Name, Value: v.Value})
+		}
+		c.Environments = append(c.Environments, e)
+	}
+	m.HTTPFile = f
+	m.Collection = c
+	m.CollectionPath = path
+	return m, nil
+}
+
+// httpFileCollection lists the variables and requests of a .http file as a
+// collection without folders, so entries match the requests of the file
+func httpFileCollection(f *httpfile.File, name string) *model.Collection {
+	c := &model.Collection{Name: name}
+	for _, v := range f.Variables {
+		c.Variables = append(c.Variables, model.Variable{Key: v.Name, Value: v.Value})
+	}
+	for _, r := range f.Requests {
+		saved := model.SavedRequest{
+			Name:    r.Name,
+			Request: model.Request{Method: r.Method, URL: r.URL, Body: r.Body},
+		}
+		for _, h := range r.Headers {
+			saved.Headers = append(saved.Headers, model.HeaderPair{Key: h.Key, Value: h.Value})
+		}
+		c.Requests = append(c.Requests, saved)
+	}
+	return c
+}
+
+// saveToHTTPFile writes the editor request over the loaded request of the
+// .http file, keeping the rest of the file as it is
+func saveToHTTPFile(m model.Model) model.Model {
+	var headers []httpfile.Header
+	for _, h := range m.RequestHeaders {
+		headers = append(headers, httpfile.Header{Key: h.Key, Value: h.Value})
+	}
+	idx := m.LoadedRequestIdx
+	err := m.HTTPFile.Update(idx, model.Methods[m.MethodIdx], m.URLInput.Value(), headers, m.Body)
+	if err == nil {
+		err = m.HTTPFile.Save(m.CollectionPath)
+	}
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Could not save %s: %v", filepath.Base(m.CollectionPath), err)
+		return m
+	}
+
+	environments := m.Collection.Environments
+	m.Collection = httpFileCollection(m.HTTPFile, m.Collection.Name)
+	m.Collection.Environments = environments
+	m.StatusMessage = fmt.Sprintf("Saved %q to %s", m.HTTPFile.Requests[idx].Name, filepath.Base(m.CollectionPath))
+	if m.Auth.Type != model.AuthNone {
+		m.StatusMessage += " (auth settings are not stored in .http files, use an Authorization header)"
+	}
+	if len(m.Extract) > 0 {
+		m.StatusMessage += " (extraction rules are not stored in .http files)"
+	}
+	if len(m.Assertions) > 0 {
+		m.StatusMessage += " (assertions are not stored in .http files)"
+	}
+	if !m.Contract.IsZero() {
+		m.StatusMessage += " (contracts are not stored in .http files)"
+	}
+	return m
+}
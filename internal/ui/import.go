@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/parser"
+)
+
+func openImport(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowImport = true
+	m.ImportError = ""
+	m.ImportPreview = nil
+	m.ImportInput.Reset()
+	m.ImportInput.SetWidth(m.Width - 14)
+	m.ImportInput.SetHeight(max(m.Height-18, 5))
+	return m, m.ImportInput.Focus()
+}
+
+func closeImport(m model.Model) model.Model {
+	m.ShowImport = false
+	m.ImportError = ""
+	m.ImportPreview = nil
+	m.ImportInput.Blur()
+	return m
+}
+
+func updateImport(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.ImportPreview != nil {
+		switch msg.String() {
+		case "enter", "ctrl+s":
+			r := *m.ImportPreview
+			m = loadRequest(m, &model.SavedRequest{Name: r.Method + " " + r.URL, Request: r})
+			m.LoadedRequestIdx = -1
+			if strings.HasPrefix(m.StatusMessage, "Loaded") {
+				m.StatusMessage = fmt.Sprintf("Imported %s request %s %s", m.ImportFormat, r.Method, r.URL)
+			}
+			m = closeImport(m)
+			m.ImportInput.Reset()
+			return m, nil
+		case "esc", "e":
+			// Back to the command to fix it
+			m.ImportPreview = nil
+			return m, m.ImportInput.Focus()
+		case "ctrl+c":
+			return closeImport(m), nil
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return closeImport(m), nil
+
+	case "ctrl+s":
+		input := m.ImportInput.Value()
+		if strings.TrimSpace(input) == "" {
+			m.ImportError = "Nothing to import"
+			return m, nil
+		}
+		r, format, err := parser.Parse(input)
+		if err != nil {
+			m.ImportError = err.Error()
+			return m, nil
+		}
+		m.ImportPreview = &r
+		m.ImportFormat = string(format)
+		m.ImportError = ""
+		m.ImportInput.Blur()
+		return m, nil
+
+	default:
+		m.ImportInput, cmd = m.ImportInput.Update(msg)
+		m.ImportError = ""
+		return m, cmd
+	}
+}
+
+// RenderImport renders the command import modal
+func RenderImport(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Import Request"))
+	content.WriteString("\n\n")
+
+	if m.ImportPreview != nil {
+		content.WriteString(renderImportPreview(m, *m.ImportPreview))
+		content.WriteString("\n")
+		content.WriteString(muted.Render("enter: apply • esc/e: back to editing"))
+	} else {
+		label := "Paste a curl, fetch, HTTPie or PowerShell command:"
+		if format, ok := parser.Detect(m.ImportInput.Value()); ok {
+			label = fmt.Sprintf("Paste a curl, fetch, HTTPie or PowerShell command (detected %s):", format)
+		}
+		content.WriteString(LabelStyle.Render(label))
+		content.WriteString("\n\n")
+		content.WriteString(m.ImportInput.View())
+		content.WriteString("\n")
+		if lines := m.ImportInput.LineCount(); lines > m.ImportInput.Height() {
+			content.WriteString(muted.Render(fmt.Sprintf("%d lines", lines)))
+		}
+		content.WriteString("\n")
+
+		if m.ImportError != "" {
+			errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
+			content.WriteString(errorStyle.Render("✗ " + m.ImportError))
+			content.WriteString("\n")
+		}
+
+		content.WriteString("\n")
+		content.WriteString(muted.Render("ctrl+s: preview • enter: new line • esc: cancel"))
+		content.WriteString("\n\n")
+		content.WriteString(muted.Italic(true).Render("Example: curl -X POST https://api.example.com/users -H \"Content-Type: application/json\" -d @user.json"))
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
+
+// renderImportPreview shows the parsed request the way it will be loaded
+func renderImportPreview(m model.Model, r model.Request) string {
+	var b strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("Parsed %s command:", m.ImportFormat)))
+	b.WriteString("\n\n")
+	b.WriteString(SelectedMethodStyle.Render(r.Method))
+	b.WriteString(" ")
+	b.WriteString(r.URL)
+	b.WriteString("\n\n")
+
+	budget := max(m.Height-16, 4)
+	if len(r.Headers) > 0 {
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("Headers (%d)", len(r.Headers))))
+		b.WriteString("\n")
+		for i, h := range r.Headers {
+			if i == budget/2 {
+				b.WriteString(muted.Render(fmt.Sprintf("  … %d more", len(r.Headers)-i)))
+				b.WriteString("\n")
+				break
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", h.Key, h.Value)
+		}
+		b.WriteString("\n")
+		budget -= min(len(r.Headers), budget/2)
+	}
+
+	if r.Auth != nil {
+		switch r.Auth.Type {
+		case model.AuthBasic:
+			b.WriteString(LabelStyle.Render("Auth"))
+			fmt.Fprintf(&b, "\n  Basic %s\n\n", r.Auth.Username)
+		case model.AuthBearer:
+			// The token itself stays out of the preview, like the password
+			b.WriteString(LabelStyle.Render("Auth"))
+			fmt.Fprintf(&b, "\n  Bearer token (%d characters)\n\n", len(r.Auth.Token))
+		}
+	}
+
+	if r.Settings != nil && !r.Settings.IsZero() {
+		b.WriteString(LabelStyle.Render("Settings"))
+		fmt.Fprintf(&b, "\n  %s\n", settingsSummary(*r.Settings))
+		if r.Settings.CertFile != "" {
+			fmt.Fprintf(&b, "  cert %s\n", r.Settings.CertFile)
+		}
+		if r.Settings.KeyFile != "" {
+			fmt.Fprintf(&b, "  key %s\n", r.Settings.KeyFile)
+		}
+		for _, entry := range r.Settings.Resolve {
+			fmt.Fprintf(&b, "  resolve %s\n", entry)
+		}
+		b.WriteString("\n")
+	}
+
+	if r.Body != "" {
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("Body (%d bytes)", len(r.Body))))
+		b.WriteString("\n")
+		lines := strings.Split(r.Body, "\n")
+		for i, line := range lines {
+			if i == max(budget, 2) {
+				b.WriteString(muted.Render(fmt.Sprintf("  … %d more lines", len(lines)-i)))
+				b.WriteString("\n")
+				break
+			}
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/tbourrel/apitty/internal/infer"
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// sameEndpoint tells whether two requests call the same method and path,
+// whatever their query
+func sameEndpoint(a, b model.Request) bool {
+	ua, errA := url.Parse(a.URL)
+	ub, errB := url.Parse(b.URL)
+	if errA != nil || errB != nil {
+		return a.Method == b.Method && a.URL == b.URL
+	}
+	return strings.EqualFold(a.Method, b.Method) && ua.Host == ub.Host && ua.Path == ub.Path
+}
+
+// inferSamples decodes the whole body, and with InferHistory the whole
+// bodies of the responses to the same endpoint kept in the history
+func inferSamples(m model.Model) ([]any, error) {
+	doc, err := jsonpath.Decode([]byte(wholeBody(m)))
+	if err != nil {
+		return nil, err
+	}
+	samples := []any{doc}
+	if !m.InferHistory {
+		return samples, nil
+	}
+	for _, e := range m.History {
+		// The response itself is usually in the history, and a body seen
+		// twice adds nothing to the types
+		if e.Response.Body == m.ResponseBody || e.Response.Truncated || !sameEndpoint(e.Request, m.ResponseRequest) {
+			continue
+		}
+		if doc, err := jsonpath.Decode([]byte(e.Response.Body)); err == nil {
+			samples = append(samples, doc)
+		}
+	}
+	return samples, nil
+}
+
+// loadInfer infers the types of the samples
+func loadInfer(m model.Model) model.Model {
+	samples, err := inferSamples(m)
+	if err != nil {
+		m.StatusMessage = "The models need a JSON body"
+		if m.BodyFile == "" && previewNotice(m) != "" {
+			m.StatusMessage = "Only the start of the body was kept, the models need all of it"
+		}
+		m.ShowInfer = false
+		return m
+	}
+	m.InferType = infer.Infer(samples...)
+	m.InferSamples = len(samples)
+	path := ""
+	if u, err := url.Parse(m.ResponseRequest.URL); err == nil {
+		path = u.Path
+	}
+	m.InferName = infer.RootName(path, m.InferType)
+	m.InferOffset = 0
+	return m
+}
+
+func openInfer(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowInfer = true
+	m.StatusMessage = ""
+	return loadInfer(m), nil
+}
+
+// inferredCode returns the output of the selected target
+func inferredCode(m model.Model) (infer.Generator, string) {
+	generators := infer.Generators()
+	g := generators[m.InferIdx%len(generators)]
+	return g, g.Generate(m.InferType, m.InferName)
+}
+
+func updateInfer(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	n := len(infer.Generators())
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.ShowInfer = false
+		return m, nil
+
+	case "tab", "l", "right":
+		m.InferIdx = (m.InferIdx + 1) % n
+		m.InferOffset = 0
+		return m, nil
+
+	case "shift+tab", "h", "left":
+		m.InferIdx = (m.InferIdx - 1 + n) % n
+		m.InferOffset = 0
+		return m, nil
+
+	case "j", "down":
+		_, code := inferredCode(m)
+		if m.InferOffset < strings.Count(code, "\n")-1 {
+			m.InferOffset++
+		}
+		return m, nil
+
+	case "k", "up":
+		if m.InferOffset > 0 {
+			m.InferOffset--
+		}
+		return m, nil
+
+	case "a":
+		m.InferHistory = !m.InferHistory
+		return loadInfer(m), nil
+
+	case "y":
+		g, code := inferredCode(m)
+		if err := clipboard.WriteAll(code); err != nil {
+			// No system clipboard, ask the terminal to copy it instead
+			termenv.Copy(code)
+			m.StatusMessage = fmt.Sprintf("Copied the %s models through the terminal", g.Name())
+		} else {
+			m.StatusMessage = fmt.Sprintf("Copied the %s models to the clipboard", g.Name())
+		}
+		m.ShowInfer = false
+		return m, nil
+
+	case "w":
+		g, code := inferredCode(m)
+		path := fmt.Sprintf("apitty-%s-%s.%s", strings.ToLower(m.InferName), time.Now().Format("20060102-150405"), g.Extension())
+		if err := createFile(path, []byte(code)); err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not save the models: %v", err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Saved the %s models to %s", g.Name(), path)
+		}
+		m.ShowInfer = false
+		return m, nil
+	}
+	return m, nil
+}
+
+// RenderInfer renders the types inferred from the body
+func RenderInfer(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Infer Models"))
+	content.WriteString("\n\n")
+
+	var targets []string
+	for i, g := range infer.Generators() {
+		if i == m.InferIdx%len(infer.Generators()) {
+			targets = append(targets, SelectedMethodStyle.Render(g.Name()))
+		} else {
+			targets = append(targets, MethodStyle.Render(g.Name()))
+		}
+	}
+	content.WriteString(strings.Join(targets, " "))
+	content.WriteString("\n\n")
+
+	samples := "From this response"
+	if m.InferHistory {
+		samples = fmt.Sprintf("From %d responses to %s %s", m.InferSamples, m.ResponseRequest.Method, m.ResponseRequest.URL)
+	}
+	content.WriteString(muted.Render(samples + ", fields missing from some objects are optional"))
+	content.WriteString("\n")
+
+	_, code := inferredCode(m)
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	visible := max(m.Height-15, 3)
+	start := min(m.InferOffset, max(len(lines)-1, 0))
+	end := min(start+visible, len(lines))
+	codeBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#626262")).
+		Padding(0, 1).
+		Width(m.Width - 14)
+	content.WriteString(codeBox.Render(strings.Join(lines[start:end], "\n")))
+	content.WriteString("\n")
+	if len(lines) > visible {
+		content.WriteString(muted.Render(fmt.Sprintf("lines %d-%d of %d", start+1, end, len(lines))))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	history := "a: add history samples"
+	if m.InferHistory {
+		history = "a: this response only"
+	}
+	content.WriteString(muted.Render("tab/h/l: change target • j/k: scroll • " + history + " • y: copy • w: save to file • esc/q: close"))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+// settingsToggles lists the on/off settings, shown before the text inputs
+var settingsToggles = []string{"Skip TLS verification", "Follow redirects", "Request compression", "Fresh connection"}
+
+// settingsInputLabels lists the labels of model.SettingsInputs
+var settingsInputLabels = []string{"Timeout (ms)", "Client certificate", "Client key", "Resolve", "Preview (KiB)"}
+
+func openSettingsForm(m model.Model) (model.Model, tea.Cmd) {
+	m.ShowSettingsForm = true
+	m.SettingsFocusField = 0
+	m.StatusMessage = ""
+	loadSettingsInputs(&m)
+	return m, nil
+}
+
+// loadSettingsInputs copies the request settings into the form inputs
+func loadSettingsInputs(m *model.Model) {
+	s := m.Settings
+	values := []string{"", s.CertFile, s.KeyFile, strings.Join(s.Resolve, ", "), ""}
+	if s.TimeoutMs > 0 {
+		values[0] = strconv.Itoa(s.TimeoutMs)
+	}
+	if s.PreviewKB > 0 {
+		values[4] = strconv.Itoa(s.PreviewKB)
+	}
+	for i := range m.SettingsInputs {
+		m.SettingsInputs[i].Blur()
+		m.SettingsInputs[i].SetValue(values[i])
+	}
+}
+
+// saveSettingsInputs copies the form inputs back into the request settings
+func saveSettingsInputs(m *model.Model) error {
+	val := func(i int) string {
+		return strings.TrimSpace(m.SettingsInputs[i].Value())
+	}
+	s := m.Settings
+	s.TimeoutMs = 0
+	if v := val(0); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return fmt.Errorf("invalid timeout %q, expected milliseconds", v)
+		}
+		s.TimeoutMs = ms
+	}
+	s.PreviewKB = 0
+	if v := val(4); v != "" {
+		kb, err := strconv.Atoi(v)
+		if err != nil || kb < 0 {
+			return fmt.Errorf("invalid preview size %q, expected KiB", v)
+		}
+		s.PreviewKB = kb
+	}
+	s.CertFile, s.KeyFile = val(1), val(2)
+	s.Resolve = nil
+	for _, entry := range strings.Split(val(3), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			s.Resolve = append(s.Resolve, entry)
+		}
+	}
+	m.Settings = s
+	return nil
+}
+
+// settingsToggle returns the state of an on/off setting
+func settingsToggle(s model.Settings, idx int) bool {
+	switch idx {
+	case 0:
+		return s.Insecure
+	case 1:
+		return !s.NoRedirects
+	case 2:
+		return s.Compressed
+	}
+	return s.FreshConnection
+}
+
+// settingsSummary describes the settings that differ from the defaults
+func settingsSummary(s model.Settings) string {
+	if s.IsZero() {
+		return "default"
+	}
+	var parts []string
+	if s.Insecure {
+		parts = append(parts, "insecure")
+	}
+	if s.NoRedirects {
+		parts = append(parts, "no redirects")
+	}
+	if s.Compressed {
+		parts = append(parts, "compressed")
+	}
+	if s.TimeoutMs > 0 {
+		parts = append(parts, fmt.Sprintf("%dms", s.TimeoutMs))
+	}
+	if s.CertFile != "" {
+		parts = append(parts, "client cert")
+	}
+	if len(s.Resolve) > 0 {
+		parts = append(parts, "resolve")
+	}
+	if s.FreshConnection {
+		parts = append(parts, "fresh connection")
+	}
+	if s.PreviewKB > 0 {
+		parts = append(parts, fmt.Sprintf("%d KiB preview", s.PreviewKB))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func updateSettingsForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	fields := len(settingsToggles) + len(m.SettingsInputs)
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.ShowSettingsForm = false
+		loadSettingsInputs(&m)
+		return m, nil
+
+	case "enter":
+		if err := saveSettingsInputs(&m); err != nil {
+			m.StatusMessage = err.Error()
+			return m, nil
+		}
+		m.ShowSettingsForm = false
+		m.StatusMessage = ""
+		loadSettingsInputs(&m)
+		return m, nil
+
+	case "tab", "shift+tab", "down", "up":
+		if msg.String() == "tab" || msg.String() == "down" {
+			m.SettingsFocusField = (m.SettingsFocusField + 1) % fields
+		} else {
+			m.SettingsFocusField = (m.SettingsFocusField + fields - 1) % fields
+		}
+		for i := range m.SettingsInputs {
+			m.SettingsInputs[i].Blur()
+		}
+		if m.SettingsFocusField >= len(settingsToggles) {
+			m.SettingsInputs[m.SettingsFocusField-len(settingsToggles)].Focus()
+			return m, textinput.Blink
+		}
+		return m, nil
+	}
+
+	if m.SettingsFocusField < len(settingsToggles) {
+		switch msg.String() {
+		case " ", "x", "h", "l", "left", "right":
+			switch m.SettingsFocusField {
+			case 0:
+				m.Settings.Insecure = !m.Settings.Insecure
+			case 1:
+				m.Settings.NoRedirects = !m.Settings.NoRedirects
+			case 2:
+				m.Settings.Compressed = !m.Settings.Compressed
+			case 3:
+				m.Settings.FreshConnection = !m.Settings.FreshConnection
+			}
+		}
+		return m, nil
+	}
+
+	idx := m.SettingsFocusField - len(settingsToggles)
+	m.SettingsInputs[idx], cmd = m.SettingsInputs[idx].Update(msg)
+	return m, cmd
+}
+
+// RenderSettingsForm renders the request transport settings form
+func RenderSettingsForm(m model.Model) string {
+	var content strings.Builder
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Request Settings"))
+	content.WriteString("\n\n")
+
+	width := 0
+	for _, l := range append(append([]string{}, settingsToggles...), settingsInputLabels...) {
+		width = max(width, len(l))
+	}
+
+	for i, l := range settingsToggles {
+		label := l + ":" + strings.Repeat(" ", width-len(l)+1)
+		if m.SettingsFocusField == i {
+			label = focused.Render(label)
+		}
+		state := MethodStyle.Render("off")
+		if settingsToggle(m.Settings, i) {
+			state = SelectedMethodStyle.Render("on")
+		}
+		content.WriteString(label + state + "\n")
+	}
+	content.WriteString("\n")
+
+	for i, l := range settingsInputLabels {
+		label := l + ":" + strings.Repeat(" ", width-len(l)+1)
+		if m.SettingsFocusField == len(settingsToggles)+i {
+			label = focused.Render(label)
+		}
+		content.WriteString(label + m.SettingsInputs[i].View() + "\n")
+	}
+	content.WriteString(muted.Italic(true).Render("Resolve pins host:port:address entries, separated by commas"))
+	content.WriteString("\n")
+	content.WriteString(muted.Italic(true).Render("A fresh connection skips kept-alive connections to time DNS, connect and TLS"))
+	content.WriteString("\n")
+	content.WriteString(muted.Italic(true).Render("Larger bodies show their first KiB, save them with S on the response"))
+	content.WriteString("\n\n")
+	if m.StatusMessage != "" {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(muted.Render("tab/↑↓: next field • space: toggle • enter: save • esc: cancel"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
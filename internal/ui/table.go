@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/jsonpath"
+	"github.com/tbourrel/apitty/internal/model"
+	"github.com/tbourrel/apitty/internal/table"
+)
+
+// openTable lays out the first array of objects found in the body, or asks
+// for the path of one
+func openTable(m model.Model) (model.Model, tea.Cmd) {
+	doc, err := jsonpath.Decode([]byte(wholeBody(m)))
+	if err != nil {
+		if m.BodyFile == "" && previewNotice(m) != "" {
+			m.StatusMessage = "Only the start of the body was kept, the table needs all of it"
+		} else {
+			m.StatusMessage = "The table view needs a JSON body"
+		}
+		return m, nil
+	}
+	m.ShowTable = true
+	m.ShowTableCell = false
+	m.StatusMessage = ""
+	path, ok := table.Find(doc)
+	if !ok {
+		m.Table = nil
+		m.StatusMessage = "No array of objects in the body, enter the path of one"
+		return editTablePath(m)
+	}
+	return loadTable(m, path), nil
+}
+
+// loadTable lays out the value at a path of the body. A path matching
+// several objects, such as a filter, lays them out as rows.
+func loadTable(m model.Model, path string) model.Model {
+	doc, err := jsonpath.Decode([]byte(wholeBody(m)))
+	if err != nil {
+		m.StatusMessage = err.Error()
+		return m
+	}
+	matches, err := jsonpath.Query(doc, path)
+	if err != nil {
+		m.StatusMessage = err.Error()
+		return m
+	}
+	var v any = matches
+	if len(matches) == 1 {
+		v = matches[0]
+	}
+	t, err := table.New(v)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("%s is %s", path, err)
+		return m
+	}
+	m.Table = t
+	m.TablePath = path
+	m.TableRow, m.TableCol, m.TableTop, m.TableLeft = 0, 0, 0, 0
+	m.StatusMessage = ""
+	return m
+}
+
+func editTablePath(m model.Model) (model.Model, tea.Cmd) {
+	m.TableEditPath = true
+	m.TablePathInput.SetValue(m.TablePath)
+	m.TablePathInput.CursorEnd()
+	return m, m.TablePathInput.Focus()
+}
+
+// tableRows is the number of rows the table shows at once
+func tableRows(m model.Model) int {
+	return max(m.Height-15, 1)
+}
+
+// tableWidth is the width the columns of the table are laid out in
+func tableWidth(m model.Model) int {
+	return max(m.Width-14, 20)
+}
+
+// columnWidth is the width a column is laid out at, with room for the sort
+// arrow of the column the rows are sorted by
+func columnWidth(t *table.Table, col int) int {
+	if col == t.SortColumn {
+		return t.Widths[col] + 2
+	}
+	return t.Widths[col]
+}
+
+// lastColumn returns the last column that fits in view after TableLeft
+func lastColumn(m model.Model) int {
+	last := m.TableLeft
+	used := columnWidth(m.Table, last)
+	for last+1 < len(m.Table.Columns) {
+		used += 3 + columnWidth(m.Table, last+1)
+		if used > tableWidth(m) {
+			break
+		}
+		last++
+	}
+	return last
+}
+
+// scrollTable keeps the selected cell in view
+func scrollTable(m model.Model) model.Model {
+	m.TableRow = max(min(m.TableRow, len(m.Table.Rows)-1), 0)
+	m.TableCol = max(min(m.TableCol, len(m.Table.Columns)-1), 0)
+
+	rows := tableRows(m)
+	if m.TableRow < m.TableTop {
+		m.TableTop = m.TableRow
+	} else if m.TableRow >= m.TableTop+rows {
+		m.TableTop = m.TableRow - rows + 1
+	}
+
+	if m.TableCol < m.TableLeft {
+		m.TableLeft = m.TableCol
+	}
+	for m.TableLeft < m.TableCol && m.TableCol > lastColumn(m) {
+		m.TableLeft++
+	}
+	return m
+}
+
+func updateTable(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.TableEditPath {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.TableEditPath = false
+			m.TablePathInput.Blur()
+			m.StatusMessage = ""
+			if m.Table == nil {
+				m.ShowTable = false
+			}
+			return m, nil
+		case "enter":
+			m = loadTable(m, strings.TrimSpace(m.TablePathInput.Value()))
+			if m.StatusMessage == "" {
+				m.TableEditPath = false
+				m.TablePathInput.Blur()
+			}
+			return m, nil
+		}
+		m.TablePathInput, cmd = m.TablePathInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.ShowTableCell {
+		switch msg.String() {
+		case "esc", "enter", "q", "ctrl+c":
+			m.ShowTableCell = false
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.ShowTable = false
+		m.StatusMessage = ""
+		return m, nil
+	case "p":
+		return editTablePath(m)
+	}
+	if m.Table == nil {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "j", "down":
+		m.TableRow++
+	case "k", "up":
+		m.TableRow--
+	case "l", "right":
+		m.TableCol++
+	case "h", "left":
+		m.TableCol--
+	case "d", "pgdown":
+		m.TableRow += tableRows(m) / 2
+	case "u", "pgup":
+		m.TableRow -= tableRows(m) / 2
+	case "g", "home":
+		m.TableRow = 0
+	case "G", "end":
+		m.TableRow = len(m.Table.Rows) - 1
+	case "0":
+		m.TableCol = 0
+	case "$":
+		m.TableCol = len(m.Table.Columns) - 1
+	case "s":
+		m.Table.Sort(m.TableCol)
+		m.TableRow, m.TableTop = 0, 0
+	case "enter":
+		m.ShowTableCell = true
+	case "x":
+		path := "apitty-table-" + time.Now().Format("20060102-150405") + ".csv"
+		return m, table.ExportCmd(path, m.Table)
+	}
+	return scrollTable(m), nil
+}
+
+// updateTableExported reports the result of a CSV export
+func updateTableExported(m model.Model, msg table.ExportedMsg) (model.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.StatusMessage = fmt.Sprintf("Could not export the table: %v", msg.Err)
+	} else {
+		m.StatusMessage = "Exported the table to " + msg.Path
+	}
+	return m, nil
+}
+
+// fitCell cuts a cell to a width, or pads it to it
+func fitCell(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return string([]rune(s)[:max(width-1, 0)]) + "…"
+}
+
+// renderTableGrid renders the rows and columns in view, the selected cell
+// highlighted
+func renderTableGrid(m model.Model) string {
+	t := m.Table
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Bold(true)
+	null := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Italic(true)
+	separator := muted.Render(" │ ")
+	last := lastColumn(m)
+
+	var b strings.Builder
+	var header, rule []string
+	for col := m.TableLeft; col <= last; col++ {
+		name := t.Columns[col]
+		width := columnWidth(t, col)
+		if col == t.SortColumn {
+			arrow := " ▲"
+			if t.Descending {
+				arrow = " ▼"
+			}
+			name = fitCell(name, width-2) + arrow
+		}
+		header = append(header, LabelStyle.Render(fitCell(name, width)))
+		rule = append(rule, strings.Repeat("─", width))
+	}
+	b.WriteString("  " + strings.Join(header, separator) + "\n")
+	b.WriteString("  " + muted.Render(strings.Join(rule, "─┼─")) + "\n")
+
+	for row := m.TableTop; row < min(m.TableTop+tableRows(m), len(t.Rows)); row++ {
+		marker := "  "
+		if row == m.TableRow {
+			marker = selected.Render("▸ ")
+		}
+		var cells []string
+		for col := m.TableLeft; col <= last; col++ {
+			cell := fitCell(t.Cell(row, col), columnWidth(t, col))
+			switch v, ok := t.Value(row, col); {
+			case row == m.TableRow && col == m.TableCol:
+				cell = selected.Reverse(true).Render(cell)
+			case ok && v == nil:
+				cell = null.Render(cell)
+			}
+			cells = append(cells, cell)
+		}
+		b.WriteString(marker + strings.Join(cells, separator) + "\n")
+	}
+	return b.String()
+}
+
+// RenderTable renders the table view of the body
+func RenderTable(m model.Model) string {
+	if m.ShowTableCell && m.Table != nil {
+		return renderTableCell(m)
+	}
+
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render("Table"))
+	content.WriteString("\n\n")
+
+	if m.TableEditPath {
+		content.WriteString("Path: " + m.TablePathInput.View() + "\n")
+		content.WriteString(muted.Render("A JSONPath to an array of objects, or to objects like $.items[?(@.active == true)]"))
+	} else if t := m.Table; t != nil {
+		summary := fmt.Sprintf("%s · %d rows × %d columns", m.TablePath, len(t.Rows), len(t.Columns))
+		if last := lastColumn(m); m.TableLeft > 0 || last < len(t.Columns)-1 {
+			summary += fmt.Sprintf(" · columns %d–%d", m.TableLeft+1, last+1)
+		}
+		content.WriteString(muted.Render(summary))
+	}
+	content.WriteString("\n\n")
+
+	if m.Table != nil {
+		content.WriteString(renderTableGrid(m))
+		content.WriteString("\n")
+	}
+	// The path is the only input, its errors are the ones to stand out
+	if m.StatusMessage != "" && m.TableEditPath {
+		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗ " + m.StatusMessage))
+		content.WriteString("\n\n")
+	} else if m.StatusMessage != "" {
+		content.WriteString(muted.Render(m.StatusMessage))
+		content.WriteString("\n\n")
+	}
+
+	if m.TableEditPath {
+		content.WriteString(muted.Render("enter: show • esc: cancel"))
+	} else {
+		content.WriteString(muted.Render("hjkl: move • s: sort • enter: cell • p: path • x: export CSV • esc: close"))
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(1, 4).
+		Width(m.Width - 4).
+		Height(m.Height - 2)
+
+	return "\n" + box.Render(content.String())
+}
+
+// renderTableCell renders the selected cell in full
+func renderTableCell(m model.Model) string {
+	var content strings.Builder
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	content.WriteString(TitleStyle.Render(m.Table.Columns[m.TableCol]))
+	content.WriteString("\n\n")
+	content.WriteString(muted.Render(fmt.Sprintf("Row %d of %d", m.TableRow+1, len(m.Table.Rows))))
+	content.WriteString("\n\n")
+	content.WriteString(m.Table.Detail(m.TableRow, m.TableCol))
+	content.WriteString("\n\n")
+	content.WriteString(muted.Render("esc: close"))
+
+	modalBox := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Padding(2, 4).
+		Width(m.Width - 20)
+
+	return "\n\n\n" + lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		modalBox.Render(content.String()),
+	)
+}
@@ -6,10 +6,15 @@ import (
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/bench"
+	"github.com/tbourrel/apitty/internal/cookies"
+	"github.com/tbourrel/apitty/internal/grpc"
+	"github.com/tbourrel/apitty/internal/history"
 	"github.com/tbourrel/apitty/internal/http"
 	"github.com/tbourrel/apitty/internal/model"
-	"github.com/tbourrel/apitty/internal/parser"
-	"github.com/tbourrel/apitty/internal/text"
+	"github.com/tbourrel/apitty/internal/table"
+	"github.com/tbourrel/apitty/internal/transfer"
+	"github.com/tbourrel/apitty/internal/vars"
 )
 
 // Update handles all messages and updates the model
@@ -23,14 +28,8 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 		m.Height = msg.Height
 
 		// Update viewport size
-		if !m.ViewportReady {
-			m.Viewport.Width = m.Width - 10
-			m.Viewport.Height = m.Height - 14
-			m.ViewportReady = true
-		} else {
-			m.Viewport.Width = m.Width - 10
-			m.Viewport.Height = m.Height - 14
-		}
+		resizeViewport(&m)
+		m.ViewportReady = true
 
 		// Update text input width
 		m.URLInput.Width = m.Width - 26
@@ -73,6 +72,15 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if m.Loading {
+			switch msg.String() {
+			case "esc":
+				// The response reports the cancellation
+				if m.Transfer != nil {
+					m.Transfer.Cancel()
+				}
+			case "ctrl+c":
+				return m, tea.Quit
+			}
 			return m, nil
 		}
 
@@ -86,15 +94,100 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 			return updateHeadersForm(m, msg)
 		}
 
-		// If curl import is open, handle it separately
-		if m.ShowCurlImport {
-			return updateCurlImport(m, msg)
+		// If the import modal is open, handle it separately
+		if m.ShowImport {
+			return updateImport(m, msg)
+		}
+
+		// If the schema explorer is open, handle it separately
+		if m.ShowSchemaExplorer {
+			return updateSchemaExplorer(m, msg)
+		}
+
+		// If the GraphQL editor is open, handle it separately
+		if m.ShowGraphQLForm {
+			return updateGraphQLForm(m, msg)
+		}
+
+		// If the gRPC form is open, handle it separately
+		if m.ShowGRPCForm {
+			return updateGRPCForm(m, msg)
+		}
+
+		// If the auth form is open, handle it separately
+		if m.ShowAuthForm {
+			return updateAuthForm(m, msg)
+		}
+
+		// If the settings form is open, handle it separately
+		if m.ShowSettingsForm {
+			return updateSettingsForm(m, msg)
+		}
+
+		// If the extraction rules are open, handle them separately
+		if m.ShowExtractForm {
+			return updateExtractForm(m, msg)
+		}
+
+		// If the assertions are open, handle them separately
+		if m.ShowAssertForm {
+			return updateAssertForm(m, msg)
+		}
+
+		// If the contract is open, handle it separately
+		if m.ShowContractForm {
+			return updateContractForm(m, msg)
+		}
+
+		// If the save prompt is open, handle it separately
+		if m.ShowSaveBody {
+			return updateSaveBody(m, msg)
+		}
+
+		// If the hex view prompt is open, handle it separately
+		if m.ShowHexPrompt {
+			return updateHexPrompt(m, msg)
+		}
+
+		// If the table view is open, handle it separately
+		if m.ShowTable {
+			return updateTable(m, msg)
+		}
+
+		// If the inferred models are open, handle them separately
+		if m.ShowInfer {
+			return updateInfer(m, msg)
+		}
+
+		// If the benchmark is open, handle it separately
+		if m.ShowBench {
+			return updateBench(m, msg)
+		}
+
+		// If the cookie editor is open, handle it separately
+		if m.ShowCookieForm {
+			return updateCookieForm(m, msg)
+		}
+
+		// If the collection browser is open, handle it separately
+		if m.ShowCollection {
+			return updateCollection(m, msg)
+		}
+
+		// If the history browser is open, handle it separately
+		if m.ShowHistory {
+			return updateHistory(m, msg)
+		}
+
+		// If the code generator is open, handle it separately
+		if m.ShowCodegen {
+			return updateCodegen(m, msg)
 		}
 
 		// If URL is focused, let text input handle most keys
 		if m.Focus == model.FocusURL {
 			switch msg.String() {
-			case "tab", "shift+tab", "ctrl+c", "ctrl+s", "enter", "?":
+			case "tab", "shift+tab", "ctrl+c", "ctrl+s", "ctrl+g", "ctrl+p", "ctrl+o", "enter", "?":
 				// Let these fall through to navigation/actions
 			default:
 				// Let text input handle the key
@@ -103,8 +196,42 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 			}
 		}
 
+		// The Cookies tab lists the jar, with or without a response
+		if m.Focus == model.FocusResponse && m.CurrentView == model.ViewCookies {
+			if m, cmd, ok := handleCookieKeys(m, msg); ok {
+				return m, cmd
+			}
+		}
+
+		// S saves the body shown in the response
+		if m.Focus == model.FocusResponse && m.CurrentView == model.ViewBody && m.Response != "" && msg.String() == "S" {
+			return openSaveBody(m)
+		}
+
+		// L lays out an array of objects in the body as a table
+		if m.Focus == model.FocusResponse && m.CurrentView == model.ViewBody && m.Response != "" && msg.String() == "L" {
+			return openTable(m)
+		}
+
+		// M infers a schema and typed models from the body
+		if m.Focus == model.FocusResponse && m.CurrentView == model.ViewBody && m.Response != "" && msg.String() == "M" {
+			return openInfer(m)
+		}
+
+		// X saves the response as an example of the loaded request
+		if m.Focus == model.FocusResponse && m.CurrentView == model.ViewBody && m.Response != "" && msg.String() == "X" {
+			return saveExample(m), nil
+		}
+
+		// The hex view of a binary body jumps to offsets and searches bytes
+		if m.Focus == model.FocusResponse && m.CurrentView == model.ViewBody && m.ResponseBinary {
+			if m, cmd, ok := handleHexKeys(m, msg); ok {
+				return m, cmd
+			}
+		}
+
 		// If response is focused, handle scrolling
-		if m.Focus == model.FocusResponse && m.Response != "" {
+		if m.Focus == model.FocusResponse && (m.Response != "" || m.CurrentView == model.ViewCookies || msg.String() == "t") {
 			if handleResponseKeys(&m, msg) {
 				return m, nil
 			}
@@ -122,34 +249,98 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 
 	case model.ResponseMsg:
 		m.Loading = false
+		m = keepBody(m, msg)
+		entry := wholeEntry(m, msg.Entry)
+		m = runAssertions(m, entry)
+		m = runContract(m, entry)
+		showResponse(&m, msg)
+		m = applyExtraction(m, entry)
+		if msg.Entry != nil {
+			m.History = history.Add(m.History, *msg.Entry)
+			cmds = append(cmds, history.SaveCmd(m.HistoryPath, m.History))
+		}
+		cmds = append(cmds, saveCookies(m))
+
+	case model.HistorySavedMsg:
 		if msg.Err != nil {
-			m.Response = fmt.Sprintf("Error: %v", msg.Err)
-			m.ResponseHeaders = ""
-			m.StatusCode = "Error"
+			m.StatusMessage = fmt.Sprintf("Could not save history: %v", msg.Err)
+		}
+		return m, nil
+
+	case cookies.SavedMsg:
+		if msg.Err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not save cookies: %v", msg.Err)
+		}
+		return m, nil
+
+	case model.SchemaMsg:
+		if msg.Err != nil {
+			m.GraphQLStatus = fmt.Sprintf("Introspection failed: %v", msg.Err)
 		} else {
-			m.Response = msg.Resp
-			m.ResponseHeaders = msg.Headers
-			m.StatusCode = msg.Status
+			m.GraphQLSchema = msg.Schema
+			m.SchemaExplorerPath = nil
+			m.SchemaExplorerIdx = 0
+			m.GraphQLStatus = fmt.Sprintf("Schema loaded: %d types", len(msg.Schema.UserTypes()))
 		}
-		// Update viewport content with wrapping
-		content := m.Response
-		if m.CurrentView == model.ViewHeaders && m.ResponseHeaders != "" {
-			content = m.ResponseHeaders
+		return m, nil
+
+	case transfer.TickMsg:
+		if m.Loading && m.Transfer != nil {
+			return m, transfer.TickCmd()
 		}
-		m.Viewport.SetContent(text.WrapText(content, m.Viewport.Width))
-		m.Viewport.GotoTop()
+		return m, nil
+
+	case http.BodySavedMsg:
+		if msg.Err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not save the body: %v", msg.Err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Saved %s to %s", formatBytes(msg.Size), msg.Path)
+		}
+		return m, nil
+
+	case bench.TickMsg, bench.DoneMsg, bench.ExportedMsg:
+		return updateBenchMsg(m, msg)
+
+	case table.ExportedMsg:
+		return updateTableExported(m, msg)
+
+	case model.GRPCDescriptorsMsg:
+		if msg.Err != nil {
+			m.GRPCStatus = fmt.Sprintf("Failed to load services: %v", msg.Err)
+			return m, nil
+		}
+		m.GRPCFiles = msg.Files
+		m.GRPCMethods = grpc.Methods(msg.Files)
+		m.GRPCMethodIdx = 0
+		m.GRPCMethod = ""
+		m.GRPCStatus = fmt.Sprintf("%d methods loaded", len(m.GRPCMethods))
+		return m, nil
 	}
 
-	// Update text input
-	m.URLInput, cmd = m.URLInput.Update(msg)
-	cmds = append(cmds, cmd)
+	// Forward non-key messages (cursor blink) to the gRPC request editor
+	if m.ShowGRPCForm {
+		if m.GRPCFocusField == 1 {
+			m.GRPCProtoInput, cmd = m.GRPCProtoInput.Update(msg)
+		} else {
+			m.GRPCRequest, cmd = m.GRPCRequest.Update(msg)
+		}
+		cmds = append(cmds, cmd)
+	}
 
-	// Update viewport only when response is focused
-	if m.Focus == model.FocusResponse {
-		m.Viewport, cmd = m.Viewport.Update(msg)
+	// Forward non-key messages (cursor blink) to the GraphQL editor
+	if m.ShowGraphQLForm {
+		if m.GraphQLFocusField == 0 {
+			m.GraphQLQuery, cmd = m.GraphQLQuery.Update(msg)
+		} else {
+			m.GraphQLVariables, cmd = m.GraphQLVariables.Update(msg)
+		}
 		cmds = append(cmds, cmd)
 	}
 
+	// Update text input
+	m.URLInput, cmd = m.URLInput.Update(msg)
+	cmds = append(cmds, cmd)
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -184,18 +375,29 @@ func handleResponseKeys(m *model.Model, msg tea.KeyMsg) bool {
 	switch msg.String() {
 	case "f":
 		m.Fullscreen = !m.Fullscreen
+		resizeViewport(m)
+		return true
+	case "w":
+		m.Viewport.NoWrap = !m.Viewport.NoWrap
 		return true
 	case "t":
-		if m.CurrentView == model.ViewBody {
+		switch m.CurrentView {
+		case model.ViewBody:
 			m.CurrentView = model.ViewHeaders
-		} else {
+		case model.ViewHeaders:
+			m.CurrentView = model.ViewCookies
+		case model.ViewCookies:
+			m.CurrentView = model.ViewTests
+		case model.ViewTests:
+			// The Contract tab only shows up once there is a contract
+			m.CurrentView = model.ViewBody
+			if !m.Contract.IsZero() {
+				m.CurrentView = model.ViewContract
+			}
+		default:
 			m.CurrentView = model.ViewBody
 		}
-		content := m.Response
-		if m.CurrentView == model.ViewHeaders {
-			content = m.ResponseHeaders
-		}
-		m.Viewport.SetContent(text.WrapText(content, m.Viewport.Width))
+		UpdateViewportContent(m)
 		m.Viewport.GotoTop()
 		return true
 	case "j", "down":
@@ -257,10 +459,68 @@ func handleGlobalKeys(m model.Model, msg tea.KeyMsg, cmds []tea.Cmd) (model.Mode
 
 	case "i":
 		if m.Focus != model.FocusURL && !m.Loading {
-			m.ShowCurlImport = true
-			m.CurlInput.SetValue("")
-			m.CurlInput.Focus()
-			return m, textinput.Blink
+			return openImport(m)
+		}
+		return m, nil
+
+	case "a":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openAuthForm(m)
+		}
+		return m, nil
+
+	case "s":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openSettingsForm(m)
+		}
+		return m, nil
+
+	case "v":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openExtractForm(m)
+		}
+		return m, nil
+
+	case "T":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openAssertForm(m)
+		}
+		return m, nil
+
+	case "K":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openContractForm(m)
+		}
+		return m, nil
+
+	case "B":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openBench(m)
+		}
+		return m, nil
+
+	case "c":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openCollection(m)
+		}
+		return m, nil
+
+	case "e":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return cycleEnvironment(m), nil
+		}
+		return m, nil
+
+	case "H":
+		if m.Focus != model.FocusURL && !m.Loading {
+			return openHistory(m)
+		}
+		return m, nil
+
+	case "C":
+		if m.Focus != model.FocusURL {
+			m.ShowCodegen = true
+			m.CodegenOffset = 0
 		}
 		return m, nil
 
@@ -278,12 +538,27 @@ func handleGlobalKeys(m model.Model, msg tea.KeyMsg, cmds []tea.Cmd) (model.Mode
 		}
 		return m, nil
 
+	case "ctrl+g":
+		if !m.Loading {
+			return openGraphQLForm(m)
+		}
+		return m, nil
+
+	case "ctrl+p":
+		if !m.Loading {
+			return openGRPCForm(m)
+		}
+		return m, nil
+
+	case "ctrl+o":
+		if !m.Loading {
+			return saveLoadedRequest(m), nil
+		}
+		return m, nil
+
 	case "ctrl+s":
 		if m.URLInput.Value() != "" && !m.Loading {
-			m.Response = ""
-			m.StatusCode = "Sending..."
-			m.Loading = true
-			return m, http.SendRequestCmd(model.Methods[m.MethodIdx], m.URLInput.Value(), m.RequestHeaders, m.Body)
+			return sendRequest(m)
 		}
 		return m, nil
 
@@ -320,10 +595,7 @@ func handleGlobalKeys(m model.Model, msg tea.KeyMsg, cmds []tea.Cmd) (model.Mode
 	case "enter":
 		if m.Focus == model.FocusURL {
 			if m.URLInput.Value() != "" && !m.Loading {
-				m.Response = ""
-				m.StatusCode = "Sending..."
-				m.Loading = true
-				return m, http.SendRequestCmd(model.Methods[m.MethodIdx], m.URLInput.Value(), m.RequestHeaders, m.Body)
+				return sendRequest(m)
 			}
 		}
 		return m, nil
@@ -332,6 +604,104 @@ func handleGlobalKeys(m model.Model, msg tea.KeyMsg, cmds []tea.Cmd) (model.Mode
 	return m, nil
 }
 
+// showResponse displays a response, or its error, in the response box
+func showResponse(m *model.Model, msg model.ResponseMsg) {
+	if cancelled(msg.Err) {
+		m.Response = "Request cancelled"
+		m.ResponseHeaders = ""
+		m.StatusCode = "Cancelled"
+	} else if msg.Err != nil {
+		m.Response = fmt.Sprintf("Error: %v", msg.Err)
+		m.ResponseHeaders = ""
+		m.StatusCode = "Error"
+	} else {
+		m.Response = msg.Resp
+		m.ResponseHeaders = msg.Headers
+		m.StatusCode = msg.Status
+	}
+	m.ResponseFormat = ""
+	if msg.Err == nil {
+		m.ResponseFormat = msg.Format
+	}
+	showBinary(m)
+	m.Connection = connectionLabel(msg.Entry)
+	UpdateViewportContent(m)
+	m.Viewport.GotoTop()
+}
+
+// connectionLabel tells whether a request reused a kept-alive connection,
+// or how long opening a new one took
+func connectionLabel(e *model.HistoryEntry) string {
+	switch {
+	case e == nil:
+		return ""
+	case e.ReusedConnection:
+		return "reused connection"
+	case e.Timings.DNS+e.Timings.Connect > 0:
+		return "new connection, " + formatLatency(e.Timings.DNS+e.Timings.Connect) + " setup"
+	}
+	return "new connection"
+}
+
+// responseContent returns the text shown in the viewport for the current tab
+func responseContent(m model.Model) string {
+	switch m.CurrentView {
+	case model.ViewHeaders:
+		if m.ResponseHeaders != "" {
+			return m.ResponseHeaders
+		}
+	case model.ViewTests:
+		return testReport(m)
+	case model.ViewContract:
+		return contractReport(m)
+	}
+	if notice := previewNotice(m); notice != "" {
+		return notice + "\n\n" + m.Response
+	}
+	return m.Response
+}
+
+// currentRequest builds the request described by the editor, with variables expanded
+func currentRequest(m model.Model) model.Request {
+	req := model.Request{
+		Method:  model.Methods[m.MethodIdx],
+		URL:     m.URLInput.Value(),
+		Headers: m.RequestHeaders,
+		Body:    m.Body,
+	}
+	if m.Auth.Type != model.AuthNone {
+		auth := m.Auth
+		req.Auth = &auth
+	}
+	if !m.Settings.IsZero() {
+		settings := m.Settings
+		req.Settings = &settings
+	}
+	req.Extract = m.Extract
+	req.Assertions = m.Assertions
+	if !m.Contract.IsZero() {
+		c := m.Contract
+		req.Contract = &c
+	}
+	return vars.ExpandRequest(req, m.Collection.EnvironmentVariableMap(m.EnvironmentIdx))
+}
+
+// sendRequest marks the model as loading and returns the command sending the request
+func sendRequest(m model.Model) (model.Model, tea.Cmd) {
+	m.Response = ""
+	m.StatusCode = "Sending..."
+	m.Connection = ""
+	m.Loading = true
+	m.StatusMessage = ""
+	m.Transfer = transfer.New(previewSize(m.Settings))
+	req := currentRequest(m)
+	m.SentAssertions = req.Assertions
+	if m.BodyMode == model.BodyGraphQL {
+		return m, tea.Batch(http.SendGraphQLCmd(req, m.GraphQLQuery.Value(), m.GraphQLVariables.Value(), cookieJar(m), m.Transfer), transfer.TickCmd())
+	}
+	return m, tea.Batch(http.SendCmd(req, cookieJar(m), m.Transfer), transfer.TickCmd())
+}
+
 func updateHeadersForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -456,69 +826,104 @@ func updateHeadersForm(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
 	}
 }
 
-func updateCurlImport(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
-	var cmd tea.Cmd
+// View renders the appropriate view based on current state
+func View(m model.Model) string {
+	if m.ShowHelp {
+		return RenderHelp(m)
+	}
 
-	switch msg.String() {
-	case "esc", "ctrl+c":
-		m.ShowCurlImport = false
-		m.CurlInput.Blur()
-		return m, nil
+	if m.ShowImport {
+		return RenderImport(m)
+	}
 
-	case "enter":
-		curlCmd := m.CurlInput.Value()
-		if curlCmd != "" {
-			method, url, headers := parser.ParseCurlCommand(curlCmd)
+	if m.ShowHeadersForm {
+		return RenderHeadersForm(m)
+	}
 
-			// Apply parsed values
-			if url != "" {
-				m.URLInput.SetValue(url)
-			}
+	if m.ShowSchemaExplorer {
+		return RenderSchemaExplorer(m)
+	}
 
-			// Set method
-			for idx, meth := range model.Methods {
-				if meth == method {
-					m.MethodIdx = idx
-					break
-				}
-			}
+	if m.ShowGraphQLForm {
+		return RenderGraphQLForm(m)
+	}
 
-			// Add headers
-			m.RequestHeaders = headers
-		}
-		m.ShowCurlImport = false
-		m.CurlInput.Blur()
-		m.CurlInput.SetValue("")
-		return m, nil
+	if m.ShowGRPCForm {
+		return RenderGRPCForm(m)
+	}
 
-	default:
-		m.CurlInput, cmd = m.CurlInput.Update(msg)
-		return m, cmd
+	if m.ShowAuthForm {
+		return RenderAuthForm(m)
 	}
-}
 
-// View renders the appropriate view based on current state
-func View(m model.Model) string {
-	if m.ShowHelp {
-		return RenderHelp(m)
+	if m.ShowSettingsForm {
+		return RenderSettingsForm(m)
 	}
 
-	if m.ShowCurlImport {
-		return RenderCurlImport(m)
+	if m.ShowCookieForm {
+		return RenderCookieForm(m)
 	}
 
-	if m.ShowHeadersForm {
-		return RenderHeadersForm(m)
+	if m.ShowExtractForm {
+		return RenderExtractForm(m)
 	}
 
+	if m.ShowAssertForm {
+		return RenderAssertForm(m)
+	}
+
+	if m.ShowContractForm {
+		return RenderContractForm(m)
+	}
+
+	if m.ShowBench {
+		return RenderBench(m)
+	}
+
+	if m.ShowSaveBody {
+		return RenderSaveBody(m)
+	}
+
+	if m.ShowHexPrompt {
+		return RenderHexPrompt(m)
+	}
+
+	if m.ShowTable {
+		return RenderTable(m)
+	}
+
+	if m.ShowInfer {
+		return RenderInfer(m)
+	}
+
+	if m.ShowCollection {
+		return RenderCollection(m)
+	}
+
+	if m.ShowHistory {
+		return RenderHistory(m)
+	}
+
+	if m.ShowCodegen {
+		return RenderCodegen(m)
+	}
+
+	resizeViewport(&m)
+	if m.Fullscreen && m.Response != "" {
+		return RenderFullscreen(m)
+	}
+	return RenderMain(m)
+}
+
+// resizeViewport fits the viewport to the response box, or to the screen in
+// fullscreen
+func resizeViewport(m *model.Model) {
 	if m.Fullscreen && m.Response != "" {
-		// Update viewport dimensions for fullscreen
 		m.Viewport.Width = m.Width - 8
 		m.Viewport.Height = m.Height - 8
-		return RenderFullscreen(m)
+		return
 	}
 
-	// Update viewport dimensions for normal view
 	boxWidth := m.Width - 6
 	if boxWidth < 40 {
 		boxWidth = 40
@@ -529,6 +934,4 @@ func View(m model.Model) string {
 	}
 	m.Viewport.Width = boxWidth - 2
 	m.Viewport.Height = responseHeight - 2
-
-	return RenderMain(m)
 }
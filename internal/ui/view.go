@@ -5,8 +5,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tbourrel/apitty/internal/format"
 	"github.com/tbourrel/apitty/internal/model"
-	"github.com/tbourrel/apitty/internal/text"
 )
 
 // RenderMain renders the main application view
@@ -52,6 +52,43 @@ func RenderMain(m model.Model) string {
 	} else {
 		requestContent.WriteString(ButtonStyle.Render(headerBtn))
 	}
+	requestContent.WriteString(" ")
+	bodyBtn := "Body: Raw"
+	if m.BodyMode == model.BodyGraphQL {
+		bodyBtn = "Body: GraphQL"
+	}
+	requestContent.WriteString(ButtonStyle.Render(bodyBtn))
+	requestContent.WriteString(" ")
+	requestContent.WriteString(ButtonStyle.Render("Auth: " + authTypeLabel(m.Auth.Type)))
+	requestContent.WriteString(" ")
+	requestContent.WriteString(ButtonStyle.Render("Settings: " + settingsSummary(m.Settings)))
+	if len(m.Extract) > 0 {
+		requestContent.WriteString(" ")
+		requestContent.WriteString(ButtonStyle.Render(fmt.Sprintf("Extract: %d", len(m.Extract))))
+	}
+	if len(m.Assertions) > 0 {
+		requestContent.WriteString(" ")
+		requestContent.WriteString(ButtonStyle.Render(fmt.Sprintf("Tests: %d", len(m.Assertions))))
+	}
+	if !m.Contract.IsZero() {
+		requestContent.WriteString(" ")
+		requestContent.WriteString(ButtonStyle.Render("Contract: " + contractSummary(m.Contract)))
+	}
+	if m.Collection != nil {
+		requestContent.WriteString(" ")
+		requestContent.WriteString(ButtonStyle.Render("Collection: " + m.Collection.Name))
+		if len(m.Collection.Environments) > 0 {
+			requestContent.WriteString(" ")
+			requestContent.WriteString(ButtonStyle.Render("Env: " + environmentName(m)))
+		}
+	}
+	if m.StatusMessage != "" {
+		requestContent.WriteString("  ")
+		requestContent.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262")).
+			Italic(true).
+			Render(m.StatusMessage))
+	}
 
 	// Apply box style based on focus
 	requestBoxStyle := InputBoxStyle.Width(boxWidth)
@@ -61,20 +98,32 @@ func RenderMain(m model.Model) string {
 	sections = append(sections, requestBoxStyle.Render(requestContent.String()))
 
 	// Response display
-	viewType := "Body"
-	if m.CurrentView == model.ViewHeaders {
-		viewType = "Headers"
-	}
-	responseLabel := LabelStyle.Render(fmt.Sprintf("Response - %s", viewType))
+	responseLabel := LabelStyle.Render(fmt.Sprintf("Response - %s", responseViewName(m)))
 	if m.StatusCode != "" {
 		responseLabel += " - " + lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("#04B575")).
-			Render(m.StatusCode)
+			Render(statusText(m))
+	}
+	if m.Connection != "" {
+		responseLabel += lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render(" · " + m.Connection)
+	}
+
+	if badge := testBadge(m); badge != "" {
+		responseLabel += " " + badge
+	}
+	if badge := contractBadge(m); badge != "" {
+		responseLabel += " " + badge
 	}
 
 	var responseView string
-	if m.Response == "" {
+	if m.CurrentView == model.ViewCookies {
+		responseView = renderCookieTable(m, boxWidth-4, responseHeight-2)
+	} else if m.CurrentView == model.ViewTests && m.Response == "" {
+		responseView = testReport(m)
+	} else if m.CurrentView == model.ViewContract && m.Response == "" {
+		responseView = contractReport(m)
+	} else if m.Response == "" {
 		responseView = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#626262")).
 			Italic(true).
@@ -101,19 +150,27 @@ func RenderMain(m model.Model) string {
 
 // RenderFullscreen renders the fullscreen response view
 func RenderFullscreen(m model.Model) string {
-	viewType := "Body"
-	if m.CurrentView == model.ViewHeaders {
-		viewType = "Headers"
-	}
-	responseLabel := LabelStyle.Render(fmt.Sprintf("Response - %s (Fullscreen)", viewType))
+	responseLabel := LabelStyle.Render(fmt.Sprintf("Response - %s (Fullscreen)", responseViewName(m)))
 	if m.StatusCode != "" {
 		responseLabel += " - " + lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("#04B575")).
-			Render(m.StatusCode)
+			Render(statusText(m))
+	}
+	if m.Connection != "" {
+		responseLabel += lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render(" · " + m.Connection)
+	}
+	if badge := testBadge(m); badge != "" {
+		responseLabel += " " + badge
+	}
+	if badge := contractBadge(m); badge != "" {
+		responseLabel += " " + badge
 	}
 
 	responseView := m.Viewport.View()
+	if m.CurrentView == model.ViewCookies {
+		responseView = renderCookieTable(m, m.Width-8, m.Height-6)
+	}
 
 	responseDisplay := responseLabel + "\n" + responseView
 	if m.CurrentView == model.ViewBody {
@@ -132,6 +189,24 @@ func RenderFullscreen(m model.Model) string {
 	return "\n" + fullscreenBox.Render(responseDisplay)
 }
 
+// responseViewName names the tab shown in the response box
+func responseViewName(m model.Model) string {
+	switch m.CurrentView {
+	case model.ViewHeaders:
+		return "Headers"
+	case model.ViewCookies:
+		return "Cookies (" + environmentName(m) + ")"
+	case model.ViewTests:
+		return "Tests"
+	case model.ViewContract:
+		return "Contract"
+	}
+	if m.ResponseFormat != "" {
+		return "Body (" + m.ResponseFormat + ")"
+	}
+	return "Body"
+}
+
 // RenderHelp renders the help screen
 func RenderHelp(m model.Model) string {
 	helpBox := lipgloss.NewStyle().
@@ -236,47 +311,6 @@ func RenderHeadersForm(m model.Model) string {
 	return "\n" + formBox.Render(content.String())
 }
 
-// RenderCurlImport renders the cURL import modal
-func RenderCurlImport(m model.Model) string {
-	var content strings.Builder
-
-	content.WriteString(TitleStyle.Render("Import from cURL"))
-	content.WriteString("\n\n")
-
-	content.WriteString(LabelStyle.Render("Paste your curl command:"))
-	content.WriteString("\n\n")
-	content.WriteString(m.CurlInput.View())
-	content.WriteString("\n\n")
-
-	// Instructions
-	instructions := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#626262")).
-		Render("enter: import • esc: cancel")
-	content.WriteString(instructions)
-
-	// Example
-	content.WriteString("\n\n")
-	example := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#626262")).
-		Italic(true).
-		Render("Example: curl -X POST https://api.example.com/users -H \"Content-Type: application/json\"")
-	content.WriteString(example)
-
-	modalBox := lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#7D56F4")).
-		Padding(2, 4).
-		Width(m.Width - 20).
-		Height(14)
-
-	// Center the modal
-	return "\n\n\n" + lipgloss.Place(
-		m.Width, m.Height,
-		lipgloss.Center, lipgloss.Center,
-		modalBox.Render(content.String()),
-	)
-}
-
 // GetHelpContent returns the help text content
 func GetHelpContent() string {
 	return `
@@ -290,7 +324,20 @@ GLOBAL KEYBINDINGS
   ctrl+c    Quit the application
   ctrl+s    Send HTTP request (from anywhere)
   h         Open headers form (add/edit request headers)
-  i         Import from cURL command
+  a         Open auth settings (Basic, Bearer, API key)
+  s         Open request settings (TLS, redirects, timeout, client cert)
+  v         Extract response values into variables (request chaining)
+  T         Edit the assertions checked against every response
+  K         Link the request to a JSON Schema or an OpenAPI operation
+  B         Benchmark the current request
+  c         Browse the loaded collection
+  e         Switch the collection environment
+  ctrl+o    Save the edited request back to the collection or .http file
+  H         Browse the request history
+  C         Generate code for the current request
+  i         Import a curl, fetch, HTTPie or PowerShell command
+  ctrl+g    Open GraphQL editor (query, variables, schema)
+  ctrl+p    Open gRPC client (server reflection or .proto files)
 
 NAVIGATION
   tab       Cycle forward through fields (Method → URL → Response)
@@ -305,7 +352,7 @@ URL INPUT (when focused)
   Type normally - all keys work including h/j/k/l
 
 RESPONSE BOX (when focused)
-  t         Toggle between Body and Headers view
+  t         Cycle between Body, Headers, Cookies, Tests and Contract
   f         Toggle fullscreen mode
   j / ↓     Scroll down one line
   k / ↑     Scroll up one line
@@ -314,12 +361,137 @@ RESPONSE BOX (when focused)
   g         Jump to top
   G         Jump to bottom
   w         Toggle text wrapping
+  S         Save the whole response body to a file
+  L         Show an array of JSON objects as a table
+  M         Infer a JSON Schema, Go or TypeScript models from the body
+  X         Save the response as an example of the loaded request
+  :         Jump to an offset of a binary body
+  /         Search bytes in a binary body (n: next)
+  esc       Cancel the request in flight
 
 FULLSCREEN MODE (when active)
   f         Exit fullscreen
-  t         Toggle between Body and Headers
+  t         Cycle between Body, Headers, Cookies, Tests and Contract
   All scroll keys (j/k/d/u/g/G) work as normal
 
+TABLE VIEW (L)
+  h/j/k/l   Move between cells, 0/$ first and last column
+  s         Sort by the column, again to reverse
+  enter     Show the cell in full
+  p         Change the JSONPath of the rows, filters included
+  x         Export the rows to CSV
+
+MODEL INFERENCE (M)
+  tab       Switch between JSON Schema, Go and TypeScript
+  a         Merge the responses to the same endpoint in the history
+  y / w     Copy, or save to a file
+
+ASSERTIONS (T)
+  One per line: <status|header NAME|jsonpath PATH|time|size> <op> [value]
+  status == 2xx, header Content-Type contains json, time < 500ms
+  jsonpath $.id type number, jsonpath $.error !exists, size <= 10kb
+  Operators: == != < <= > >= contains matches exists !exists type
+  Values may reference {{variables}}; results show in the Tests tab
+
+CONTRACT (K)
+  JSON Schema   File the body must match, as JSON or YAML
+  OpenAPI       Specification checking the status, content type,
+                headers and body of the response
+  Operation     operationId or "GET /orders/{id}", empty to match the URL
+  Violations are listed by JSONPath in the Contract tab
+
+BENCHMARK (B)
+  tab       Next field (requests, duration, concurrency, rate)
+  enter     Start; stops after the requests or the duration
+  esc       Stop a running benchmark, or close
+  x         Export the results as JSON
+  r / n     Run again with the same settings / change them
+
+COOKIES TAB (one jar per environment)
+  j / k     Select a cookie
+  enter     Edit the selected cookie
+  n         Add a cookie
+  d / x     Delete the selected cookie
+  D         Clear the jar of the environment
+  p         Toggle saving cookies to disk across sessions
+
+GRAPHQL EDITOR
+  tab       Switch between query and variables
+  ctrl+n    Complete field name (repeat to cycle suggestions)
+  ctrl+y    Accept highlighted suggestion
+  ctrl+r    Introspect the endpoint schema
+  ctrl+e    Browse the schema explorer
+  ctrl+t    Toggle body mode between Raw and GraphQL
+  ctrl+s    Send as GraphQL
+  esc       Close editor
+
+GRPC CLIENT
+  tab       Cycle between methods, descriptors and request
+  j / k     Navigate methods
+  enter     Select method and fill in a request template
+  ctrl+l    Load services (reflection when descriptors are empty)
+  ctrl+s    Invoke the selected method
+  esc       Close
+
+AUTH SETTINGS
+  j / k     Change auth type (on the Type field)
+  tab       Next field
+  enter     Save
+  esc       Cancel
+
+COLLECTION BROWSER
+  j / k     Navigate requests
+  enter     Load request into the editor
+  esc / q   Close
+
+HISTORY
+  j / k     Navigate requests, newest first
+  enter     Load the request and its recorded response
+  x         Export the history as a HAR file in the current directory
+  esc / q   Close
+
+EXTRACTION RULES
+  j / k     Navigate rules
+  a         Add a rule: variable, source and expression
+  enter / e Edit the selected rule
+  d         Delete the selected rule
+  j / k     Change the source on the Source field: JSONPath ($.token),
+            header name, regex (first group is kept) or cookie name
+  esc       Back / close
+  Values are stored into the active environment, or the collection
+  variables without one, when a response arrives.
+
+REQUEST SETTINGS
+  tab / ↑↓  Next field
+  space     Toggle TLS verification, redirects, compression or
+            fresh connections (no kept-alive connection reuse)
+  enter     Save
+  esc       Cancel
+
+CODE GENERATOR
+  tab / h/l Change target (Go, Python, JavaScript, HTTPie, PowerShell)
+  j / k     Scroll the code
+  y         Copy the code to the clipboard
+  w         Save the code to a new apitty-<timestamp>.<ext> file
+  esc / q   Close
+
+IMPORT
+  Paste a curl, fetch(), HTTPie (http/https) or PowerShell
+  (Invoke-WebRequest/Invoke-RestMethod) command; the format is detected
+  automatically. Browser "Copy as cURL/fetch/PowerShell" output works as is,
+  including multi-line commands with \ continuations, $'...' strings and
+  curl -d @file. curl -u, -b, -A, -G, -k, --compressed, --cert/--key,
+  --resolve and --max-time map onto auth, headers and request settings.
+  enter     New line
+  ctrl+s    Preview the parsed method, URL, headers and body
+  enter     Apply the previewed request (esc goes back to editing)
+  esc       Cancel
+
+VARIABLES
+  {{name}} in the URL, headers, body and auth is replaced by the
+  collection variable of the same name when the request is sent.
+  Variables of the active environment (e) take precedence.
+
 MOUSE SUPPORT
   Scroll    Scroll the response box (when focused)
   Click     Switch focus between elements
@@ -334,11 +506,20 @@ Press ? to close this help
 `
 }
 
-// UpdateViewportContent updates the viewport content with proper wrapping
+// UpdateViewportContent shows the current tab in the viewport, which wraps
+// and colorizes the lines as they come into view
 func UpdateViewportContent(m *model.Model) {
-	content := m.Response
-	if m.CurrentView == model.ViewHeaders && m.ResponseHeaders != "" {
-		content = m.ResponseHeaders
+	m.Viewport.Highlight = nil
+	if f, ok := format.Lookup(m.ResponseFormat); ok && m.CurrentView == model.ViewBody {
+		m.Viewport.Highlight = func(line string) string {
+			// The preview notice above the body is already styled
+			if strings.Contains(line, "\x1b") {
+				return line
+			}
+			return f.Highlight(line)
+		}
+	} else if m.CurrentView == model.ViewBody && m.ResponseBinary {
+		m.Viewport.Highlight = highlightHex(m.HexMatch)
 	}
-	m.Viewport.SetContent(text.WrapText(content, m.Viewport.Width))
+	m.Viewport.SetContent(responseContent(*m))
 }
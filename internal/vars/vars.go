@@ -0,0 +1,89 @@
+package vars
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+var referencePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// maxDepth stops variables that reference each other from expanding forever
+const maxDepth = 10
+
+// Expand replaces {{name}} references with their values. Values may
+// reference other variables. Unknown references are left untouched.
+func Expand(s string, vars map[string]string) string {
+	return expand(s, vars, 0)
+}
+
+func expand(s string, vars map[string]string, depth int) string {
+	if !strings.Contains(s, "{{") || depth > maxDepth {
+		return s
+	}
+	return referencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := referencePattern.FindStringSubmatch(ref)[1]
+		if val, ok := vars[name]; ok {
+			return expand(val, vars, depth+1)
+		}
+		return ref
+	})
+}
+
+// ExpandRequest expands the references in every part of a request
+func ExpandRequest(req model.Request, vars map[string]string) model.Request {
+	out := req
+	out.URL = Expand(req.URL, vars)
+	out.Body = Expand(req.Body, vars)
+	out.Headers = make([]model.HeaderPair, len(req.Headers))
+	for i, h := range req.Headers {
+		out.Headers[i] = model.HeaderPair{Key: Expand(h.Key, vars), Value: Expand(h.Value, vars)}
+	}
+	if req.Auth != nil {
+		auth := *req.Auth
+		auth.Username = Expand(auth.Username, vars)
+		auth.Password = Expand(auth.Password, vars)
+		auth.Token = Expand(auth.Token, vars)
+		auth.Key = Expand(auth.Key, vars)
+		auth.Value = Expand(auth.Value, vars)
+		out.Auth = &auth
+	}
+	if req.Assertions != nil {
+		out.Assertions = make([]model.Assertion, len(req.Assertions))
+		for i, a := range req.Assertions {
+			a.Target = Expand(a.Target, vars)
+			a.Value = Expand(a.Value, vars)
+			out.Assertions[i] = a
+		}
+	}
+	return out
+}
+
+// References lists the distinct variable names referenced in s
+func References(s string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range referencePattern.FindAllStringSubmatch(s, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// QueryEscape escapes s for a query string or form body, leaving {{name}}
+// references intact so they can still be expanded
+func QueryEscape(s string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range referencePattern.FindAllStringIndex(s, -1) {
+		out.WriteString(url.QueryEscape(s[last:loc[0]]))
+		out.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(url.QueryEscape(s[last:]))
+	return out.String()
+}
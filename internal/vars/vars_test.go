@@ -0,0 +1,85 @@
+package vars
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tbourrel/apitty/internal/model"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{
+		"baseUrl": "https://api.example.com",
+		"id":      "42",
+		"user":    "{{baseUrl}}/users/{{id}}",
+		"loop":    "{{loop}}",
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no references", "https://example.com", "https://example.com"},
+		{"single reference", "{{baseUrl}}/users", "https://api.example.com/users"},
+		{"several references", "{{baseUrl}}/users/{{id}}", "https://api.example.com/users/42"},
+		{"spaces inside braces", "{{ id }}", "42"},
+		{"unknown reference", "{{baseUrl}}/{{missing}}", "https://api.example.com/{{missing}}"},
+		{"nested references", "{{user}}", "https://api.example.com/users/42"},
+		{"self reference", "{{loop}}", "{{loop}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expand(tt.input, vars); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandRequest(t *testing.T) {
+	vars := map[string]string{"host": "example.com", "token": "secret"}
+	req := model.Request{
+		Method:  "GET",
+		URL:     "https://{{host}}/me",
+		Headers: []model.HeaderPair{{Key: "X-Host", Value: "{{host}}"}},
+		Auth:    &model.Auth{Type: model.AuthBearer, Token: "{{token}}"},
+		Assertions: []model.Assertion{
+			{Kind: model.AssertHeader, Target: "X-Host", Op: "==", Value: "{{host}}"},
+		},
+	}
+
+	out := ExpandRequest(req, vars)
+	if out.URL != "https://example.com/me" {
+		t.Errorf("unexpected URL %q", out.URL)
+	}
+	if out.Headers[0].Value != "example.com" {
+		t.Errorf("unexpected header value %q", out.Headers[0].Value)
+	}
+	if out.Auth.Token != "secret" {
+		t.Errorf("unexpected token %q", out.Auth.Token)
+	}
+	if out.Assertions[0].Value != "example.com" {
+		t.Errorf("unexpected assertion value %q", out.Assertions[0].Value)
+	}
+
+	// The original request must not be modified
+	if req.Headers[0].Value != "{{host}}" || req.Auth.Token != "{{token}}" || req.Assertions[0].Value != "{{host}}" {
+		t.Error("expected original request to be left untouched")
+	}
+}
+
+func TestReferences(t *testing.T) {
+	refs := References("{{a}}/{{b}}?x={{a}}")
+	if strings.Join(refs, ",") != "a,b" {
+		t.Errorf("expected [a b], got %v", refs)
+	}
+}
+
+func TestQueryEscape(t *testing.T) {
+	got := QueryEscape("a b&{{user}}/{{ id }}=")
+	if got != "a+b%26{{user}}%2F{{ id }}%3D" {
+		t.Errorf("unexpected escape %q", got)
+	}
+}
@@ -1,25 +1,106 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/collection"
+	"github.com/tbourrel/apitty/internal/cookies"
+	"github.com/tbourrel/apitty/internal/history"
 	"github.com/tbourrel/apitty/internal/model"
 	"github.com/tbourrel/apitty/internal/ui"
 )
 
 func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "import" {
+		if err := runImport(args[1:], os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "export" {
+		if err := runExport(args[1:], os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) > 0 && args[0] == "mock" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := runMock(ctx, args[1:], os.Stderr)
+		stop()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) > 0 && args[0] == "test" {
+		if err := runTest(args[1:], os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	m := model.InitialModel()
+	if path, err := history.DefaultPath(); err == nil {
+		m.HistoryPath = path
+		if m.History, err = history.Load(path); err != nil {
+			m.StatusMessage = fmt.Sprintf("History not loaded: %v", err)
+			// Keep the unreadable file rather than overwriting it
+			m.HistoryPath = ""
+		}
+	}
+	if path, err := cookies.DefaultPath(); err == nil {
+		m.CookiesPath = path
+		// Saving is on when a previous session left a cookie file
+		store, saved, err := cookies.Load(path)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Cookies not loaded: %v", err)
+			m.CookiesPath = ""
+		} else {
+			m.Cookies, m.PersistCookies = store, saved
+		}
+	}
+	switch {
+	case len(args) > 0 && (strings.HasSuffix(args[0], ".http") || strings.HasSuffix(args[0], ".rest")):
+		var err error
+		m, err = ui.OpenHTTPFile(m, args[0])
+		if err != nil {
+			fmt.Println("Error loading .http file:", err)
+			os.Exit(1)
+		}
+	case len(args) > 0:
+		c, err := collection.Load(args[0])
+		if err != nil {
+			fmt.Println("Error loading collection:", err)
+			os.Exit(1)
+		}
+		m.Collection = c
+		m.CollectionPath = args[0]
+	}
 
 	// Set up Update and View from ui package
+	app := &appModel{m: m}
 	p := tea.NewProgram(
-		&appModel{m: m},
+		app,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	ui.Close(app.m)
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
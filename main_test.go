@@ -1,13 +1,27 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tbourrel/apitty/internal/bench"
+	"github.com/tbourrel/apitty/internal/collection"
+	httpClient "github.com/tbourrel/apitty/internal/http"
 	"github.com/tbourrel/apitty/internal/json"
+	"github.com/tbourrel/apitty/internal/mock"
 	"github.com/tbourrel/apitty/internal/model"
 	"github.com/tbourrel/apitty/internal/parser"
 	"github.com/tbourrel/apitty/internal/text"
+	"github.com/tbourrel/apitty/internal/transfer"
 	"github.com/tbourrel/apitty/internal/ui"
 )
 
@@ -78,7 +92,17 @@ func TestResponseViewToggle(t *testing.T) {
 		t.Errorf("expected response view to be ViewHeaders, got %v", m.CurrentView)
 	}
 
-	// Toggle back to Body
+	// Then to Cookies, Tests, and back to Body
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = newModel
+	if m.CurrentView != model.ViewCookies {
+		t.Errorf("expected response view to be ViewCookies, got %v", m.CurrentView)
+	}
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = newModel
+	if m.CurrentView != model.ViewTests {
+		t.Errorf("expected response view to be ViewTests, got %v", m.CurrentView)
+	}
 	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
 	m = newModel
 	if m.CurrentView != model.ViewBody {
@@ -245,21 +269,21 @@ func TestHeaderFormToggle(t *testing.T) {
 func TestCurlImportToggle(t *testing.T) {
 	m := model.InitialModel()
 
-	if m.ShowCurlImport {
+	if m.ShowImport {
 		t.Error("expected curl import to be hidden initially")
 	}
 
 	// Toggle curl import on
 	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
 	m = newModel
-	if !m.ShowCurlImport {
+	if !m.ShowImport {
 		t.Error("expected curl import to be shown")
 	}
 
 	// Close with Esc
 	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEsc})
 	m = newModel
-	if m.ShowCurlImport {
+	if m.ShowImport {
 		t.Error("expected curl import to be hidden after Esc")
 	}
 }
@@ -289,3 +313,1236 @@ func TestView(t *testing.T) {
 		t.Error("expected non-empty view")
 	}
 }
+
+func TestGraphQLFormToggle(t *testing.T) {
+	m := model.InitialModel()
+
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlG})
+	m = newModel
+	if !m.ShowGraphQLForm {
+		t.Error("expected GraphQL form to be shown")
+	}
+
+	// Switching the body mode from inside the form
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = newModel
+	if m.BodyMode != model.BodyGraphQL {
+		t.Error("expected body mode to be GraphQL")
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel
+	if m.ShowGraphQLForm {
+		t.Error("expected GraphQL form to be hidden after Esc")
+	}
+}
+
+func TestCollectionLoadRequest(t *testing.T) {
+	m := model.InitialModel()
+	m.Collection = &model.Collection{
+		Name:      "Pets",
+		Variables: []model.Variable{{Key: "baseUrl", Value: "https://pets.example.com"}},
+		Requests: []model.SavedRequest{{
+			Name: "Create pet",
+			Request: model.Request{
+				Method:  "POST",
+				URL:     "{{baseUrl}}/pets",
+				Headers: []model.HeaderPair{{Key: "Content-Type", Value: "application/json"}},
+				Body:    `{"name": "Rex"}`,
+				Auth:    &model.Auth{Type: model.AuthBearer, Token: "{{token}}"},
+			},
+		}},
+	}
+
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = newModel
+	if !m.ShowCollection {
+		t.Fatal("expected collection browser to be shown")
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if m.ShowCollection {
+		t.Error("expected collection browser to close after loading a request")
+	}
+	if model.Methods[m.MethodIdx] != "POST" || m.URLInput.Value() != "{{baseUrl}}/pets" {
+		t.Errorf("unexpected request loaded: %s %s", model.Methods[m.MethodIdx], m.URLInput.Value())
+	}
+	if m.Body != `{"name": "Rex"}` || len(m.RequestHeaders) != 1 {
+		t.Errorf("expected body and headers to be loaded")
+	}
+	if m.Auth.Type != model.AuthBearer {
+		t.Errorf("expected bearer auth, got %q", m.Auth.Type)
+	}
+}
+
+func TestCollectionEnvironmentCycle(t *testing.T) {
+	m := model.InitialModel()
+	m.Focus = model.FocusMethod
+	m.Collection = &model.Collection{
+		Name:      "Pets",
+		Variables: []model.Variable{{Key: "baseUrl", Value: "https://pets.example.com"}},
+		Environments: []model.Environment{
+			{Name: "Staging", Variables: []model.Variable{{Key: "baseUrl", Value: "https://staging.pets.example.com"}}},
+		},
+	}
+
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m = newModel
+	if m.EnvironmentIdx != 0 || m.StatusMessage != "Environment: Staging" {
+		t.Fatalf("expected Staging to be selected, got %d %q", m.EnvironmentIdx, m.StatusMessage)
+	}
+	if got := m.Collection.EnvironmentVariableMap(m.EnvironmentIdx)["baseUrl"]; got != "https://staging.pets.example.com" {
+		t.Errorf("expected environment variable to override the collection one, got %q", got)
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m = newModel
+	if m.EnvironmentIdx != -1 {
+		t.Errorf("expected cycling past the last environment to select none, got %d", m.EnvironmentIdx)
+	}
+}
+
+func TestHTTPFileLoadEditSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.http")
+	original := "@baseUrl = https://example.com\n\n### Ping\n# health probe\nGET {{baseUrl}}/ping\nAccept: text/plain\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ui.OpenHTTPFile(model.InitialModel(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Collection.Name != "api.http" || len(m.Collection.Entries()) != 1 {
+		t.Fatalf("expected a collection with one request, got %+v", m.Collection)
+	}
+	if m.Collection.VariableMap()["baseUrl"] != "https://example.com" {
+		t.Errorf("expected file variables to become collection variables")
+	}
+
+	m.Focus = model.FocusMethod
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	newModel, _ = ui.Update(newModel, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if m.LoadedRequestIdx != 0 || m.URLInput.Value() != "{{baseUrl}}/ping" {
+		t.Fatalf("expected the request to be loaded, got %d %q", m.LoadedRequestIdx, m.URLInput.Value())
+	}
+
+	m.MethodIdx = 1 // POST
+	m.Body = "hello"
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = newModel
+	if !strings.HasPrefix(m.StatusMessage, `Saved "Ping"`) {
+		t.Errorf("expected a saved status, got %q", m.StatusMessage)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "@baseUrl = https://example.com\n\n### Ping\n# health probe\nPOST {{baseUrl}}/ping\nAccept: text/plain\n\nhello\n"
+	if string(data) != expected {
+		t.Errorf("unexpected file:\n%s", data)
+	}
+}
+
+func TestHistoryRecordAndLoad(t *testing.T) {
+	m := model.InitialModel()
+	m.HistoryPath = filepath.Join(t.TempDir(), "history.json")
+	entry := &model.HistoryEntry{
+		Request:  model.Request{Method: "PUT", URL: "https://example.com/items/1", Body: `{"a":1}`},
+		Response: model.Response{Status: "200 OK", StatusCode: 200, Body: `{"ok":true}`},
+	}
+
+	newModel, cmd := ui.Update(m, model.ResponseMsg{Resp: `{"ok":true}`, Status: "200 OK", Entry: entry})
+	m = newModel
+	if len(m.History) != 1 {
+		t.Fatalf("expected the response to be recorded, got %d entries", len(m.History))
+	}
+	if cmd == nil {
+		t.Fatal("expected a command saving the history")
+	}
+	if msg, ok := cmd().(model.HistorySavedMsg); !ok || msg.Err != nil {
+		t.Errorf("expected the history to be saved, got %#v", msg)
+	}
+
+	// Start from a blank editor and load the entry back
+	m.URLInput.SetValue("")
+	m.Response = ""
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'H'}})
+	newModel, _ = ui.Update(newModel, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if m.ShowHistory {
+		t.Error("expected the history browser to close")
+	}
+	if model.Methods[m.MethodIdx] != "PUT" || m.URLInput.Value() != "https://example.com/items/1" || m.Body != `{"a":1}` {
+		t.Errorf("unexpected request loaded: %s %s %s", model.Methods[m.MethodIdx], m.URLInput.Value(), m.Body)
+	}
+	if m.StatusCode != "200 OK" || !strings.Contains(m.Response, `"ok": true`) {
+		t.Errorf("expected the recorded response to be shown, got %q %q", m.StatusCode, m.Response)
+	}
+}
+
+func TestCodegenModal(t *testing.T) {
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.MethodIdx = 1 // POST
+	m.URLInput.SetValue("{{baseUrl}}/items")
+	m.Body = `{"name":"x"}`
+	m.Collection = &model.Collection{Variables: []model.Variable{{Key: "baseUrl", Value: "https://example.com"}}}
+
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	m = newModel
+	if !m.ShowCodegen {
+		t.Fatal("expected the code generator to open")
+	}
+	if view := ui.View(m); !strings.Contains(view, `"https://example.com/items"`) {
+		t.Errorf("expected Go code with variables expanded, got:\n%s", view)
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyTab})
+	m = newModel
+	if view := ui.View(m); !strings.Contains(view, "requests.request") {
+		t.Errorf("expected Python code after tab, got:\n%s", view)
+	}
+
+	// GraphQL requests are sent as an envelope, which may not build
+	m.BodyMode = model.BodyGraphQL
+	m.GraphQLQuery.SetValue("{ items { id } }")
+	if view := ui.View(m); !strings.Contains(view, `{\"query\":`) || !strings.Contains(view, "application/json") {
+		t.Errorf("expected the GraphQL envelope, got:\n%s", view)
+	}
+	m.GraphQLVariables.SetValue("[1]")
+	if view := ui.View(m); !strings.Contains(view, "variables must be a JSON object") {
+		t.Errorf("expected the envelope error, got:\n%s", view)
+	}
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if !newModel.ShowCodegen || !strings.Contains(newModel.StatusMessage, "variables must be a JSON object") {
+		t.Errorf("expected the code not to be copied, got %q", newModel.StatusMessage)
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if newModel.ShowCodegen {
+		t.Error("expected esc to close the code generator")
+	}
+}
+
+func TestImportModal(t *testing.T) {
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = newModel
+	m.ImportInput.SetValue("http example.com")
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = newModel
+	if view := ui.View(m); !strings.Contains(view, "detected HTTPie") {
+		t.Errorf("expected the format to be detected, got:\n%s", view)
+	}
+
+	// A failed parse keeps the modal open with an error
+	m.ImportInput.SetValue("curl -H 'Accept: */*'")
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = newModel
+	if !m.ShowImport || m.ImportPreview != nil || !strings.Contains(m.ImportError, "no URL found") {
+		t.Fatalf("expected a parse error, got %q", m.ImportError)
+	}
+	if m.URLInput.Value() != "" {
+		t.Errorf("expected the editor to be left alone, got URL %q", m.URLInput.Value())
+	}
+
+	// A multi-line command pasted from docs, longer than the old 2000 character limit
+	m.ImportInput.Reset()
+	body := strings.Repeat("x", 3000)
+	paste := "curl 'https://example.com/items' \\\n  -X PUT \\\n  -H 'Content-Type: application/json' \\\n  --data-raw $'{\"note\": \"" + body + "\\n\"}'"
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(paste), Paste: true})
+	m = newModel
+	if m.ImportInput.Value() != paste {
+		t.Fatalf("expected the paste to be kept as is, got %d characters", len(m.ImportInput.Value()))
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = newModel
+	if m.ImportPreview == nil {
+		t.Fatalf("expected a preview, got error %q", m.ImportError)
+	}
+	if view := ui.View(m); !strings.Contains(view, "Parsed cURL command") || !strings.Contains(view, "Content-Type: application/json") {
+		t.Errorf("expected the parsed request to be previewed, got:\n%s", view)
+	}
+	if m.URLInput.Value() != "" {
+		t.Error("expected the request to be applied only after confirmation")
+	}
+
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if m.ShowImport {
+		t.Fatal("expected the modal to close")
+	}
+	if model.Methods[m.MethodIdx] != "PUT" || m.URLInput.Value() != "https://example.com/items" || m.Body != `{"note": "`+body+"\n\"}" || len(m.RequestHeaders) != 1 {
+		t.Errorf("unexpected request %s %s %v", model.Methods[m.MethodIdx], m.URLInput.Value(), m.RequestHeaders)
+	}
+	if !strings.Contains(m.StatusMessage, "Imported cURL request") {
+		t.Errorf("unexpected status %q", m.StatusMessage)
+	}
+}
+
+func TestSettingsForm(t *testing.T) {
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.Focus = model.FocusMethod
+
+	// curl options land in the request settings
+	newModel, _ := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = newModel
+	m.ImportInput.SetValue("curl -s --oauth2-bearer abc123 --compressed --resolve example.com:443:127.0.0.1 https://example.com/items")
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = newModel
+	view := ui.View(m)
+	for _, want := range []string{"Bearer token (6 characters)", "no redirects, compressed, resolve", "resolve example.com:443:127.0.0.1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the preview, got:\n%s", want, view)
+		}
+	}
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel
+	m.ImportInput.SetValue("curl -skL --max-time 2.5 -u admin:secret https://example.com/items")
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = newModel
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if m.URLInput.Value() != "https://example.com/items" || m.Auth.Type != model.AuthBasic {
+		t.Fatalf("unexpected request %q with auth %v", m.URLInput.Value(), m.Auth.Type)
+	}
+	if !m.Settings.Insecure || m.Settings.TimeoutMs != 2500 {
+		t.Fatalf("unexpected settings %+v", m.Settings)
+	}
+	if view := ui.View(m); !strings.Contains(view, "Settings: insecure, 2500ms") {
+		t.Errorf("expected the settings summary in the request box, got:\n%s", view)
+	}
+
+	// Toggle redirects off and change the timeout in the form
+	m.Focus = model.FocusMethod
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newModel
+	if !m.ShowSettingsForm || m.SettingsInputs[0].Value() != "2500" {
+		t.Fatalf("expected the settings form with the timeout, got %q", m.SettingsInputs[0].Value())
+	}
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyDown})
+	m = newModel
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = newModel
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyDown})
+	m = newModel
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyDown})
+	m = newModel
+	m.SettingsInputs[0].SetValue("soon")
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if !m.ShowSettingsForm || !strings.Contains(ui.View(m), "invalid timeout") {
+		t.Fatal("expected an invalid timeout to keep the form open")
+	}
+	m.SettingsInputs[0].SetValue("500")
+	newModel, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel
+	if m.ShowSettingsForm {
+		t.Fatal("expected the form to close")
+	}
+	if !m.Settings.NoRedirects || m.Settings.TimeoutMs != 500 || !m.Settings.Insecure {
+		t.Errorf("unexpected settings %+v", m.Settings)
+	}
+}
+
+func TestCookiesTab(t *testing.T) {
+	m := model.InitialModel()
+	m.Width, m.Height = 140, 40
+	m.CookiesPath = filepath.Join(t.TempDir(), "cookies.json")
+	m.Collection = &model.Collection{
+		Name:         "Shop",
+		Environments: []model.Environment{{Name: "Staging"}},
+	}
+	m.URLInput.SetValue("https://shop.example.com:8443/cart")
+	m.Focus = model.FocusResponse
+
+	key := func(r rune) tea.KeyMsg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+	}
+	update := func(msg tea.Msg) tea.Cmd {
+		t.Helper()
+		newModel, cmd := ui.Update(m, msg)
+		m = newModel
+		return cmd
+	}
+
+	// Body, Headers, then Cookies, even before any response
+	update(key('t'))
+	update(key('t'))
+	if m.CurrentView != model.ViewCookies {
+		t.Fatalf("expected the Cookies tab, got %v", m.CurrentView)
+	}
+	if view := ui.View(m); !strings.Contains(view, "Response - Cookies (None)") || !strings.Contains(view, "No cookies") {
+		t.Errorf("expected an empty jar, got:\n%s", view)
+	}
+
+	// Add a cookie, the domain defaults to the host of the URL
+	update(key('n'))
+	if !m.ShowCookieForm || m.CookieInputs[2].Value() != "shop.example.com" {
+		t.Fatalf("expected the cookie form for the URL host, got domain %q", m.CookieInputs[2].Value())
+	}
+	m.CookieInputs[0].SetValue("session")
+	m.CookieInputs[1].SetValue("abc123")
+	m.CookieInputs[4].SetValue("next week")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.ShowCookieForm || !strings.Contains(ui.View(m), "invalid expiry") {
+		t.Fatal("expected an invalid expiry to keep the form open")
+	}
+	m.CookieInputs[4].SetValue("2030-01-02 03:04")
+	for range 7 {
+		update(tea.KeyMsg{Type: tea.KeyTab})
+	}
+	update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ShowCookieForm {
+		t.Fatalf("expected the form to close, got %q", m.StatusMessage)
+	}
+	view := ui.View(m)
+	for _, want := range []string{"session", "abc123", "shop.example.com", "2030-01-02 03:04", "HttpOnly"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the cookie table, got:\n%s", want, view)
+		}
+	}
+
+	// Each environment has its own jar
+	m.Focus = model.FocusMethod
+	update(key('e'))
+	m.Focus = model.FocusResponse
+	if view := ui.View(m); !strings.Contains(view, "Cookies (Staging)") || strings.Contains(view, "abc123") {
+		t.Errorf("expected the empty Staging jar, got:\n%s", view)
+	}
+	m.Focus = model.FocusMethod
+	update(key('e'))
+	m.Focus = model.FocusResponse
+
+	// Save to disk, then edit and delete
+	cmd := update(key('p'))
+	if !m.PersistCookies || cmd == nil {
+		t.Fatal("expected saving to be turned on")
+	}
+	cmd()
+	data, err := os.ReadFile(m.CookiesPath)
+	if err != nil || !strings.Contains(string(data), "abc123") {
+		t.Fatalf("expected the cookie file, got %q, %v", data, err)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.CookieEditing == nil || m.CookieInputs[1].Value() != "abc123" {
+		t.Fatal("expected the cookie to be edited")
+	}
+	m.CookieInputs[1].SetValue("xyz")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := ui.View(m); !strings.Contains(view, "xyz") || strings.Contains(view, "abc123") {
+		t.Errorf("expected the value to be replaced, got:\n%s", view)
+	}
+
+	if cmd := update(key('d')); cmd != nil {
+		cmd()
+	}
+	if view := ui.View(m); !strings.Contains(view, "No cookies") {
+		t.Errorf("expected the cookie to be deleted, got:\n%s", view)
+	}
+	data, _ = os.ReadFile(m.CookiesPath)
+	if strings.Contains(string(data), "xyz") {
+		t.Errorf("expected the deletion to be saved, got %s", data)
+	}
+}
+
+func TestExtractionRules(t *testing.T) {
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.Focus = model.FocusMethod
+	m.Collection = &model.Collection{
+		Name:         "Shop",
+		Environments: []model.Environment{{Name: "Staging"}},
+	}
+	m.EnvironmentIdx = 0
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		newModel, _ := ui.Update(m, msg)
+		m = newModel
+	}
+
+	// Add a JSONPath rule and a header rule
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if !m.ShowExtractForm {
+		t.Fatal("expected the extraction rules to open")
+	}
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m.ExtractInputs[0].SetValue("auth_token")
+	m.ExtractInputs[1].SetValue("$.access_token[")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(ui.View(m), "invalid JSONPath") {
+		t.Fatal("expected an invalid JSONPath to be reported")
+	}
+	m.ExtractInputs[1].SetValue("$.access_token")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m.ExtractInputs[0].SetValue("next")
+	update(tea.KeyMsg{Type: tea.KeyTab})
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	update(tea.KeyMsg{Type: tea.KeyTab})
+	m.ExtractInputs[1].SetValue("Location")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.Extract) != 2 || m.Extract[1].Source != model.ExtractHeader {
+		t.Fatalf("unexpected rules %+v", m.Extract)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// A login response fills the Staging environment
+	entry := &model.HistoryEntry{
+		Request: model.Request{Method: "POST", URL: "https://shop.example.com/login"},
+		Response: model.Response{
+			Status: "200 OK", StatusCode: 200,
+			Headers: []model.HeaderPair{{Key: "Content-Type", Value: "application/json"}},
+			Body:    `{"access_token": "tok-123"}`,
+		},
+	}
+	update(model.ResponseMsg{Resp: entry.Response.Body, Status: "200 OK", Entry: entry})
+	vars := m.Collection.EnvironmentVariableMap(0)
+	if vars["auth_token"] != "tok-123" {
+		t.Errorf("expected the token in the environment, got %v", vars)
+	}
+	if !strings.Contains(m.StatusMessage, "Extracted auth_token") || !strings.Contains(m.StatusMessage, "next: no Location header") {
+		t.Errorf("unexpected status %q", m.StatusMessage)
+	}
+
+	// The next request uses it, as the generated code shows
+	m.URLInput.SetValue("https://shop.example.com/orders")
+	m.RequestHeaders = []model.HeaderPair{{Key: "Authorization", Value: "Bearer {{auth_token}}"}}
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	if view := ui.View(m); !strings.Contains(view, "Bearer tok-123") {
+		t.Errorf("expected the extracted token in the request, got:\n%s", view)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.Focus = model.FocusMethod
+	m.ViewportReady = true
+	m.Collection = &model.Collection{
+		Name:         "Shop",
+		Environments: []model.Environment{{Name: "Staging", Variables: []model.Variable{{Key: "sku", Value: "W-1"}}}},
+	}
+	m.EnvironmentIdx = 0
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		newModel, _ := ui.Update(m, msg)
+		m = newModel
+	}
+	add := func(line string) {
+		t.Helper()
+		update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		m.AssertInput.SetValue(line)
+		update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'T'}})
+	if !m.ShowAssertForm {
+		t.Fatal("expected the assertions to open")
+	}
+	add("status is 200")
+	if !strings.Contains(ui.View(m), "expected an operator") {
+		t.Fatal("expected an invalid assertion to be reported")
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	add("status == 2xx")
+	add("jsonpath $.sku == {{sku}}")
+	add("jsonpath $.stock > 10")
+	if len(m.Assertions) != 3 {
+		t.Fatalf("unexpected assertions %+v", m.Assertions)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// The assertions are the ones of the request as it was sent, even when
+	// it changes before the response arrives
+	m.URLInput.SetValue("https://shop.example.com/items/1")
+	update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if !m.Loading {
+		t.Fatal("expected the request to be sent")
+	}
+	m.Assertions = m.Assertions[:1]
+
+	entry := &model.HistoryEntry{
+		Request: model.Request{Method: "GET", URL: "https://shop.example.com/items/1"},
+		Response: model.Response{
+			Status: "200 OK", StatusCode: 200,
+			Body: `{"sku": "W-1", "stock": 4}`,
+		},
+	}
+	update(model.ResponseMsg{Resp: entry.Response.Body, Status: "200 OK", Entry: entry})
+	if len(m.TestResults) != 3 || !m.TestResults[0].Passed || !m.TestResults[1].Passed || m.TestResults[2].Passed {
+		t.Fatalf("unexpected results %+v", m.TestResults)
+	}
+	if view := ui.View(m); !strings.Contains(view, "1/3 failed") {
+		t.Errorf("expected a failure badge, got:\n%s", view)
+	}
+
+	// The Tests tab lists the failure
+	m.Focus = model.FocusResponse
+	for m.CurrentView != model.ViewTests {
+		update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	}
+	if view := ui.View(m); !strings.Contains(view, "jsonpath $.stock > 10 — got 4") {
+		t.Errorf("expected the failed assertion in the Tests tab, got:\n%s", view)
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1)%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 50
+	m.Focus = model.FocusMethod
+	m.URLInput.SetValue(server.URL)
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		newModel, _ := ui.Update(m, msg)
+		m = newModel
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'B'}})
+	if !m.ShowBench || !strings.Contains(ui.View(m), "Concurrency:") {
+		t.Fatal("expected the benchmark form to open")
+	}
+	m.BenchInputs[0].SetValue("")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Bench != nil || !strings.Contains(ui.View(m), "set a number of requests or a duration") {
+		t.Fatal("expected a benchmark without an end to be rejected")
+	}
+
+	m.BenchInputs[0].SetValue("20")
+	m.BenchInputs[2].SetValue("4")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Bench == nil || m.BenchTarget != "GET "+server.URL {
+		t.Fatalf("expected the benchmark to start, got %q", m.StatusMessage)
+	}
+	// Run it here rather than through the returned command
+	m.Bench.Run(context.Background())
+	update(bench.DoneMsg{Runner: m.Bench})
+
+	if m.BenchSummary.Requests != 20 || m.BenchSummary.Statuses[200] != 16 || m.BenchSummary.Statuses[503] != 4 {
+		t.Fatalf("unexpected summary %+v", m.BenchSummary)
+	}
+	view := ui.View(m)
+	for _, want := range []string{"Done", "20/20 requests", "Reused connections", "p99", "Latency histogram", "200", "16 (80%)", "503", "x: export JSON"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the dashboard:\n%s", want, view)
+		}
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.Bench != nil || !strings.Contains(ui.View(m), "Requests:") {
+		t.Error("expected n to go back to the form")
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.ShowBench {
+		t.Error("expected esc to close the benchmark")
+	}
+
+	// The response label tells whether the connection was reused
+	update(model.ResponseMsg{Resp: "{}", Status: "200 OK", Entry: &model.HistoryEntry{ReusedConnection: true}})
+	if view := ui.View(m); !strings.Contains(view, "200 OK · reused connection") {
+		t.Errorf("expected the reused connection in the response label, got:\n%s", view)
+	}
+	update(model.ResponseMsg{Resp: "{}", Status: "200 OK", Entry: &model.HistoryEntry{Timings: model.Timings{DNS: 2 * time.Millisecond, Connect: 10 * time.Millisecond}}})
+	if view := ui.View(m); !strings.Contains(view, "200 OK · new connection, 12ms setup") {
+		t.Errorf("expected the new connection in the response label, got:\n%s", view)
+	}
+}
+
+func TestLargeResponse(t *testing.T) {
+	body := `{"items": [` + strings.Repeat(`{"id": 1}, `, 10_000) + `{"id": 2}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Viewport.Width, m.Viewport.Height = 110, 26
+	m.Focus = model.FocusResponse
+
+	update := func(msg tea.Msg) tea.Cmd {
+		t.Helper()
+		newModel, cmd := ui.Update(m, msg)
+		m = newModel
+		return cmd
+	}
+
+	// The status shows the progress while loading, esc cancels
+	m.Loading = true
+	m.StatusCode = "Sending..."
+	m.Transfer = transfer.New(0)
+	if view := ui.View(m); !strings.Contains(view, "Sending... • esc: cancel") {
+		t.Errorf("expected the cancel hint while loading, got:\n%s", view)
+	}
+	tr := m.Transfer
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	update(httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, tr)())
+	if m.Loading || m.StatusCode != "Cancelled" || m.Response != "Request cancelled" {
+		t.Fatalf("expected the request to be cancelled, got %q: %q", m.StatusCode, m.Response)
+	}
+
+	// Only the start of the body is shown
+	update(httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, transfer.New(1024))())
+	if m.BodyFile == "" || len(m.ResponseBody) != 1024 || m.ResponseType != "application/json" {
+		t.Fatalf("expected a streamed body, got %d bytes of %s in %q", len(m.ResponseBody), m.ResponseType, m.BodyFile)
+	}
+	defer ui.Close(m)
+	if view := ui.View(m); !strings.Contains(view, "Showing the first 1.0 KiB of 107.4 KiB • S: save the whole body") {
+		t.Errorf("expected the preview notice, got:\n%s", view)
+	}
+
+	// S saves the whole body
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	if !m.ShowSaveBody || m.SaveBodyInput.Value() != "response.json" {
+		t.Fatalf("expected the save prompt with a JSON file name, got %q", m.SaveBodyInput.Value())
+	}
+	dest := filepath.Join(t.TempDir(), "items.json")
+	m.SaveBodyInput.SetValue(dest)
+	cmd := update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ShowSaveBody || cmd == nil {
+		t.Fatal("expected the prompt to close and save the body")
+	}
+	update(cmd())
+	if data, err := os.ReadFile(dest); err != nil || string(data) != body {
+		t.Fatalf("expected the whole body in %s, got %d bytes, %v", dest, len(data), err)
+	}
+	if !strings.Contains(m.StatusMessage, "Saved 107.4 KiB to "+dest) {
+		t.Errorf("unexpected status %q", m.StatusMessage)
+	}
+
+	// The next response removes the file of the previous one
+	previous := m.BodyFile
+	update(httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, transfer.New(0))())
+	if _, err := os.Stat(previous); !os.IsNotExist(err) || strings.Contains(ui.View(m), "Showing the first") {
+		t.Errorf("expected %s to be removed and the whole body shown, got %v", previous, err)
+	}
+}
+
+func TestStreamedBodyChecks(t *testing.T) {
+	var items []string
+	for i := 0; i < 100; i++ {
+		items = append(items, fmt.Sprintf(`{"id": %d}`, i))
+	}
+	body := `{"items": [` + strings.Join(items, ", ") + `], "next": "page-2"}`
+	path := filepath.Join(t.TempDir(), "body")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.SentAssertions = []model.Assertion{
+		{Kind: model.AssertJSONPath, Target: "$.items[99].id", Op: "==", Value: "99"},
+		{Kind: model.AssertSize, Op: ">", Value: "1kb"},
+	}
+	m.Extract = []model.ExtractRule{{Variable: "next", Source: model.ExtractJSON, Expression: "$.next"}}
+
+	// Only the start of the body is kept in memory, the checks read the file
+	entry := &model.HistoryEntry{
+		Request:  model.Request{Method: "GET", URL: "https://example.com/items"},
+		Response: model.Response{Status: "200 OK", StatusCode: 200, Body: body[:64], Size: int64(len(body)), Truncated: true},
+	}
+	m, _ = ui.Update(m, model.ResponseMsg{Resp: body[:64], Status: "200 OK", Entry: entry, BodyFile: path})
+	if len(m.TestResults) != 2 || !m.TestResults[0].Passed || !m.TestResults[1].Passed {
+		t.Errorf("expected the assertions to pass on the whole body, got %+v", m.TestResults)
+	}
+	if got := m.Collection.VariableMap()["next"]; got != "page-2" {
+		t.Errorf("expected the value to be extracted from the whole body, got %q", got)
+	}
+	if len(m.History) != 1 || m.History[0].Response.Body != body[:64] {
+		t.Error("expected the history to keep only the start of the body")
+	}
+}
+
+func TestResponseViewport(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("[\n")
+	for i := 0; i < 250_000; i++ {
+		fmt.Fprintf(&body, "  {\n    \"id\": %d\n  },\n", i)
+	}
+	body.WriteString("  {\n    \"note\": \"" + strings.Repeat("long ", 40) + "end\"\n  }\n]")
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Focus = model.FocusResponse
+	m, _ = ui.Update(m, model.ResponseMsg{Resp: body.String(), Status: "200 OK", Format: "JSON"})
+	if m.Viewport.LineCount() != 750_005 {
+		t.Fatalf("expected every line to be indexed, got %d", m.Viewport.LineCount())
+	}
+	if view := ui.View(m); !strings.Contains(view, `"id": 0`) || strings.Contains(view, `"id": 100`) {
+		t.Errorf("expected the first lines only, got:\n%s", view)
+	}
+
+	// G jumps to the end, where the long line wraps
+	m, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}})
+	view := ui.View(m)
+	if !strings.Contains(view, `"id": 249999`) || !strings.Contains(view, "end\"") || !strings.Contains(view, "100%") {
+		t.Errorf("expected the end of the body, got:\n%s", view)
+	}
+
+	// w cuts long lines instead of wrapping them
+	m, _ = ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}})
+	if view := ui.View(m); !m.Viewport.NoWrap || strings.Contains(view, "end\"") {
+		t.Errorf("expected the long line to be cut, got:\n%s", view)
+	}
+}
+
+func TestBinaryResponse(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	body := append(append(append([]byte{}, png...), make([]byte, 4096)...), "IEND\xaeB`\x82"...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Focus = model.FocusResponse
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ = ui.Update(m, msg)
+	}
+	keys := func(s string) {
+		t.Helper()
+		for _, r := range s {
+			update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	update(httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, nil)())
+	view := ui.View(m)
+	if !m.ResponseBinary || !strings.Contains(view, "Binary body · image/png · 4.0 KiB (4120 bytes)") {
+		t.Fatalf("expected the binary summary, got:\n%s", view)
+	}
+	if !strings.Contains(view, "00000000  89 50 4e 47 0d 0a 1a 0a  00 00 00 0d 49 48 44 52  |.PNG........IHDR|") {
+		t.Errorf("expected the hex dump, got:\n%s", view)
+	}
+
+	// : jumps to an offset
+	keys(":")
+	if !m.ShowHexPrompt || m.HexSearch {
+		t.Fatal("expected the offset prompt")
+	}
+	keys("0x2000")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.ShowHexPrompt || !strings.Contains(ui.View(m), "past the 4120 bytes shown") {
+		t.Fatalf("expected an offset past the body to be refused, got %q", m.StatusMessage)
+	}
+	m.HexInput.SetValue("0x800")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := ui.View(m); m.ShowHexPrompt || !strings.Contains(view, "00000800  00") || strings.Contains(view, "|.PNG") {
+		t.Errorf("expected the dump to start at 0x800, got:\n%s", view)
+	}
+
+	// / searches bytes, n moves to the next match
+	keys("/")
+	keys(`"IEND"`)
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.HexMatch != 4112 || !strings.Contains(m.StatusMessage, "Found at 0x1010 (4112)") {
+		t.Fatalf("expected a match at 4112, got %d: %q", m.HexMatch, m.StatusMessage)
+	}
+	if view := ui.View(m); !strings.Contains(view, "|IEND.B`.|") {
+		t.Errorf("expected the match to be shown, got:\n%s", view)
+	}
+	keys("n")
+	if m.HexMatch != 4112 || !strings.Contains(m.StatusMessage, "from the start") {
+		t.Errorf("expected the search to wrap to the same match, got %d: %q", m.HexMatch, m.StatusMessage)
+	}
+
+	// Text bodies are not dumped
+	update(model.ResponseMsg{Resp: "plain", Status: "200 OK", Entry: &model.HistoryEntry{Response: model.Response{Body: "plain"}}})
+	if m.ResponseBinary || m.Response != "plain" {
+		t.Errorf("expected a text body to be shown as is, got %q", m.Response)
+	}
+}
+
+func TestFormattedResponse(t *testing.T) {
+	bodies := map[string]string{
+		"/items.csv": "id,name\n1,Alice\n2,Bob\n",
+		"/envelope":  `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><ok/></soap:Body></soap:Envelope>`,
+	}
+	types := map[string]string{"/items.csv": "text/csv", "/envelope": "application/soap+xml; charset=utf-8"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", types[r.URL.Path])
+		_, _ = w.Write([]byte(bodies[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+
+	m, _ = ui.Update(m, httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL + "/items.csv"}, nil, nil)())
+	view := ui.View(m)
+	if !strings.Contains(view, "Response - Body (CSV)") || !strings.Contains(view, "1  │ Alice") {
+		t.Errorf("expected the CSV table, got:\n%s", view)
+	}
+
+	m, _ = ui.Update(m, httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL + "/envelope"}, nil, nil)())
+	view = ui.View(m)
+	if !strings.Contains(view, "Response - Body (XML)") || !strings.Contains(view, "    <ok/>") {
+		t.Errorf("expected the indented envelope, got:\n%s", view)
+	}
+}
+
+func TestTableView(t *testing.T) {
+	body := `{"meta": {"total": 3}, "data": {"items": [
+		{"id": 3, "name": "Carol", "active": true},
+		{"id": 10, "name": "Alice", "active": false, "tags": ["admin"]},
+		{"id": 2, "name": "Bob", "active": true}
+	]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Focus = model.FocusResponse
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ = ui.Update(m, msg)
+	}
+	keys := func(s string) {
+		t.Helper()
+		for _, r := range s {
+			update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	update(httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL}, nil, nil)())
+	keys("L")
+	if !m.ShowTable || m.TablePath != "$.data.items" {
+		t.Fatalf("expected the table of $.data.items, got %q: %q", m.TablePath, m.StatusMessage)
+	}
+	view := ui.View(m)
+	if !strings.Contains(view, "$.data.items · 3 rows × 4 columns") || !strings.Contains(view, "Carol") {
+		t.Errorf("expected the table, got:\n%s", view)
+	}
+
+	// Sort by id, numerically, then reverse
+	keys("ls")
+	if m.Table.Columns[m.TableCol] != "id" || m.Table.Cell(0, 1) != "2" || m.Table.Cell(2, 1) != "10" {
+		t.Errorf("expected the rows sorted by id, got %v", m.Table.Rows)
+	}
+	keys("s")
+	if !m.Table.Descending || m.Table.Cell(0, 1) != "10" {
+		t.Errorf("expected the rows sorted by descending id, got %v", m.Table.Rows)
+	}
+	if view := ui.View(m); !strings.Contains(view, "id ▼") {
+		t.Errorf("expected the sort arrow, got:\n%s", view)
+	}
+
+	// Enter shows the selected cell in full
+	keys("$")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := ui.View(m); !m.ShowTableCell || !strings.Contains(view, "Row 1 of 3") || !strings.Contains(view, `"admin"`) {
+		t.Errorf("expected the tags of Alice, got:\n%s", view)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// A filter lays out the objects it matches
+	keys("p")
+	m.TablePathInput.SetValue("$.data.items[?(@.active == true)]")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.TableEditPath || len(m.Table.Rows) != 2 || len(m.Table.Columns) != 3 {
+		t.Fatalf("expected the 2 active items, got %q", m.StatusMessage)
+	}
+	keys("p")
+	m.TablePathInput.SetValue("$.meta")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.TableEditPath || !strings.Contains(ui.View(m), "$.meta is not an array of objects") {
+		t.Errorf("expected the path to be refused, got %q", m.StatusMessage)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// x exports the rows to CSV
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	_, cmd := ui.Update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	update(cmd())
+	if !strings.HasPrefix(m.StatusMessage, "Exported the table to apitty-table-") {
+		t.Fatalf("expected the export, got %q", m.StatusMessage)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(m.StatusMessage, "Exported the table to ")))
+	if err != nil || string(data) != "active,id,name\ntrue,3,Carol\ntrue,2,Bob\n" {
+		t.Errorf("unexpected CSV %q, %v", data, err)
+	}
+
+	keys("q")
+	if m.ShowTable {
+		t.Error("expected q to close the table")
+	}
+}
+
+func TestInferModels(t *testing.T) {
+	bodies := []string{
+		`[{"id": 1, "email": "a@example.com"}]`,
+		`[{"id": 2, "email": null, "nickname": "bo"}]`,
+	}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(bodies[int(calls.Add(1)-1)%len(bodies)]))
+	}))
+	defer server.Close()
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Focus = model.FocusResponse
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ = ui.Update(m, msg)
+	}
+	keys := func(s string) {
+		t.Helper()
+		for _, r := range s {
+			update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	req := model.Request{Method: "GET", URL: server.URL + "/users?page=1"}
+	update(httpClient.SendCmd(req, nil, nil)())
+	req.URL = server.URL + "/users?page=2"
+	update(httpClient.SendCmd(req, nil, nil)())
+
+	keys("M")
+	if !m.ShowInfer || m.InferName != "Users" || m.InferSamples != 1 {
+		t.Fatalf("expected the models of the last response, got %q from %d samples: %q", m.InferName, m.InferSamples, m.StatusMessage)
+	}
+	if view := ui.View(m); !strings.Contains(view, `"$schema": "https://json-schema.org/draft/2020-12/schema"`) {
+		t.Errorf("expected the JSON Schema first, got:\n%s", view)
+	}
+
+	// Merging the first page makes nickname optional
+	keys("la")
+	view := ui.View(m)
+	if m.InferSamples != 2 || !strings.Contains(view, "From 2 responses to GET") {
+		t.Fatalf("expected the history samples, got %d:\n%s", m.InferSamples, view)
+	}
+	for _, field := range []string{"type Users []User", "Email    *string", `json:"nickname,omitempty"`} {
+		if !strings.Contains(view, field) {
+			t.Errorf("expected %q in:\n%s", field, view)
+		}
+	}
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	keys("lw")
+	if m.ShowInfer || !strings.HasPrefix(m.StatusMessage, "Saved the TypeScript models to apitty-users-") {
+		t.Fatalf("expected the models to be saved, got %q", m.StatusMessage)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(m.StatusMessage, "Saved the TypeScript models to ")))
+	if err != nil || !strings.Contains(string(data), "  email: string | null;\n  id: number;\n  nickname?: string;") {
+		t.Errorf("unexpected TypeScript %q, %v", data, err)
+	}
+
+	// Bodies that are not JSON have no models
+	update(model.ResponseMsg{Resp: "plain", Status: "200 OK", Entry: &model.HistoryEntry{Response: model.Response{Body: "plain"}}})
+	keys("M")
+	if m.ShowInfer || m.StatusMessage != "The models need a JSON body" {
+		t.Errorf("expected the models to be refused, got %q", m.StatusMessage)
+	}
+}
+
+func TestContract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "7", "items": [{"sku": "W-1", "quantity": 0}]}`))
+	}))
+	defer server.Close()
+
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	err := os.WriteFile(spec, []byte(`openapi: 3.0.3
+info: {title: Shop}
+paths:
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      responses:
+        "200":
+          description: The order
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, items]
+                properties:
+                  id: {type: integer}
+                  items:
+                    type: array
+                    items:
+                      properties:
+                        quantity: {type: integer, minimum: 1}
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Focus = model.FocusMethod
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ = ui.Update(m, msg)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'K'}})
+	if !m.ShowContractForm {
+		t.Fatal("expected the contract to open")
+	}
+	m.ContractInputs[1].SetValue("missing.yaml")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.ShowContractForm || !strings.Contains(ui.View(m), "cannot read missing.yaml") {
+		t.Fatal("expected a missing specification to be reported")
+	}
+	m.ContractInputs[1].SetValue(spec)
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ShowContractForm || m.Contract.OpenAPI != spec {
+		t.Fatalf("expected the contract to be saved, got %+v: %q", m.Contract, m.StatusMessage)
+	}
+
+	// The operation is found from the URL
+	update(httpClient.SendCmd(model.Request{Method: "GET", URL: server.URL + "/orders/7"}, nil, nil)())
+	if len(m.Violations) != 2 {
+		t.Fatalf("unexpected violations %v", m.Violations)
+	}
+	if view := ui.View(m); !strings.Contains(view, "✗ 2 contract violations") {
+		t.Errorf("expected a violations badge, got:\n%s", view)
+	}
+
+	m.Focus = model.FocusResponse
+	for m.CurrentView != model.ViewContract {
+		update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	}
+	view := ui.View(m)
+	for _, line := range []string{"$.id — expected integer, got string", "$.items[0].quantity — 0 is less than the minimum 1"} {
+		if !strings.Contains(view, line) {
+			t.Errorf("expected %q in the Contract tab, got:\n%s", line, view)
+		}
+	}
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if m.CurrentView != model.ViewBody {
+		t.Errorf("expected the Contract tab to cycle back to the body, got %v", m.CurrentView)
+	}
+}
+
+func TestSaveExample(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "shop.json")
+	err := os.WriteFile(path, []byte(`{"name": "Shop", "variables": [{"key": "baseUrl", "value": "`+server.URL+`/v1"}],
+		"requests": [{"name": "Create order", "method": "POST", "url": "{{baseUrl}}/orders"}]}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := collection.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := model.InitialModel()
+	m.Width, m.Height = 120, 40
+	m.ViewportReady = true
+	m.Collection, m.CollectionPath = c, path
+	m.Focus = model.FocusMethod
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ = ui.Update(m, msg)
+	}
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'X'}})
+	if m.StatusMessage != "" {
+		t.Fatalf("expected X to do nothing without a response, got %q", m.StatusMessage)
+	}
+
+	update(httpClient.SendCmd(model.Request{Method: "POST", URL: server.URL + "/v1/orders"}, nil, nil)())
+	m.Focus = model.FocusResponse
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'X'}})
+	if !strings.Contains(m.StatusMessage, "Load a request") {
+		t.Fatalf("expected a request to be loaded first, got %q", m.StatusMessage)
+	}
+
+	m.LoadedRequestIdx = 0
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'X'}})
+	if m.StatusMessage != `Saved the response as example 1 of "Create order"` {
+		t.Fatalf("unexpected status %q", m.StatusMessage)
+	}
+
+	c, err = collection.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples := c.Entries()[0].Request.Examples
+	if len(examples) != 1 || examples[0].Status != 201 || examples[0].Name != "Created" || examples[0].Body != `{"id": 7}` {
+		t.Fatalf("unexpected examples %+v", examples)
+	}
+	for _, h := range examples[0].Headers {
+		if h.Key == "Date" || h.Key == "Content-Length" {
+			t.Errorf("expected %s to be left out of the example", h.Key)
+		}
+	}
+
+	// The mock server answers with the saved example
+	s, err := mock.New(c, mock.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/orders", nil))
+	if rec.Code != 201 || rec.Body.String() != `{"id": 7}` || rec.Header().Get("X-Request-Id") != "abc" {
+		t.Errorf("unexpected mock response %d %v %s", rec.Code, rec.Header(), rec.Body)
+	}
+}